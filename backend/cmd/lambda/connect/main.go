@@ -16,7 +16,7 @@ import (
 
 var (
 	cfg      *config.Config
-	dbClient *db.Client
+	dbClient db.Store
 )
 
 func init() {
@@ -37,8 +37,12 @@ func handler(ctx context.Context, event events.APIGatewayWebsocketProxyRequest)
 
 	observability.Info(ctx, "WebSocket $connect", "connectionId", connectionID)
 
-	// Extract userId from authorizer context (set by Lambda authorizer)
+	// Extract userId from authorizer context (set by Lambda authorizer). It is the player's
+	// identity for the whole connection, so a connection without one gets an anonymous ID.
 	userId, _ := event.RequestContext.Authorizer.(map[string]interface{})["userId"].(string)
+	if userId == "" {
+		userId = models.NewAnonymousUserID()
+	}
 
 	// Extract sessionId from query string
 	sessionID := event.QueryStringParameters["sessionId"]
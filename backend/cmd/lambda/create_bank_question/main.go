@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler serves POST /api/question-bank: adds a question to the caller's question bank.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	observability.Info(ctx, "creating bank question")
+
+	var req models.BankQuestionInput
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", "Invalid request body", requestID), nil
+	}
+	for i := range req.Options {
+		if req.Options[i].ID == "" {
+			req.Options[i].ID = uuid.New().String()
+		}
+	}
+
+	// Whole seconds keep the updatedAt index key in chronological string order
+	now := time.Now().UTC().Truncate(time.Second)
+	question := &models.BankQuestion{
+		Question:   models.Question{QuestionID: uuid.New().String()},
+		HostUserID: userId,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := req.Apply(question); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+
+	if err := dbClient.CreateBankQuestion(ctx, question); err != nil {
+		observability.Error(ctx, "failed to create bank question", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to create question", requestID), nil
+	}
+
+	observability.Info(ctx, "bank question created", "questionId", question.QuestionID)
+	return successResponse(200, question, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -12,13 +13,14 @@ import (
 
 	"kahootclone/internal/config"
 	"kahootclone/internal/db"
+	"kahootclone/internal/game"
 	"kahootclone/internal/models"
 	"kahootclone/internal/observability"
 )
 
 var (
 	cfg      *config.Config
-	dbClient *db.Client
+	dbClient db.Store
 )
 
 func init() {
@@ -35,9 +37,11 @@ func init() {
 }
 
 type createQuizRequest struct {
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Questions   []models.Question `json:"questions"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Tags        []string              `json:"tags"`
+	Questions   []models.Question     `json:"questions"`
+	Rules       []models.QuestionRule `json:"rules"`
 }
 
 func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -54,14 +58,12 @@ func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.A
 		return errorResponse(400, "VALIDATION_ERROR", "Invalid request body", requestID), nil
 	}
 
-	if req.Title == "" {
-		return errorResponse(400, "VALIDATION_ERROR", "Title is required", requestID), nil
+	if err := models.ValidateQuiz(req.Title, req.Questions, req.Rules); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
 	}
-	if len(req.Questions) == 0 {
-		return errorResponse(400, "VALIDATION_ERROR", "At least one question is required", requestID), nil
-	}
-	if len(req.Questions) > 100 {
-		return errorResponse(400, "VALIDATION_ERROR", "Maximum 100 questions per quiz", requestID), nil
+	tags := models.NormalizeTags(req.Tags)
+	if err := models.ValidateTags(tags); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
 	}
 
 	// Assign IDs to questions and options
@@ -76,13 +78,25 @@ func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.A
 		}
 	}
 
-	now := time.Now().UTC()
+	// Fill questions linked to the question bank with its current content
+	if err := game.LinkBankQuestions(ctx, dbClient, userId, req.Questions); err != nil {
+		if errors.Is(err, db.ErrBankQuestionNotFound) {
+			return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+		}
+		observability.Error(ctx, "failed to link bank questions", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to read the question bank", requestID), nil
+	}
+
+	// Whole seconds keep the updatedAt index key in chronological string order
+	now := time.Now().UTC().Truncate(time.Second)
 	quiz := &models.Quiz{
 		QuizID:      uuid.New().String(),
 		HostUserID:  userId,
 		Title:       req.Title,
 		Description: req.Description,
+		Tags:        tags,
 		Questions:   req.Questions,
+		Rules:       req.Rules,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
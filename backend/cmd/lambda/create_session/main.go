@@ -3,9 +3,9 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log/slog"
-	"math/rand"
+	"math/rand/v2"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -14,13 +14,14 @@ import (
 
 	"kahootclone/internal/config"
 	"kahootclone/internal/db"
+	"kahootclone/internal/game"
 	"kahootclone/internal/models"
 	"kahootclone/internal/observability"
 )
 
 var (
 	cfg      *config.Config
-	dbClient *db.Client
+	dbClient db.Store
 )
 
 func init() {
@@ -38,6 +39,7 @@ func init() {
 
 type createSessionRequest struct {
 	QuizID string `json:"quizId"`
+	Seed   *int64 `json:"seed"` // replays the questions drawn by an earlier session
 }
 
 func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -70,16 +72,48 @@ func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.A
 		return errorResponse(403, "FORBIDDEN", "You don't own this quiz", requestID), nil
 	}
 
-	// Generate unique 6-digit PIN
-	pin, err := generateUniquePIN(ctx)
+	// Freeze the questions this session will play, so later edits don't affect it
+	quiz, err = game.SnapshotQuiz(ctx, dbClient, quiz)
+	if errors.Is(err, db.ErrBankQuestionNotFound) {
+		return errorResponse(409, "BANK_QUESTION_NOT_FOUND", err.Error(), requestID), nil
+	}
+	var poolErr *game.PoolError
+	if errors.As(err, &poolErr) {
+		return errorResponse(409, "QUESTION_POOL_INVALID", poolErr.Message, requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to snapshot quiz", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to prepare quiz", requestID), nil
+	}
+	if quiz == nil {
+		return errorResponse(404, "NOT_FOUND", "Quiz not found", requestID), nil
+	}
+
+	// The seed picks the questions the quiz's rules draw for this session
+	var seed int64
+	if len(quiz.Rules) > 0 {
+		if req.Seed != nil {
+			seed = *req.Seed
+		} else {
+			// Within 2^53, so the seed survives a round trip through JavaScript numbers
+			seed = rand.Int64N(1 << 53)
+		}
+	}
+
+	// Reserve a unique 6-digit PIN for the new session
+	sessionID := uuid.New().String()
+	pin, err := dbClient.AllocatePIN(ctx, sessionID)
 	if err != nil {
+		observability.Error(ctx, "failed to allocate PIN", "error", err.Error())
 		return errorResponse(500, "INTERNAL_ERROR", "Failed to generate PIN", requestID), nil
 	}
 
 	session := &models.Session{
-		SessionID:            uuid.New().String(),
+		SessionID:            sessionID,
 		PIN:                  pin,
 		QuizID:               req.QuizID,
+		QuizVersion:          quiz.Version,
+		QuizSeed:             seed,
 		HostUserID:           userId,
 		Status:               models.SessionStatusLobby,
 		CurrentQuestionIndex: 0,
@@ -88,6 +122,7 @@ func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.A
 
 	if err := dbClient.CreateSession(ctx, session); err != nil {
 		observability.Error(ctx, "failed to create session", "error", err.Error())
+		_ = dbClient.ReleasePIN(ctx, pin, sessionID)
 		return errorResponse(500, "INTERNAL_ERROR", "Failed to create session", requestID), nil
 	}
 
@@ -95,20 +130,6 @@ func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.A
 	return successResponse(201, session, requestID), nil
 }
 
-func generateUniquePIN(ctx context.Context) (string, error) {
-	for attempt := 0; attempt < 10; attempt++ {
-		pin := fmt.Sprintf("%06d", rand.Intn(1000000))
-		existing, err := dbClient.GetSessionByPIN(ctx, pin)
-		if err != nil {
-			return "", err
-		}
-		if existing == nil || existing.Status == models.SessionStatusFinished {
-			return pin, nil
-		}
-	}
-	return "", fmt.Errorf("failed to generate unique PIN after 10 attempts")
-}
-
 func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
 	body, _ := json.Marshal(map[string]interface{}{
 		"success":   true,
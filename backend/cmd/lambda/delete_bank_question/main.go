@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler serves DELETE /api/question-bank/{questionId}. Quizzes linked to the question
+// keep their copies of it.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	questionID := event.PathParameters["questionId"]
+	if questionID == "" {
+		return errorResponse(400, "VALIDATION_ERROR", "Question ID is required", requestID), nil
+	}
+
+	observability.Info(ctx, "deleting bank question", "questionId", questionID)
+
+	question, err := dbClient.GetBankQuestion(ctx, questionID)
+	if err != nil {
+		observability.Error(ctx, "failed to get bank question", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve question", requestID), nil
+	}
+	if question == nil {
+		return errorResponse(404, "NOT_FOUND", "Question not found", requestID), nil
+	}
+	if question.HostUserID != userId {
+		return errorResponse(403, "FORBIDDEN", "You don't have access to this question", requestID), nil
+	}
+
+	if err := dbClient.DeleteBankQuestion(ctx, questionID); err != nil {
+		observability.Error(ctx, "failed to delete bank question", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to delete question", requestID), nil
+	}
+
+	observability.Info(ctx, "bank question deleted", "questionId", questionID)
+	return successResponse(200, map[string]interface{}{"questionId": questionID}, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
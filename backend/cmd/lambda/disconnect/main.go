@@ -14,7 +14,7 @@ import (
 
 var (
 	cfg      *config.Config
-	dbClient *db.Client
+	dbClient db.Store
 )
 
 func init() {
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/media"
+	"kahootclone/internal/observability"
+	"kahootclone/internal/quizio"
+)
+
+var (
+	cfg        *config.Config
+	dbClient   db.Store
+	mediaStore media.BlobStore
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+	mediaStore, err = media.New(cfg)
+	if err != nil {
+		slog.Error("failed to initialize media store", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler downloads a quiz as a file in the requested format. The body is the file itself
+// rather than the usual JSON envelope; errors still use the envelope.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	quizID := event.PathParameters["quizId"]
+	if quizID == "" {
+		return errorResponse(400, "VALIDATION_ERROR", "Quiz ID is required", requestID), nil
+	}
+	format := event.QueryStringParameters["format"]
+
+	observability.Info(ctx, "exporting quiz", "quizId", quizID, "format", format)
+
+	quiz, err := dbClient.GetQuiz(ctx, quizID)
+	if err != nil {
+		observability.Error(ctx, "failed to get quiz", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID), nil
+	}
+	if quiz == nil {
+		return errorResponse(404, "NOT_FOUND", "Quiz not found", requestID), nil
+	}
+	if quiz.HostUserID != userId {
+		return errorResponse(403, "FORBIDDEN", "You don't have access to this quiz", requestID), nil
+	}
+
+	file, err := quizio.Export(format, quiz, func(key string) (*quizio.MediaFile, error) {
+		if mediaStore == nil {
+			return nil, errors.New("no media store is configured")
+		}
+		data, contentType, err := mediaStore.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return &quizio.MediaFile{Key: key, ContentType: contentType, Data: data}, nil
+	})
+	switch {
+	case errors.Is(err, quizio.ErrUnknownFormat):
+		return errorResponse(400, "VALIDATION_ERROR", "Format must be one of "+strings.Join(quizio.ExportFormats(), ", "), requestID), nil
+	case errors.Is(err, quizio.ErrNotExportable):
+		return errorResponse(422, "QUIZ_NOT_EXPORTABLE", err.Error(), requestID), nil
+	case err != nil:
+		observability.Error(ctx, "failed to export quiz", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to export quiz", requestID), nil
+	}
+
+	// Packages are binary; API Gateway decodes them when the API's binary media types include them
+	body, binary := string(file.Data), !strings.HasPrefix(file.ContentType, "text/") && file.ContentType != "application/xml"
+	if binary {
+		body = base64.StdEncoding.EncodeToString(file.Data)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":                  file.ContentType,
+			"Content-Disposition":           fmt.Sprintf("attachment; filename=%q", file.Name),
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Allow-Headers":  "Content-Type,Authorization",
+			"Access-Control-Expose-Headers": "Content-Disposition",
+		},
+		Body:            body,
+		IsBase64Encoded: binary,
+	}, nil
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/media"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+	"kahootclone/internal/reportio"
+)
+
+// maxInlineExport is the largest export returned in the response body. Lambda responses are
+// limited to 6 MB and binary bodies grow by a third when base64-encoded.
+const maxInlineExport = 4 << 20
+
+// exportKeyPrefix starts the media store keys of exports too large to return inline. The
+// bucket should expire objects under it after a day.
+const exportKeyPrefix = "exports/"
+
+var (
+	cfg        *config.Config
+	dbClient   db.Store
+	mediaStore media.BlobStore
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+	mediaStore, err = media.New(cfg)
+	if err != nil {
+		slog.Error("failed to initialize media store", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler downloads a finished session's gradebook (CSV or XLSX) or PDF summary to its host.
+// The body is the file itself rather than the usual JSON envelope; exports over
+// maxInlineExport are streamed to the media store as they are written instead, and a signed
+// link to them is returned in the envelope. Errors use the envelope too.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	sessionID := event.PathParameters["sessionId"]
+	if sessionID == "" {
+		return errorResponse(400, "VALIDATION_ERROR", "Session ID is required", requestID), nil
+	}
+	format := event.QueryStringParameters["format"]
+	export, err := reportio.NewExport(format)
+	if err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", "Format must be one of "+strings.Join(reportio.Formats(), ", "), requestID), nil
+	}
+
+	observability.Info(ctx, "exporting session report", "sessionId", sessionID, "format", format)
+
+	session, err := dbClient.GetSession(ctx, sessionID)
+	if err != nil {
+		observability.Error(ctx, "failed to get session", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve session", requestID), nil
+	}
+	if session == nil {
+		return errorResponse(404, "NOT_FOUND", "Session not found", requestID), nil
+	}
+	if session.HostUserID != userId {
+		return errorResponse(403, "FORBIDDEN", "Only the host can export the session report", requestID), nil
+	}
+	if session.Status != models.SessionStatusFinished {
+		return errorResponse(409, "SESSION_NOT_FINISHED", "Reports can be exported once the game has ended", requestID), nil
+	}
+
+	// The session is loaded before anything is written, so missing data is reported as an error
+	var write func(w io.Writer) error
+	var title string
+	if export.NeedsGradebook() {
+		var gradebook *models.Gradebook
+		if gradebook, err = game.SessionGradebook(ctx, dbClient, session); err == nil {
+			title = gradebook.QuizTitle
+			write = func(w io.Writer) error { return export.WriteGradebook(w, gradebook) }
+		}
+	} else {
+		var report *models.SessionReport
+		if report, err = game.SessionReport(ctx, dbClient, session, models.RankMode(cfg.LeaderboardRankMode)); err == nil {
+			title = report.QuizTitle
+			write = func(w io.Writer) error { return export.WriteReport(w, report) }
+		}
+	}
+	if errors.Is(err, game.ErrQuizNotFound) {
+		return errorResponse(404, "NOT_FOUND", "The session's quiz no longer exists", requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to load session report", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to build session report", requestID), nil
+	}
+	fileName := export.FileName(title)
+
+	// The export is written into a pipe as it is generated. Up to maxInlineExport bytes are
+	// read to return in the response; a larger export is streamed on to the media store.
+	pr, pw := io.Pipe()
+	defer pr.Close() // stops the writer if the upload gives up early
+	go func() {
+		pw.CloseWithError(write(pw))
+	}()
+	head := make([]byte, maxInlineExport+1)
+	n, err := io.ReadFull(pr, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		observability.Error(ctx, "failed to export session report", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to export session report", requestID), nil
+	}
+	head = head[:n]
+
+	if n > maxInlineExport {
+		if mediaStore == nil {
+			return errorResponse(413, "PAYLOAD_TOO_LARGE", "The export is too large to download; try another format", requestID), nil
+		}
+		// The file name is the last path segment, so the browser saves it under that name
+		key := exportKeyPrefix + sessionID + "/" + requestID + "/" + fileName
+		if err := mediaStore.PutReader(ctx, key, export.ContentType, io.MultiReader(bytes.NewReader(head), pr)); err != nil {
+			observability.Error(ctx, "failed to store export", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to store export", requestID), nil
+		}
+		url, err := mediaStore.SignedURL(ctx, key, cfg.MediaURLTTL)
+		if err != nil {
+			observability.Error(ctx, "failed to sign export url", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to store export", requestID), nil
+		}
+		return successResponse(200, models.ReportDownload{
+			URL:       url,
+			FileName:  fileName,
+			ExpiresAt: time.Now().Add(cfg.MediaURLTTL).UTC(),
+		}, requestID), nil
+	}
+
+	// Workbooks and PDFs are binary; API Gateway decodes them when the API's binary media types include them
+	body, binary := string(head), !strings.HasPrefix(export.ContentType, "text/")
+	if binary {
+		body = base64.StdEncoding.EncodeToString(head)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":                  export.ContentType,
+			"Content-Disposition":           fmt.Sprintf("attachment; filename=%q", fileName),
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Allow-Headers":  "Content-Type,Authorization",
+			"Access-Control-Expose-Headers": "Content-Disposition",
+		},
+		Body:            body,
+		IsBase64Encoded: binary,
+	}, nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
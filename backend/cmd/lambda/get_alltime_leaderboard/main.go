@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler serves both GET /api/quizzes/{quizId}/leaderboard and
+// GET /api/hosts/{hostUserId}/leaderboard, depending on which path parameter is set.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	role, _ := event.RequestContext.Authorizer["role"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	from, to, limit, err := game.ParseAllTimeQuery(
+		event.QueryStringParameters["from"],
+		event.QueryStringParameters["to"],
+		event.QueryStringParameters["limit"],
+	)
+	if err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+
+	var key, id string
+	var results []models.PlayerResult
+	if quizID := event.PathParameters["quizId"]; quizID != "" {
+		key, id = "quizId", quizID
+		observability.Info(ctx, "getting quiz all-time leaderboard", "quizId", quizID)
+
+		quiz, err := dbClient.GetQuiz(ctx, quizID)
+		if err != nil {
+			observability.Error(ctx, "failed to get quiz", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID), nil
+		}
+		if quiz == nil {
+			return errorResponse(404, "NOT_FOUND", "Quiz not found", requestID), nil
+		}
+		if quiz.HostUserID != userId && role != "admin" {
+			return errorResponse(403, "FORBIDDEN", "You don't have access to this quiz", requestID), nil
+		}
+
+		results, err = dbClient.ListQuizResults(ctx, quizID, from, to)
+		if err != nil {
+			observability.Error(ctx, "failed to list quiz results", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve leaderboard", requestID), nil
+		}
+	} else if hostUserID := event.PathParameters["hostUserId"]; hostUserID != "" {
+		key, id = "hostUserId", hostUserID
+		observability.Info(ctx, "getting host all-time leaderboard", "hostUserId", hostUserID)
+
+		if hostUserID != userId && role != "admin" {
+			return errorResponse(403, "FORBIDDEN", "You can only view your own sessions", requestID), nil
+		}
+
+		results, err = dbClient.ListHostResults(ctx, hostUserID, from, to)
+		if err != nil {
+			observability.Error(ctx, "failed to list host results", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve leaderboard", requestID), nil
+		}
+	} else {
+		return errorResponse(400, "VALIDATION_ERROR", "Quiz ID or host user ID is required", requestID), nil
+	}
+
+	leaderboard := game.AggregateResults(results, models.RankMode(cfg.LeaderboardRankMode))
+	if len(leaderboard) > limit {
+		leaderboard = leaderboard[:limit]
+	}
+
+	response := map[string]interface{}{
+		key:           id,
+		"from":        from,
+		"to":          to,
+		"leaderboard": leaderboard,
+	}
+
+	return successResponse(200, response, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
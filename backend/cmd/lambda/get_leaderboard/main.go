@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -13,12 +14,14 @@ import (
 	"kahootclone/internal/cache"
 	"kahootclone/internal/config"
 	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/models"
 	"kahootclone/internal/observability"
 )
 
 var (
 	cfg         *config.Config
-	dbClient    *db.Client
+	dbClient    db.Store
 	redisClient *cache.RedisClient
 )
 
@@ -51,23 +54,63 @@ func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.A
 
 	observability.Info(ctx, "getting leaderboard", "sessionId", sessionID)
 
-	// Try Redis first for real-time leaderboard
-	topN := 100
-	leaderboard, err := redisClient.GetTopN(ctx, sessionID, topN)
+	session, err := dbClient.GetSession(ctx, sessionID)
 	if err != nil {
+		observability.Error(ctx, "failed to get session", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve session", requestID), nil
+	}
+	if session == nil {
+		return errorResponse(404, "NOT_FOUND", "Session not found", requestID), nil
+	}
+
+	limit, err := game.ParsePageLimit(event.QueryStringParameters["limit"])
+	if err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+	cursor := event.QueryStringParameters["cursor"]
+
+	leaderboard, nextCursor, err := game.SessionLeaderboardPage(ctx, dbClient, redisClient, session, limit, cursor)
+	if errors.Is(err, db.ErrInvalidCursor) {
+		return errorResponse(400, "VALIDATION_ERROR", "Invalid cursor", requestID), nil
+	}
+	if err != nil {
+		if session.Status == models.SessionStatusFinished {
+			observability.Error(ctx, "failed to get final leaderboard", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve leaderboard", requestID), nil
+		}
+		// Redis holds the real-time leaderboard for live games
 		slog.Warn("failed to get leaderboard from Redis, falling back to DynamoDB", "error", err.Error())
-		// Could fall back to computing from answers table if needed
-		leaderboard = nil
+		leaderboard, nextCursor, err = leaderboardFromAnswers(ctx, sessionID, limit, cursor)
+		if err != nil {
+			observability.Error(ctx, "failed to compute leaderboard from answers", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve leaderboard", requestID), nil
+		}
 	}
 
 	response := map[string]interface{}{
 		"sessionId":   sessionID,
 		"leaderboard": leaderboard,
+		"nextCursor":  nextCursor,
 	}
 
 	return successResponse(200, response, requestID), nil
 }
 
+// leaderboardFromAnswers computes one leaderboard page directly from the answers table when
+// Redis is unavailable.
+func leaderboardFromAnswers(ctx context.Context, sessionID string, limit int, cursor string) ([]models.PlayerScore, string, error) {
+	answers, err := dbClient.GetAnswersBySession(ctx, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	connections, err := dbClient.GetConnectionsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	scores := game.ScoresFromAnswers(answers, connections, redisClient.RankMode)
+	return db.OffsetPage(scores, limit, cursor)
+}
+
 func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
 	body, _ := json.Marshal(map[string]interface{}{
 		"success":   true,
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler returns the post-game report of a session: per-question accuracy, timing and
+// option choices, and per-player results. Only the session's host may read it.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	sessionID := event.PathParameters["sessionId"]
+	if sessionID == "" {
+		return errorResponse(400, "VALIDATION_ERROR", "Session ID is required", requestID), nil
+	}
+
+	observability.Info(ctx, "getting session report", "sessionId", sessionID)
+
+	session, err := dbClient.GetSession(ctx, sessionID)
+	if err != nil {
+		observability.Error(ctx, "failed to get session", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve session", requestID), nil
+	}
+	if session == nil {
+		return errorResponse(404, "NOT_FOUND", "Session not found", requestID), nil
+	}
+	if session.HostUserID != userId {
+		return errorResponse(403, "FORBIDDEN", "Only the host can view the session report", requestID), nil
+	}
+
+	report, err := game.SessionReport(ctx, dbClient, session, models.RankMode(cfg.LeaderboardRankMode))
+	if errors.Is(err, game.ErrQuizNotFound) {
+		return errorResponse(404, "NOT_FOUND", "The session's quiz no longer exists", requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to build session report", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to build session report", requestID), nil
+	}
+
+	return successResponse(200, report, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/media"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+	"kahootclone/internal/quizio"
+)
+
+var (
+	cfg        *config.Config
+	dbClient   db.Store
+	mediaStore media.BlobStore
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+	mediaStore, err = media.New(cfg)
+	if err != nil {
+		slog.Error("failed to initialize media store", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler creates a quiz from an uploaded file; the request body is the file itself. XLSX
+// uploads arrive base64-encoded when the API's binary media types include them.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	format := event.QueryStringParameters["format"]
+	observability.Info(ctx, "importing quiz", "format", format)
+
+	file := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if file, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return errorResponse(400, "VALIDATION_ERROR", "Invalid request body", requestID), nil
+		}
+	}
+
+	imported, files, err := quizio.Import(format, bytes.NewReader(file))
+	var importErr *quizio.ImportError
+	switch {
+	case errors.As(err, &importErr):
+		return errorDetailsResponse(400, "VALIDATION_ERROR", importErr.Error(), importErr.Errors, requestID), nil
+	case errors.Is(err, quizio.ErrUnknownFormat):
+		return errorResponse(400, "VALIDATION_ERROR", "Format must be one of "+strings.Join(quizio.ImportFormats(), ", "), requestID), nil
+	case errors.Is(err, quizio.ErrTooLarge):
+		return errorResponse(413, "PAYLOAD_TOO_LARGE", "File is too large", requestID), nil
+	case err != nil:
+		return errorResponse(400, "VALIDATION_ERROR", "Invalid file", requestID), nil
+	}
+
+	if title := event.QueryStringParameters["title"]; title != "" {
+		imported.Title = title
+	}
+	if err := models.ValidateQuiz(imported.Title, imported.Questions, nil); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+
+	if len(files) > 0 && mediaStore == nil {
+		return errorResponse(501, "MEDIA_UNAVAILABLE", "This server has no media store for the file's images", requestID), nil
+	}
+	for i, f := range files {
+		if err := media.Put(ctx, mediaStore, f.Key, &media.File{ContentType: f.ContentType, Data: f.Data, Thumbnail: f.Thumbnail}); err != nil {
+			observability.Error(ctx, "failed to store media", "key", f.Key, "error", err.Error())
+			deleteMedia(ctx, files[:i])
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to store the file's images", requestID), nil
+		}
+	}
+
+	// Whole seconds keep the updatedAt index key in chronological string order
+	now := time.Now().UTC().Truncate(time.Second)
+	quiz := &models.Quiz{
+		QuizID:      uuid.New().String(),
+		HostUserID:  userId,
+		Title:       imported.Title,
+		Description: imported.Description,
+		Questions:   imported.Questions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := dbClient.CreateQuiz(ctx, quiz); err != nil {
+		observability.Error(ctx, "failed to create quiz", "error", err.Error())
+		deleteMedia(ctx, files)
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to create quiz", requestID), nil
+	}
+
+	observability.Info(ctx, "quiz imported", "quizId", quiz.QuizID, "questions", len(quiz.Questions))
+	return successResponse(201, quiz, requestID), nil
+}
+
+// deleteMedia removes the images stored for an import that failed, so they aren't orphaned.
+func deleteMedia(ctx context.Context, files []quizio.MediaFile) {
+	for _, f := range files {
+		if err := media.Delete(ctx, mediaStore, f.Key); err != nil {
+			observability.Warn(ctx, "failed to delete media", "key", f.Key, "error", err.Error())
+		}
+	}
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorDetailsResponse(statusCode int, code, message string, details interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]interface{}{"code": code, "message": message, "details": details},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -19,7 +19,7 @@ import (
 
 var (
 	cfg         *config.Config
-	dbClient    *db.Client
+	dbClient    db.Store
 	redisClient *cache.RedisClient
 )
 
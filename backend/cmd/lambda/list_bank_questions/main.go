@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler serves GET /api/question-bank: the caller's bank questions, most recently updated
+// first, optionally filtered by a text substring (q) and tag.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	params := event.QueryStringParameters
+	limit, err := game.ParsePageLimit(params["limit"])
+	if err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+
+	observability.Info(ctx, "listing bank questions")
+
+	filter := models.BankFilter{Text: params["q"], Tag: params["tag"]}
+	questions, nextCursor, err := dbClient.ListBankQuestions(ctx, userId, filter, limit, params["cursor"])
+	if errors.Is(err, db.ErrInvalidCursor) {
+		return errorResponse(400, "VALIDATION_ERROR", "Invalid cursor", requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to list bank questions", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to list questions", requestID), nil
+	}
+	if questions == nil {
+		questions = []models.BankQuestion{}
+	}
+
+	return successResponse(200, map[string]interface{}{
+		"questions":  questions,
+		"nextCursor": nextCursor,
+	}, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler serves GET /api/quizzes: the caller's quizzes, most recently updated first,
+// optionally filtered by title substring and tag. Admins may list another host's quizzes
+// with hostUserId.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	role, _ := event.RequestContext.Authorizer["role"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	params := event.QueryStringParameters
+	hostUserID := userId
+	if requested := params["hostUserId"]; requested != "" && requested != userId {
+		if role != "admin" {
+			return errorResponse(403, "FORBIDDEN", "You can only list your own quizzes", requestID), nil
+		}
+		hostUserID = requested
+	}
+
+	limit, err := game.ParsePageLimit(params["limit"])
+	if err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+
+	observability.Info(ctx, "listing quizzes", "hostUserId", hostUserID)
+
+	filter := models.QuizFilter{Title: params["title"], Tag: params["tag"]}
+	quizzes, nextCursor, err := dbClient.ListQuizzesByHost(ctx, hostUserID, filter, limit, params["cursor"])
+	if errors.Is(err, db.ErrInvalidCursor) {
+		return errorResponse(400, "VALIDATION_ERROR", "Invalid cursor", requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to list quizzes", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to list quizzes", requestID), nil
+	}
+	if quizzes == nil {
+		quizzes = []models.Quiz{}
+	}
+
+	return successResponse(200, map[string]interface{}{
+		"quizzes":    quizzes,
+		"nextCursor": nextCursor,
+	}, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
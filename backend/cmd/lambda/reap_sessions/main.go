@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"kahootclone/internal/cache"
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg         *config.Config
+	dbClient    db.Store
+	redisClient *cache.RedisClient
+	gameEngine  *game.Engine
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+
+	redisClient, err = cache.NewRedisClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize Redis client", "error", err.Error())
+		panic(err)
+	}
+
+	broadcaster := game.NewBroadcaster(dbClient, cfg.Env)
+	gameEngine = game.NewEngine(dbClient, redisClient, redisClient, broadcaster)
+}
+
+// handler is invoked by an EventBridge schedule (e.g. rate(5 minutes)).
+func handler(ctx context.Context, event events.CloudWatchEvent) error {
+	observability.Info(ctx, "idle session reaper invoked", "idleTimeout", cfg.SessionIdleTimeout.String())
+
+	count, err := gameEngine.ReapIdleSessions(ctx, cfg.SessionIdleTimeout)
+	if err != nil {
+		observability.Error(ctx, "idle session reaper failed", "reaped", count, "error", err.Error())
+		return err
+	}
+
+	observability.Info(ctx, "idle session reaper finished", "reaped", count)
+	return nil
+}
+
+func main() {
+	lambda.Start(handler)
+}
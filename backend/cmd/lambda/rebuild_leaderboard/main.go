@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/cache"
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg         *config.Config
+	dbClient    db.Store
+	redisClient *cache.RedisClient
+	gameEngine  *game.Engine
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+
+	redisClient, err = cache.NewRedisClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize Redis client", "error", err.Error())
+		panic(err)
+	}
+
+	broadcaster := game.NewBroadcaster(dbClient, cfg.Env)
+	gameEngine = game.NewEngine(dbClient, redisClient, redisClient, broadcaster)
+}
+
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	role, _ := event.RequestContext.Authorizer["role"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	sessionID := event.PathParameters["sessionId"]
+	if sessionID == "" {
+		return errorResponse(400, "VALIDATION_ERROR", "Session ID is required", requestID), nil
+	}
+
+	observability.Info(ctx, "rebuilding leaderboard", "sessionId", sessionID)
+
+	session, err := dbClient.GetSession(ctx, sessionID)
+	if err != nil {
+		observability.Error(ctx, "failed to get session", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve session", requestID), nil
+	}
+	if session == nil {
+		return errorResponse(404, "NOT_FOUND", "Session not found", requestID), nil
+	}
+	if session.HostUserID != userId && role != "admin" {
+		return errorResponse(403, "FORBIDDEN", "Only the host can rebuild the leaderboard", requestID), nil
+	}
+	if session.Status == models.SessionStatusFinished {
+		return errorResponse(409, "GAME_FINISHED", "Finished sessions are served from saved results", requestID), nil
+	}
+
+	leaderboard, err := gameEngine.RebuildLeaderboard(ctx, sessionID)
+	if errors.Is(err, game.ErrRebuildInProgress) {
+		return errorResponse(409, "REBUILD_IN_PROGRESS", "A leaderboard rebuild is already running", requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to rebuild leaderboard", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to rebuild leaderboard", requestID), nil
+	}
+	if len(leaderboard) > 100 {
+		leaderboard = leaderboard[:100]
+	}
+
+	response := map[string]interface{}{
+		"sessionId":   sessionID,
+		"leaderboard": leaderboard,
+	}
+
+	observability.Info(ctx, "leaderboard rebuilt", "sessionId", sessionID, "players", len(leaderboard))
+	return successResponse(200, response, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler serves PUT /api/question-bank/{questionId}: replaces a bank question. Quizzes
+// linked to it pick up the change the next time they are saved or a session starts.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	var req models.BankQuestionInput
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", "Invalid request body", requestID), nil
+	}
+	for i := range req.Options {
+		if req.Options[i].ID == "" {
+			req.Options[i].ID = uuid.New().String()
+		}
+	}
+
+	questionID := event.PathParameters["questionId"]
+	if questionID == "" {
+		return errorResponse(400, "VALIDATION_ERROR", "Question ID is required", requestID), nil
+	}
+
+	observability.Info(ctx, "updating bank question", "questionId", questionID)
+
+	question, err := dbClient.GetBankQuestion(ctx, questionID)
+	if err != nil {
+		observability.Error(ctx, "failed to get bank question", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve question", requestID), nil
+	}
+	if question == nil {
+		return errorResponse(404, "NOT_FOUND", "Question not found", requestID), nil
+	}
+	if question.HostUserID != userId {
+		return errorResponse(403, "FORBIDDEN", "You don't have access to this question", requestID), nil
+	}
+
+	if err := req.Apply(question); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+	question.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	err = dbClient.UpdateBankQuestion(ctx, question)
+	if errors.Is(err, db.ErrBankQuestionNotFound) {
+		return errorResponse(404, "NOT_FOUND", "Question not found", requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to update bank question", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to update question", requestID), nil
+	}
+
+	observability.Info(ctx, "bank question updated", "questionId", questionID)
+	return successResponse(200, question, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
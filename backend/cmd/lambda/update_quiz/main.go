@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/game"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg      *config.Config
+	dbClient db.Store
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	dbClient, err = db.NewClient(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		panic(err)
+	}
+}
+
+type updateQuizRequest struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Tags        []string              `json:"tags"`
+	Questions   []models.Question     `json:"questions"`
+	Rules       []models.QuestionRule `json:"rules"`
+	Version     *int64                `json:"version"` // the version the editor started from
+}
+
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	var req updateQuizRequest
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", "Invalid request body", requestID), nil
+	}
+
+	if req.Version == nil {
+		return errorResponse(400, "VALIDATION_ERROR", "Version is required", requestID), nil
+	}
+	if err := models.ValidateQuiz(req.Title, req.Questions, req.Rules); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+	tags := models.NormalizeTags(req.Tags)
+	if err := models.ValidateTags(tags); err != nil {
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+
+	quizID := event.PathParameters["quizId"]
+	if quizID == "" {
+		return errorResponse(400, "VALIDATION_ERROR", "Quiz ID is required", requestID), nil
+	}
+
+	observability.Info(ctx, "updating quiz", "quizId", quizID)
+
+	quiz, err := dbClient.GetQuiz(ctx, quizID)
+	if err != nil {
+		observability.Error(ctx, "failed to get quiz", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID), nil
+	}
+	if quiz == nil {
+		return errorResponse(404, "NOT_FOUND", "Quiz not found", requestID), nil
+	}
+	if quiz.HostUserID != userId {
+		return errorResponse(403, "FORBIDDEN", "You don't have access to this quiz", requestID), nil
+	}
+
+	// Assign IDs to new questions and options
+	for i := range req.Questions {
+		if req.Questions[i].QuestionID == "" {
+			req.Questions[i].QuestionID = uuid.New().String()
+		}
+		for j := range req.Questions[i].Options {
+			if req.Questions[i].Options[j].ID == "" {
+				req.Questions[i].Options[j].ID = uuid.New().String()
+			}
+		}
+	}
+
+	// Fill questions linked to the question bank with its current content
+	if err := game.LinkBankQuestions(ctx, dbClient, userId, req.Questions); err != nil {
+		if errors.Is(err, db.ErrBankQuestionNotFound) {
+			return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+		}
+		observability.Error(ctx, "failed to link bank questions", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to read the question bank", requestID), nil
+	}
+
+	// Changing questions under a running game would break scoring for its players
+	if !models.SameQuestions(quiz.Questions, req.Questions) {
+		live, err := dbClient.HasLiveSession(ctx, quizID)
+		if err != nil {
+			observability.Error(ctx, "failed to check for live sessions", "error", err.Error())
+			return errorResponse(500, "INTERNAL_ERROR", "Failed to check sessions", requestID), nil
+		}
+		if live {
+			return errorResponse(409, "QUIZ_IN_USE", "Questions can't be changed while a session is playing this quiz", requestID), nil
+		}
+	}
+
+	quiz.Title = req.Title
+	quiz.Description = req.Description
+	quiz.Tags = tags
+	quiz.Questions = req.Questions
+	quiz.Rules = req.Rules
+	quiz.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	err = dbClient.UpdateQuiz(ctx, quiz, *req.Version)
+	if errors.Is(err, db.ErrVersionConflict) {
+		return errorResponse(409, "VERSION_CONFLICT", "The quiz was changed by someone else; reload and try again", requestID), nil
+	}
+	if err != nil {
+		observability.Error(ctx, "failed to update quiz", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to update quiz", requestID), nil
+	}
+
+	observability.Info(ctx, "quiz updated", "quizId", quiz.QuizID, "version", quiz.Version)
+	return successResponse(200, quiz, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/media"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+var (
+	cfg        *config.Config
+	mediaStore media.BlobStore
+)
+
+func init() {
+	cfg = config.Load()
+	observability.InitLogger(cfg.LogLevel, cfg.Env)
+	observability.InitTracer(cfg.Env)
+
+	var err error
+	mediaStore, err = media.New(cfg)
+	if err != nil {
+		slog.Error("failed to initialize media store", "error", err.Error())
+		panic(err)
+	}
+}
+
+// handler stores an image or audio clip for a question or option; the request body is the
+// file itself, base64-encoded when the API's binary media types include its type, and ?alt=
+// optionally describes it.
+func handler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestID := uuid.New().String()
+	ctx = observability.WithRequestID(ctx, requestID)
+
+	userId, _ := event.RequestContext.Authorizer["userId"].(string)
+	ctx = observability.WithUserID(ctx, userId)
+
+	if mediaStore == nil {
+		return errorResponse(501, "MEDIA_UNAVAILABLE", "This server has no media store", requestID), nil
+	}
+
+	data := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return errorResponse(400, "VALIDATION_ERROR", "Invalid request body", requestID), nil
+		}
+	}
+	alt := strings.TrimSpace(event.QueryStringParameters["alt"])
+	if len(alt) > models.MaxMediaAltLength {
+		return errorResponse(400, "VALIDATION_ERROR", fmt.Sprintf("Alt text must be at most %d characters", models.MaxMediaAltLength), requestID), nil
+	}
+
+	file, err := media.Prepare(data)
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		return errorResponse(413, "PAYLOAD_TOO_LARGE", err.Error(), requestID), nil
+	case err != nil:
+		return errorResponse(400, "VALIDATION_ERROR", err.Error(), requestID), nil
+	}
+
+	uploaded, err := media.Store(ctx, mediaStore, file, alt, cfg.MediaURLTTL)
+	if err != nil {
+		observability.Error(ctx, "failed to store media", "error", err.Error())
+		return errorResponse(500, "INTERNAL_ERROR", "Failed to store the file", requestID), nil
+	}
+
+	observability.Info(ctx, "media uploaded", "key", uploaded.Media.Key, "type", uploaded.Media.Type, "bytes", len(file.Data))
+	return successResponse(201, uploaded, requestID), nil
+}
+
+func successResponse(statusCode int, data interface{}, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func errorResponse(statusCode int, code, message, requestID string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization",
+		},
+		Body: string(body),
+	}
+}
+
+func main() {
+	lambda.Start(handler)
+}
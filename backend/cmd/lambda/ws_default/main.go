@@ -11,13 +11,14 @@ import (
 	"kahootclone/internal/config"
 	"kahootclone/internal/db"
 	"kahootclone/internal/game"
+	"kahootclone/internal/media"
 	"kahootclone/internal/models"
 	"kahootclone/internal/observability"
 )
 
 var (
 	cfg         *config.Config
-	dbClient    *db.Client
+	dbClient    db.Store
 	redisClient *cache.RedisClient
 	gameEngine  *game.Engine
 )
@@ -40,8 +41,15 @@ func init() {
 		panic(err)
 	}
 
+	mediaStore, err := media.New(cfg)
+	if err != nil {
+		slog.Error("failed to initialize media store", "error", err.Error())
+		panic(err)
+	}
+
 	broadcaster := game.NewBroadcaster(dbClient, cfg.Env)
-	gameEngine = game.NewEngine(dbClient, redisClient, broadcaster)
+	gameEngine = game.NewEngine(dbClient, redisClient, redisClient, broadcaster)
+	gameEngine.Media, gameEngine.MediaURLTTL = mediaStore, cfg.MediaURLTTL
 }
 
 func handler(ctx context.Context, event events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"math/rand"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -20,19 +23,23 @@ import (
 	"kahootclone/internal/config"
 	"kahootclone/internal/db"
 	"kahootclone/internal/game"
+	"kahootclone/internal/media"
 	"kahootclone/internal/models"
 	"kahootclone/internal/observability"
+	"kahootclone/internal/quizio"
+	"kahootclone/internal/reportio"
 )
 
 var (
-	cfg         *config.Config
-	dbClient    *db.Client
-	redisClient *cache.RedisClient
-	validator   *auth.CognitoValidator
-	gameEngine  *game.Engine
-	broadcaster *game.Broadcaster
-	hub         *game.Hub
-	upgrader    = websocket.Upgrader{
+	cfg              *config.Config
+	dbClient         db.Store
+	leaderboardCache cache.Leaderboard
+	mediaStore       media.BlobStore
+	validator        *auth.CognitoValidator
+	gameEngine       *game.Engine
+	broadcaster      *game.Broadcaster
+	hub              *game.Hub
+	upgrader         = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool { return true }, // Allow all origins in local dev
 	}
 )
@@ -44,21 +51,29 @@ func main() {
 
 	slog.Info("starting KahootClone local server", "env", cfg.Env, "port", cfg.Port)
 
-	// Initialize DynamoDB
+	// Initialize storage (DynamoDB, or in-memory with STORAGE_BACKEND=memory)
 	var err error
-	dbClient, err = db.NewClient(context.Background(), cfg)
+	dbClient, err = db.NewStore(context.Background(), cfg)
 	if err != nil {
-		slog.Error("failed to initialize DynamoDB client", "error", err.Error())
+		slog.Error("failed to initialize storage", "backend", cfg.StorageBackend, "error", err.Error())
 		os.Exit(1)
 	}
+	slog.Info("storage initialized", "backend", cfg.StorageBackend)
 
-	// Initialize Redis
-	redisClient, err = cache.NewRedisClient(context.Background(), cfg)
+	// Initialize the leaderboard (Redis, or in-process with LEADERBOARD_BACKEND=memory)
+	leaderboardCache, err = cache.NewLeaderboard(context.Background(), cfg)
 	if err != nil {
-		slog.Error("failed to initialize Redis client", "error", err.Error())
+		slog.Error("failed to initialize leaderboard", "backend", cfg.LeaderboardBackend, "error", err.Error())
+		os.Exit(1)
+	}
+	defer leaderboardCache.Close()
+
+	// Initialize the media store for question media (disabled unless MEDIA_BACKEND or MEDIA_DIR is set)
+	mediaStore, err = media.New(cfg)
+	if err != nil {
+		slog.Error("failed to initialize media store", "backend", cfg.MediaBackend, "error", err.Error())
 		os.Exit(1)
 	}
-	defer redisClient.Close()
 
 	// Initialize Cognito validator (async — don't block startup)
 	validator = auth.NewCognitoValidator(cfg.CognitoRegion, cfg.CognitoUserPoolID, cfg.CognitoClientID)
@@ -69,7 +84,15 @@ func main() {
 	hub = game.NewHub()
 	broadcaster = game.NewBroadcaster(dbClient, cfg.Env)
 	broadcaster.SetHub(hub)
-	gameEngine = game.NewEngine(dbClient, redisClient, broadcaster)
+	// Every connection is served by this process, so session state is cached in-process
+	gameEngine = game.NewEngine(dbClient, leaderboardCache, cache.NewMemorySessionCache(cfg), broadcaster)
+	gameEngine.Media, gameEngine.MediaURLTTL = mediaStore, cfg.MediaURLTTL
+
+	// Finalize abandoned sessions in the background
+	reaperCtx, stopReaper := context.WithCancel(context.Background())
+	defer stopReaper()
+	go gameEngine.RunReaper(reaperCtx, cfg.ReaperInterval, cfg.SessionIdleTimeout)
+	slog.Info("idle session reaper started", "interval", cfg.ReaperInterval.String(), "idleTimeout", cfg.SessionIdleTimeout.String())
 
 	// Setup routes
 	mux := http.NewServeMux()
@@ -80,14 +103,38 @@ func main() {
 	// WebSocket endpoint (auth via query param)
 	mux.HandleFunc("/ws", handleWebSocket)
 
+	// Media files behind signed URLs (the signature is the auth)
+	if fileStore, ok := mediaStore.(*media.FileStore); ok {
+		mux.Handle("GET /media/", fileStore)
+	}
+
 	// REST API routes (with auth middleware)
 	authMiddleware := auth.Middleware(validator)
 
 	mux.Handle("POST /api/quizzes", authMiddleware(http.HandlerFunc(handleCreateQuiz)))
+	mux.Handle("GET /api/quizzes", authMiddleware(http.HandlerFunc(handleListQuizzes)))
+	mux.Handle("POST /api/quizzes/import", authMiddleware(http.HandlerFunc(handleImportQuiz)))
 	mux.Handle("GET /api/quizzes/{quizId}", authMiddleware(http.HandlerFunc(handleGetQuiz)))
+	mux.Handle("PUT /api/quizzes/{quizId}", authMiddleware(http.HandlerFunc(handleUpdateQuiz)))
+	mux.Handle("DELETE /api/quizzes/{quizId}", authMiddleware(http.HandlerFunc(handleDeleteQuiz)))
+	mux.Handle("POST /api/quizzes/{quizId}/duplicate", authMiddleware(http.HandlerFunc(handleDuplicateQuiz)))
+	mux.Handle("GET /api/quizzes/{quizId}/export", authMiddleware(http.HandlerFunc(handleExportQuiz)))
+	mux.Handle("GET /api/quizzes/{quizId}/leaderboard", authMiddleware(http.HandlerFunc(handleGetQuizLeaderboard)))
+	mux.Handle("POST /api/question-bank", authMiddleware(http.HandlerFunc(handleCreateBankQuestion)))
+	mux.Handle("GET /api/question-bank", authMiddleware(http.HandlerFunc(handleListBankQuestions)))
+	mux.Handle("GET /api/question-bank/{questionId}", authMiddleware(http.HandlerFunc(handleGetBankQuestion)))
+	mux.Handle("PUT /api/question-bank/{questionId}", authMiddleware(http.HandlerFunc(handleUpdateBankQuestion)))
+	mux.Handle("DELETE /api/question-bank/{questionId}", authMiddleware(http.HandlerFunc(handleDeleteBankQuestion)))
+	mux.Handle("POST /api/media", authMiddleware(http.HandlerFunc(handleUploadMedia)))
+	mux.Handle("GET /api/hosts/{hostUserId}/leaderboard", authMiddleware(http.HandlerFunc(handleGetHostLeaderboard)))
 	mux.Handle("POST /api/sessions", authMiddleware(http.HandlerFunc(handleCreateSession)))
 	mux.Handle("POST /api/sessions/{sessionId}/join", authMiddleware(http.HandlerFunc(handleJoinSession)))
 	mux.Handle("GET /api/sessions/{sessionId}/leaderboard", authMiddleware(http.HandlerFunc(handleGetLeaderboard)))
+	mux.Handle("POST /api/sessions/{sessionId}/leaderboard/rebuild", authMiddleware(http.HandlerFunc(handleRebuildLeaderboard)))
+	mux.Handle("GET /api/sessions/{sessionId}/report", authMiddleware(http.HandlerFunc(handleGetSessionReport)))
+	mux.Handle("GET /api/sessions/{sessionId}/report/export", authMiddleware(http.HandlerFunc(handleExportSessionReport)))
+	mux.Handle("GET /api/me/sessions", authMiddleware(http.HandlerFunc(handleListMySessions)))
+	mux.Handle("GET /api/me/sessions/{sessionId}", authMiddleware(http.HandlerFunc(handleGetMySession)))
 
 	// Note: CORS preflight is handled by corsMiddleware, no need for explicit OPTIONS route
 	// Wrap with logging middleware
@@ -107,6 +154,7 @@ func main() {
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
 		slog.Info("shutting down server...")
+		stopReaper()
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		server.Shutdown(ctx)
@@ -151,7 +199,7 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 		userID = claims.UserID
 	} else {
-		userID = "anon-" + uuid.New().String()[:8]
+		userID = models.NewAnonymousUserID()
 	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -163,7 +211,7 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	connectionID := uuid.New().String()
 
 	// Register in hub
-	hub.Register(connectionID, sessionID, conn)
+	hub.Register(connectionID, sessionID, userID, conn)
 
 	// Register in DynamoDB
 	playerRole := models.PlayerRolePlayer
@@ -229,24 +277,23 @@ func handleCreateQuiz(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetClaims(r.Context())
 
 	var req struct {
-		Title       string            `json:"title"`
-		Description string            `json:"description"`
-		Questions   []models.Question `json:"questions"`
+		Title       string                `json:"title"`
+		Description string                `json:"description"`
+		Tags        []string              `json:"tags"`
+		Questions   []models.Question     `json:"questions"`
+		Rules       []models.QuestionRule `json:"rules"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
 		return
 	}
-	if req.Title == "" {
-		writeError(w, 400, "VALIDATION_ERROR", "Title is required", requestID)
-		return
-	}
-	if len(req.Questions) == 0 {
-		writeError(w, 400, "VALIDATION_ERROR", "At least one question is required", requestID)
+	if err := models.ValidateQuiz(req.Title, req.Questions, req.Rules); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
 		return
 	}
-	if len(req.Questions) > 100 {
-		writeError(w, 400, "VALIDATION_ERROR", "Maximum 100 questions per quiz", requestID)
+	tags := models.NormalizeTags(req.Tags)
+	if err := models.ValidateTags(tags); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
 		return
 	}
 
@@ -261,13 +308,27 @@ func handleCreateQuiz(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	now := time.Now().UTC()
+	// Fill questions linked to the question bank with its current content
+	if err := game.LinkBankQuestions(r.Context(), dbClient, claims.UserID, req.Questions); err != nil {
+		if errors.Is(err, db.ErrBankQuestionNotFound) {
+			writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+			return
+		}
+		slog.Error("failed to link bank questions", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to read the question bank", requestID)
+		return
+	}
+
+	// Whole seconds keep the updatedAt index key in chronological string order
+	now := time.Now().UTC().Truncate(time.Second)
 	quiz := &models.Quiz{
 		QuizID:      uuid.New().String(),
 		HostUserID:  claims.UserID,
 		Title:       req.Title,
 		Description: req.Description,
+		Tags:        tags,
 		Questions:   req.Questions,
+		Rules:       req.Rules,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -303,221 +364,1110 @@ func handleGetQuiz(w http.ResponseWriter, r *http.Request) {
 	writeSuccess(w, 200, quiz, requestID)
 }
 
-func handleCreateSession(w http.ResponseWriter, r *http.Request) {
+func handleUpdateQuiz(w http.ResponseWriter, r *http.Request) {
 	requestID := uuid.New().String()
 	claims := auth.GetClaims(r.Context())
+	quizID := r.PathValue("quizId")
 
 	var req struct {
-		QuizID string `json:"quizId"`
+		Title       string                `json:"title"`
+		Description string                `json:"description"`
+		Tags        []string              `json:"tags"`
+		Questions   []models.Question     `json:"questions"`
+		Rules       []models.QuestionRule `json:"rules"`
+		Version     *int64                `json:"version"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
 		return
 	}
-	if req.QuizID == "" {
-		writeError(w, 400, "VALIDATION_ERROR", "Quiz ID is required", requestID)
+	if req.Version == nil {
+		writeError(w, 400, "VALIDATION_ERROR", "Version is required", requestID)
+		return
+	}
+	if err := models.ValidateQuiz(req.Title, req.Questions, req.Rules); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+	tags := models.NormalizeTags(req.Tags)
+	if err := models.ValidateTags(tags); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
 		return
 	}
 
-	quiz, err := dbClient.GetQuiz(r.Context(), req.QuizID)
-	if err != nil || quiz == nil {
+	quiz, err := dbClient.GetQuiz(r.Context(), quizID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID)
+		return
+	}
+	if quiz == nil {
 		writeError(w, 404, "NOT_FOUND", "Quiz not found", requestID)
 		return
 	}
 	if quiz.HostUserID != claims.UserID {
-		writeError(w, 403, "FORBIDDEN", "You don't own this quiz", requestID)
+		writeError(w, 403, "FORBIDDEN", "You don't have access to this quiz", requestID)
 		return
 	}
 
-	pin, err := generateUniquePIN(r.Context())
-	if err != nil {
-		writeError(w, 500, "INTERNAL_ERROR", "Failed to generate PIN", requestID)
+	for i := range req.Questions {
+		if req.Questions[i].QuestionID == "" {
+			req.Questions[i].QuestionID = uuid.New().String()
+		}
+		for j := range req.Questions[i].Options {
+			if req.Questions[i].Options[j].ID == "" {
+				req.Questions[i].Options[j].ID = uuid.New().String()
+			}
+		}
+	}
+
+	// Fill questions linked to the question bank with its current content
+	if err := game.LinkBankQuestions(r.Context(), dbClient, claims.UserID, req.Questions); err != nil {
+		if errors.Is(err, db.ErrBankQuestionNotFound) {
+			writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+			return
+		}
+		slog.Error("failed to link bank questions", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to read the question bank", requestID)
 		return
 	}
 
-	session := &models.Session{
-		SessionID:            uuid.New().String(),
-		PIN:                  pin,
-		QuizID:               req.QuizID,
-		HostUserID:           claims.UserID,
-		Status:               models.SessionStatusLobby,
-		CurrentQuestionIndex: 0,
-		CreatedAt:            time.Now().UTC(),
+	// Changing questions under a running game would break scoring for its players
+	if !models.SameQuestions(quiz.Questions, req.Questions) {
+		live, err := dbClient.HasLiveSession(r.Context(), quizID)
+		if err != nil {
+			writeError(w, 500, "INTERNAL_ERROR", "Failed to check sessions", requestID)
+			return
+		}
+		if live {
+			writeError(w, 409, "QUIZ_IN_USE", "Questions can't be changed while a session is playing this quiz", requestID)
+			return
+		}
 	}
 
-	if err := dbClient.CreateSession(r.Context(), session); err != nil {
-		writeError(w, 500, "INTERNAL_ERROR", "Failed to create session", requestID)
+	quiz.Title = req.Title
+	quiz.Description = req.Description
+	quiz.Tags = tags
+	quiz.Questions = req.Questions
+	quiz.Rules = req.Rules
+	quiz.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	err = dbClient.UpdateQuiz(r.Context(), quiz, *req.Version)
+	if errors.Is(err, db.ErrVersionConflict) {
+		writeError(w, 409, "VERSION_CONFLICT", "The quiz was changed by someone else; reload and try again", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to update quiz", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to update quiz", requestID)
 		return
 	}
 
-	writeSuccess(w, 201, session, requestID)
+	writeSuccess(w, 200, quiz, requestID)
 }
 
-func handleJoinSession(w http.ResponseWriter, r *http.Request) {
+func handleDeleteQuiz(w http.ResponseWriter, r *http.Request) {
 	requestID := uuid.New().String()
 	claims := auth.GetClaims(r.Context())
-	sessionID := r.PathValue("sessionId")
+	quizID := r.PathValue("quizId")
 
-	var req struct {
-		Nickname string `json:"nickname"`
-		PIN      string `json:"pin"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
+	quiz, err := dbClient.GetQuiz(r.Context(), quizID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID)
 		return
 	}
-	if req.Nickname == "" || len(req.Nickname) > 20 {
-		writeError(w, 400, "VALIDATION_ERROR", "Nickname must be 1-20 characters", requestID)
+	if quiz == nil {
+		writeError(w, 404, "NOT_FOUND", "Quiz not found", requestID)
 		return
 	}
-
-	// Look up by PIN if sessionId not provided
-	if sessionID == "" && req.PIN != "" {
-		session, err := dbClient.GetSessionByPIN(r.Context(), req.PIN)
-		if err != nil || session == nil {
-			writeError(w, 404, "NOT_FOUND", "No session found with this PIN", requestID)
-			return
-		}
-		sessionID = session.SessionID
+	if quiz.HostUserID != claims.UserID {
+		writeError(w, 403, "FORBIDDEN", "You don't have access to this quiz", requestID)
+		return
 	}
 
-	session, err := dbClient.GetSession(r.Context(), sessionID)
-	if err != nil || session == nil {
-		writeError(w, 404, "NOT_FOUND", "Session not found", requestID)
+	live, err := dbClient.HasLiveSession(r.Context(), quizID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to check sessions", requestID)
 		return
 	}
-	if session.Status != models.SessionStatusLobby {
-		writeError(w, 409, "GAME_ALREADY_STARTED", "This game has already started", requestID)
+	if live {
+		writeError(w, 409, "QUIZ_IN_USE", "A quiz can't be deleted while a session is playing it", requestID)
 		return
 	}
 
-	count, _ := dbClient.GetPlayerCountBySession(r.Context(), sessionID)
-	if count >= 2000 {
-		writeError(w, 409, "SESSION_FULL", "Session is full (max 2000 players)", requestID)
+	if err := dbClient.DeleteQuiz(r.Context(), quizID); err != nil {
+		slog.Error("failed to delete quiz", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to delete quiz", requestID)
 		return
 	}
 
-	_ = redisClient.UpsertScore(r.Context(), sessionID, claims.UserID, 0)
-	_ = redisClient.SetNickname(r.Context(), sessionID, claims.UserID, req.Nickname)
-
 	writeSuccess(w, 200, map[string]interface{}{
-		"sessionId": sessionID,
-		"pin":       session.PIN,
-		"nickname":  req.Nickname,
+		"quizId": quizID,
 	}, requestID)
 }
 
-func handleGetLeaderboard(w http.ResponseWriter, r *http.Request) {
+func handleDuplicateQuiz(w http.ResponseWriter, r *http.Request) {
 	requestID := uuid.New().String()
-	sessionID := r.PathValue("sessionId")
+	claims := auth.GetClaims(r.Context())
+	quizID := r.PathValue("quizId")
 
-	leaderboard, err := redisClient.GetTopN(r.Context(), sessionID, 100)
+	quiz, err := dbClient.GetQuiz(r.Context(), quizID)
 	if err != nil {
-		slog.Warn("failed to get leaderboard", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID)
+		return
+	}
+	if quiz == nil {
+		writeError(w, 404, "NOT_FOUND", "Quiz not found", requestID)
+		return
+	}
+	if quiz.HostUserID != claims.UserID {
+		writeError(w, 403, "FORBIDDEN", "You don't have access to this quiz", requestID)
+		return
 	}
 
-	writeSuccess(w, 200, map[string]interface{}{
-		"sessionId":   sessionID,
-		"leaderboard": leaderboard,
-	}, requestID)
-}
+	now := time.Now().UTC().Truncate(time.Second)
+	duplicate := &models.Quiz{
+		QuizID:      uuid.New().String(),
+		HostUserID:  claims.UserID,
+		Title:       quiz.Title + " (copy)",
+		Description: quiz.Description,
+		Tags:        quiz.Tags,
+		Questions:   quiz.Questions,
+		Rules:       quiz.Rules,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
 
-func handleCORS(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-	w.WriteHeader(204)
+	if err := dbClient.CreateQuiz(r.Context(), duplicate); err != nil {
+		slog.Error("failed to duplicate quiz", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to duplicate quiz", requestID)
+		return
+	}
+
+	writeSuccess(w, 201, duplicate, requestID)
 }
 
-// --- Helpers ---
+// handleImportQuiz creates a quiz from an uploaded file; the request body is the file itself.
+func handleImportQuiz(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	query := r.URL.Query()
 
-func generateUniquePIN(ctx context.Context) (string, error) {
-	for attempt := 0; attempt < 10; attempt++ {
-		pin := fmt.Sprintf("%06d", rand.Intn(1000000))
-		existing, err := dbClient.GetSessionByPIN(ctx, pin)
-		if err != nil {
-			return "", err
-		}
-		if existing == nil || existing.Status == models.SessionStatusFinished {
-			return pin, nil
+	imported, files, err := quizio.Import(query.Get("format"), r.Body)
+	var importErr *quizio.ImportError
+	switch {
+	case errors.As(err, &importErr):
+		writeErrorDetails(w, 400, "VALIDATION_ERROR", importErr.Error(), importErr.Errors, requestID)
+		return
+	case errors.Is(err, quizio.ErrUnknownFormat):
+		writeError(w, 400, "VALIDATION_ERROR", "Format must be one of "+strings.Join(quizio.ImportFormats(), ", "), requestID)
+		return
+	case errors.Is(err, quizio.ErrTooLarge):
+		writeError(w, 413, "PAYLOAD_TOO_LARGE", "File is too large", requestID)
+		return
+	case err != nil:
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid file", requestID)
+		return
+	}
+
+	if title := query.Get("title"); title != "" {
+		imported.Title = title
+	}
+	if err := models.ValidateQuiz(imported.Title, imported.Questions, nil); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	if len(files) > 0 && mediaStore == nil {
+		writeError(w, 501, "MEDIA_UNAVAILABLE", "This server has no media store for the file's images", requestID)
+		return
+	}
+	for i, f := range files {
+		if err := media.Put(r.Context(), mediaStore, f.Key, &media.File{ContentType: f.ContentType, Data: f.Data, Thumbnail: f.Thumbnail}); err != nil {
+			slog.Error("failed to store media", "key", f.Key, "error", err.Error())
+			deleteMedia(r.Context(), files[:i])
+			writeError(w, 500, "INTERNAL_ERROR", "Failed to store the file's images", requestID)
+			return
 		}
 	}
-	return "", fmt.Errorf("failed to generate unique PIN")
-}
 
-func writeJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
-}
+	// Whole seconds keep the updatedAt index key in chronological string order
+	now := time.Now().UTC().Truncate(time.Second)
+	quiz := &models.Quiz{
+		QuizID:      uuid.New().String(),
+		HostUserID:  claims.UserID,
+		Title:       imported.Title,
+		Description: imported.Description,
+		Questions:   imported.Questions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
 
-func writeSuccess(w http.ResponseWriter, status int, data interface{}, requestID string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":   true,
-		"data":      data,
-		"requestId": requestID,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
-}
+	if err := dbClient.CreateQuiz(r.Context(), quiz); err != nil {
+		slog.Error("failed to create quiz", "error", err.Error())
+		deleteMedia(r.Context(), files)
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to create quiz", requestID)
+		return
+	}
 
-func writeError(w http.ResponseWriter, status int, code, message, requestID string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":   false,
-		"error":     map[string]string{"code": code, "message": message},
-		"requestId": requestID,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
+	writeSuccess(w, 201, quiz, requestID)
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(204)
-			return
+// deleteMedia removes the images stored for an import that failed, so they aren't orphaned.
+func deleteMedia(ctx context.Context, files []quizio.MediaFile) {
+	for _, f := range files {
+		if err := media.Delete(ctx, mediaStore, f.Key); err != nil {
+			slog.Warn("failed to delete media", "key", f.Key, "error", err.Error())
 		}
-		next.ServeHTTP(w, r)
-	})
+	}
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		requestID := uuid.New().String()
+// handleUploadMedia stores an image or audio clip for a question or option; the request body
+// is the file itself and ?alt= optionally describes it.
+func handleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
 
-		// Wrap response writer to capture status code
-		wrapped := &statusWriter{ResponseWriter: w, status: 200}
+	if mediaStore == nil {
+		writeError(w, 501, "MEDIA_UNAVAILABLE", "This server has no media store", requestID)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, media.MaxUploadSize+1))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
+		return
+	}
+	alt := strings.TrimSpace(r.URL.Query().Get("alt"))
+	if len(alt) > models.MaxMediaAltLength {
+		writeError(w, 400, "VALIDATION_ERROR", fmt.Sprintf("Alt text must be at most %d characters", models.MaxMediaAltLength), requestID)
+		return
+	}
 
-		ctx := observability.WithRequestID(r.Context(), requestID)
-		r = r.WithContext(ctx)
+	file, err := media.Prepare(data)
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		writeError(w, 413, "PAYLOAD_TOO_LARGE", err.Error(), requestID)
+		return
+	case err != nil:
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
 
-		next.ServeHTTP(wrapped, r)
+	uploaded, err := media.Store(r.Context(), mediaStore, file, alt, cfg.MediaURLTTL)
+	if err != nil {
+		slog.Error("failed to store media", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to store the file", requestID)
+		return
+	}
 
-		slog.Info("request",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"status", wrapped.status,
-			"latency", time.Since(start).String(),
-			"requestId", requestID,
-		)
-	})
+	writeSuccess(w, 201, uploaded, requestID)
 }
 
-type statusWriter struct {
-	http.ResponseWriter
-	status int
-}
+// handleExportQuiz downloads a quiz as a file in the requested format.
+func handleExportQuiz(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	quizID := r.PathValue("quizId")
 
-func (w *statusWriter) WriteHeader(status int) {
-	w.status = status
-	w.ResponseWriter.WriteHeader(status)
+	quiz, err := dbClient.GetQuiz(r.Context(), quizID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID)
+		return
+	}
+	if quiz == nil {
+		writeError(w, 404, "NOT_FOUND", "Quiz not found", requestID)
+		return
+	}
+	if quiz.HostUserID != claims.UserID {
+		writeError(w, 403, "FORBIDDEN", "You don't have access to this quiz", requestID)
+		return
+	}
+
+	file, err := quizio.Export(r.URL.Query().Get("format"), quiz, func(key string) (*quizio.MediaFile, error) {
+		if mediaStore == nil {
+			return nil, errors.New("no media store is configured")
+		}
+		data, contentType, err := mediaStore.Get(r.Context(), key)
+		if err != nil {
+			return nil, err
+		}
+		return &quizio.MediaFile{Key: key, ContentType: contentType, Data: data}, nil
+	})
+	switch {
+	case errors.Is(err, quizio.ErrUnknownFormat):
+		writeError(w, 400, "VALIDATION_ERROR", "Format must be one of "+strings.Join(quizio.ExportFormats(), ", "), requestID)
+		return
+	case errors.Is(err, quizio.ErrNotExportable):
+		writeError(w, 422, "QUIZ_NOT_EXPORTABLE", err.Error(), requestID)
+		return
+	case err != nil:
+		slog.Error("failed to export quiz", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to export quiz", requestID)
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	w.WriteHeader(200)
+	w.Write(file.Data)
+}
+
+func handleListQuizzes(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	query := r.URL.Query()
+
+	hostUserID := claims.UserID
+	if requested := query.Get("hostUserId"); requested != "" && requested != claims.UserID {
+		if claims.Role != "admin" {
+			writeError(w, 403, "FORBIDDEN", "You can only list your own quizzes", requestID)
+			return
+		}
+		hostUserID = requested
+	}
+
+	limit, err := game.ParsePageLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	filter := models.QuizFilter{Title: query.Get("title"), Tag: query.Get("tag")}
+	quizzes, nextCursor, err := dbClient.ListQuizzesByHost(r.Context(), hostUserID, filter, limit, query.Get("cursor"))
+	if errors.Is(err, db.ErrInvalidCursor) {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid cursor", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to list quizzes", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to list quizzes", requestID)
+		return
+	}
+	if quizzes == nil {
+		quizzes = []models.Quiz{}
+	}
+
+	writeSuccess(w, 200, map[string]interface{}{
+		"quizzes":    quizzes,
+		"nextCursor": nextCursor,
+	}, requestID)
+}
+
+func handleCreateBankQuestion(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+
+	var req models.BankQuestionInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
+		return
+	}
+	for i := range req.Options {
+		if req.Options[i].ID == "" {
+			req.Options[i].ID = uuid.New().String()
+		}
+	}
+
+	// Whole seconds keep the updatedAt index key in chronological string order
+	now := time.Now().UTC().Truncate(time.Second)
+	question := &models.BankQuestion{
+		Question:   models.Question{QuestionID: uuid.New().String()},
+		HostUserID: claims.UserID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := req.Apply(question); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	if err := dbClient.CreateBankQuestion(r.Context(), question); err != nil {
+		slog.Error("failed to create bank question", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to create question", requestID)
+		return
+	}
+
+	writeSuccess(w, 201, question, requestID)
+}
+
+// ownBankQuestion loads the bank question named in the path and checks the caller owns it,
+// writing the error response and returning nil if not.
+func ownBankQuestion(w http.ResponseWriter, r *http.Request, requestID string) *models.BankQuestion {
+	claims := auth.GetClaims(r.Context())
+
+	question, err := dbClient.GetBankQuestion(r.Context(), r.PathValue("questionId"))
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve question", requestID)
+		return nil
+	}
+	if question == nil {
+		writeError(w, 404, "NOT_FOUND", "Question not found", requestID)
+		return nil
+	}
+	if question.HostUserID != claims.UserID {
+		writeError(w, 403, "FORBIDDEN", "You don't have access to this question", requestID)
+		return nil
+	}
+	return question
+}
+
+func handleGetBankQuestion(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+
+	question := ownBankQuestion(w, r, requestID)
+	if question == nil {
+		return
+	}
+
+	writeSuccess(w, 200, question, requestID)
+}
+
+func handleUpdateBankQuestion(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+
+	var req models.BankQuestionInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
+		return
+	}
+	for i := range req.Options {
+		if req.Options[i].ID == "" {
+			req.Options[i].ID = uuid.New().String()
+		}
+	}
+
+	question := ownBankQuestion(w, r, requestID)
+	if question == nil {
+		return
+	}
+	if err := req.Apply(question); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+	question.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	err := dbClient.UpdateBankQuestion(r.Context(), question)
+	if errors.Is(err, db.ErrBankQuestionNotFound) {
+		writeError(w, 404, "NOT_FOUND", "Question not found", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to update bank question", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to update question", requestID)
+		return
+	}
+
+	writeSuccess(w, 200, question, requestID)
+}
+
+func handleDeleteBankQuestion(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+
+	question := ownBankQuestion(w, r, requestID)
+	if question == nil {
+		return
+	}
+
+	if err := dbClient.DeleteBankQuestion(r.Context(), question.QuestionID); err != nil {
+		slog.Error("failed to delete bank question", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to delete question", requestID)
+		return
+	}
+
+	writeSuccess(w, 200, map[string]interface{}{
+		"questionId": question.QuestionID,
+	}, requestID)
+}
+
+func handleListBankQuestions(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	query := r.URL.Query()
+
+	limit, err := game.ParsePageLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	filter := models.BankFilter{Text: query.Get("q"), Tag: query.Get("tag")}
+	questions, nextCursor, err := dbClient.ListBankQuestions(r.Context(), claims.UserID, filter, limit, query.Get("cursor"))
+	if errors.Is(err, db.ErrInvalidCursor) {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid cursor", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to list bank questions", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to list questions", requestID)
+		return
+	}
+	if questions == nil {
+		questions = []models.BankQuestion{}
+	}
+
+	writeSuccess(w, 200, map[string]interface{}{
+		"questions":  questions,
+		"nextCursor": nextCursor,
+	}, requestID)
+}
+
+func handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+
+	var req struct {
+		QuizID string `json:"quizId"`
+		Seed   *int64 `json:"seed"` // replays the questions drawn by an earlier session
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
+		return
+	}
+	if req.QuizID == "" {
+		writeError(w, 400, "VALIDATION_ERROR", "Quiz ID is required", requestID)
+		return
+	}
+
+	quiz, err := dbClient.GetQuiz(r.Context(), req.QuizID)
+	if err != nil || quiz == nil {
+		writeError(w, 404, "NOT_FOUND", "Quiz not found", requestID)
+		return
+	}
+	if quiz.HostUserID != claims.UserID {
+		writeError(w, 403, "FORBIDDEN", "You don't own this quiz", requestID)
+		return
+	}
+
+	// Freeze the questions this session will play, so later edits don't affect it
+	quiz, err = game.SnapshotQuiz(r.Context(), dbClient, quiz)
+	if errors.Is(err, db.ErrBankQuestionNotFound) {
+		writeError(w, 409, "BANK_QUESTION_NOT_FOUND", err.Error(), requestID)
+		return
+	}
+	var poolErr *game.PoolError
+	if errors.As(err, &poolErr) {
+		writeError(w, 409, "QUESTION_POOL_INVALID", poolErr.Message, requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to snapshot quiz", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to prepare quiz", requestID)
+		return
+	}
+	if quiz == nil {
+		writeError(w, 404, "NOT_FOUND", "Quiz not found", requestID)
+		return
+	}
+
+	// The seed picks the questions the quiz's rules draw for this session
+	var seed int64
+	if len(quiz.Rules) > 0 {
+		if req.Seed != nil {
+			seed = *req.Seed
+		} else {
+			// Within 2^53, so the seed survives a round trip through JavaScript numbers
+			seed = rand.Int64N(1 << 53)
+		}
+	}
+
+	sessionID := uuid.New().String()
+	pin, err := dbClient.AllocatePIN(r.Context(), sessionID)
+	if err != nil {
+		slog.Error("failed to allocate PIN", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to generate PIN", requestID)
+		return
+	}
+
+	session := &models.Session{
+		SessionID:            sessionID,
+		PIN:                  pin,
+		QuizID:               req.QuizID,
+		QuizVersion:          quiz.Version,
+		QuizSeed:             seed,
+		HostUserID:           claims.UserID,
+		Status:               models.SessionStatusLobby,
+		CurrentQuestionIndex: 0,
+		CreatedAt:            time.Now().UTC(),
+	}
+
+	if err := dbClient.CreateSession(r.Context(), session); err != nil {
+		_ = dbClient.ReleasePIN(r.Context(), pin, sessionID)
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to create session", requestID)
+		return
+	}
+
+	writeSuccess(w, 201, session, requestID)
+}
+
+func handleJoinSession(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	sessionID := r.PathValue("sessionId")
+
+	var req struct {
+		Nickname string `json:"nickname"`
+		PIN      string `json:"pin"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid request body", requestID)
+		return
+	}
+	if req.Nickname == "" || len(req.Nickname) > 20 {
+		writeError(w, 400, "VALIDATION_ERROR", "Nickname must be 1-20 characters", requestID)
+		return
+	}
+
+	// Look up by PIN if sessionId not provided
+	if sessionID == "" && req.PIN != "" {
+		session, err := dbClient.GetSessionByPIN(r.Context(), req.PIN)
+		if err != nil || session == nil {
+			writeError(w, 404, "NOT_FOUND", "No session found with this PIN", requestID)
+			return
+		}
+		sessionID = session.SessionID
+	}
+
+	session, err := dbClient.GetSession(r.Context(), sessionID)
+	if err != nil || session == nil {
+		writeError(w, 404, "NOT_FOUND", "Session not found", requestID)
+		return
+	}
+	if session.Status != models.SessionStatusLobby {
+		writeError(w, 409, "GAME_ALREADY_STARTED", "This game has already started", requestID)
+		return
+	}
+
+	count, _ := dbClient.GetPlayerCountBySession(r.Context(), sessionID)
+	if count >= 2000 {
+		writeError(w, 409, "SESSION_FULL", "Session is full (max 2000 players)", requestID)
+		return
+	}
+
+	_ = leaderboardCache.UpsertScore(r.Context(), sessionID, claims.UserID, 0)
+	_ = leaderboardCache.SetNickname(r.Context(), sessionID, claims.UserID, req.Nickname)
+
+	writeSuccess(w, 200, map[string]interface{}{
+		"sessionId": sessionID,
+		"pin":       session.PIN,
+		"nickname":  req.Nickname,
+	}, requestID)
+}
+
+func handleGetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	sessionID := r.PathValue("sessionId")
+
+	session, err := dbClient.GetSession(r.Context(), sessionID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve session", requestID)
+		return
+	}
+	if session == nil {
+		writeError(w, 404, "NOT_FOUND", "Session not found", requestID)
+		return
+	}
+
+	limit, err := game.ParsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	leaderboard, nextCursor, err := game.SessionLeaderboardPage(r.Context(), dbClient, leaderboardCache, session, limit, r.URL.Query().Get("cursor"))
+	if errors.Is(err, db.ErrInvalidCursor) {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid cursor", requestID)
+		return
+	}
+	if err != nil {
+		slog.Warn("failed to get leaderboard", "error", err.Error())
+	}
+
+	writeSuccess(w, 200, map[string]interface{}{
+		"sessionId":   sessionID,
+		"leaderboard": leaderboard,
+		"nextCursor":  nextCursor,
+	}, requestID)
+}
+
+func handleRebuildLeaderboard(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	sessionID := r.PathValue("sessionId")
+
+	session, err := dbClient.GetSession(r.Context(), sessionID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve session", requestID)
+		return
+	}
+	if session == nil {
+		writeError(w, 404, "NOT_FOUND", "Session not found", requestID)
+		return
+	}
+	if session.HostUserID != claims.UserID && claims.Role != "admin" {
+		writeError(w, 403, "FORBIDDEN", "Only the host can rebuild the leaderboard", requestID)
+		return
+	}
+	if session.Status == models.SessionStatusFinished {
+		writeError(w, 409, "GAME_FINISHED", "Finished sessions are served from saved results", requestID)
+		return
+	}
+
+	leaderboard, err := gameEngine.RebuildLeaderboard(r.Context(), sessionID)
+	if errors.Is(err, game.ErrRebuildInProgress) {
+		writeError(w, 409, "REBUILD_IN_PROGRESS", "A leaderboard rebuild is already running", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to rebuild leaderboard", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to rebuild leaderboard", requestID)
+		return
+	}
+	if len(leaderboard) > 100 {
+		leaderboard = leaderboard[:100]
+	}
+
+	writeSuccess(w, 200, map[string]interface{}{
+		"sessionId":   sessionID,
+		"leaderboard": leaderboard,
+	}, requestID)
+}
+
+// handleGetSessionReport returns the post-game report of a session to its host.
+func handleGetSessionReport(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	sessionID := r.PathValue("sessionId")
+
+	session, err := dbClient.GetSession(r.Context(), sessionID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve session", requestID)
+		return
+	}
+	if session == nil {
+		writeError(w, 404, "NOT_FOUND", "Session not found", requestID)
+		return
+	}
+	if session.HostUserID != claims.UserID {
+		writeError(w, 403, "FORBIDDEN", "Only the host can view the session report", requestID)
+		return
+	}
+
+	report, err := game.SessionReport(r.Context(), dbClient, session, models.RankMode(cfg.LeaderboardRankMode))
+	if errors.Is(err, game.ErrQuizNotFound) {
+		writeError(w, 404, "NOT_FOUND", "The session's quiz no longer exists", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to build session report", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to build session report", requestID)
+		return
+	}
+
+	writeSuccess(w, 200, report, requestID)
+}
+
+// handleExportSessionReport downloads a finished session's gradebook (CSV or XLSX) or PDF
+// summary to its host, writing the file to the response as it is generated.
+func handleExportSessionReport(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	sessionID := r.PathValue("sessionId")
+
+	export, err := reportio.NewExport(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", "Format must be one of "+strings.Join(reportio.Formats(), ", "), requestID)
+		return
+	}
+
+	session, err := dbClient.GetSession(r.Context(), sessionID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve session", requestID)
+		return
+	}
+	if session == nil {
+		writeError(w, 404, "NOT_FOUND", "Session not found", requestID)
+		return
+	}
+	if session.HostUserID != claims.UserID {
+		writeError(w, 403, "FORBIDDEN", "Only the host can export the session report", requestID)
+		return
+	}
+	if session.Status != models.SessionStatusFinished {
+		writeError(w, 409, "SESSION_NOT_FINISHED", "Reports can be exported once the game has ended", requestID)
+		return
+	}
+
+	var gradebook *models.Gradebook
+	var report *models.SessionReport
+	var title string
+	if export.NeedsGradebook() {
+		gradebook, err = game.SessionGradebook(r.Context(), dbClient, session)
+		if gradebook != nil {
+			title = gradebook.QuizTitle
+		}
+	} else {
+		report, err = game.SessionReport(r.Context(), dbClient, session, models.RankMode(cfg.LeaderboardRankMode))
+		if report != nil {
+			title = report.QuizTitle
+		}
+	}
+	if errors.Is(err, game.ErrQuizNotFound) {
+		writeError(w, 404, "NOT_FOUND", "The session's quiz no longer exists", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to load session report", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to build session report", requestID)
+		return
+	}
+
+	// Large sessions take longer to write than the server's default write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(5 * time.Minute))
+
+	w.Header().Set("Content-Type", export.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName(title)))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	w.WriteHeader(200)
+	if gradebook != nil {
+		err = export.WriteGradebook(w, gradebook)
+	} else {
+		err = export.WriteReport(w, report)
+	}
+	if err != nil {
+		// The status is already sent; the client sees a truncated download
+		slog.Error("failed to write session report export", "error", err.Error())
+	}
+}
+
+// handleListMySessions lists the sessions the signed-in player answered in, most recent first.
+func handleListMySessions(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	query := r.URL.Query()
+
+	limit, err := game.ParseHistoryLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	sessions, nextCursor, err := game.PlayerHistory(r.Context(), dbClient, claims.UserID, limit, query.Get("cursor"))
+	if errors.Is(err, db.ErrInvalidCursor) {
+		writeError(w, 400, "VALIDATION_ERROR", "Invalid cursor", requestID)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to list player history", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to list sessions", requestID)
+		return
+	}
+
+	writeSuccess(w, 200, map[string]interface{}{
+		"sessions":   sessions,
+		"nextCursor": nextCursor,
+	}, requestID)
+}
+
+// handleGetMySession returns the signed-in player's answers in a finished session beside the
+// correct ones.
+func handleGetMySession(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+
+	review, err := game.ReviewSession(r.Context(), dbClient, r.PathValue("sessionId"), claims.UserID)
+	switch {
+	case errors.Is(err, game.ErrNotPlayed):
+		writeError(w, 404, "NOT_FOUND", "You didn't play in this session", requestID)
+		return
+	case errors.Is(err, game.ErrSessionNotFinished):
+		writeError(w, 409, "SESSION_NOT_FINISHED", "Answers can be reviewed once the game has ended", requestID)
+		return
+	case err != nil:
+		slog.Error("failed to review session", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve session", requestID)
+		return
+	}
+
+	writeSuccess(w, 200, review, requestID)
+}
+
+func handleGetQuizLeaderboard(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	quizID := r.PathValue("quizId")
+
+	from, to, limit, err := game.ParseAllTimeQuery(r.URL.Query().Get("from"), r.URL.Query().Get("to"), r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	quiz, err := dbClient.GetQuiz(r.Context(), quizID)
+	if err != nil {
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve quiz", requestID)
+		return
+	}
+	if quiz == nil {
+		writeError(w, 404, "NOT_FOUND", "Quiz not found", requestID)
+		return
+	}
+	if quiz.HostUserID != claims.UserID && claims.Role != "admin" {
+		writeError(w, 403, "FORBIDDEN", "You don't have access to this quiz", requestID)
+		return
+	}
+
+	results, err := dbClient.ListQuizResults(r.Context(), quizID, from, to)
+	if err != nil {
+		slog.Error("failed to list quiz results", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve leaderboard", requestID)
+		return
+	}
+	writeAllTimeLeaderboard(w, "quizId", quizID, from, to, limit, results, requestID)
+}
+
+func handleGetHostLeaderboard(w http.ResponseWriter, r *http.Request) {
+	requestID := uuid.New().String()
+	claims := auth.GetClaims(r.Context())
+	hostUserID := r.PathValue("hostUserId")
+
+	if hostUserID != claims.UserID && claims.Role != "admin" {
+		writeError(w, 403, "FORBIDDEN", "You can only view your own sessions", requestID)
+		return
+	}
+
+	from, to, limit, err := game.ParseAllTimeQuery(r.URL.Query().Get("from"), r.URL.Query().Get("to"), r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, 400, "VALIDATION_ERROR", err.Error(), requestID)
+		return
+	}
+
+	results, err := dbClient.ListHostResults(r.Context(), hostUserID, from, to)
+	if err != nil {
+		slog.Error("failed to list host results", "error", err.Error())
+		writeError(w, 500, "INTERNAL_ERROR", "Failed to retrieve leaderboard", requestID)
+		return
+	}
+	writeAllTimeLeaderboard(w, "hostUserId", hostUserID, from, to, limit, results, requestID)
+}
+
+// writeAllTimeLeaderboard aggregates session results and writes the top limit players.
+func writeAllTimeLeaderboard(w http.ResponseWriter, key, id string, from, to time.Time, limit int, results []models.PlayerResult, requestID string) {
+	leaderboard := game.AggregateResults(results, leaderboardCache.Mode())
+	if len(leaderboard) > limit {
+		leaderboard = leaderboard[:limit]
+	}
+
+	writeSuccess(w, 200, map[string]interface{}{
+		key:           id,
+		"from":        from,
+		"to":          to,
+		"leaderboard": leaderboard,
+	}, requestID)
+}
+
+func handleCORS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(204)
+}
+
+// --- Helpers ---
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeSuccess(w http.ResponseWriter, status int, data interface{}, requestID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code, message, requestID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]string{"code": code, "message": message},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// writeErrorDetails writes an error whose cause is a list of problems, such as the rows of an
+// imported file that failed validation.
+func writeErrorDetails(w http.ResponseWriter, status int, code, message string, details interface{}, requestID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   false,
+		"error":     map[string]interface{}{"code": code, "message": message, "details": details},
+		"requestId": requestID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(204)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := uuid.New().String()
+
+		// Wrap response writer to capture status code
+		wrapped := &statusWriter{ResponseWriter: w, status: 200}
+
+		ctx := observability.WithRequestID(r.Context(), requestID)
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(wrapped, r)
+
+		slog.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", wrapped.status,
+			"latency", time.Since(start).String(),
+			"requestId", requestID,
+		)
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to extend the write
+// deadline of a long download.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
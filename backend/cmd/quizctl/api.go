@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"kahootclone/internal/quizio"
+)
+
+// apiClient calls the quiz API as one user.
+type apiClient struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func newAPIClient(base, token string) *apiClient {
+	return &apiClient{
+		base:  strings.TrimSuffix(base, "/"),
+		token: token,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// apiResponse is the envelope every API response is wrapped in.
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   struct {
+		Code    string             `json:"code"`
+		Message string             `json:"message"`
+		Details []quizio.LineError `json:"details"`
+	} `json:"error"`
+}
+
+// apiError is an error response from the API.
+type apiError struct {
+	Code    string
+	Message string
+	Details []quizio.LineError // file problems, for imports
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("server rejected the request: %s (%s)", e.Message, e.Code)
+}
+
+// do sends a request with an optional body and decodes the response's data into out.
+// Error responses are returned as *apiError.
+func (c *apiClient) do(method, path, contentType string, body []byte, out any) error {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.base+path, r)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var envelope apiResponse
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	if !envelope.Success {
+		return &apiError{Code: envelope.Error.Code, Message: envelope.Error.Message, Details: envelope.Error.Details}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// doJSON sends v as a JSON body.
+func (c *apiClient) doJSON(method, path string, v, out any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.do(method, path, "application/json", body, out)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+
+	"kahootclone/internal/models"
+)
+
+// lintedQuiz is a Markdown quiz file that parsed cleanly.
+type lintedQuiz struct {
+	path string
+	quiz *models.Quiz
+}
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	push := fs.Bool("push", false, "create or update each quiz through the API once every file passes")
+	api := fs.String("api", os.Getenv("QUIZCTL_API"), "server base URL for -push, e.g. http://localhost:8080")
+	token := fs.String("token", os.Getenv("QUIZCTL_TOKEN"), "access token for -api")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		usage()
+	}
+
+	var quizzes []lintedQuiz
+	failed := false
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		quiz, err := models.ParseMarkdownQuiz(data)
+		var mdErr *models.MarkdownError
+		if errors.As(err, &mdErr) {
+			for _, issue := range mdErr.Issues {
+				fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", path, issue.Line, issue.Column, issue.Message)
+			}
+			failed = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		quizzes = append(quizzes, lintedQuiz{path: path, quiz: quiz})
+	}
+	if failed {
+		return errReported
+	}
+
+	if !*push {
+		for _, l := range quizzes {
+			fmt.Printf("%s: ok, %d questions\n", l.path, len(l.quiz.Questions))
+		}
+		return nil
+	}
+	if *api == "" {
+		return errors.New("-push needs -api or $QUIZCTL_API")
+	}
+	client := newAPIClient(*api, *token)
+	for _, l := range quizzes {
+		if err := pushQuiz(client, l); err != nil {
+			return fmt.Errorf("%s: %w", l.path, err)
+		}
+	}
+	return nil
+}
+
+// quizRequest is the body of the create and update quiz endpoints.
+type quizRequest struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Questions   []models.Question `json:"questions"`
+	Version     *int64            `json:"version,omitempty"`
+}
+
+// pushQuiz creates the quiz, or updates the one named by its id if the file changed it.
+func pushQuiz(c *apiClient, l lintedQuiz) error {
+	quiz := l.quiz
+	req := quizRequest{
+		Title:       quiz.Title,
+		Description: quiz.Description,
+		Tags:        quiz.Tags,
+		Questions:   quiz.Questions,
+	}
+
+	if quiz.QuizID == "" {
+		var created models.Quiz
+		if err := c.doJSON(http.MethodPost, "/api/quizzes", req, &created); err != nil {
+			return err
+		}
+		fmt.Printf("%s: created quiz %s; add \"id: %s\" to its front matter so later pushes update it\n", l.path, created.QuizID, created.QuizID)
+		return nil
+	}
+
+	path := "/api/quizzes/" + url.PathEscape(quiz.QuizID)
+	var current models.Quiz
+	if err := c.do(http.MethodGet, path, "", nil, &current); err != nil {
+		return err
+	}
+	if current.Title == quiz.Title && current.Description == quiz.Description &&
+		slices.Equal(current.Tags, quiz.Tags) && models.SameQuestions(current.Questions, quiz.Questions) {
+		fmt.Printf("%s: quiz %s is up to date\n", l.path, quiz.QuizID)
+		return nil
+	}
+
+	req.Version = &current.Version
+	var updated models.Quiz
+	if err := c.doJSON(http.MethodPut, path, req, &updated); err != nil {
+		return err
+	}
+	fmt.Printf("%s: updated quiz %s to version %d\n", l.path, updated.QuizID, updated.Version)
+	return nil
+}
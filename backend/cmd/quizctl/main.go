@@ -0,0 +1,176 @@
+// Command quizctl works with quiz files from the command line.
+//
+//	quizctl import [-format csv|xlsx|gift|aiken|moodlexml|qti] [-title T] [-api URL -token JWT] FILE
+//	quizctl lint [-push] [-api URL -token JWT] FILE...
+//
+// import checks a spreadsheet in the layout documented in internal/quizio, a GIFT, Aiken or
+// Moodle XML question bank, or a QTI 2.1 zip package, printing every problem as
+// FILE:LINE: message (FILE(ITEM):LINE: message for a file inside a package). With -api it
+// then uploads the file to the server's import endpoint and creates the quiz; without it, it
+// prints the quiz that would be created.
+//
+// lint checks Markdown quizzes in the format documented in internal/models, printing every
+// problem as FILE:LINE:COLUMN: message. With -push it then creates each quiz, or updates the
+// quiz named by the id in its front matter when the file has changed it.
+//
+// -api and -token default to $QUIZCTL_API and $QUIZCTL_TOKEN.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kahootclone/internal/models"
+	"kahootclone/internal/quizio"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(os.Args[2:])
+	case "lint":
+		err = runLint(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "quizctl: unknown command %q\n", os.Args[1])
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "quizctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: quizctl import [-format csv|xlsx|gift|aiken|moodlexml|qti] [-title T] [-api URL -token JWT] FILE")
+	fmt.Fprintln(os.Stderr, "       quizctl lint [-push] [-api URL -token JWT] FILE...")
+	os.Exit(2)
+}
+
+// errReported means the problems were already printed.
+var errReported = errors.New("file has errors")
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "file format, one of "+strings.Join(quizio.ImportFormats(), ", ")+" (default: from the file extension)")
+	title := fs.String("title", "", "quiz title (default: the file's category, or the file name)")
+	api := fs.String("api", os.Getenv("QUIZCTL_API"), "server base URL, e.g. http://localhost:8080; omit to only check the file")
+	token := fs.String("token", os.Getenv("QUIZCTL_TOKEN"), "access token for -api")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	path := fs.Arg(0)
+
+	if *format == "" {
+		*format = formatFromPath(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Check locally first so problems are reported against the file name
+	quiz, files, err := quizio.Import(*format, bytes.NewReader(data))
+	var importErr *quizio.ImportError
+	if errors.As(err, &importErr) {
+		printLineErrors(path, importErr.Errors)
+		return errReported
+	}
+	if err != nil {
+		return err
+	}
+	// GIFT and Moodle XML files may name the quiz with a category; spreadsheets and Aiken can't
+	if *title == "" && quiz.Title == "" {
+		*title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if *title != "" {
+		quiz.Title = *title
+	}
+	if err := models.ValidateQuiz(quiz.Title, quiz.Questions, nil); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	if *api == "" {
+		out, _ := json.MarshalIndent(quiz, "", "  ")
+		fmt.Println(string(out))
+		if len(files) > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %d images would be stored in the media store\n", path, len(files))
+		}
+		return nil
+	}
+
+	created, err := upload(*api, *token, *format, *title, data)
+	if errors.As(err, &importErr) {
+		printLineErrors(path, importErr.Errors)
+		return errReported
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created quiz %s %q with %d questions\n", created.QuizID, created.Title, len(created.Questions))
+	return nil
+}
+
+// formatFromPath infers a file's format from its extension, including the double extensions
+// export gives text formats, such as quiz.gift.txt.
+func formatFromPath(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	switch ext {
+	case "xml":
+		return quizio.FormatMoodleXML
+	case "zip":
+		return quizio.FormatQTI
+	case "txt":
+		return strings.TrimPrefix(filepath.Ext(strings.TrimSuffix(name, ".txt")), ".")
+	}
+	return ext
+}
+
+// upload posts a file to the import endpoint and returns the created quiz.
+func upload(api, token, format, title string, data []byte) (*models.Quiz, error) {
+	query := url.Values{"format": {format}}
+	if title != "" {
+		query.Set("title", title)
+	}
+	var quiz models.Quiz
+	err := newAPIClient(api, token).do(http.MethodPost, "/api/quizzes/import?"+query.Encode(), "application/octet-stream", data, &quiz)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && len(apiErr.Details) > 0 {
+		return nil, &quizio.ImportError{Errors: apiErr.Details}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quiz, nil
+}
+
+func printLineErrors(path string, errs []quizio.LineError) {
+	for _, e := range errs {
+		path := path
+		if e.File != "" {
+			path += "(" + e.File + ")"
+		}
+		if e.Column != "" {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s: %s\n", path, e.Line, e.Column, e.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s\n", path, e.Line, e.Message)
+		}
+	}
+}
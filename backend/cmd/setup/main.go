@@ -44,10 +44,37 @@ func main() {
 				BillingMode: types.BillingModePayPerRequest,
 				AttributeDefinitions: []types.AttributeDefinition{
 					{AttributeName: aws.String("quizId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("hostUserId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("updatedAt"), AttributeType: types.ScalarAttributeTypeS},
 				},
 				KeySchema: []types.KeySchemaElement{
 					{AttributeName: aws.String("quizId"), KeyType: types.KeyTypeHash},
 				},
+				GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+					{
+						IndexName: aws.String("hostUserId-index"),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("hostUserId"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String("updatedAt"), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+					},
+				},
+			},
+		},
+		{
+			name: "kahootclone-quiz-versions",
+			input: &dynamodb.CreateTableInput{
+				TableName:   aws.String("kahootclone-quiz-versions"),
+				BillingMode: types.BillingModePayPerRequest,
+				AttributeDefinitions: []types.AttributeDefinition{
+					{AttributeName: aws.String("quizId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("version"), AttributeType: types.ScalarAttributeTypeN},
+				},
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("quizId"), KeyType: types.KeyTypeHash},
+					{AttributeName: aws.String("version"), KeyType: types.KeyTypeRange},
+				},
 			},
 		},
 		{
@@ -58,6 +85,10 @@ func main() {
 				AttributeDefinitions: []types.AttributeDefinition{
 					{AttributeName: aws.String("sessionId"), AttributeType: types.ScalarAttributeTypeS},
 					{AttributeName: aws.String("pin"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("status"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("lastActivityAt"), AttributeType: types.ScalarAttributeTypeN},
+					{AttributeName: aws.String("quizId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("createdAt"), AttributeType: types.ScalarAttributeTypeS},
 				},
 				KeySchema: []types.KeySchemaElement{
 					{AttributeName: aws.String("sessionId"), KeyType: types.KeyTypeHash},
@@ -70,6 +101,22 @@ func main() {
 						},
 						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
 					},
+					{
+						IndexName: aws.String("status-index"),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("status"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String("lastActivityAt"), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+					},
+					{
+						IndexName: aws.String("quizId-createdAt-index"),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("quizId"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String("createdAt"), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+					},
 				},
 			},
 		},
@@ -105,11 +152,98 @@ func main() {
 				AttributeDefinitions: []types.AttributeDefinition{
 					{AttributeName: aws.String("sessionId"), AttributeType: types.ScalarAttributeTypeS},
 					{AttributeName: aws.String("userIdQuestionId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("historyUserId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("answeredAt"), AttributeType: types.ScalarAttributeTypeS},
 				},
 				KeySchema: []types.KeySchemaElement{
 					{AttributeName: aws.String("sessionId"), KeyType: types.KeyTypeHash},
 					{AttributeName: aws.String("userIdQuestionId"), KeyType: types.KeyTypeRange},
 				},
+				GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+					{
+						// Sparse: only authenticated players' answers carry historyUserId
+						IndexName: aws.String("historyUserId-answeredAt-index"),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("historyUserId"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String("answeredAt"), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly},
+					},
+				},
+			},
+		},
+		{
+			name: "kahootclone-pins",
+			input: &dynamodb.CreateTableInput{
+				TableName:   aws.String("kahootclone-pins"),
+				BillingMode: types.BillingModePayPerRequest,
+				AttributeDefinitions: []types.AttributeDefinition{
+					{AttributeName: aws.String("pin"), AttributeType: types.ScalarAttributeTypeS},
+				},
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("pin"), KeyType: types.KeyTypeHash},
+				},
+			},
+		},
+		{
+			name: "kahootclone-results",
+			input: &dynamodb.CreateTableInput{
+				TableName:   aws.String("kahootclone-results"),
+				BillingMode: types.BillingModePayPerRequest,
+				AttributeDefinitions: []types.AttributeDefinition{
+					{AttributeName: aws.String("sessionId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("recordKey"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("quizId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("hostUserId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("finishedAt"), AttributeType: types.ScalarAttributeTypeN},
+				},
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("sessionId"), KeyType: types.KeyTypeHash},
+					{AttributeName: aws.String("recordKey"), KeyType: types.KeyTypeRange},
+				},
+				GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+					{
+						IndexName: aws.String("quizId-finishedAt-index"),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("quizId"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String("finishedAt"), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+					},
+					{
+						IndexName: aws.String("hostUserId-finishedAt-index"),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("hostUserId"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String("finishedAt"), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+					},
+				},
+			},
+		},
+		{
+			name: "kahootclone-question-bank",
+			input: &dynamodb.CreateTableInput{
+				TableName:   aws.String("kahootclone-question-bank"),
+				BillingMode: types.BillingModePayPerRequest,
+				AttributeDefinitions: []types.AttributeDefinition{
+					{AttributeName: aws.String("questionId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("hostUserId"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("updatedAt"), AttributeType: types.ScalarAttributeTypeS},
+				},
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("questionId"), KeyType: types.KeyTypeHash},
+				},
+				GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+					{
+						IndexName: aws.String("hostUserId-index"),
+						KeySchema: []types.KeySchemaElement{
+							{AttributeName: aws.String("hostUserId"), KeyType: types.KeyTypeHash},
+							{AttributeName: aws.String("updatedAt"), KeyType: types.KeyTypeRange},
+						},
+						Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+					},
+				},
 			},
 		},
 	}
@@ -124,6 +258,22 @@ func main() {
 		}
 	}
 
+	// Enable TTL so expired sessions, answers, connections and PIN reservations are removed
+	for _, name := range []string{"kahootclone-sessions", "kahootclone-connections", "kahootclone-answers", "kahootclone-pins"} {
+		_, err := client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName: aws.String(name),
+			TimeToLiveSpecification: &types.TimeToLiveSpecification{
+				AttributeName: aws.String("ttl"),
+				Enabled:       aws.Bool(true),
+			},
+		})
+		if err != nil {
+			fmt.Printf("  ⚠ TTL on %s: %v\n", name, err)
+		} else {
+			fmt.Printf("  ⏱ TTL enabled on %s\n", name)
+		}
+	}
+
 	// List tables to verify
 	fmt.Println("\nVerifying tables...")
 	result, err := client.ListTables(ctx, &dynamodb.ListTablesInput{})
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+)
+
+// Leaderboard backends selectable with LEADERBOARD_BACKEND.
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+var (
+	// ErrSessionMissing is returned by CountAnswer when a session's leaderboard has been
+	// lost and no rebuild is running.
+	ErrSessionMissing = errors.New("leaderboard session missing")
+	// ErrPlayerNotFound is returned for a player who isn't on the session's leaderboard.
+	ErrPlayerNotFound = errors.New("not found in leaderboard")
+)
+
+// PendingAnswer is an answer submitted while a rebuild held the lock, left for the rebuild
+// to add if its read of the answers missed it.
+type PendingAnswer struct {
+	UserID     string
+	QuestionID string
+}
+
+// Leaderboard is the real-time per-session leaderboard used by the game engine.
+// Players are ordered by points descending, then cumulative answer time ascending, then
+// userId descending; ranks follow Mode.
+type Leaderboard interface {
+	// Mode returns how tied players are ranked.
+	Mode() models.RankMode
+
+	UpsertScore(ctx context.Context, sessionID, userID string, score float64) error
+	IncrementScore(ctx context.Context, sessionID, userID string, delta float64, timeMs int64) error
+	// CountAnswer adds a stored answer's points and time like IncrementScore, but never
+	// recreates a lost leaderboard: it returns ErrSessionMissing if the session is gone, and
+	// while a rebuild holds the lock it queues the answer for FinishRebuild instead.
+	CountAnswer(ctx context.Context, sessionID, userID, questionID string, delta float64, timeMs int64) error
+	SetNickname(ctx context.Context, sessionID, userID, nickname string) error
+
+	GetTopN(ctx context.Context, sessionID string, n int) ([]models.PlayerScore, error)
+	GetRange(ctx context.Context, sessionID string, offset, n int) ([]models.PlayerScore, error)
+	GetAroundPlayer(ctx context.Context, sessionID, userID string, n int) ([]models.PlayerScore, error)
+	GetPlayerRank(ctx context.Context, sessionID, userID string) (int64, error)
+	GetPlayerScore(ctx context.Context, sessionID, userID string) (float64, error)
+	GetPlayerCount(ctx context.Context, sessionID string) (int64, error)
+
+	SnapshotRanks(ctx context.Context, sessionID string, scores []models.PlayerScore) error
+	GetPreviousRanks(ctx context.Context, sessionID string) (map[string]int64, error)
+	GetPreviousRank(ctx context.Context, sessionID, userID string) (int64, error)
+
+	DeleteSession(ctx context.Context, sessionID string) error
+	SessionExists(ctx context.Context, sessionID string) (bool, error)
+	ReplaceSession(ctx context.Context, sessionID string, scores []models.PlayerScore) error
+	// AcquireRebuildLock returns a token identifying the holder, or "" if the lock is held.
+	// The release methods only act while the lock is still held with that token, so a
+	// rebuild that outlived the lock's TTL can't free a lock someone else has since taken.
+	AcquireRebuildLock(ctx context.Context, sessionID string) (string, error)
+	// FinishRebuild frees the lock if no answers were queued while it was held. Otherwise it
+	// keeps the lock and returns the queued answers, which the caller counts before calling
+	// FinishRebuild again.
+	FinishRebuild(ctx context.Context, sessionID, token string) ([]PendingAnswer, error)
+	// ReleaseRebuildLock frees the lock after a failed rebuild, dropping queued answers.
+	ReleaseRebuildLock(ctx context.Context, sessionID, token string) error
+
+	Close() error
+}
+
+var (
+	_ Leaderboard = (*RedisClient)(nil)
+	_ Leaderboard = (*MemoryLeaderboard)(nil)
+)
+
+// NewLeaderboard returns the leaderboard backend selected by cfg.LeaderboardBackend.
+func NewLeaderboard(ctx context.Context, cfg *config.Config) (Leaderboard, error) {
+	switch cfg.LeaderboardBackend {
+	case BackendRedis:
+		return NewRedisClient(ctx, cfg)
+	case BackendMemory:
+		return NewMemoryLeaderboard(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown leaderboard backend %q", cfg.LeaderboardBackend)
+	}
+}
+
+// fallbackNickname is shown for players whose nickname was never recorded.
+func fallbackNickname(userID string) string {
+	if len(userID) > 8 {
+		return userID[:8]
+	}
+	return userID
+}
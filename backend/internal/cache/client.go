@@ -3,15 +3,20 @@ package cache
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
 	"kahootclone/internal/config"
+	"kahootclone/internal/models"
 )
 
 // RedisClient wraps the go-redis client.
 type RedisClient struct {
 	Client *redis.Client
+	KeyTTL time.Duration // expiry refreshed on every session key write so abandoned sessions age out
+
+	RankMode models.RankMode // how tied players are ranked
 }
 
 // NewRedisClient creates a new Redis client from the application config.
@@ -30,7 +35,16 @@ func NewRedisClient(ctx context.Context, cfg *config.Config) (*RedisClient, erro
 		slog.Info("Redis client connected", "addr", cfg.RedisAddr)
 	}
 
-	return &RedisClient{Client: rdb}, nil
+	return &RedisClient{
+		Client:   rdb,
+		KeyTTL:   cfg.SessionTTL,
+		RankMode: models.RankMode(cfg.LeaderboardRankMode),
+	}, nil
+}
+
+// Mode returns how tied players are ranked.
+func (r *RedisClient) Mode() models.RankMode {
+	return r.RankMode
 }
 
 // Close closes the Redis connection.
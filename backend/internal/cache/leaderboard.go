@@ -3,8 +3,12 @@ package cache
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
 	"kahootclone/internal/models"
@@ -24,27 +28,203 @@ func nicknameKey(sessionID string) string {
 	return nicknameKeyPrefix + sessionID
 }
 
-// UpsertScore adds or updates a player's score in the leaderboard.
+// pointsKey is a sorted set of the distinct point totals in a session, used for dense ranks.
+const pointsKeyPrefix = "leaderboard-points:"
+
+func pointsKey(sessionID string) string {
+	return pointsKeyPrefix + sessionID
+}
+
+// pointCountsKey counts how many players hold each point total, so pointsKey
+// entries can be removed when the last player leaves a total.
+const pointCountsKeyPrefix = "leaderboard-point-counts:"
+
+func pointCountsKey(sessionID string) string {
+	return pointCountsKeyPrefix + sessionID
+}
+
+// previousRanksKey holds each player's rank as of the last question close, for rank-change deltas.
+const previousRanksKeyPrefix = "leaderboard-prev-ranks:"
+
+func previousRanksKey(sessionID string) string {
+	return previousRanksKeyPrefix + sessionID
+}
+
+// rebuildLockKey guards a session's leaderboard while it is being rebuilt.
+const rebuildLockKeyPrefix = "leaderboard-rebuild:"
+
+func rebuildLockKey(sessionID string) string {
+	return rebuildLockKeyPrefix + sessionID
+}
+
+// rebuildPendingKey holds the answers queued for the rebuild holding the lock.
+const rebuildPendingKeyPrefix = "leaderboard-rebuild-pending:"
+
+func rebuildPendingKey(sessionID string) string {
+	return rebuildPendingKeyPrefix + sessionID
+}
+
+// pendingMember encodes a queued answer as a member of the pending set.
+func pendingMember(userID, questionID string) string {
+	return userID + "\x1f" + questionID
+}
+
+// rebuildLockTTL bounds how long a crashed rebuild can block the next one.
+const rebuildLockTTL = 30 * time.Second
+
+// timeScale splits a sorted-set score into points (high part) and a time bonus (low part):
+//
+//	score = points*timeScale + (timeScale - 1 - totalTimeMs)
+//
+// so equal points are ordered by cumulative answer time, fastest first. Cumulative time is
+// capped just below timeScale (~11 days) and points stay exact in a float64 up to ~9 million.
+const timeScale = 1e9
+
+func encodeScore(points float64, totalTimeMs int64) float64 {
+	if totalTimeMs < 0 {
+		totalTimeMs = 0
+	}
+	if totalTimeMs > timeScale-1 {
+		totalTimeMs = timeScale - 1
+	}
+	return points*timeScale + float64(timeScale-1-totalTimeMs)
+}
+
+func decodeScore(score float64) (points float64, totalTimeMs int64) {
+	points = math.Floor(score / timeScale)
+	totalTimeMs = int64(timeScale - 1 - (score - points*timeScale))
+	return points, totalTimeMs
+}
+
+// setScoreScript sets (or, when ARGV[4] is "1", increments) a player's points and cumulative
+// time, keeping the distinct-points set and its per-total counts in step.
+// KEYS: leaderboard, points, point counts. ARGV: member, points, timeMs, incr, ttlSeconds, timeScale.
+// Numbers are formatted with %.0f because Lua's default conversion only keeps 14 digits.
+var setScoreScript = redis.NewScript(setScoreLua)
+
+const setScoreLua = `
+local lb, pts, cnt = KEYS[1], KEYS[2], KEYS[3]
+local member = ARGV[1]
+local p = tonumber(ARGV[2])
+local t = tonumber(ARGV[3])
+local incr = ARGV[4] == "1"
+local ttl = tonumber(ARGV[5])
+local scale = tonumber(ARGV[6])
+
+local cur = redis.call("ZSCORE", lb, member)
+if cur then
+	cur = tonumber(cur)
+	local oldP = math.floor(cur / scale)
+	local oldT = scale - 1 - (cur - oldP * scale)
+	if incr then
+		p = p + oldP
+		t = t + oldT
+	end
+	local oldKey = string.format("%.0f", oldP)
+	if redis.call("HINCRBY", cnt, oldKey, -1) <= 0 then
+		redis.call("HDEL", cnt, oldKey)
+		redis.call("ZREM", pts, oldKey)
+	end
+end
+
+if t < 0 then t = 0 end
+if t > scale - 1 then t = scale - 1 end
+
+local newKey = string.format("%.0f", p)
+redis.call("ZADD", lb, string.format("%.0f", p * scale + (scale - 1 - t)), member)
+redis.call("HINCRBY", cnt, newKey, 1)
+redis.call("ZADD", pts, newKey, newKey)
+
+if ttl > 0 then
+	redis.call("EXPIRE", lb, ttl)
+	redis.call("EXPIRE", pts, ttl)
+	redis.call("EXPIRE", cnt, ttl)
+end
+return newKey
+`
+
+func (r *RedisClient) setScore(ctx context.Context, sessionID, userID string, points float64, timeMs int64, incr bool) error {
+	incrArg := "0"
+	if incr {
+		incrArg = "1"
+	}
+	keys := []string{leaderboardKey(sessionID), pointsKey(sessionID), pointCountsKey(sessionID)}
+	return setScoreScript.Run(ctx, r.Client, keys,
+		userID,
+		strconv.FormatFloat(points, 'f', 0, 64),
+		timeMs,
+		incrArg,
+		int64(r.KeyTTL/time.Second),
+		int64(timeScale),
+	).Err()
+}
+
+// countAnswerScript increments like setScoreScript, unless a rebuild holds the lock, when it
+// queues the answer in the pending set, or the leaderboard is gone, when it writes nothing.
+// KEYS: leaderboard, points, point counts, rebuild lock, pending. ARGV: as setScoreScript,
+// then the pending member and the lock TTL in seconds.
+var countAnswerScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[4]) == 1 then
+	redis.call("SADD", KEYS[5], ARGV[7])
+	redis.call("EXPIRE", KEYS[5], ARGV[8])
+	return "queued"
+end
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return "missing"
+end
+` + setScoreLua)
+
+// UpsertScore sets a player's score in the leaderboard and resets their cumulative time.
 func (r *RedisClient) UpsertScore(ctx context.Context, sessionID, userID string, score float64) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	observability.Debug(ctx, "upserting score", "sessionId", sessionID, "userId", userID, "score", score)
 
-	return r.Client.ZAdd(ctx, leaderboardKey(sessionID), redis.Z{
-		Score:  score,
-		Member: userID,
-	}).Err()
+	return r.setScore(ctx, sessionID, userID, score, 0, false)
+}
+
+// IncrementScore atomically adds points and answer time to a player's totals.
+// The time is used to break ties between players with equal points.
+func (r *RedisClient) IncrementScore(ctx context.Context, sessionID, userID string, delta float64, timeMs int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "incrementing score", "sessionId", sessionID, "userId", userID, "delta", delta, "timeMs", timeMs)
+
+	return r.setScore(ctx, sessionID, userID, delta, timeMs, true)
 }
 
-// IncrementScore atomically increments a player's score.
-func (r *RedisClient) IncrementScore(ctx context.Context, sessionID, userID string, delta float64) error {
+// CountAnswer adds a stored answer's points and time to a player's totals. It returns
+// ErrSessionMissing if the leaderboard is gone, and queues the answer for FinishRebuild
+// while a rebuild holds the lock.
+func (r *RedisClient) CountAnswer(ctx context.Context, sessionID, userID, questionID string, delta float64, timeMs int64) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	observability.Debug(ctx, "incrementing score", "sessionId", sessionID, "userId", userID, "delta", delta)
+	observability.Debug(ctx, "counting answer", "sessionId", sessionID, "userId", userID, "questionId", questionID, "delta", delta)
 
-	return r.Client.ZIncrBy(ctx, leaderboardKey(sessionID), delta, userID).Err()
+	keys := []string{
+		leaderboardKey(sessionID), pointsKey(sessionID), pointCountsKey(sessionID),
+		rebuildLockKey(sessionID), rebuildPendingKey(sessionID),
+	}
+	result, err := countAnswerScript.Run(ctx, r.Client, keys,
+		userID,
+		strconv.FormatFloat(delta, 'f', 0, 64),
+		timeMs,
+		"1",
+		int64(r.KeyTTL/time.Second),
+		int64(timeScale),
+		pendingMember(userID, questionID),
+		int64(rebuildLockTTL/time.Second),
+	).Text()
+	if err != nil {
+		return err
+	}
+	if result == "missing" {
+		return ErrSessionMissing
+	}
+	return nil
 }
 
 // SetNickname stores a user's nickname for leaderboard display.
@@ -52,56 +232,190 @@ func (r *RedisClient) SetNickname(ctx context.Context, sessionID, userID, nickna
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	return r.Client.HSet(ctx, nicknameKey(sessionID), userID, nickname).Err()
+	pipe := r.Client.Pipeline()
+	pipe.HSet(ctx, nicknameKey(sessionID), userID, nickname)
+	pipe.Expire(ctx, nicknameKey(sessionID), r.KeyTTL)
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
 // GetTopN returns the top N players with scores, sorted descending.
+// Ranks follow the client's RankMode.
 func (r *RedisClient) GetTopN(ctx context.Context, sessionID string, n int) ([]models.PlayerScore, error) {
+	return r.GetRange(ctx, sessionID, 0, n)
+}
+
+// GetRange returns up to n players starting at 0-based position offset, in leaderboard order
+// with ranks under the client's RankMode. N of 0 or less returns every player from offset on.
+func (r *RedisClient) GetRange(ctx context.Context, sessionID string, offset, n int) ([]models.PlayerScore, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	observability.Debug(ctx, "getting top N", "sessionId", sessionID, "n", n)
+	observability.Debug(ctx, "getting leaderboard range", "sessionId", sessionID, "offset", offset, "n", n)
+
+	stop := int64(-1)
+	if n > 0 {
+		stop = int64(offset + n - 1)
+	}
+	return r.rangeScores(ctx, sessionID, int64(offset), stop)
+}
+
+// GetAroundPlayer returns the player's own entry together with up to n players directly
+// above and n directly below them, in leaderboard order with ranks under the client's RankMode.
+func (r *RedisClient) GetAroundPlayer(ctx context.Context, sessionID, userID string, n int) ([]models.PlayerScore, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	results, err := r.Client.ZRevRangeWithScores(ctx, leaderboardKey(sessionID), 0, int64(n-1)).Result()
+	position, err := r.Client.ZRevRank(ctx, leaderboardKey(sessionID), userID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("player %s %w", userID, ErrPlayerNotFound)
+		}
+		return nil, err
+	}
+
+	start := position - int64(n)
+	if start < 0 {
+		start = 0
+	}
+	return r.rangeScores(ctx, sessionID, start, position+int64(n))
+}
+
+// rangeScores reads positions start..stop (0-based, inclusive, as ZREVRANGE) with nicknames
+// and assigns ranks under the client's RankMode.
+func (r *RedisClient) rangeScores(ctx context.Context, sessionID string, start, stop int64) ([]models.PlayerScore, error) {
+	results, err := r.Client.ZRevRangeWithScores(ctx, leaderboardKey(sessionID), start, stop).Result()
 	if err != nil {
 		return nil, err
 	}
+	if len(results) == 0 {
+		return []models.PlayerScore{}, nil
+	}
 
-	// Fetch all nicknames for this session
-	nicknames, _ := r.Client.HGetAll(ctx, nicknameKey(sessionID)).Result()
+	userIDs := make([]string, len(results))
+	for i, z := range results {
+		userIDs[i] = z.Member.(string)
+	}
+	names, _ := r.Client.HMGet(ctx, nicknameKey(sessionID), userIDs...).Result()
 
 	scores := make([]models.PlayerScore, len(results))
 	for i, z := range results {
-		userID := z.Member.(string)
-		nickname := nicknames[userID]
+		var nickname string
+		if i < len(names) {
+			nickname, _ = names[i].(string)
+		}
 		if nickname == "" {
-			nickname = userID[:8] // fallback
+			nickname = fallbackNickname(userIDs[i])
 		}
+		points, totalTimeMs := decodeScore(z.Score)
 		scores[i] = models.PlayerScore{
-			UserID:   userID,
-			Nickname: nickname,
-			Score:    z.Score,
-			Rank:     int64(i + 1),
+			UserID:      userIDs[i],
+			Nickname:    nickname,
+			Score:       points,
+			TotalTimeMs: totalTimeMs,
 		}
 	}
+
+	firstRank := start + 1
+	if r.RankMode == models.RankModeDense {
+		firstRank, err = r.denseRank(ctx, sessionID, scores[0].Score)
+		if err != nil {
+			return nil, err
+		}
+	}
+	models.AssignRanks(scores, firstRank, r.RankMode)
 	return scores, nil
 }
 
-// GetPlayerRank returns a player's rank (1-indexed from top).
+// SnapshotRanks records every player's current rank so later rank changes can be reported.
+// Called when a question closes with the full, ranked leaderboard.
+func (r *RedisClient) SnapshotRanks(ctx context.Context, sessionID string, scores []models.PlayerScore) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "snapshotting ranks", "sessionId", sessionID, "players", len(scores))
+
+	pipe := r.Client.TxPipeline()
+	pipe.Del(ctx, previousRanksKey(sessionID))
+	if len(scores) > 0 {
+		ranks := make(map[string]interface{}, len(scores))
+		for _, s := range scores {
+			ranks[s.UserID] = s.Rank
+		}
+		pipe.HSet(ctx, previousRanksKey(sessionID), ranks)
+		pipe.Expire(ctx, previousRanksKey(sessionID), r.KeyTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetPreviousRanks returns the ranks recorded by the last SnapshotRanks call (userId -> rank).
+// The map is empty before the first question has closed.
+func (r *RedisClient) GetPreviousRanks(ctx context.Context, sessionID string) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	values, err := r.Client.HGetAll(ctx, previousRanksKey(sessionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[string]int64, len(values))
+	for userID, v := range values {
+		if rank, err := strconv.ParseInt(v, 10, 64); err == nil {
+			ranks[userID] = rank
+		}
+	}
+	return ranks, nil
+}
+
+// GetPreviousRank returns a player's rank as of the last question close, or 0 if none was recorded.
+func (r *RedisClient) GetPreviousRank(ctx context.Context, sessionID, userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rank, err := r.Client.HGet(ctx, previousRanksKey(sessionID), userID).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return rank, err
+}
+
+// GetPlayerRank returns a player's rank (1-indexed from top) under the client's RankMode.
 func (r *RedisClient) GetPlayerRank(ctx context.Context, sessionID, userID string) (int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	if r.RankMode == models.RankModeDense {
+		score, err := r.Client.ZScore(ctx, leaderboardKey(sessionID), userID).Result()
+		if err != nil {
+			if err == redis.Nil {
+				return -1, fmt.Errorf("player %s %w", userID, ErrPlayerNotFound)
+			}
+			return -1, err
+		}
+		points, _ := decodeScore(score)
+		return r.denseRank(ctx, sessionID, points)
+	}
+
 	rank, err := r.Client.ZRevRank(ctx, leaderboardKey(sessionID), userID).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return -1, fmt.Errorf("player %s not found in leaderboard", userID)
+			return -1, fmt.Errorf("player %s %w", userID, ErrPlayerNotFound)
 		}
 		return -1, err
 	}
 	return rank + 1, nil // Convert 0-indexed to 1-indexed
 }
 
+// denseRank returns 1 + the number of distinct point totals above points.
+func (r *RedisClient) denseRank(ctx context.Context, sessionID string, points float64) (int64, error) {
+	above, err := r.Client.ZCount(ctx, pointsKey(sessionID), "("+strconv.FormatFloat(points, 'f', 0, 64), "+inf").Result()
+	if err != nil {
+		return -1, err
+	}
+	return above + 1, nil
+}
+
 // GetPlayerScore returns a player's current score.
 func (r *RedisClient) GetPlayerScore(ctx context.Context, sessionID, userID string) (float64, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -114,7 +428,8 @@ func (r *RedisClient) GetPlayerScore(ctx context.Context, sessionID, userID stri
 		}
 		return 0, err
 	}
-	return score, nil
+	points, _ := decodeScore(score)
+	return points, nil
 }
 
 // GetPlayerCount returns the total number of players in the leaderboard.
@@ -135,6 +450,136 @@ func (r *RedisClient) DeleteSession(ctx context.Context, sessionID string) error
 	pipe := r.Client.Pipeline()
 	pipe.Del(ctx, leaderboardKey(sessionID))
 	pipe.Del(ctx, nicknameKey(sessionID))
+	pipe.Del(ctx, pointsKey(sessionID))
+	pipe.Del(ctx, pointCountsKey(sessionID))
+	pipe.Del(ctx, previousRanksKey(sessionID))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SessionExists reports whether the leaderboard for a session is present in Redis.
+func (r *RedisClient) SessionExists(ctx context.Context, sessionID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	n, err := r.Client.Exists(ctx, leaderboardKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ReplaceSession atomically replaces a session's leaderboard and nicknames with the given scores.
+func (r *RedisClient) ReplaceSession(ctx context.Context, sessionID string, scores []models.PlayerScore) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "replacing session leaderboard", "sessionId", sessionID, "players", len(scores))
+
+	pipe := r.Client.TxPipeline()
+	pipe.Del(ctx, leaderboardKey(sessionID), nicknameKey(sessionID), pointsKey(sessionID), pointCountsKey(sessionID))
+	if len(scores) > 0 {
+		members := make([]redis.Z, len(scores))
+		nicknames := make(map[string]interface{}, len(scores))
+		counts := make(map[string]int64)
+		for i, s := range scores {
+			members[i] = redis.Z{Score: encodeScore(s.Score, s.TotalTimeMs), Member: s.UserID}
+			if s.Nickname != "" {
+				nicknames[s.UserID] = s.Nickname
+			}
+			counts[strconv.FormatFloat(s.Score, 'f', 0, 64)]++
+		}
+
+		distinct := make([]redis.Z, 0, len(counts))
+		countValues := make(map[string]interface{}, len(counts))
+		for points, n := range counts {
+			value, _ := strconv.ParseFloat(points, 64)
+			distinct = append(distinct, redis.Z{Score: value, Member: points})
+			countValues[points] = n
+		}
+
+		pipe.ZAdd(ctx, leaderboardKey(sessionID), members...)
+		pipe.ZAdd(ctx, pointsKey(sessionID), distinct...)
+		pipe.HSet(ctx, pointCountsKey(sessionID), countValues)
+		pipe.Expire(ctx, leaderboardKey(sessionID), r.KeyTTL)
+		pipe.Expire(ctx, pointsKey(sessionID), r.KeyTTL)
+		pipe.Expire(ctx, pointCountsKey(sessionID), r.KeyTTL)
+		if len(nicknames) > 0 {
+			pipe.HSet(ctx, nicknameKey(sessionID), nicknames)
+			pipe.Expire(ctx, nicknameKey(sessionID), r.KeyTTL)
+		}
+	}
 	_, err := pipe.Exec(ctx)
 	return err
 }
+
+// AcquireRebuildLock claims the right to rebuild a session's leaderboard and returns the
+// token to release it with. Returns "" if another rebuild is already in progress. Answers
+// queued for an earlier rebuild are dropped: the new rebuild reads them from the database.
+func (r *RedisClient) AcquireRebuildLock(ctx context.Context, sessionID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	token := uuid.NewString()
+	acquired, err := r.Client.SetNX(ctx, rebuildLockKey(sessionID), token, rebuildLockTTL).Result()
+	if err != nil || !acquired {
+		return "", err
+	}
+	if err := r.Client.Del(ctx, rebuildPendingKey(sessionID)).Err(); err != nil {
+		_ = r.ReleaseRebuildLock(ctx, sessionID, token)
+		return "", err
+	}
+	return token, nil
+}
+
+// finishRebuildScript frees the lock if it still holds the caller's token and no answers are
+// queued; otherwise it hands the queued answers over and keeps the lock.
+// KEYS: lock, pending. ARGV: token.
+var finishRebuildScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return {}
+end
+local pending = redis.call("SMEMBERS", KEYS[2])
+if #pending > 0 then
+	redis.call("DEL", KEYS[2])
+	return pending
+end
+redis.call("DEL", KEYS[1])
+return {}
+`)
+
+// FinishRebuild frees the rebuild lock if no answers were queued while it was held, and
+// otherwise returns them, keeping the lock.
+func (r *RedisClient) FinishRebuild(ctx context.Context, sessionID, token string) ([]PendingAnswer, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	members, err := finishRebuildScript.Run(ctx, r.Client, []string{rebuildLockKey(sessionID), rebuildPendingKey(sessionID)}, token).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]PendingAnswer, 0, len(members))
+	for _, m := range members {
+		userID, questionID, _ := strings.Cut(m, "\x1f")
+		pending = append(pending, PendingAnswer{UserID: userID, QuestionID: questionID})
+	}
+	return pending, nil
+}
+
+// releaseLockScript deletes a lock and its queued answers only if it still holds the
+// caller's token. KEYS: lock, pending. ARGV: token.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1], KEYS[2])
+end
+return 0
+`)
+
+// ReleaseRebuildLock frees the rebuild lock for a session if it is still held with token,
+// dropping any queued answers.
+func (r *RedisClient) ReleaseRebuildLock(ctx context.Context, sessionID, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return releaseLockScript.Run(ctx, r.Client, []string{rebuildLockKey(sessionID), rebuildPendingKey(sessionID)}, token).Err()
+}
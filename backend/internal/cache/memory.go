@@ -0,0 +1,470 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// memorySweepInterval is how often writes purge sessions whose KeyTTL has passed.
+const memorySweepInterval = time.Minute
+
+// MemoryLeaderboard is an in-process Leaderboard for the local server and single-node
+// deployments. It ranks exactly like the Redis implementation, including time clamping and
+// dense ranks, using one skip list per session. Sessions expire KeyTTL after their last write,
+// like the Redis keys; state is lost when the process exits.
+type MemoryLeaderboard struct {
+	mu        sync.RWMutex
+	sessions  map[string]*memorySession
+	locks     map[string]memoryLock // sessionId -> rebuild lock
+	nextSweep time.Time
+	seed      int64
+
+	KeyTTL   time.Duration
+	RankMode models.RankMode
+}
+
+// memorySession holds one session's leaderboard state.
+type memorySession struct {
+	list          *skipList
+	entries       map[string]rankedEntry // userId -> current entry in list
+	nicknames     map[string]string
+	pointCounts   map[float64]int // players holding each point total
+	points        []float64       // distinct point totals, descending, for dense ranks
+	previousRanks map[string]int64
+	expiresAt     time.Time
+}
+
+// NewMemoryLeaderboard creates an empty in-process leaderboard.
+func NewMemoryLeaderboard(cfg *config.Config) *MemoryLeaderboard {
+	return &MemoryLeaderboard{
+		sessions: make(map[string]*memorySession),
+		locks:    make(map[string]memoryLock),
+		seed:     time.Now().UnixNano(),
+		KeyTTL:   cfg.SessionTTL,
+		RankMode: models.RankMode(cfg.LeaderboardRankMode),
+	}
+}
+
+// Mode returns how tied players are ranked.
+func (m *MemoryLeaderboard) Mode() models.RankMode {
+	return m.RankMode
+}
+
+// Close releases nothing; it exists to satisfy Leaderboard.
+func (m *MemoryLeaderboard) Close() error {
+	return nil
+}
+
+// session returns a live session for reading, or nil. Callers must hold mu.
+func (m *MemoryLeaderboard) session(sessionID string) *memorySession {
+	s, ok := m.sessions[sessionID]
+	if !ok || (m.KeyTTL > 0 && time.Now().After(s.expiresAt)) {
+		return nil
+	}
+	return s
+}
+
+// writable returns the session for writing, creating it if needed, and pushes its expiry
+// forward. Callers must hold mu for writing.
+func (m *MemoryLeaderboard) writable(sessionID string) *memorySession {
+	now := time.Now()
+	if now.After(m.nextSweep) {
+		m.sweep(now)
+	}
+
+	s := m.session(sessionID)
+	if s == nil {
+		m.seed++
+		s = &memorySession{
+			list:          newSkipList(m.seed),
+			entries:       make(map[string]rankedEntry),
+			nicknames:     make(map[string]string),
+			pointCounts:   make(map[float64]int),
+			previousRanks: make(map[string]int64),
+		}
+		m.sessions[sessionID] = s
+	}
+	s.expiresAt = now.Add(m.KeyTTL)
+	return s
+}
+
+// sweep drops expired sessions and rebuild locks. Callers must hold mu for writing.
+func (m *MemoryLeaderboard) sweep(now time.Time) {
+	if m.KeyTTL > 0 {
+		for id, s := range m.sessions {
+			if now.After(s.expiresAt) {
+				delete(m.sessions, id)
+			}
+		}
+	}
+	for id, lock := range m.locks {
+		if now.After(lock.expiresAt) {
+			delete(m.locks, id)
+		}
+	}
+	m.nextSweep = now.Add(memorySweepInterval)
+}
+
+// set places a player at the given totals, keeping the distinct-points index in step.
+func (s *memorySession) set(userID string, points float64, totalTimeMs int64) {
+	if old, ok := s.entries[userID]; ok {
+		s.list.delete(old)
+		s.removePoints(old.points)
+	}
+	e := rankedEntry{userID: userID, points: points, totalTimeMs: clampTime(totalTimeMs)}
+	s.list.insert(e)
+	s.entries[userID] = e
+	s.addPoints(points)
+}
+
+func (s *memorySession) addPoints(points float64) {
+	s.pointCounts[points]++
+	if s.pointCounts[points] > 1 {
+		return
+	}
+	i := sort.Search(len(s.points), func(i int) bool { return s.points[i] <= points })
+	s.points = append(s.points, 0)
+	copy(s.points[i+1:], s.points[i:])
+	s.points[i] = points
+}
+
+func (s *memorySession) removePoints(points float64) {
+	s.pointCounts[points]--
+	if s.pointCounts[points] > 0 {
+		return
+	}
+	delete(s.pointCounts, points)
+	i := sort.Search(len(s.points), func(i int) bool { return s.points[i] <= points })
+	if i < len(s.points) && s.points[i] == points {
+		s.points = append(s.points[:i], s.points[i+1:]...)
+	}
+}
+
+// denseRank returns 1 + the number of distinct point totals above points.
+func (s *memorySession) denseRank(points float64) int64 {
+	return int64(sort.Search(len(s.points), func(i int) bool { return s.points[i] <= points })) + 1
+}
+
+// scores converts entries to display form and assigns ranks, the first entry being at
+// 1-based position start.
+func (s *memorySession) scores(entries []rankedEntry, start int, mode models.RankMode) []models.PlayerScore {
+	scores := make([]models.PlayerScore, len(entries))
+	for i, e := range entries {
+		nickname := s.nicknames[e.userID]
+		if nickname == "" {
+			nickname = fallbackNickname(e.userID)
+		}
+		scores[i] = models.PlayerScore{
+			UserID:      e.userID,
+			Nickname:    nickname,
+			Score:       e.points,
+			TotalTimeMs: e.totalTimeMs,
+		}
+	}
+	if len(scores) == 0 {
+		return scores
+	}
+	firstRank := int64(start)
+	if mode == models.RankModeDense {
+		firstRank = s.denseRank(scores[0].Score)
+	}
+	models.AssignRanks(scores, firstRank, mode)
+	return scores
+}
+
+// clampTime bounds cumulative time to the range the Redis score encoding can represent.
+func clampTime(totalTimeMs int64) int64 {
+	if totalTimeMs < 0 {
+		return 0
+	}
+	if totalTimeMs > timeScale-1 {
+		return timeScale - 1
+	}
+	return totalTimeMs
+}
+
+// UpsertScore sets a player's score in the leaderboard and resets their cumulative time.
+func (m *MemoryLeaderboard) UpsertScore(ctx context.Context, sessionID, userID string, score float64) error {
+	observability.Debug(ctx, "upserting score", "sessionId", sessionID, "userId", userID, "score", score)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writable(sessionID).set(userID, score, 0)
+	return nil
+}
+
+// IncrementScore atomically adds points and answer time to a player's totals.
+func (m *MemoryLeaderboard) IncrementScore(ctx context.Context, sessionID, userID string, delta float64, timeMs int64) error {
+	observability.Debug(ctx, "incrementing score", "sessionId", sessionID, "userId", userID, "delta", delta, "timeMs", timeMs)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.writable(sessionID)
+	cur := s.entries[userID]
+	s.set(userID, cur.points+delta, cur.totalTimeMs+timeMs)
+	return nil
+}
+
+// CountAnswer adds a stored answer's points and time to a player's totals. It returns
+// ErrSessionMissing if the session is gone, and queues the answer for FinishRebuild while a
+// rebuild holds the lock.
+func (m *MemoryLeaderboard) CountAnswer(ctx context.Context, sessionID, userID, questionID string, delta float64, timeMs int64) error {
+	observability.Debug(ctx, "counting answer", "sessionId", sessionID, "userId", userID, "questionId", questionID, "delta", delta)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if lock, ok := m.locks[sessionID]; ok && time.Now().Before(lock.expiresAt) {
+		lock.pending = append(lock.pending, PendingAnswer{UserID: userID, QuestionID: questionID})
+		m.locks[sessionID] = lock
+		return nil
+	}
+	if s := m.session(sessionID); s == nil || s.list.length == 0 {
+		return ErrSessionMissing
+	}
+	s := m.writable(sessionID)
+	cur := s.entries[userID]
+	s.set(userID, cur.points+delta, cur.totalTimeMs+timeMs)
+	return nil
+}
+
+// SetNickname stores a user's nickname for leaderboard display.
+func (m *MemoryLeaderboard) SetNickname(ctx context.Context, sessionID, userID, nickname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writable(sessionID).nicknames[userID] = nickname
+	return nil
+}
+
+// GetTopN returns the top N players with scores, sorted descending. N of 0 or less returns
+// every player, matching ZREVRANGE 0 -1.
+func (m *MemoryLeaderboard) GetTopN(ctx context.Context, sessionID string, n int) ([]models.PlayerScore, error) {
+	return m.GetRange(ctx, sessionID, 0, n)
+}
+
+// GetRange returns up to n players starting at 0-based position offset, in leaderboard order.
+// N of 0 or less returns every player from offset on.
+func (m *MemoryLeaderboard) GetRange(ctx context.Context, sessionID string, offset, n int) ([]models.PlayerScore, error) {
+	observability.Debug(ctx, "getting leaderboard range", "sessionId", sessionID, "offset", offset, "n", n)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.session(sessionID)
+	if s == nil {
+		return []models.PlayerScore{}, nil
+	}
+	if n <= 0 {
+		n = s.list.length
+	}
+	return s.scores(s.list.rangeFrom(offset+1, n), offset+1, m.RankMode), nil
+}
+
+// GetAroundPlayer returns the player's own entry together with up to n players directly
+// above and n directly below them.
+func (m *MemoryLeaderboard) GetAroundPlayer(ctx context.Context, sessionID, userID string, n int) ([]models.PlayerScore, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.session(sessionID)
+	var e rankedEntry
+	var ok bool
+	if s != nil {
+		e, ok = s.entries[userID]
+	}
+	if !ok {
+		return nil, fmt.Errorf("player %s %w", userID, ErrPlayerNotFound)
+	}
+
+	position := s.list.position(e)
+	start := position - n
+	if start < 1 {
+		start = 1
+	}
+	return s.scores(s.list.rangeFrom(start, position+n-start+1), start, m.RankMode), nil
+}
+
+// GetPlayerRank returns a player's rank (1-indexed from top) under the leaderboard's RankMode.
+func (m *MemoryLeaderboard) GetPlayerRank(ctx context.Context, sessionID, userID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.session(sessionID)
+	var e rankedEntry
+	var ok bool
+	if s != nil {
+		e, ok = s.entries[userID]
+	}
+	if !ok {
+		return -1, fmt.Errorf("player %s %w", userID, ErrPlayerNotFound)
+	}
+	if m.RankMode == models.RankModeDense {
+		return s.denseRank(e.points), nil
+	}
+	return int64(s.list.position(e)), nil
+}
+
+// GetPlayerScore returns a player's current score, or 0 if they have none.
+func (m *MemoryLeaderboard) GetPlayerScore(ctx context.Context, sessionID, userID string) (float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if s := m.session(sessionID); s != nil {
+		return s.entries[userID].points, nil
+	}
+	return 0, nil
+}
+
+// GetPlayerCount returns the total number of players in the leaderboard.
+func (m *MemoryLeaderboard) GetPlayerCount(ctx context.Context, sessionID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if s := m.session(sessionID); s != nil {
+		return int64(s.list.length), nil
+	}
+	return 0, nil
+}
+
+// SnapshotRanks records every player's current rank so later rank changes can be reported.
+func (m *MemoryLeaderboard) SnapshotRanks(ctx context.Context, sessionID string, scores []models.PlayerScore) error {
+	observability.Debug(ctx, "snapshotting ranks", "sessionId", sessionID, "players", len(scores))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ranks := make(map[string]int64, len(scores))
+	for _, p := range scores {
+		ranks[p.UserID] = p.Rank
+	}
+	m.writable(sessionID).previousRanks = ranks
+	return nil
+}
+
+// GetPreviousRanks returns the ranks recorded by the last SnapshotRanks call (userId -> rank).
+func (m *MemoryLeaderboard) GetPreviousRanks(ctx context.Context, sessionID string) (map[string]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ranks := make(map[string]int64)
+	if s := m.session(sessionID); s != nil {
+		for userID, rank := range s.previousRanks {
+			ranks[userID] = rank
+		}
+	}
+	return ranks, nil
+}
+
+// GetPreviousRank returns a player's rank as of the last question close, or 0 if none was recorded.
+func (m *MemoryLeaderboard) GetPreviousRank(ctx context.Context, sessionID, userID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if s := m.session(sessionID); s != nil {
+		return s.previousRanks[userID], nil
+	}
+	return 0, nil
+}
+
+// DeleteSession removes all leaderboard data for a session.
+func (m *MemoryLeaderboard) DeleteSession(ctx context.Context, sessionID string) error {
+	observability.Debug(ctx, "deleting session leaderboard", "sessionId", sessionID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+// SessionExists reports whether the session has any players in the leaderboard.
+func (m *MemoryLeaderboard) SessionExists(ctx context.Context, sessionID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.session(sessionID)
+	return s != nil && s.list.length > 0, nil
+}
+
+// ReplaceSession replaces a session's leaderboard and nicknames with the given scores.
+// Previously snapshotted ranks are kept.
+func (m *MemoryLeaderboard) ReplaceSession(ctx context.Context, sessionID string, scores []models.PlayerScore) error {
+	observability.Debug(ctx, "replacing session leaderboard", "sessionId", sessionID, "players", len(scores))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.writable(sessionID)
+	m.seed++
+	s.list = newSkipList(m.seed)
+	s.entries = make(map[string]rankedEntry, len(scores))
+	s.nicknames = make(map[string]string, len(scores))
+	s.pointCounts = make(map[float64]int)
+	s.points = nil
+	for _, p := range scores {
+		s.set(p.UserID, p.Score, p.TotalTimeMs)
+		if p.Nickname != "" {
+			s.nicknames[p.UserID] = p.Nickname
+		}
+	}
+	return nil
+}
+
+// memoryLock is a held rebuild lock.
+type memoryLock struct {
+	token     string
+	expiresAt time.Time
+	pending   []PendingAnswer // answers queued for the rebuild
+}
+
+// AcquireRebuildLock claims the right to rebuild a session's leaderboard and returns the
+// token to release it with. Returns "" if another rebuild is already in progress.
+func (m *MemoryLeaderboard) AcquireRebuildLock(ctx context.Context, sessionID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if lock, ok := m.locks[sessionID]; ok && now.Before(lock.expiresAt) {
+		return "", nil
+	}
+	token := uuid.NewString()
+	m.locks[sessionID] = memoryLock{token: token, expiresAt: now.Add(rebuildLockTTL)}
+	return token, nil
+}
+
+// FinishRebuild frees the rebuild lock if no answers were queued while it was held, and
+// otherwise returns them, keeping the lock.
+func (m *MemoryLeaderboard) FinishRebuild(ctx context.Context, sessionID, token string) ([]PendingAnswer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock, ok := m.locks[sessionID]
+	if !ok || lock.token != token {
+		return nil, nil
+	}
+	if pending := lock.pending; len(pending) > 0 {
+		lock.pending = nil
+		m.locks[sessionID] = lock
+		return pending, nil
+	}
+	delete(m.locks, sessionID)
+	return nil, nil
+}
+
+// ReleaseRebuildLock frees the rebuild lock for a session if it is still held with token,
+// dropping any queued answers.
+func (m *MemoryLeaderboard) ReleaseRebuildLock(ctx context.Context, sessionID, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks[sessionID].token == token {
+		delete(m.locks, sessionID)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+)
+
+func newTestLeaderboard(mode models.RankMode) *MemoryLeaderboard {
+	return NewMemoryLeaderboard(&config.Config{SessionTTL: time.Hour, LeaderboardRankMode: string(mode)})
+}
+
+// seedTies gives a leaderboard the same shape in every test:
+//
+//	a: 300 points, 900 ms   b: 300 points, 500 ms   c: 300 points, 500 ms
+//	d: 100 points, 100 ms   e:   0 points
+//
+// so b and c tie on points and time (c wins on userId) and both beat a on time.
+func seedTies(t *testing.T, lb Leaderboard) {
+	t.Helper()
+	ctx := context.Background()
+	for _, s := range []struct {
+		userID string
+		points float64
+		timeMs int64
+	}{
+		{"a", 300, 900},
+		{"b", 300, 500},
+		{"c", 200, 200},
+		{"d", 100, 100},
+		{"e", 0, 0},
+	} {
+		if err := lb.IncrementScore(ctx, "s", s.userID, s.points, s.timeMs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// c reaches 300 points and 500 ms over two answers
+	if err := lb.IncrementScore(ctx, "s", "c", 100, 300); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func userIDs(scores []models.PlayerScore) []string {
+	ids := make([]string, len(scores))
+	for i, s := range scores {
+		ids[i] = s.UserID
+	}
+	return ids
+}
+
+func ranks(scores []models.PlayerScore) []int64 {
+	out := make([]int64, len(scores))
+	for i, s := range scores {
+		out[i] = s.Rank
+	}
+	return out
+}
+
+func equal[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryLeaderboardBreaksTies(t *testing.T) {
+	lb := newTestLeaderboard(models.RankModeTiebreak)
+	seedTies(t, lb)
+
+	top, err := lb.GetTopN(context.Background(), "s", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c", "b", "a", "d", "e"}; !equal(userIDs(top), want) {
+		t.Errorf("got order %v, want %v", userIDs(top), want)
+	}
+	if top[0].TotalTimeMs != 500 || top[2].TotalTimeMs != 900 {
+		t.Errorf("got times %d and %d, want 500 and 900", top[0].TotalTimeMs, top[2].TotalTimeMs)
+	}
+}
+
+func TestMemoryLeaderboardRankModes(t *testing.T) {
+	tests := []struct {
+		mode      models.RankMode
+		wantRanks []int64
+	}{
+		{models.RankModeTiebreak, []int64{1, 2, 3, 4, 5}},
+		{models.RankModeDense, []int64{1, 1, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			ctx := context.Background()
+			lb := newTestLeaderboard(tt.mode)
+			seedTies(t, lb)
+
+			top, err := lb.GetTopN(ctx, "s", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(ranks(top), tt.wantRanks) {
+				t.Errorf("got ranks %v, want %v", ranks(top), tt.wantRanks)
+			}
+
+			// Ranks of a window and of single players agree with the full list
+			window, err := lb.GetRange(ctx, "s", 2, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(ranks(window), tt.wantRanks[2:4]) {
+				t.Errorf("got window ranks %v, want %v", ranks(window), tt.wantRanks[2:4])
+			}
+			for i, s := range top {
+				rank, err := lb.GetPlayerRank(ctx, "s", s.UserID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if rank != tt.wantRanks[i] {
+					t.Errorf("GetPlayerRank(%s) = %d, want %d", s.UserID, rank, tt.wantRanks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLeaderboardAroundPlayerAtEdges(t *testing.T) {
+	ctx := context.Background()
+	lb := newTestLeaderboard(models.RankModeTiebreak)
+	seedTies(t, lb)
+
+	tests := []struct {
+		userID string
+		n      int
+		want   []string
+	}{
+		{"c", 2, []string{"c", "b", "a"}},            // top: nobody above
+		{"e", 2, []string{"a", "d", "e"}},            // bottom: nobody below
+		{"a", 1, []string{"b", "a", "d"}},            // middle
+		{"b", 10, []string{"c", "b", "a", "d", "e"}}, // window wider than the list
+		{"d", 0, []string{"d"}},
+	}
+	for _, tt := range tests {
+		around, err := lb.GetAroundPlayer(ctx, "s", tt.userID, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equal(userIDs(around), tt.want) {
+			t.Errorf("around %s (n=%d): got %v, want %v", tt.userID, tt.n, userIDs(around), tt.want)
+		}
+		// Ranks in the slice are absolute positions, not positions within the slice
+		for _, s := range around {
+			rank, _ := lb.GetPlayerRank(ctx, "s", s.UserID)
+			if s.Rank != rank {
+				t.Errorf("around %s: %s has rank %d, want %d", tt.userID, s.UserID, s.Rank, rank)
+			}
+		}
+	}
+
+	if _, err := lb.GetAroundPlayer(ctx, "s", "missing", 2); err == nil {
+		t.Error("got no error for a player not in the leaderboard")
+	}
+}
+
+func TestMemoryLeaderboardPreviousRank(t *testing.T) {
+	ctx := context.Background()
+	lb := newTestLeaderboard(models.RankModeTiebreak)
+	seedTies(t, lb)
+
+	if rank, _ := lb.GetPreviousRank(ctx, "s", "a"); rank != 0 {
+		t.Errorf("got previous rank %d before any snapshot, want 0", rank)
+	}
+
+	top, _ := lb.GetTopN(ctx, "s", 0)
+	if err := lb.SnapshotRanks(ctx, "s", top); err != nil {
+		t.Fatal(err)
+	}
+	// e overtakes everyone after the snapshot
+	if err := lb.IncrementScore(ctx, "s", "e", 1000, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	prev, _ := lb.GetPreviousRank(ctx, "s", "e")
+	cur, _ := lb.GetPlayerRank(ctx, "s", "e")
+	if prev != 5 || cur != 1 {
+		t.Errorf("e: got previous rank %d and rank %d, want 5 and 1", prev, cur)
+	}
+	all, _ := lb.GetPreviousRanks(ctx, "s")
+	if len(all) != 5 || all["c"] != 1 {
+		t.Errorf("got previous ranks %v, want all 5 players with c first", all)
+	}
+	if rank, _ := lb.GetPreviousRank(ctx, "s", "joined-later"); rank != 0 {
+		t.Errorf("got previous rank %d for a player not in the snapshot, want 0", rank)
+	}
+}
+
+func TestMemoryLeaderboardUpsertResetsTime(t *testing.T) {
+	ctx := context.Background()
+	lb := newTestLeaderboard(models.RankModeTiebreak)
+	seedTies(t, lb)
+
+	if err := lb.UpsertScore(ctx, "s", "a", 300); err != nil {
+		t.Fatal(err)
+	}
+	// a keeps 300 points but no longer has the slowest time
+	top, _ := lb.GetTopN(ctx, "s", 1)
+	if top[0].UserID != "a" || top[0].TotalTimeMs != 0 {
+		t.Errorf("got leader %+v, want a with no time", top[0])
+	}
+	count, _ := lb.GetPlayerCount(ctx, "s")
+	if count != 5 {
+		t.Errorf("got %d players, want 5", count)
+	}
+}
+
+func TestMemoryLeaderboardReplaceAndDelete(t *testing.T) {
+	ctx := context.Background()
+	lb := newTestLeaderboard(models.RankModeDense)
+	seedTies(t, lb)
+
+	err := lb.ReplaceSession(ctx, "s", []models.PlayerScore{
+		{UserID: "x", Nickname: "Xena", Score: 50},
+		{UserID: "y", Score: 50, TotalTimeMs: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, _ := lb.GetTopN(ctx, "s", 0)
+	if !equal(userIDs(top), []string{"x", "y"}) || !equal(ranks(top), []int64{1, 1}) {
+		t.Errorf("got %v ranked %v, want [x y] ranked [1 1]", userIDs(top), ranks(top))
+	}
+	if top[0].Nickname != "Xena" || top[1].Nickname != "y" {
+		t.Errorf("got nicknames %q and %q, want Xena and the fallback y", top[0].Nickname, top[1].Nickname)
+	}
+
+	if err := lb.DeleteSession(ctx, "s"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := lb.SessionExists(ctx, "s"); exists {
+		t.Error("session still exists after DeleteSession")
+	}
+}
+
+func TestMemoryLeaderboardRebuildLockToken(t *testing.T) {
+	ctx := context.Background()
+	lb := newTestLeaderboard(models.RankModeDense)
+
+	first, err := lb.AcquireRebuildLock(ctx, "s")
+	if err != nil || first == "" {
+		t.Fatalf("AcquireRebuildLock = %q, %v; want a token", first, err)
+	}
+	if token, _ := lb.AcquireRebuildLock(ctx, "s"); token != "" {
+		t.Fatalf("acquired a held lock with token %q", token)
+	}
+
+	// The first rebuild outlives the TTL and a second one takes the lock over
+	lb.locks["s"] = memoryLock{token: first, expiresAt: time.Now().Add(-time.Second)}
+	second, _ := lb.AcquireRebuildLock(ctx, "s")
+	if second == "" || second == first {
+		t.Fatalf("got token %q after expiry, want a new one", second)
+	}
+
+	// The first rebuild finishing must not free the second one's lock
+	if err := lb.ReleaseRebuildLock(ctx, "s", first); err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := lb.AcquireRebuildLock(ctx, "s"); token != "" {
+		t.Error("a stale token released the current lock")
+	}
+	if err := lb.ReleaseRebuildLock(ctx, "s", second); err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := lb.AcquireRebuildLock(ctx, "s"); token == "" {
+		t.Error("lock still held after its holder released it")
+	}
+}
+
+func TestMemoryLeaderboardCountAnswer(t *testing.T) {
+	ctx := context.Background()
+	lb := newTestLeaderboard(models.RankModeDense)
+
+	// A lost session is reported, not recreated
+	if err := lb.CountAnswer(ctx, "s", "a", "q1", 100, 10); !errors.Is(err, ErrSessionMissing) {
+		t.Fatalf("CountAnswer on a missing session = %v, want ErrSessionMissing", err)
+	}
+	if exists, _ := lb.SessionExists(ctx, "s"); exists {
+		t.Fatal("CountAnswer recreated a missing session")
+	}
+
+	// While a rebuild holds the lock, answers are queued for it instead of counted
+	token, _ := lb.AcquireRebuildLock(ctx, "s")
+	if err := lb.CountAnswer(ctx, "s", "a", "q1", 100, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := lb.ReplaceSession(ctx, "s", []models.PlayerScore{{UserID: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if score, _ := lb.GetPlayerScore(ctx, "s", "a"); score != 0 {
+		t.Errorf("got score %v while the rebuild held the lock, want the answer queued", score)
+	}
+	pending, err := lb.FinishRebuild(ctx, "s", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0] != (PendingAnswer{UserID: "a", QuestionID: "q1"}) {
+		t.Fatalf("got pending %+v, want a's answer to q1", pending)
+	}
+	if token, _ := lb.AcquireRebuildLock(ctx, "s"); token != "" {
+		t.Fatal("FinishRebuild freed the lock while handing over queued answers")
+	}
+	if pending, _ := lb.FinishRebuild(ctx, "s", token); len(pending) != 0 {
+		t.Fatalf("got pending %+v again, want none", pending)
+	}
+
+	// Once the lock is free, answers are counted directly
+	if err := lb.CountAnswer(ctx, "s", "a", "q2", 100, 10); err != nil {
+		t.Fatal(err)
+	}
+	if score, _ := lb.GetPlayerScore(ctx, "s", "a"); score != 100 {
+		t.Errorf("got score %v, want 100", score)
+	}
+}
@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+)
+
+// SessionCache holds the state read on every answer submission — the session, the
+// connection→player mapping and the quiz snapshot — so the answer path doesn't hit the
+// database each time. Getters return nil on a miss. Writers of a session must call
+// InvalidateSession after changing it. Connections and quiz snapshots never change once
+// written (connection IDs are not reused), so their entries only age out.
+type SessionCache interface {
+	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
+	SetSession(ctx context.Context, session *models.Session) error
+	InvalidateSession(ctx context.Context, sessionID string) error
+
+	GetConnection(ctx context.Context, connectionID string) (*models.Player, error)
+	SetConnection(ctx context.Context, player *models.Player) error
+
+	GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error)
+	SetQuizVersion(ctx context.Context, quiz *models.Quiz) error
+}
+
+var (
+	_ SessionCache = (*RedisClient)(nil)
+	_ SessionCache = (*MemorySessionCache)(nil)
+)
+
+// sessionStateTTL bounds how long a session can be served stale if a read races an
+// invalidation. Kept short because answers are only accepted while the session is active.
+const sessionStateTTL = 30 * time.Second
+
+const (
+	sessionStateKeyPrefix = "session-state:"
+	connectionKeyPrefix   = "connection:"
+	quizVersionKeyPrefix  = "quiz-version:"
+)
+
+func sessionStateKey(sessionID string) string {
+	return sessionStateKeyPrefix + sessionID
+}
+
+func connectionKey(connectionID string) string {
+	return connectionKeyPrefix + connectionID
+}
+
+func quizVersionKey(quizID string, version int64) string {
+	return quizVersionKeyPrefix + quizID + ":" + strconv.FormatInt(version, 10)
+}
+
+// GetSession returns the cached session, or nil on a miss.
+func (r *RedisClient) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	return getCachedJSON[models.Session](ctx, r, sessionStateKey(sessionID))
+}
+
+// SetSession caches a session for sessionStateTTL.
+func (r *RedisClient) SetSession(ctx context.Context, session *models.Session) error {
+	return r.setCachedJSON(ctx, sessionStateKey(session.SessionID), session, sessionStateTTL)
+}
+
+// InvalidateSession drops the cached session so the next read goes to the database.
+func (r *RedisClient) InvalidateSession(ctx context.Context, sessionID string) error {
+	return r.Client.Del(ctx, sessionStateKey(sessionID)).Err()
+}
+
+// GetConnection returns the cached player for a connection, or nil on a miss.
+func (r *RedisClient) GetConnection(ctx context.Context, connectionID string) (*models.Player, error) {
+	return getCachedJSON[models.Player](ctx, r, connectionKey(connectionID))
+}
+
+// SetConnection caches a connection's player for the session lifetime.
+func (r *RedisClient) SetConnection(ctx context.Context, player *models.Player) error {
+	return r.setCachedJSON(ctx, connectionKey(player.ConnectionID), player, r.KeyTTL)
+}
+
+// GetQuizVersion returns a cached quiz snapshot, or nil on a miss.
+func (r *RedisClient) GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error) {
+	return getCachedJSON[models.Quiz](ctx, r, quizVersionKey(quizID, version))
+}
+
+// SetQuizVersion caches a quiz snapshot for the session lifetime.
+func (r *RedisClient) SetQuizVersion(ctx context.Context, quiz *models.Quiz) error {
+	return r.setCachedJSON(ctx, quizVersionKey(quiz.QuizID, quiz.Version), quiz, r.KeyTTL)
+}
+
+func getCachedJSON[T any](ctx context.Context, r *RedisClient, key string) (*T, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	data, err := r.Client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *RedisClient) setCachedJSON(ctx context.Context, key string, v any, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.Client.Set(ctx, key, data, ttl).Err()
+}
+
+// MemorySessionCache is an in-process SessionCache for the local server, where every
+// connection is served by the same process. Entries expire like the Redis keys; expired
+// entries are purged on write every memorySweepInterval. Returned quizzes are shared with
+// the cache and must not be modified.
+type MemorySessionCache struct {
+	mu        sync.RWMutex
+	sessions  map[string]memoryEntry[models.Session]
+	conns     map[string]memoryEntry[models.Player]
+	quizzes   map[string]memoryEntry[*models.Quiz]
+	nextSweep time.Time
+
+	KeyTTL time.Duration
+}
+
+// memoryEntry is a cached value and when it stops being served.
+type memoryEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// NewMemorySessionCache creates an empty in-process session cache.
+func NewMemorySessionCache(cfg *config.Config) *MemorySessionCache {
+	return &MemorySessionCache{
+		sessions: make(map[string]memoryEntry[models.Session]),
+		conns:    make(map[string]memoryEntry[models.Player]),
+		quizzes:  make(map[string]memoryEntry[*models.Quiz]),
+		KeyTTL:   cfg.SessionTTL,
+	}
+}
+
+// GetSession returns a copy of the cached session, or nil on a miss.
+func (m *MemorySessionCache) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.sessions[sessionID]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, nil
+	}
+	session := e.value
+	return &session, nil
+}
+
+// SetSession caches a copy of a session for sessionStateTTL.
+func (m *MemorySessionCache) SetSession(ctx context.Context, session *models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.sweep()
+	m.sessions[session.SessionID] = memoryEntry[models.Session]{value: *session, expiresAt: now.Add(sessionStateTTL)}
+	return nil
+}
+
+// InvalidateSession drops the cached session so the next read goes to the database.
+func (m *MemorySessionCache) InvalidateSession(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+// GetConnection returns a copy of the cached player for a connection, or nil on a miss.
+func (m *MemorySessionCache) GetConnection(ctx context.Context, connectionID string) (*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.conns[connectionID]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, nil
+	}
+	player := e.value
+	return &player, nil
+}
+
+// SetConnection caches a copy of a connection's player for the session lifetime.
+func (m *MemorySessionCache) SetConnection(ctx context.Context, player *models.Player) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.sweep()
+	m.conns[player.ConnectionID] = memoryEntry[models.Player]{value: *player, expiresAt: now.Add(m.KeyTTL)}
+	return nil
+}
+
+// GetQuizVersion returns a cached quiz snapshot, or nil on a miss.
+func (m *MemorySessionCache) GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.quizzes[quizVersionKey(quizID, version)]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, nil
+	}
+	return e.value, nil
+}
+
+// SetQuizVersion caches a quiz snapshot for the session lifetime.
+func (m *MemorySessionCache) SetQuizVersion(ctx context.Context, quiz *models.Quiz) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.sweep()
+	m.quizzes[quizVersionKey(quiz.QuizID, quiz.Version)] = memoryEntry[*models.Quiz]{value: quiz, expiresAt: now.Add(m.KeyTTL)}
+	return nil
+}
+
+// sweep drops expired entries if a sweep is due and returns the current time. Callers must
+// hold mu for writing.
+func (m *MemorySessionCache) sweep() time.Time {
+	now := time.Now()
+	if now.Before(m.nextSweep) {
+		return now
+	}
+	sweepExpired(m.sessions, now)
+	sweepExpired(m.conns, now)
+	sweepExpired(m.quizzes, now)
+	m.nextSweep = now.Add(memorySweepInterval)
+	return now
+}
+
+func sweepExpired[T any](entries map[string]memoryEntry[T], now time.Time) {
+	for key, e := range entries {
+		if now.After(e.expiresAt) {
+			delete(entries, key)
+		}
+	}
+}
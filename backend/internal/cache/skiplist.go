@@ -0,0 +1,179 @@
+package cache
+
+import "math/rand"
+
+// skipListMaxLevel and skipListP follow the Redis sorted-set implementation.
+const (
+	skipListMaxLevel = 32
+	skipListP        = 0.25
+)
+
+// rankedEntry is one player's position-determining state in an in-process leaderboard.
+type rankedEntry struct {
+	userID      string
+	points      float64
+	totalTimeMs int64
+}
+
+// before reports whether a ranks ahead of b: more points first, then less cumulative time,
+// then the greater userId — the order ZREVRANGE gives the Redis composite score.
+func (a rankedEntry) before(b rankedEntry) bool {
+	if a.points != b.points {
+		return a.points > b.points
+	}
+	if a.totalTimeMs != b.totalTimeMs {
+		return a.totalTimeMs < b.totalTimeMs
+	}
+	return a.userID > b.userID
+}
+
+type skipLevel struct {
+	forward *skipNode
+	span    int // number of level-0 steps this link skips
+}
+
+type skipNode struct {
+	entry  rankedEntry
+	levels []skipLevel
+}
+
+// skipList keeps entries in leaderboard order with O(log n) insert, delete, rank lookup
+// and access by position. Each link stores its span so positions can be counted while
+// descending, as in Redis.
+type skipList struct {
+	head   *skipNode
+	level  int
+	length int
+	rnd    *rand.Rand
+}
+
+func newSkipList(seed int64) *skipList {
+	return &skipList{
+		head:  &skipNode{levels: make([]skipLevel, skipListMaxLevel)},
+		level: 1,
+		rnd:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (l *skipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && l.rnd.Float64() < skipListP {
+		level++
+	}
+	return level
+}
+
+// insert adds e, which must not already be in the list.
+func (l *skipList) insert(e rankedEntry) {
+	var update [skipListMaxLevel]*skipNode
+	var rank [skipListMaxLevel]int
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].forward != nil && x.levels[i].forward.entry.before(e) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	level := l.randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = l.head
+			update[i].levels[i].span = l.length
+		}
+		l.level = level
+	}
+
+	x = &skipNode{entry: e, levels: make([]skipLevel, level)}
+	for i := 0; i < level; i++ {
+		x.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = x
+		x.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < l.level; i++ {
+		update[i].levels[i].span++
+	}
+	l.length++
+}
+
+// delete removes e, matched on every field. Returns false if it was not found.
+func (l *skipList) delete(e rankedEntry) bool {
+	var update [skipListMaxLevel]*skipNode
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.entry.before(e) {
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.levels[0].forward
+	if x == nil || x.entry != e {
+		return false
+	}
+	for i := 0; i < l.level; i++ {
+		if update[i].levels[i].forward == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].forward = x.levels[i].forward
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+	for l.level > 1 && l.head.levels[l.level-1].forward == nil {
+		l.level--
+	}
+	l.length--
+	return true
+}
+
+// position returns e's 1-based position in the list, or 0 if it is not present.
+func (l *skipList) position(e rankedEntry) int {
+	traversed := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && !e.before(x.levels[i].forward.entry) {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if x != l.head && x.entry == e {
+			return traversed
+		}
+	}
+	return 0
+}
+
+// at returns the node at 1-based position pos, or nil if out of range.
+func (l *skipList) at(pos int) *skipNode {
+	if pos < 1 || pos > l.length {
+		return nil
+	}
+	traversed := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && traversed+x.levels[i].span <= pos {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if traversed == pos {
+			return x
+		}
+	}
+	return nil
+}
+
+// rangeFrom returns up to n entries starting at 1-based position start.
+func (l *skipList) rangeFrom(start, n int) []rankedEntry {
+	var out []rankedEntry
+	for x := l.at(start); x != nil && len(out) < n; x = x.levels[0].forward {
+		out = append(out, x.entry)
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// sortedOracle is the obvious implementation the skip list must agree with.
+type sortedOracle []rankedEntry
+
+func (o sortedOracle) sorted() []rankedEntry {
+	out := append([]rankedEntry(nil), o...)
+	sort.Slice(out, func(i, j int) bool { return out[i].before(out[j]) })
+	return out
+}
+
+func TestSkipListMatchesSortedSlice(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	list := newSkipList(2)
+	current := make(map[string]rankedEntry)
+
+	for step := 0; step < 5000; step++ {
+		userID := fmt.Sprintf("u%03d", rnd.Intn(200))
+		old, exists := current[userID]
+		switch op := rnd.Intn(3); {
+		case op == 0 && exists:
+			// Delete
+			if !list.delete(old) {
+				t.Fatalf("step %d: delete of %v failed", step, old)
+			}
+			delete(current, userID)
+		default:
+			// Insert, or update by delete and reinsert. Few distinct points and times
+			// make ties common.
+			if exists && !list.delete(old) {
+				t.Fatalf("step %d: delete of %v failed", step, old)
+			}
+			e := rankedEntry{userID: userID, points: float64(rnd.Intn(5) * 100), totalTimeMs: int64(rnd.Intn(3) * 1000)}
+			list.insert(e)
+			current[userID] = e
+		}
+
+		if step%50 != 0 {
+			continue
+		}
+		var oracle sortedOracle
+		for _, e := range current {
+			oracle = append(oracle, e)
+		}
+		want := oracle.sorted()
+
+		if list.length != len(want) {
+			t.Fatalf("step %d: length %d, want %d", step, list.length, len(want))
+		}
+		got := list.rangeFrom(1, len(want))
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("step %d: position %d is %v, want %v", step, i+1, got[i], want[i])
+			}
+			if pos := list.position(want[i]); pos != i+1 {
+				t.Fatalf("step %d: position of %v is %d, want %d", step, want[i], pos, i+1)
+			}
+			if node := list.at(i + 1); node == nil || node.entry != want[i] {
+				t.Fatalf("step %d: at(%d) is wrong", step, i+1)
+			}
+		}
+	}
+}
+
+func TestSkipListMissingEntries(t *testing.T) {
+	list := newSkipList(1)
+	e := rankedEntry{userID: "a", points: 100}
+	list.insert(e)
+
+	stale := rankedEntry{userID: "a", points: 200}
+	if list.delete(stale) {
+		t.Error("deleted an entry whose fields don't match")
+	}
+	if pos := list.position(stale); pos != 0 {
+		t.Errorf("got position %d for a missing entry, want 0", pos)
+	}
+	if list.at(0) != nil || list.at(2) != nil {
+		t.Error("at returned a node out of range")
+	}
+	if got := list.rangeFrom(2, 5); len(got) != 0 {
+		t.Errorf("got %v past the end, want nothing", got)
+	}
+}
+
+// TestBeforeMatchesRedisOrder checks that the in-process order is the order ZREVRANGE gives
+// the Redis composite score: descending score, then descending member on equal scores.
+func TestBeforeMatchesRedisOrder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	var entries []rankedEntry
+	for i := 0; i < 300; i++ {
+		entries = append(entries, rankedEntry{
+			userID:      fmt.Sprintf("u%02d", rnd.Intn(50)),
+			points:      float64(rnd.Intn(4) * 500),
+			totalTimeMs: int64(rnd.Intn(4) * 250),
+		})
+	}
+	for _, a := range entries {
+		for _, b := range entries[:40] {
+			sa, sb := encodeScore(a.points, a.totalTimeMs), encodeScore(b.points, b.totalTimeMs)
+			redisBefore := sa > sb || (sa == sb && a.userID > b.userID)
+			if a.before(b) != redisBefore {
+				t.Fatalf("%v before %v: got %v, Redis order says %v", a, b, a.before(b), redisBefore)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,7 +14,12 @@ import (
 	"kahootclone/internal/observability"
 )
 
-// PutAnswer stores a player's answer to a question.
+// ErrAlreadyAnswered is returned when a player submits a second answer to the same question.
+var ErrAlreadyAnswered = errors.New("question already answered")
+
+// PutAnswer stores a player's answer to a question. The put is conditional on no answer
+// existing yet, so concurrent submissions record exactly one answer; the others get
+// ErrAlreadyAnswered.
 func (c *Client) PutAnswer(ctx context.Context, answer *models.Answer) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -24,41 +30,138 @@ func (c *Client) PutAnswer(ctx context.Context, answer *models.Answer) error {
 		"questionId", answer.QuestionID,
 	)
 
+	// Keep the answer for the retention period after the latest time its game can finish
+	answer.TTL = time.Now().Add(c.SessionTTL + c.AnswerRetention).Unix()
+	if !models.IsAnonymous(answer.UserID) {
+		answer.HistoryUserID = answer.UserID
+	}
+
 	item, err := attributevalue.MarshalMap(answer)
 	if err != nil {
 		return err
 	}
 
 	_, err = c.DDB.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(c.AnswersTable),
-		Item:      item,
+		TableName:           aws.String(c.AnswersTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(userIdQuestionId)"),
 	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrAlreadyAnswered
+	}
 	return err
 }
 
-// GetAnswersBySession retrieves all answers for a given session.
+// GetAnswersBySession retrieves all answers for a given session, following
+// LastEvaluatedKey so results larger than one 1 MB query page are not truncated.
 func (c *Client) GetAnswersBySession(ctx context.Context, sessionID string) ([]models.Answer, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	observability.Debug(ctx, "getting answers by session", "sessionId", sessionID)
 
-	result, err := c.DDB.Query(ctx, &dynamodb.QueryInput{
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.AnswersTable),
+		KeyConditionExpression: aws.String("sessionId = :sid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sid": &types.AttributeValueMemberS{Value: sessionID},
+		},
+	}
+
+	return queryAll[models.Answer](ctx, c.DDB, input)
+}
+
+// EachAnswerBySession calls fn for every answer of a session, ordered by userId#questionId,
+// one query page at a time.
+func (c *Client) EachAnswerBySession(ctx context.Context, sessionID string, fn func(models.Answer) error) error {
+	// Large sessions take many pages, and fn may be writing a download as it goes
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	observability.Debug(ctx, "iterating answers by session", "sessionId", sessionID)
+
+	return queryEach(ctx, c.DDB, &dynamodb.QueryInput{
 		TableName:              aws.String(c.AnswersTable),
 		KeyConditionExpression: aws.String("sessionId = :sid"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":sid": &types.AttributeValueMemberS{Value: sessionID},
 		},
+	}, fn)
+}
+
+// GetAnswersByUser retrieves one player's answers in a session.
+func (c *Client) GetAnswersByUser(ctx context.Context, sessionID, userID string) ([]models.Answer, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "getting answers by user", "sessionId", sessionID, "userId", userID)
+
+	return queryAll[models.Answer](ctx, c.DDB, &dynamodb.QueryInput{
+		TableName:              aws.String(c.AnswersTable),
+		KeyConditionExpression: aws.String("sessionId = :sid AND begins_with(userIdQuestionId, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sid":    &types.AttributeValueMemberS{Value: sessionID},
+			":prefix": &types.AttributeValueMemberS{Value: userID + "#"},
+		},
 	})
+}
+
+// ListAnsweredSessions returns the IDs of up to limit sessions an authenticated player
+// answered in, most recently answered first, starting at cursor, and the cursor of the next
+// page ("" on the last page). It reads the sparse historyUserId-answeredAt-index, which
+// holds one key-only item per answer, and collapses each session's run of answers into one
+// entry. The cursor points after the last answer of the page's last session, so a session
+// whose answers interleave with another's (the same account playing two games at once) can
+// appear on two pages.
+func (c *Client) ListAnsweredSessions(ctx context.Context, userID string, limit int, cursor string) ([]string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "listing answered sessions", "userId", userID, "limit", limit)
+
+	startKey, err := decodeKeyCursor(cursor)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
+	fetch := queryFetcher(c.DDB, &dynamodb.QueryInput{
+		TableName:              aws.String(c.AnswersTable),
+		IndexName:              aws.String("historyUserId-answeredAt-index"),
+		KeyConditionExpression: aws.String("historyUserId = :uid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: userID},
+		},
+		ScanIndexForward: aws.Bool(false),
+	})
 
-	var answers []models.Answer
-	if err := attributevalue.UnmarshalListOfMaps(result.Items, &answers); err != nil {
-		return nil, err
+	var sessionIDs []string
+	seen := make(map[string]bool)
+	var lastKey map[string]types.AttributeValue // key of the last answer taken into the page
+	for {
+		items, pageKey, err := fetch(ctx, startKey, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, item := range items {
+			sid, _ := item["sessionId"].(*types.AttributeValueMemberS)
+			if sid == nil {
+				continue
+			}
+			if !seen[sid.Value] {
+				if len(sessionIDs) == limit {
+					next, err := encodeKeyCursor(lastKey)
+					return sessionIDs, next, err
+				}
+				seen[sid.Value] = true
+				sessionIDs = append(sessionIDs, sid.Value)
+			}
+			lastKey = item
+		}
+		if len(pageKey) == 0 {
+			return sessionIDs, "", nil
+		}
+		startKey = pageKey
 	}
-	return answers, nil
 }
 
 // GetAnswer retrieves a specific player's answer to a specific question.
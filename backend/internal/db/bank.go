@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// ErrBankQuestionNotFound is returned when a bank question does not exist, or was deleted
+// while being updated.
+var ErrBankQuestionNotFound = errors.New("bank question not found")
+
+// batchGetLimit is the maximum number of keys DynamoDB accepts per BatchGetItem call.
+const batchGetLimit = 100
+
+// CreateBankQuestion stores a new question in the question bank.
+func (c *Client) CreateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "creating bank question", "questionId", question.QuestionID)
+
+	question.TextLower = strings.ToLower(question.Text)
+	item, err := attributevalue.MarshalMap(question)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DDB.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.QuestionBankTable),
+		Item:      item,
+	})
+	return err
+}
+
+// GetBankQuestion retrieves a bank question by its ID using consistent read. Returns nil if
+// it does not exist.
+func (c *Client) GetBankQuestion(ctx context.Context, questionID string) (*models.BankQuestion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "getting bank question", "questionId", questionID)
+
+	result, err := c.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(c.QuestionBankTable),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"questionId": &types.AttributeValueMemberS{Value: questionID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var question models.BankQuestion
+	if err := attributevalue.UnmarshalMap(result.Item, &question); err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+// GetBankQuestions retrieves the bank questions with the given IDs, in no particular order.
+// IDs that don't exist are skipped and duplicates are read once.
+func (c *Client) GetBankQuestions(ctx context.Context, questionIDs []string) ([]models.BankQuestion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "getting bank questions", "count", len(questionIDs))
+
+	var keys []map[string]types.AttributeValue
+	seen := make(map[string]bool, len(questionIDs))
+	for _, id := range questionIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, map[string]types.AttributeValue{
+			"questionId": &types.AttributeValueMemberS{Value: id},
+		})
+	}
+
+	var questions []models.BankQuestion
+	for start := 0; start < len(keys); start += batchGetLimit {
+		end := min(start+batchGetLimit, len(keys))
+		pending := map[string]types.KeysAndAttributes{
+			c.QuestionBankTable: {Keys: keys[start:end], ConsistentRead: aws.Bool(true)},
+		}
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt > 5 {
+					return nil, fmt.Errorf("%d keys still unprocessed after retries", len(pending[c.QuestionBankTable].Keys))
+				}
+				time.Sleep(time.Duration(attempt*attempt) * 50 * time.Millisecond)
+			}
+
+			out, err := c.DDB.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: pending})
+			if err != nil {
+				return nil, err
+			}
+			var page []models.BankQuestion
+			if err := attributevalue.UnmarshalListOfMaps(out.Responses[c.QuestionBankTable], &page); err != nil {
+				return nil, err
+			}
+			questions = append(questions, page...)
+			pending = out.UnprocessedKeys
+		}
+	}
+	return questions, nil
+}
+
+// UpdateBankQuestion replaces a bank question. Returns ErrBankQuestionNotFound if it was
+// deleted in the meantime.
+func (c *Client) UpdateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "updating bank question", "questionId", question.QuestionID)
+
+	question.TextLower = strings.ToLower(question.Text)
+	item, err := attributevalue.MarshalMap(question)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DDB.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.QuestionBankTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(questionId)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrBankQuestionNotFound
+	}
+	return err
+}
+
+// DeleteBankQuestion deletes a bank question. Quizzes referencing it keep their copies.
+// Deleting a question that does not exist is not an error.
+func (c *Client) DeleteBankQuestion(ctx context.Context, questionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "deleting bank question", "questionId", questionID)
+
+	_, err := c.DDB.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.QuestionBankTable),
+		Key: map[string]types.AttributeValue{
+			"questionId": &types.AttributeValueMemberS{Value: questionID},
+		},
+	})
+	return err
+}
+
+// ListBankQuestions returns up to limit of a host's bank questions matching filter, most
+// recently updated first, starting at cursor, and the cursor of the next page ("" on the
+// last page). A limit of 0 or less returns every remaining question. Uses the
+// hostUserId-index GSI.
+func (c *Client) ListBankQuestions(ctx context.Context, hostUserID string, filter models.BankFilter, limit int, cursor string) ([]models.BankQuestion, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "listing bank questions", "hostUserId", hostUserID, "limit", limit)
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.QuestionBankTable),
+		IndexName:              aws.String("hostUserId-index"),
+		KeyConditionExpression: aws.String("hostUserId = :uid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: hostUserID},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+
+	filter = filter.Normalize()
+	var conditions []string
+	if filter.Text != "" {
+		conditions = append(conditions, "contains(textLower, :text)")
+		input.ExpressionAttributeValues[":text"] = &types.AttributeValueMemberS{Value: filter.Text}
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, "contains(tags, :tag)")
+		input.ExpressionAttributeValues[":tag"] = &types.AttributeValueMemberS{Value: filter.Tag}
+	}
+	if len(conditions) > 0 {
+		input.FilterExpression = aws.String(strings.Join(conditions, " AND "))
+	}
+
+	return queryPage[models.BankQuestion](ctx, c.DDB, input, limit, cursor)
+}
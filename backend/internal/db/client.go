@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
@@ -13,11 +14,18 @@ import (
 
 // Client wraps the DynamoDB client and table names.
 type Client struct {
-	DDB              *dynamodb.Client
-	QuizzesTable     string
-	SessionsTable    string
-	ConnectionsTable string
-	AnswersTable     string
+	DDB               DynamoAPI
+	QuizzesTable      string
+	QuizVersionsTable string
+	SessionsTable     string
+	ConnectionsTable  string
+	AnswersTable      string
+	PinsTable         string
+	ResultsTable      string
+	QuestionBankTable string
+
+	SessionTTL      time.Duration // lifetime of unfinished sessions and PIN reservations
+	AnswerRetention time.Duration // how long answers and finished sessions are kept after a game ends
 }
 
 // NewClient creates a new DynamoDB client from the application config.
@@ -45,10 +53,16 @@ func NewClient(ctx context.Context, cfg *config.Config) (*Client, error) {
 	}
 
 	return &Client{
-		DDB:              ddbClient,
-		QuizzesTable:     cfg.QuizzesTable,
-		SessionsTable:    cfg.SessionsTable,
-		ConnectionsTable: cfg.ConnectionsTable,
-		AnswersTable:     cfg.AnswersTable,
+		DDB:               ddbClient,
+		QuizzesTable:      cfg.QuizzesTable,
+		QuizVersionsTable: cfg.QuizVersionsTable,
+		SessionsTable:     cfg.SessionsTable,
+		ConnectionsTable:  cfg.ConnectionsTable,
+		AnswersTable:      cfg.AnswersTable,
+		PinsTable:         cfg.PinsTable,
+		ResultsTable:      cfg.ResultsTable,
+		QuestionBankTable: cfg.QuestionBankTable,
+		SessionTTL:        cfg.SessionTTL,
+		AnswerRetention:   cfg.AnswerRetention,
 	}, nil
 }
@@ -57,29 +57,21 @@ func (c *Client) DeleteConnection(ctx context.Context, sessionID, connectionID s
 	return err
 }
 
-// GetConnectionsBySession returns all connections for a given session.
+// GetConnectionsBySession returns all connections for a given session, following
+// LastEvaluatedKey so sessions larger than one 1 MB query page are not truncated.
 func (c *Client) GetConnectionsBySession(ctx context.Context, sessionID string) ([]models.Player, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	observability.Debug(ctx, "getting connections by session", "sessionId", sessionID)
 
-	result, err := c.DDB.Query(ctx, &dynamodb.QueryInput{
+	return queryAll[models.Player](ctx, c.DDB, &dynamodb.QueryInput{
 		TableName:              aws.String(c.ConnectionsTable),
 		KeyConditionExpression: aws.String("sessionId = :sid"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":sid": &types.AttributeValueMemberS{Value: sessionID},
 		},
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	var players []models.Player
-	if err := attributevalue.UnmarshalListOfMaps(result.Items, &players); err != nil {
-		return nil, err
-	}
-	return players, nil
 }
 
 // GetPlayerCountBySession returns the number of players (non-host) connected to a session.
@@ -0,0 +1,563 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamo is an in-memory DynamoAPI whose Query and Scan stop after pageSize evaluated
+// items and return a LastEvaluatedKey, the way DynamoDB stops at 1 MB. Like DynamoDB, the
+// page size (and any Limit) counts items before the FilterExpression is applied.
+//
+// Tables and indexes declared with schema and index behave like DynamoDB's: writes replace
+// the item with the same primary key, and queries return items in sort key order. Items of
+// undeclared tables are kept in insertion order, so tests insert them sorted. An index query
+// sees only the items that have the index's key attributes, as a sparse GSI would. The
+// LastEvaluatedKey is the position of the next item rather than its primary key, which is
+// enough to exercise cursors end to end.
+type fakeDynamo struct {
+	pageSize int
+	tables   map[string][]map[string]types.AttributeValue
+	keys     map[string]fakeKey // table -> primary key
+	indexes  map[string]fakeKey // table + "/" + index -> index key
+
+	gets    int // GetItem calls made
+	queries int // Query calls made
+	scans   int // Scan calls made
+}
+
+// fakeKey names the partition and (optional) sort key attributes of a table or index.
+// keysOnly marks an index with a KEYS_ONLY projection.
+type fakeKey struct {
+	hash, rng string
+	keysOnly  bool
+}
+
+func newFakeDynamo(pageSize int) *fakeDynamo {
+	return &fakeDynamo{
+		pageSize: pageSize,
+		tables:   make(map[string][]map[string]types.AttributeValue),
+		keys:     make(map[string]fakeKey),
+		indexes:  make(map[string]fakeKey),
+	}
+}
+
+// schema declares a table's primary key; rng is "" for a table without a sort key.
+func (f *fakeDynamo) schema(table, hash, rng string) {
+	f.keys[table] = fakeKey{hash: hash, rng: rng}
+}
+
+// index declares a global secondary index of table.
+func (f *fakeDynamo) index(table, index, hash, rng string) {
+	f.indexes[table+"/"+index] = fakeKey{hash: hash, rng: rng}
+}
+
+// keysOnlyIndex declares a global secondary index that projects only the table and index keys.
+func (f *fakeDynamo) keysOnlyIndex(table, index, hash, rng string) {
+	f.indexes[table+"/"+index] = fakeKey{hash: hash, rng: rng, keysOnly: true}
+}
+
+// put marshals v and stores it in table.
+func (f *fakeDynamo) put(t *testing.T, table string, v any) {
+	t.Helper()
+	item, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+	}
+	f.store(table, item)
+}
+
+// store writes item, replacing the item with the same primary key in a declared table.
+func (f *fakeDynamo) store(table string, item map[string]types.AttributeValue) {
+	if i := f.find(table, f.keyOf(table, item)); i >= 0 {
+		f.tables[table][i] = item
+		return
+	}
+	f.tables[table] = append(f.tables[table], item)
+}
+
+// keyOf returns the primary key attributes of item, or nil for an undeclared table.
+func (f *fakeDynamo) keyOf(table string, item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	k, ok := f.keys[table]
+	if !ok {
+		return nil
+	}
+	key := map[string]types.AttributeValue{k.hash: item[k.hash]}
+	if k.rng != "" {
+		key[k.rng] = item[k.rng]
+	}
+	return key
+}
+
+// find returns the position of the item with the given key in table, or -1.
+func (f *fakeDynamo) find(table string, key map[string]types.AttributeValue) int {
+	if key == nil {
+		return -1
+	}
+	for i, item := range f.tables[table] {
+		if fakeMatchesKey(item, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func fakeMatchesKey(item, key map[string]types.AttributeValue) bool {
+	for name, want := range key {
+		got, ok := item[name]
+		if !ok || fakeCompare(got, want) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// check evaluates a ConditionExpression against the item currently stored at position i.
+func (f *fakeDynamo) check(table string, i int, condition string, expr fakeExpr) error {
+	if condition == "" {
+		return nil
+	}
+	current := map[string]types.AttributeValue{}
+	if i >= 0 {
+		current = f.tables[table][i]
+	}
+	ok, err := expr.eval(condition, current)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	return nil
+}
+
+var errFakeUnsupported = errors.New("fakeDynamo: operation not supported")
+
+// GetItem returns the first item whose attributes match every attribute of the key.
+func (f *fakeDynamo) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.gets++
+	table := aws.ToString(params.TableName)
+	for _, item := range f.tables[table] {
+		if fakeMatchesKey(item, params.Key) {
+			return &dynamodb.GetItemOutput{Item: item}, nil
+		}
+	}
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func (f *fakeDynamo) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	table := aws.ToString(params.TableName)
+	expr := fakeExpr{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+	if err := f.check(table, f.find(table, f.keyOf(table, params.Item)), aws.ToString(params.ConditionExpression), expr); err != nil {
+		return nil, err
+	}
+	f.store(table, params.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+// UpdateItem supports SET clauses assigning a value or if_not_exists(path, value), and
+// returning the updated item with ReturnValues ALL_NEW. Like DynamoDB, updating a missing
+// item creates it from the key.
+func (f *fakeDynamo) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	table := aws.ToString(params.TableName)
+	expr := fakeExpr{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+	i := f.find(table, params.Key)
+	if err := f.check(table, i, aws.ToString(params.ConditionExpression), expr); err != nil {
+		return nil, err
+	}
+
+	item := make(map[string]types.AttributeValue)
+	if i >= 0 {
+		for k, v := range f.tables[table][i] {
+			item[k] = v
+		}
+	}
+	for k, v := range params.Key {
+		item[k] = v
+	}
+
+	update := strings.TrimSpace(aws.ToString(params.UpdateExpression))
+	if !strings.HasPrefix(update, "SET ") {
+		return nil, fmt.Errorf("fakeDynamo: unsupported update %q", update)
+	}
+	for _, assignment := range fakeSplitTopLevel(strings.TrimPrefix(update, "SET ")) {
+		path, value, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return nil, fmt.Errorf("fakeDynamo: bad assignment %q", assignment)
+		}
+		name := expr.name(path)
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "if_not_exists(") {
+			args := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "if_not_exists("), ")"), ",")
+			if len(args) != 2 {
+				return nil, fmt.Errorf("fakeDynamo: bad value %q", value)
+			}
+			if _, exists := item[expr.name(args[0])]; exists {
+				continue
+			}
+			value = strings.TrimSpace(args[1])
+		}
+		v, ok := expr.values[value]
+		if !ok {
+			return nil, fmt.Errorf("fakeDynamo: unknown value %q", value)
+		}
+		item[name] = v
+	}
+
+	if i >= 0 {
+		f.tables[table][i] = item
+	} else {
+		f.tables[table] = append(f.tables[table], item)
+	}
+	if params.ReturnValues == types.ReturnValueAllNew {
+		return &dynamodb.UpdateItemOutput{Attributes: item}, nil
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// fakeSplitTopLevel splits a comma-separated list, ignoring commas inside parentheses.
+func fakeSplitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+func (f *fakeDynamo) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	table := aws.ToString(params.TableName)
+	expr := fakeExpr{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+	i := f.find(table, params.Key)
+	if err := f.check(table, i, aws.ToString(params.ConditionExpression), expr); err != nil {
+		return nil, err
+	}
+	if i >= 0 {
+		f.tables[table] = append(f.tables[table][:i], f.tables[table][i+1:]...)
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamo) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	for table, requests := range params.RequestItems {
+		for _, r := range requests {
+			switch {
+			case r.PutRequest != nil:
+				f.store(table, r.PutRequest.Item)
+			case r.DeleteRequest != nil:
+				if i := f.find(table, r.DeleteRequest.Key); i >= 0 {
+					f.tables[table] = append(f.tables[table][:i], f.tables[table][i+1:]...)
+				}
+			default:
+				return nil, errFakeUnsupported
+			}
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (f *fakeDynamo) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{Responses: make(map[string][]map[string]types.AttributeValue)}
+	for table, ka := range params.RequestItems {
+		for _, key := range ka.Keys {
+			if i := f.find(table, key); i >= 0 {
+				out.Responses[table] = append(out.Responses[table], f.tables[table][i])
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDynamo) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.queries++
+	table := aws.ToString(params.TableName)
+	expr := fakeExpr{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+
+	key, declared := f.keys[table]
+	if params.IndexName != nil {
+		key, declared = f.indexes[table+"/"+aws.ToString(params.IndexName)]
+	}
+
+	var candidates []map[string]types.AttributeValue
+	for _, item := range f.tables[table] {
+		if declared && !fakeHasKey(item, key) {
+			continue
+		}
+		ok, err := expr.eval(aws.ToString(params.KeyConditionExpression), item)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if key.keysOnly {
+			projected := f.keyOf(table, item)
+			projected[key.hash] = item[key.hash]
+			if key.rng != "" {
+				projected[key.rng] = item[key.rng]
+			}
+			item = projected
+		}
+		candidates = append(candidates, item)
+	}
+	if declared && key.rng != "" {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return fakeCompare(candidates[i][key.rng], candidates[j][key.rng]) < 0
+		})
+	}
+	if params.ScanIndexForward != nil && !*params.ScanIndexForward {
+		for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		}
+	}
+
+	items, lastKey, err := f.evaluate(candidates, expr, aws.ToString(params.FilterExpression), params.ExclusiveStartKey, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: lastKey, Count: int32(len(items))}, nil
+}
+
+// fakeHasKey reports whether item has every key attribute, so it appears in a sparse index.
+func fakeHasKey(item map[string]types.AttributeValue, key fakeKey) bool {
+	if _, ok := item[key.hash]; !ok {
+		return false
+	}
+	if key.rng != "" {
+		if _, ok := item[key.rng]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeDynamo) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	f.scans++
+	expr := fakeExpr{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+	items, lastKey, err := f.evaluate(f.tables[aws.ToString(params.TableName)], expr, aws.ToString(params.FilterExpression), params.ExclusiveStartKey, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.ScanOutput{Items: items, LastEvaluatedKey: lastKey, Count: int32(len(items))}, nil
+}
+
+// evaluate reads one page of candidates starting after startKey, then applies the filter.
+func (f *fakeDynamo) evaluate(candidates []map[string]types.AttributeValue, expr fakeExpr, filter string, startKey map[string]types.AttributeValue, limit *int32) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	start := 0
+	if pos, ok := startKey["pos"].(*types.AttributeValueMemberN); ok {
+		n, err := strconv.Atoi(pos.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		start = n
+	} else if startKey != nil {
+		// A key taken from a returned item, as DynamoDB also accepts: resume after that item
+		start = -1
+		for i, item := range candidates {
+			if fakeMatchesKey(item, startKey) {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, nil, fmt.Errorf("fakeDynamo: unexpected start key %v", startKey)
+		}
+	}
+
+	size := f.pageSize
+	if limit != nil && int(*limit) < size {
+		size = int(*limit)
+	}
+	end := start + size
+	if end > len(candidates) {
+		end = len(candidates)
+	}
+
+	var items []map[string]types.AttributeValue
+	for _, item := range candidates[start:end] {
+		ok, err := expr.eval(filter, item)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			items = append(items, item)
+		}
+	}
+
+	var lastKey map[string]types.AttributeValue
+	if end < len(candidates) {
+		lastKey = map[string]types.AttributeValue{"pos": &types.AttributeValueMemberN{Value: strconv.Itoa(end)}}
+	}
+	return items, lastKey, nil
+}
+
+// fakeExpr evaluates the small subset of the DynamoDB expression language used by Client:
+// OR of AND-joined comparisons (=, <>, <, <=, >, >=), BETWEEN, begins_with, contains,
+// attribute_exists and attribute_not_exists. Parentheses are not supported.
+type fakeExpr struct {
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+func (e fakeExpr) eval(expr string, item map[string]types.AttributeValue) (bool, error) {
+	if expr == "" {
+		return true, nil
+	}
+	for _, alternative := range strings.Split(expr, " OR ") {
+		ok, err := e.all(alternative, item)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// all evaluates AND-joined clauses.
+func (e fakeExpr) all(expr string, item map[string]types.AttributeValue) (bool, error) {
+	parts := strings.Split(expr, " AND ")
+	for i := 0; i < len(parts); i++ {
+		clause := strings.TrimSpace(parts[i])
+		if strings.Contains(clause, " BETWEEN ") && i+1 < len(parts) {
+			i++
+			clause += " AND " + strings.TrimSpace(parts[i])
+		}
+		ok, err := e.clause(clause, item)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (e fakeExpr) clause(clause string, item map[string]types.AttributeValue) (bool, error) {
+	for fn, want := range map[string]bool{"attribute_exists": true, "attribute_not_exists": false} {
+		if strings.HasPrefix(clause, fn+"(") {
+			_, ok := item[e.name(strings.TrimSuffix(strings.TrimPrefix(clause, fn+"("), ")"))]
+			return ok == want, nil
+		}
+	}
+	for _, fn := range []string{"begins_with", "contains"} {
+		if !strings.HasPrefix(clause, fn+"(") {
+			continue
+		}
+		args := strings.Split(strings.TrimSuffix(strings.TrimPrefix(clause, fn+"("), ")"), ",")
+		if len(args) != 2 {
+			return false, fmt.Errorf("fakeDynamo: bad clause %q", clause)
+		}
+		attr, ok := item[e.name(args[0])]
+		if !ok {
+			return false, nil
+		}
+		want := e.values[strings.TrimSpace(args[1])]
+		if fn == "begins_with" {
+			return strings.HasPrefix(fakeString(attr), fakeString(want)), nil
+		}
+		switch v := attr.(type) {
+		case *types.AttributeValueMemberL:
+			for _, el := range v.Value {
+				if fakeString(el) == fakeString(want) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case *types.AttributeValueMemberSS:
+			for _, el := range v.Value {
+				if el == fakeString(want) {
+					return true, nil
+				}
+			}
+			return false, nil
+		default:
+			return strings.Contains(fakeString(attr), fakeString(want)), nil
+		}
+	}
+
+	fields := strings.Fields(clause)
+	if len(fields) == 5 && fields[1] == "BETWEEN" && fields[3] == "AND" {
+		attr, ok := item[e.name(fields[0])]
+		if !ok {
+			return false, nil
+		}
+		return fakeCompare(attr, e.values[fields[2]]) >= 0 && fakeCompare(attr, e.values[fields[4]]) <= 0, nil
+	}
+	if len(fields) != 3 {
+		return false, fmt.Errorf("fakeDynamo: unsupported clause %q", clause)
+	}
+	attr, ok := item[e.name(fields[0])]
+	if !ok {
+		return false, nil
+	}
+	c := fakeCompare(attr, e.values[fields[2]])
+	switch fields[1] {
+	case "=":
+		return c == 0, nil
+	case "<>":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return false, fmt.Errorf("fakeDynamo: unsupported operator in %q", clause)
+}
+
+func (e fakeExpr) name(s string) string {
+	s = strings.TrimSpace(s)
+	if n, ok := e.names[s]; ok {
+		return n
+	}
+	return s
+}
+
+func fakeString(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return v.Value
+	case *types.AttributeValueMemberBOOL:
+		return strconv.FormatBool(v.Value)
+	}
+	return ""
+}
+
+func fakeCompare(a, b types.AttributeValue) int {
+	an, aok := a.(*types.AttributeValueMemberN)
+	bn, bok := b.(*types.AttributeValueMemberN)
+	if aok && bok {
+		x, _ := strconv.ParseFloat(an.Value, 64)
+		y, _ := strconv.ParseFloat(bn.Value, 64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fakeString(a), fakeString(b))
+}
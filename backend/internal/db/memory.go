@@ -0,0 +1,790 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// MemoryStore is an in-process Store for local development. It mirrors the DynamoDB
+// backend's semantics — PIN reservations, session expiry fields, result ordering — but
+// keeps everything in maps, so all data is lost when the process exits.
+// Records are copied in and out; slices inside a record are shared and must not be mutated.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	quizzes      map[string]models.Quiz
+	quizVersions map[string]map[int64]models.Quiz // quizId -> version -> snapshot
+	bank         map[string]models.BankQuestion
+	sessions     map[string]models.Session
+	pins         map[string]models.PINReservation
+	connections  map[string]map[string]models.Player // sessionId -> connectionId -> player
+	answers      map[string]map[string]models.Answer // sessionId -> userId#questionId -> answer
+	results      map[string]models.SessionResult
+	rankings     map[string][]models.PlayerResult // sessionId -> rankings in leaderboard order
+
+	SessionTTL      time.Duration
+	AnswerRetention time.Duration
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore(cfg *config.Config) *MemoryStore {
+	return &MemoryStore{
+		quizzes:         make(map[string]models.Quiz),
+		quizVersions:    make(map[string]map[int64]models.Quiz),
+		bank:            make(map[string]models.BankQuestion),
+		sessions:        make(map[string]models.Session),
+		pins:            make(map[string]models.PINReservation),
+		connections:     make(map[string]map[string]models.Player),
+		answers:         make(map[string]map[string]models.Answer),
+		results:         make(map[string]models.SessionResult),
+		rankings:        make(map[string][]models.PlayerResult),
+		SessionTTL:      cfg.SessionTTL,
+		AnswerRetention: cfg.AnswerRetention,
+	}
+}
+
+// --- Quizzes ---
+
+// CreateQuiz stores a new quiz.
+func (m *MemoryStore) CreateQuiz(ctx context.Context, quiz *models.Quiz) error {
+	observability.Debug(ctx, "creating quiz", "quizId", quiz.QuizID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if quiz.Version == 0 {
+		quiz.Version = 1
+	}
+	m.quizzes[quiz.QuizID] = *quiz
+	return nil
+}
+
+// UpdateQuiz replaces a quiz if its stored version is still expectedVersion, and bumps
+// quiz.Version. Returns ErrVersionConflict if the quiz was changed or deleted in the meantime.
+func (m *MemoryStore) UpdateQuiz(ctx context.Context, quiz *models.Quiz, expectedVersion int64) error {
+	observability.Debug(ctx, "updating quiz", "quizId", quiz.QuizID, "version", expectedVersion)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.quizzes[quiz.QuizID]
+	if !ok || current.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+	quiz.Version = expectedVersion + 1
+	m.quizzes[quiz.QuizID] = *quiz
+	return nil
+}
+
+// DeleteQuiz deletes a quiz. Deleting a quiz that does not exist is not an error.
+func (m *MemoryStore) DeleteQuiz(ctx context.Context, quizID string) error {
+	observability.Debug(ctx, "deleting quiz", "quizId", quizID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.quizzes, quizID)
+	return nil
+}
+
+// PutQuizVersion stores an immutable snapshot of a quiz under its current version.
+// Storing a version that already exists is a no-op.
+func (m *MemoryStore) PutQuizVersion(ctx context.Context, quiz *models.Quiz) error {
+	observability.Debug(ctx, "putting quiz version", "quizId", quiz.QuizID, "version", quiz.Version)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	versions := m.quizVersions[quiz.QuizID]
+	if versions == nil {
+		versions = make(map[int64]models.Quiz)
+		m.quizVersions[quiz.QuizID] = versions
+	}
+	if _, ok := versions[quiz.Version]; !ok {
+		versions[quiz.Version] = *quiz
+	}
+	return nil
+}
+
+// GetQuizVersion retrieves a stored quiz snapshot. Returns nil if it does not exist.
+func (m *MemoryStore) GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	quiz, ok := m.quizVersions[quizID][version]
+	if !ok {
+		return nil, nil
+	}
+	return &quiz, nil
+}
+
+// GetQuiz retrieves a quiz by its ID. Returns nil if it does not exist.
+func (m *MemoryStore) GetQuiz(ctx context.Context, quizID string) (*models.Quiz, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	quiz, ok := m.quizzes[quizID]
+	if !ok {
+		return nil, nil
+	}
+	return &quiz, nil
+}
+
+// ListQuizzesByHost returns up to limit of a host's quizzes matching filter, most recently
+// updated first, starting at cursor, and the cursor of the next page ("" on the last page).
+// A limit of 0 or less returns every remaining quiz.
+func (m *MemoryStore) ListQuizzesByHost(ctx context.Context, hostUserID string, filter models.QuizFilter, limit int, cursor string) ([]models.Quiz, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	filter = filter.Normalize()
+	var quizzes []models.Quiz
+	for _, q := range m.quizzes {
+		if q.HostUserID == hostUserID && filter.Matches(&q) {
+			quizzes = append(quizzes, q)
+		}
+	}
+	sort.Slice(quizzes, func(i, j int) bool {
+		if !quizzes[i].UpdatedAt.Equal(quizzes[j].UpdatedAt) {
+			return quizzes[i].UpdatedAt.After(quizzes[j].UpdatedAt)
+		}
+		return quizzes[i].QuizID < quizzes[j].QuizID
+	})
+	return OffsetPage(quizzes, limit, cursor)
+}
+
+// --- Question bank ---
+
+// CreateBankQuestion stores a new question in the question bank.
+func (m *MemoryStore) CreateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	observability.Debug(ctx, "creating bank question", "questionId", question.QuestionID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bank[question.QuestionID] = *question
+	return nil
+}
+
+// GetBankQuestion retrieves a bank question by its ID. Returns nil if it does not exist.
+func (m *MemoryStore) GetBankQuestion(ctx context.Context, questionID string) (*models.BankQuestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	question, ok := m.bank[questionID]
+	if !ok {
+		return nil, nil
+	}
+	return &question, nil
+}
+
+// GetBankQuestions retrieves the bank questions with the given IDs, in no particular order.
+// IDs that don't exist are skipped and duplicates are read once.
+func (m *MemoryStore) GetBankQuestions(ctx context.Context, questionIDs []string) ([]models.BankQuestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var questions []models.BankQuestion
+	seen := make(map[string]bool, len(questionIDs))
+	for _, id := range questionIDs {
+		question, ok := m.bank[id]
+		if ok && !seen[id] {
+			seen[id] = true
+			questions = append(questions, question)
+		}
+	}
+	return questions, nil
+}
+
+// UpdateBankQuestion replaces a bank question. Returns ErrBankQuestionNotFound if it was
+// deleted in the meantime.
+func (m *MemoryStore) UpdateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	observability.Debug(ctx, "updating bank question", "questionId", question.QuestionID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.bank[question.QuestionID]; !ok {
+		return ErrBankQuestionNotFound
+	}
+	m.bank[question.QuestionID] = *question
+	return nil
+}
+
+// DeleteBankQuestion deletes a bank question.
+func (m *MemoryStore) DeleteBankQuestion(ctx context.Context, questionID string) error {
+	observability.Debug(ctx, "deleting bank question", "questionId", questionID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.bank, questionID)
+	return nil
+}
+
+// ListBankQuestions returns up to limit of a host's bank questions matching filter, most
+// recently updated first, starting at cursor, and the cursor of the next page ("" on the
+// last page). A limit of 0 or less returns every remaining question.
+func (m *MemoryStore) ListBankQuestions(ctx context.Context, hostUserID string, filter models.BankFilter, limit int, cursor string) ([]models.BankQuestion, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	filter = filter.Normalize()
+	var questions []models.BankQuestion
+	for _, q := range m.bank {
+		if q.HostUserID == hostUserID && filter.Matches(&q) {
+			questions = append(questions, q)
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		if !questions[i].UpdatedAt.Equal(questions[j].UpdatedAt) {
+			return questions[i].UpdatedAt.After(questions[j].UpdatedAt)
+		}
+		return questions[i].QuestionID < questions[j].QuestionID
+	})
+	return OffsetPage(questions, limit, cursor)
+}
+
+// --- Sessions ---
+
+// CreateSession stores a new session.
+func (m *MemoryStore) CreateSession(ctx context.Context, session *models.Session) error {
+	observability.Debug(ctx, "creating session", "sessionId", session.SessionID, "pin", session.PIN)
+
+	now := time.Now()
+	session.LastActivityAt = now.Unix()
+	session.TTL = now.Add(m.SessionTTL).Unix()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.SessionID] = *session
+	return nil
+}
+
+// GetSession retrieves a session by its ID. Returns nil if it does not exist.
+func (m *MemoryStore) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// GetSessionByPIN resolves a PIN to its live session via the PIN reservation.
+// Returns nil if the PIN is not reserved or the session it points to has finished.
+func (m *MemoryStore) GetSessionByPIN(ctx context.Context, pin string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reservation, ok := m.pins[pin]
+	if !ok || reservation.TTL < time.Now().Unix() {
+		return nil, nil
+	}
+	session, ok := m.sessions[reservation.SessionID]
+	if !ok || session.Status == models.SessionStatusFinished {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// UpdateSessionStatus updates the status and related fields of a session.
+func (m *MemoryStore) UpdateSessionStatus(ctx context.Context, sessionID string, status models.SessionStatus, questionIndex int) error {
+	observability.Debug(ctx, "updating session status", "sessionId", sessionID, "status", status)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Like DynamoDB's UpdateItem, updating a missing session creates it
+	session := m.sessions[sessionID]
+	session.SessionID = sessionID
+
+	now := time.Now()
+	ttl := now.Add(m.SessionTTL)
+	if status == models.SessionStatusFinished {
+		ttl = now.Add(m.AnswerRetention)
+	}
+
+	session.Status = status
+	session.CurrentQuestionIndex = questionIndex
+	session.LastActivityAt = now.Unix()
+	session.TTL = ttl.Unix()
+
+	stamp := now.UTC().Truncate(time.Second)
+	if status == models.SessionStatusActive && session.StartedAt == nil {
+		session.StartedAt = &stamp
+	} else if status == models.SessionStatusFinished {
+		session.EndedAt = &stamp
+	}
+
+	m.sessions[sessionID] = session
+	return nil
+}
+
+// ListIdleSessions returns sessions in the given status whose last activity is older than cutoff.
+func (m *MemoryStore) ListIdleSessions(ctx context.Context, status models.SessionStatus, cutoff time.Time) ([]models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var sessions []models.Session
+	for _, s := range m.sessions {
+		if s.Status == status && s.LastActivityAt < cutoff.Unix() {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActivityAt < sessions[j].LastActivityAt
+	})
+	return sessions, nil
+}
+
+// HasLiveSession reports whether an unfinished, unexpired session is playing the quiz.
+func (m *MemoryStore) HasLiveSession(ctx context.Context, quizID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now().Unix()
+	for _, s := range m.sessions {
+		if s.QuizID == quizID && s.Status != models.SessionStatusFinished && s.TTL > now {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FinishIdleSession marks a session FINISHED only if it is still unfinished and has had no
+// activity since cutoff. Returns false if the session was finished or touched in the meantime.
+func (m *MemoryStore) FinishIdleSession(ctx context.Context, sessionID string, cutoff time.Time) (bool, error) {
+	observability.Debug(ctx, "finishing idle session", "sessionId", sessionID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok || session.Status == models.SessionStatusFinished || session.LastActivityAt >= cutoff.Unix() {
+		return false, nil
+	}
+
+	now := time.Now()
+	endedAt := now.UTC().Truncate(time.Second)
+	session.Status = models.SessionStatusFinished
+	session.CurrentQuestionIndex = -1
+	session.EndedAt = &endedAt
+	session.TTL = now.Add(m.AnswerRetention).Unix()
+	m.sessions[sessionID] = session
+	return true, nil
+}
+
+// TouchSession records activity on an unfinished session whose last activity is older than
+// before, pushing its expiry and its PIN reservation's forward.
+func (m *MemoryStore) TouchSession(ctx context.Context, sessionID string, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok || session.Status == models.SessionStatusFinished || session.LastActivityAt >= before.Unix() {
+		return nil
+	}
+	now := time.Now()
+	session.LastActivityAt = now.Unix()
+	session.TTL = now.Add(m.SessionTTL).Unix()
+	m.sessions[sessionID] = session
+
+	// Players join by PIN, so its reservation has to live as long as the session
+	if session.PIN == "" {
+		return nil
+	}
+	reservation, ok := m.pins[session.PIN]
+	switch {
+	case ok && reservation.SessionID == sessionID:
+		reservation.TTL = session.TTL
+	case !ok || reservation.TTL < now.Unix():
+		reservation = models.PINReservation{PIN: session.PIN, SessionID: sessionID, ReservedAt: now.UTC(), TTL: session.TTL}
+	default:
+		return nil // the reservation lapsed and another session holds the PIN now
+	}
+	m.pins[session.PIN] = reservation
+	return nil
+}
+
+// --- PINs ---
+
+// AllocatePIN reserves a random, currently unused 6-digit PIN for the given session.
+func (m *MemoryStore) AllocatePIN(ctx context.Context, sessionID string) (string, error) {
+	return allocatePIN(ctx, func(pin string) error {
+		return m.ReservePIN(ctx, pin, sessionID)
+	})
+}
+
+// ReservePIN claims a PIN for a session for at most SessionTTL.
+// Returns ErrPINTaken if the PIN has a live reservation.
+func (m *MemoryStore) ReservePIN(ctx context.Context, pin, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	if existing, ok := m.pins[pin]; ok && existing.TTL >= now.Unix() {
+		return ErrPINTaken
+	}
+	m.pins[pin] = models.PINReservation{
+		PIN:        pin,
+		SessionID:  sessionID,
+		ReservedAt: now,
+		TTL:        now.Add(m.SessionTTL).Unix(),
+	}
+	return nil
+}
+
+// ReleasePIN frees a PIN if its reservation still belongs to the given session.
+func (m *MemoryStore) ReleasePIN(ctx context.Context, pin, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.pins[pin]; ok && existing.SessionID == sessionID {
+		delete(m.pins, pin)
+	}
+	return nil
+}
+
+// --- Connections ---
+
+// PutConnection registers a WebSocket connection.
+func (m *MemoryStore) PutConnection(ctx context.Context, player *models.Player) error {
+	observability.Debug(ctx, "putting connection",
+		"sessionId", player.SessionID,
+		"connectionId", player.ConnectionID,
+		"nickname", player.Nickname,
+	)
+
+	player.TTL = time.Now().Add(24 * time.Hour).Unix()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conns, ok := m.connections[player.SessionID]
+	if !ok {
+		conns = make(map[string]models.Player)
+		m.connections[player.SessionID] = conns
+	}
+	conns[player.ConnectionID] = *player
+	return nil
+}
+
+// DeleteConnection removes a WebSocket connection.
+func (m *MemoryStore) DeleteConnection(ctx context.Context, sessionID, connectionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if conns, ok := m.connections[sessionID]; ok {
+		delete(conns, connectionID)
+		if len(conns) == 0 {
+			delete(m.connections, sessionID)
+		}
+	}
+	return nil
+}
+
+// GetConnectionsBySession returns all connections for a given session, ordered by connection ID.
+func (m *MemoryStore) GetConnectionsBySession(ctx context.Context, sessionID string) ([]models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conns := m.connections[sessionID]
+	players := make([]models.Player, 0, len(conns))
+	for _, p := range conns {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].ConnectionID < players[j].ConnectionID
+	})
+	return players, nil
+}
+
+// GetPlayerCountBySession returns the number of players (non-host) connected to a session.
+func (m *MemoryStore) GetPlayerCountBySession(ctx context.Context, sessionID string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, p := range m.connections[sessionID] {
+		if p.Role == models.PlayerRolePlayer {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetSessionByConnectionID finds the connection record, and so the session, of a connection ID.
+func (m *MemoryStore) GetSessionByConnectionID(ctx context.Context, connectionID string) (*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, conns := range m.connections {
+		if p, ok := conns[connectionID]; ok {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("connection %s not found", connectionID)
+}
+
+// GetConnectionByUserID finds a specific player's connection in a session.
+func (m *MemoryStore) GetConnectionByUserID(ctx context.Context, sessionID, userID string) (*models.Player, error) {
+	players, err := m.GetConnectionsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range players {
+		if p.UserID == userID {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("user %s not found in session %s", userID, sessionID)
+}
+
+// --- Answers ---
+
+// PutAnswer stores a player's answer to a question.
+// Returns ErrAlreadyAnswered if the player has already answered it.
+func (m *MemoryStore) PutAnswer(ctx context.Context, answer *models.Answer) error {
+	observability.Debug(ctx, "putting answer",
+		"sessionId", answer.SessionID,
+		"userId", answer.UserID,
+		"questionId", answer.QuestionID,
+	)
+
+	answer.UserIDQuestionID = answer.UserID + "#" + answer.QuestionID
+	answer.TTL = time.Now().Add(m.SessionTTL + m.AnswerRetention).Unix()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byKey, ok := m.answers[answer.SessionID]
+	if !ok {
+		byKey = make(map[string]models.Answer)
+		m.answers[answer.SessionID] = byKey
+	}
+	if _, exists := byKey[answer.UserIDQuestionID]; exists {
+		return ErrAlreadyAnswered
+	}
+	byKey[answer.UserIDQuestionID] = *answer
+	return nil
+}
+
+// GetAnswersBySession retrieves all answers for a given session, ordered by userId#questionId.
+func (m *MemoryStore) GetAnswersBySession(ctx context.Context, sessionID string) ([]models.Answer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	byKey := m.answers[sessionID]
+	answers := make([]models.Answer, 0, len(byKey))
+	for _, a := range byKey {
+		answers = append(answers, a)
+	}
+	sort.Slice(answers, func(i, j int) bool {
+		return answers[i].UserIDQuestionID < answers[j].UserIDQuestionID
+	})
+	return answers, nil
+}
+
+// EachAnswerBySession calls fn for every answer of a session, ordered by userId#questionId.
+func (m *MemoryStore) EachAnswerBySession(ctx context.Context, sessionID string, fn func(models.Answer) error) error {
+	answers, _ := m.GetAnswersBySession(ctx, sessionID)
+	for _, a := range answers {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAnswersByUser retrieves one player's answers in a session, ordered by question ID.
+func (m *MemoryStore) GetAnswersByUser(ctx context.Context, sessionID, userID string) ([]models.Answer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var answers []models.Answer
+	for _, a := range m.answers[sessionID] {
+		if a.UserID == userID {
+			answers = append(answers, a)
+		}
+	}
+	sort.Slice(answers, func(i, j int) bool {
+		return answers[i].QuestionID < answers[j].QuestionID
+	})
+	return answers, nil
+}
+
+// ListAnsweredSessions returns the IDs of up to limit sessions an authenticated player
+// answered in, most recently answered first, starting at cursor, and the cursor of the next
+// page ("" on the last page).
+func (m *MemoryStore) ListAnsweredSessions(ctx context.Context, userID string, limit int, cursor string) ([]string, string, error) {
+	if models.IsAnonymous(userID) {
+		return nil, "", nil
+	}
+
+	m.mu.RLock()
+	lastAnswered := make(map[string]time.Time)
+	for sessionID, byKey := range m.answers {
+		for _, a := range byKey {
+			if a.UserID != userID {
+				continue
+			}
+			if t, ok := lastAnswered[sessionID]; !ok || a.AnsweredAt.After(t) {
+				lastAnswered[sessionID] = a.AnsweredAt
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	sessionIDs := make([]string, 0, len(lastAnswered))
+	for id := range lastAnswered {
+		sessionIDs = append(sessionIDs, id)
+	}
+	sort.Slice(sessionIDs, func(i, j int) bool {
+		ti, tj := lastAnswered[sessionIDs[i]], lastAnswered[sessionIDs[j]]
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return sessionIDs[i] < sessionIDs[j]
+	})
+	return OffsetPage(sessionIDs, limit, cursor)
+}
+
+// GetAnswer retrieves a specific player's answer to a specific question. Returns nil if none exists.
+func (m *MemoryStore) GetAnswer(ctx context.Context, sessionID, userID, questionID string) (*models.Answer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	answer, ok := m.answers[sessionID][userID+"#"+questionID]
+	if !ok {
+		return nil, nil
+	}
+	return &answer, nil
+}
+
+// --- Results ---
+
+// PutSessionResult stores the final summary and every player's ranking for a finished session.
+// Rankings must be in leaderboard order.
+func (m *MemoryStore) PutSessionResult(ctx context.Context, result *models.SessionResult, rankings []models.PlayerResult) error {
+	observability.Debug(ctx, "putting session result", "sessionId", result.SessionID, "players", len(rankings))
+
+	stored := make([]models.PlayerResult, len(rankings))
+	for i := range rankings {
+		rankings[i].SessionID = result.SessionID
+		rankings[i].RecordKey = models.RankRecordKey(i + 1)
+		if !models.IsAnonymous(rankings[i].UserID) {
+			rankings[i].QuizID = result.QuizID
+			rankings[i].HostUserID = result.HostUserID
+			rankings[i].FinishedAt = result.EndedAt.Unix()
+		}
+		stored[i] = rankings[i]
+	}
+	result.RecordKey = models.ResultRecordSummary
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rankings[result.SessionID] = stored
+	m.results[result.SessionID] = *result
+	return nil
+}
+
+// GetSessionResult retrieves the stored summary of a finished session.
+// Returns nil if no result has been stored.
+func (m *MemoryStore) GetSessionResult(ctx context.Context, sessionID string) (*models.SessionResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result, ok := m.results[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &result, nil
+}
+
+// GetSessionRankings returns the stored final rankings of a session ordered by rank.
+// A limit of 0 or less returns every player.
+func (m *MemoryStore) GetSessionRankings(ctx context.Context, sessionID string, limit int) ([]models.PlayerResult, error) {
+	rankings, _, err := m.GetSessionRankingsPage(ctx, sessionID, limit, "")
+	return rankings, err
+}
+
+// GetSessionRankingsPage returns up to limit stored rankings of a session ordered by rank,
+// starting at cursor, and the cursor of the next page ("" on the last page).
+// A limit of 0 or less returns every remaining player.
+func (m *MemoryStore) GetSessionRankingsPage(ctx context.Context, sessionID string, limit int, cursor string) ([]models.PlayerResult, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rankings, next, err := OffsetPage(m.rankings[sessionID], limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	return append([]models.PlayerResult(nil), rankings...), next, nil
+}
+
+// GetPlayerResult returns one player's final standing in a session, or nil if they have none.
+func (m *MemoryStore) GetPlayerResult(ctx context.Context, sessionID, userID string) (*models.PlayerResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.rankings[sessionID] {
+		if r.UserID == userID {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetFinalLeaderboard returns the top n stored rankings of a finished session
+// in leaderboard display form.
+func (m *MemoryStore) GetFinalLeaderboard(ctx context.Context, sessionID string, n int) ([]models.PlayerScore, error) {
+	rankings, err := m.GetSessionRankings(ctx, sessionID, n)
+	if err != nil {
+		return nil, err
+	}
+	return rankingsToScores(rankings), nil
+}
+
+// ListQuizResults returns every authenticated player's result from sessions of a quiz
+// that finished within [from, to], oldest session first.
+func (m *MemoryStore) ListQuizResults(ctx context.Context, quizID string, from, to time.Time) ([]models.PlayerResult, error) {
+	return m.listPlayerResults(func(r models.PlayerResult) bool { return r.QuizID == quizID }, from, to), nil
+}
+
+// ListHostResults returns every authenticated player's result from sessions run by a host
+// that finished within [from, to], oldest session first.
+func (m *MemoryStore) ListHostResults(ctx context.Context, hostUserID string, from, to time.Time) ([]models.PlayerResult, error) {
+	return m.listPlayerResults(func(r models.PlayerResult) bool { return r.HostUserID == hostUserID }, from, to), nil
+}
+
+// listPlayerResults returns matching indexed results within the window, ordered by finishedAt.
+func (m *MemoryStore) listPlayerResults(match func(models.PlayerResult) bool, from, to time.Time) []models.PlayerResult {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []models.PlayerResult
+	for _, rankings := range m.rankings {
+		for _, r := range rankings {
+			// Only authenticated players carry finishedAt, matching the sparse DynamoDB indexes
+			if r.FinishedAt == 0 || !match(r) {
+				continue
+			}
+			if r.FinishedAt >= from.Unix() && r.FinishedAt <= to.Unix() {
+				results = append(results, r)
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].FinishedAt != results[j].FinishedAt {
+			return results[i].FinishedAt < results[j].FinishedAt
+		}
+		if results[i].SessionID != results[j].SessionID {
+			return results[i].SessionID < results[j].SessionID
+		}
+		return results[i].RecordKey < results[j].RecordKey
+	})
+	return results
+}
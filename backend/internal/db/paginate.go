@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoAPI is the subset of the DynamoDB client used by Client. It lets tests substitute
+// a fake, for example one that returns small pages.
+type DynamoAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+}
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// fetchPage fetches one DynamoDB page starting after startKey, reading at most limit items
+// when limit is non-nil.
+type fetchPage func(ctx context.Context, startKey map[string]types.AttributeValue, limit *int32) (items []map[string]types.AttributeValue, lastKey map[string]types.AttributeValue, err error)
+
+func queryFetcher(api DynamoAPI, input *dynamodb.QueryInput) fetchPage {
+	return func(ctx context.Context, startKey map[string]types.AttributeValue, limit *int32) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+		in := *input
+		in.ExclusiveStartKey = startKey
+		in.Limit = limit
+		out, err := api.Query(ctx, &in)
+		if err != nil {
+			return nil, nil, err
+		}
+		return out.Items, out.LastEvaluatedKey, nil
+	}
+}
+
+func scanFetcher(api DynamoAPI, input *dynamodb.ScanInput) fetchPage {
+	return func(ctx context.Context, startKey map[string]types.AttributeValue, limit *int32) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+		in := *input
+		in.ExclusiveStartKey = startKey
+		in.Limit = limit
+		out, err := api.Scan(ctx, &in)
+		if err != nil {
+			return nil, nil, err
+		}
+		return out.Items, out.LastEvaluatedKey, nil
+	}
+}
+
+// queryAll runs a query to completion, following LastEvaluatedKey across 1 MB pages.
+func queryAll[T any](ctx context.Context, api DynamoAPI, input *dynamodb.QueryInput) ([]T, error) {
+	items, _, err := collect[T](ctx, queryFetcher(api, input), 0, nil)
+	return items, err
+}
+
+// queryEach runs a query to completion like queryAll, but hands each item to fn as its page
+// arrives instead of collecting them, so only one 1 MB page is held at a time.
+func queryEach[T any](ctx context.Context, api DynamoAPI, input *dynamodb.QueryInput, fn func(T) error) error {
+	fetch := queryFetcher(api, input)
+	var startKey map[string]types.AttributeValue
+	for {
+		items, lastKey, err := fetch(ctx, startKey, nil)
+		if err != nil {
+			return err
+		}
+		var page []T
+		if err := attributevalue.UnmarshalListOfMaps(items, &page); err != nil {
+			return err
+		}
+		for _, item := range page {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if len(lastKey) == 0 {
+			return nil
+		}
+		startKey = lastKey
+	}
+}
+
+// scanAll runs a scan to completion, following LastEvaluatedKey across 1 MB pages.
+func scanAll[T any](ctx context.Context, api DynamoAPI, input *dynamodb.ScanInput) ([]T, error) {
+	items, _, err := collect[T](ctx, scanFetcher(api, input), 0, nil)
+	return items, err
+}
+
+// queryPage returns up to limit items of a query starting at cursor, and the cursor of the
+// next page ("" when there are no more items).
+func queryPage[T any](ctx context.Context, api DynamoAPI, input *dynamodb.QueryInput, limit int, cursor string) ([]T, string, error) {
+	return page[T](ctx, queryFetcher(api, input), limit, cursor)
+}
+
+// scanPage returns up to limit items of a scan starting at cursor, and the cursor of the
+// next page ("" when there are no more items).
+func scanPage[T any](ctx context.Context, api DynamoAPI, input *dynamodb.ScanInput, limit int, cursor string) ([]T, string, error) {
+	return page[T](ctx, scanFetcher(api, input), limit, cursor)
+}
+
+func page[T any](ctx context.Context, fetch fetchPage, limit int, cursor string) ([]T, string, error) {
+	startKey, err := decodeKeyCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	items, lastKey, err := collect[T](ctx, fetch, limit, startKey)
+	if err != nil {
+		return nil, "", err
+	}
+	next, err := encodeKeyCursor(lastKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+// collect fetches pages from startKey until limit items are read (0 means no limit) or the
+// results are exhausted. Returns the key to resume from, or nil when nothing is left.
+// Each request asks for only the items still needed, so the resume key never skips items.
+func collect[T any](ctx context.Context, fetch fetchPage, limit int, startKey map[string]types.AttributeValue) ([]T, map[string]types.AttributeValue, error) {
+	var out []T
+	for {
+		var remaining *int32
+		if limit > 0 {
+			remaining = aws.Int32(int32(limit - len(out)))
+		}
+
+		items, lastKey, err := fetch(ctx, startKey, remaining)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var page []T
+		if err := attributevalue.UnmarshalListOfMaps(items, &page); err != nil {
+			return nil, nil, err
+		}
+		out = append(out, page...)
+
+		if len(lastKey) == 0 {
+			return out, nil, nil
+		}
+		if limit > 0 && len(out) >= limit {
+			return out, lastKey, nil
+		}
+		startKey = lastKey
+	}
+}
+
+// cursorValue is the JSON form of one key attribute in a cursor. Only the scalar types
+// used in table and index keys are supported.
+type cursorValue struct {
+	S *string `json:"s,omitempty"`
+	N *string `json:"n,omitempty"`
+}
+
+// encodeKeyCursor turns a LastEvaluatedKey into an opaque, URL-safe cursor.
+func encodeKeyCursor(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+	values := make(map[string]cursorValue, len(key))
+	for name, av := range key {
+		switch v := av.(type) {
+		case *types.AttributeValueMemberS:
+			values[name] = cursorValue{S: aws.String(v.Value)}
+		case *types.AttributeValueMemberN:
+			values[name] = cursorValue{N: aws.String(v.Value)}
+		default:
+			return "", fmt.Errorf("unsupported key attribute type %T in cursor", av)
+		}
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeKeyCursor reverses encodeKeyCursor. An empty cursor means the first page.
+func decodeKeyCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var values map[string]cursorValue
+	if err := json.Unmarshal(b, &values); err != nil || len(values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	key := make(map[string]types.AttributeValue, len(values))
+	for name, v := range values {
+		switch {
+		case v.S != nil:
+			key[name] = &types.AttributeValueMemberS{Value: *v.S}
+		case v.N != nil:
+			key[name] = &types.AttributeValueMemberN{Value: *v.N}
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+	return key, nil
+}
+
+// EncodeOffsetCursor returns an opaque cursor for the item at offset, for backends and
+// listings that page by position rather than by key.
+func EncodeOffsetCursor(offset int) string {
+	if offset <= 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+// OffsetPage returns the window of items selected by an offset cursor and limit, and the
+// cursor of the next window. A limit of 0 or less returns every remaining item.
+func OffsetPage[T any](items []T, limit int, cursor string) ([]T, string, error) {
+	offset, err := DecodeOffsetCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if offset >= len(items) {
+		return nil, "", nil
+	}
+	items = items[offset:]
+	if limit <= 0 || limit >= len(items) {
+		return items, "", nil
+	}
+	return items[:limit], EncodeOffsetCursor(offset + limit), nil
+}
+
+// DecodeOffsetCursor reverses EncodeOffsetCursor. An empty cursor means offset 0.
+func DecodeOffsetCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) < 3 || string(b[:2]) != "o:" {
+		return 0, ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(b[2:]))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
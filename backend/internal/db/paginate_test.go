@@ -0,0 +1,591 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"kahootclone/internal/models"
+)
+
+const fakePageSize = 3
+
+func newFakeClient(pageSize int) (*Client, *fakeDynamo) {
+	fake := newFakeDynamo(pageSize)
+
+	// The key schemas and indexes created by cmd/setup
+	fake.schema("quizzes", "quizId", "")
+	fake.index("quizzes", "hostUserId-index", "hostUserId", "updatedAt")
+	fake.schema("quiz-versions", "quizId", "version")
+	fake.schema("sessions", "sessionId", "")
+	fake.index("sessions", "pin-index", "pin", "")
+	fake.index("sessions", "status-index", "status", "lastActivityAt")
+	fake.index("sessions", "quizId-createdAt-index", "quizId", "createdAt")
+	fake.schema("connections", "sessionId", "connectionId")
+	fake.index("connections", "connectionId-index", "connectionId", "")
+	fake.schema("answers", "sessionId", "userIdQuestionId")
+	fake.keysOnlyIndex("answers", "historyUserId-answeredAt-index", "historyUserId", "answeredAt")
+	fake.schema("pins", "pin", "")
+	fake.schema("results", "sessionId", "recordKey")
+	fake.index("results", "quizId-finishedAt-index", "quizId", "finishedAt")
+	fake.index("results", "hostUserId-finishedAt-index", "hostUserId", "finishedAt")
+	fake.schema("question-bank", "questionId", "")
+	fake.index("question-bank", "hostUserId-index", "hostUserId", "updatedAt")
+
+	return &Client{
+		DDB:               fake,
+		QuizzesTable:      "quizzes",
+		QuizVersionsTable: "quiz-versions",
+		SessionsTable:     "sessions",
+		ConnectionsTable:  "connections",
+		AnswersTable:      "answers",
+		PinsTable:         "pins",
+		ResultsTable:      "results",
+		QuestionBankTable: "question-bank",
+		SessionTTL:        time.Hour,
+		AnswerRetention:   24 * time.Hour,
+	}, fake
+}
+
+type pageItem struct {
+	PK  string `dynamodbav:"pk"`
+	SK  int    `dynamodbav:"sk"`
+	Odd bool   `dynamodbav:"odd"`
+}
+
+func seedPageItems(t *testing.T, fake *fakeDynamo, n int) {
+	for i := 0; i < n; i++ {
+		fake.put(t, "items", pageItem{PK: "p", SK: i, Odd: i%2 == 1})
+	}
+}
+
+func pageQuery() *dynamodb.QueryInput {
+	return &dynamodb.QueryInput{
+		TableName:              aws.String("items"),
+		KeyConditionExpression: aws.String("pk = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "p"},
+		},
+	}
+}
+
+func assertSequence(t *testing.T, items []pageItem, want []int) {
+	t.Helper()
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, item := range items {
+		if item.SK != want[i] {
+			t.Fatalf("item %d: got sk %d, want %d", i, item.SK, want[i])
+		}
+	}
+}
+
+func seq(from, to int) []int {
+	var out []int
+	for i := from; i < to; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func TestQueryAllFollowsLastEvaluatedKey(t *testing.T) {
+	_, fake := newFakeClient(fakePageSize)
+	seedPageItems(t, fake, 10)
+
+	items, err := queryAll[pageItem](context.Background(), fake, pageQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, items, seq(0, 10))
+	if fake.queries != 4 {
+		t.Errorf("got %d queries, want 4", fake.queries)
+	}
+}
+
+func TestScanAllFollowsLastEvaluatedKey(t *testing.T) {
+	_, fake := newFakeClient(fakePageSize)
+	seedPageItems(t, fake, 7)
+
+	items, err := scanAll[pageItem](context.Background(), fake, &dynamodb.ScanInput{TableName: aws.String("items")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, items, seq(0, 7))
+	if fake.scans != 3 {
+		t.Errorf("got %d scans, want 3", fake.scans)
+	}
+}
+
+func TestQueryEachVisitsEveryPage(t *testing.T) {
+	_, fake := newFakeClient(fakePageSize)
+	seedPageItems(t, fake, 8)
+
+	var got []pageItem
+	err := queryEach(context.Background(), fake, pageQuery(), func(item pageItem) error {
+		got = append(got, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, got, seq(0, 8))
+
+	stop := errors.New("stop")
+	err = queryEach(context.Background(), fake, pageQuery(), func(pageItem) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("got %v, want the callback's error", err)
+	}
+}
+
+func TestCollectStopsAtLimit(t *testing.T) {
+	_, fake := newFakeClient(fakePageSize)
+	seedPageItems(t, fake, 10)
+	fetch := queryFetcher(fake, pageQuery())
+
+	items, lastKey, err := collect[pageItem](context.Background(), fetch, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, items, seq(0, 5))
+	if lastKey == nil {
+		t.Fatal("got no resume key with items left")
+	}
+
+	// The second request asks for only the 2 items still needed, so nothing is skipped
+	items, lastKey, err = collect[pageItem](context.Background(), fetch, 5, lastKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, items, seq(5, 10))
+	if lastKey != nil {
+		t.Fatalf("got resume key %v after the last item", lastKey)
+	}
+}
+
+func TestCollectKeepsReadingPastFilteredPages(t *testing.T) {
+	_, fake := newFakeClient(fakePageSize)
+	seedPageItems(t, fake, 12)
+	input := pageQuery()
+	input.FilterExpression = aws.String("odd = :odd")
+	input.ExpressionAttributeValues[":odd"] = &types.AttributeValueMemberBOOL{Value: true}
+
+	items, err := queryAll[pageItem](context.Background(), fake, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, items, []int{1, 3, 5, 7, 9, 11})
+}
+
+func TestQueryPageCursorRoundTrip(t *testing.T) {
+	_, fake := newFakeClient(fakePageSize)
+	seedPageItems(t, fake, 11)
+
+	var all []pageItem
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination did not terminate")
+		}
+		items, next, err := queryPage[pageItem](context.Background(), fake, pageQuery(), 4, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) > 4 {
+			t.Fatalf("page of %d items exceeds limit 4", len(items))
+		}
+		all = append(all, items...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assertSequence(t, all, seq(0, 11))
+}
+
+func TestQueryPageRejectsInvalidCursor(t *testing.T) {
+	_, fake := newFakeClient(fakePageSize)
+	for _, cursor := range []string{"!!!", "bm90LWpzb24", "e30"} {
+		if _, _, err := queryPage[pageItem](context.Background(), fake, pageQuery(), 4, cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q: got %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestKeyCursorRoundTrip(t *testing.T) {
+	key := map[string]types.AttributeValue{
+		"sessionId":  &types.AttributeValueMemberS{Value: "s-1"},
+		"finishedAt": &types.AttributeValueMemberN{Value: "1700000000"},
+	}
+	cursor, err := encodeKeyCursor(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeKeyCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fakeString(got["sessionId"]) != "s-1" || fakeString(got["finishedAt"]) != "1700000000" {
+		t.Errorf("got %v, want %v", got, key)
+	}
+	if _, ok := got["finishedAt"].(*types.AttributeValueMemberN); !ok {
+		t.Errorf("numeric key attribute decoded as %T", got["finishedAt"])
+	}
+
+	if _, err := encodeKeyCursor(map[string]types.AttributeValue{"b": &types.AttributeValueMemberBOOL{}}); err == nil {
+		t.Error("encoded a non-scalar key attribute")
+	}
+}
+
+func TestOffsetPage(t *testing.T) {
+	items := seq(0, 5)
+	var all []int
+	cursor := ""
+	for {
+		page, next, err := OffsetPage(items, 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if fmt.Sprint(all) != fmt.Sprint(items) {
+		t.Errorf("got %v, want %v", all, items)
+	}
+	if _, _, err := OffsetPage(items, 2, "bad"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("got %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestListIdleSessionsIsNotTruncated(t *testing.T) {
+	c, fake := newFakeClient(fakePageSize)
+	for i := 0; i < 10; i++ {
+		fake.put(t, c.SessionsTable, models.Session{
+			SessionID:      fmt.Sprintf("s-%02d", i),
+			Status:         models.SessionStatusLobby,
+			LastActivityAt: int64(100 + i),
+		})
+	}
+	fake.put(t, c.SessionsTable, models.Session{SessionID: "active", Status: models.SessionStatusActive, LastActivityAt: 1})
+
+	sessions, err := c.ListIdleSessions(context.Background(), models.SessionStatusLobby, time.Unix(108, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 8 {
+		t.Fatalf("got %d idle sessions, want 8", len(sessions))
+	}
+	if fake.queries < 2 {
+		t.Errorf("expected several pages, got %d queries", fake.queries)
+	}
+}
+
+func TestHasLiveSessionReadsOnlyTheQuiz(t *testing.T) {
+	ctx := context.Background()
+	c, fake := newFakeClient(fakePageSize)
+	ttl := time.Now().Add(time.Hour).Unix()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Many live sessions of other quizzes and many finished plays of this one, oldest first
+	for i := 0; i < 20; i++ {
+		fake.put(t, c.SessionsTable, models.Session{SessionID: fmt.Sprintf("other-%02d", i), QuizID: "quiz-2", Status: models.SessionStatusActive, CreatedAt: created, TTL: ttl})
+		fake.put(t, c.SessionsTable, models.Session{SessionID: fmt.Sprintf("done-%02d", i), QuizID: "quiz-1", Status: models.SessionStatusFinished, CreatedAt: created, TTL: ttl})
+	}
+	if live, err := c.HasLiveSession(ctx, "quiz-1"); err != nil || live {
+		t.Fatalf("HasLiveSession with only finished sessions = %v, %v; want false, nil", live, err)
+	}
+	// The finished plays of quiz-1 span 7 pages of 3; the other quiz's sessions add none
+	if fake.queries != 7 {
+		t.Errorf("got %d queries, want 7", fake.queries)
+	}
+
+	fake.put(t, c.SessionsTable, models.Session{SessionID: "expired", QuizID: "quiz-1", Status: models.SessionStatusLobby, CreatedAt: created.Add(time.Hour), TTL: 1})
+	fake.put(t, c.SessionsTable, models.Session{SessionID: "live", QuizID: "quiz-1", Status: models.SessionStatusLobby, CreatedAt: created.Add(2 * time.Hour), TTL: ttl})
+	fake.queries = 0
+	if live, err := c.HasLiveSession(ctx, "quiz-1"); err != nil || !live {
+		t.Fatalf("HasLiveSession with a lobby = %v, %v; want true, nil", live, err)
+	}
+	// Newest first, the live session is on the first page
+	if fake.queries != 1 {
+		t.Errorf("got %d queries, want 1", fake.queries)
+	}
+}
+
+func TestGetAnswersBySessionIsNotTruncated(t *testing.T) {
+	c, fake := newFakeClient(fakePageSize)
+	seedAnswers(t, fake, c.AnswersTable, "s-1", 10)
+	seedAnswers(t, fake, c.AnswersTable, "s-2", 4)
+
+	answers, err := c.GetAnswersBySession(context.Background(), "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 10 {
+		t.Fatalf("got %d answers, want 10", len(answers))
+	}
+
+	var visited int
+	err = c.EachAnswerBySession(context.Background(), "s-1", func(a models.Answer) error {
+		if a.SessionID != "s-1" {
+			t.Errorf("visited answer of session %s", a.SessionID)
+		}
+		visited++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited != 10 {
+		t.Errorf("visited %d answers, want 10", visited)
+	}
+}
+
+func seedAnswers(t *testing.T, fake *fakeDynamo, table, sessionID string, n int) {
+	for i := 0; i < n; i++ {
+		userID := fmt.Sprintf("u-%02d", i)
+		fake.put(t, table, models.Answer{
+			SessionID:        sessionID,
+			UserIDQuestionID: userID + "#q-1",
+			UserID:           userID,
+			QuestionID:       "q-1",
+		})
+	}
+}
+
+func TestGetConnectionsBySessionIsNotTruncated(t *testing.T) {
+	c, fake := newFakeClient(fakePageSize)
+	fake.put(t, c.ConnectionsTable, models.Player{SessionID: "s-1", ConnectionID: "c-host", Role: models.PlayerRoleHost})
+	for i := 0; i < 9; i++ {
+		fake.put(t, c.ConnectionsTable, models.Player{
+			SessionID:    "s-1",
+			ConnectionID: fmt.Sprintf("c-%02d", i),
+			Role:         models.PlayerRolePlayer,
+		})
+	}
+
+	players, err := c.GetConnectionsBySession(context.Background(), "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 10 {
+		t.Fatalf("got %d connections, want 10", len(players))
+	}
+	count, err := c.GetPlayerCountBySession(context.Background(), "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 9 {
+		t.Errorf("got %d players, want 9", count)
+	}
+}
+
+func TestGetSessionRankingsPageCursorRoundTrip(t *testing.T) {
+	c, fake := newFakeClient(fakePageSize)
+	fake.put(t, c.ResultsTable, models.SessionResult{SessionID: "s-1", RecordKey: models.ResultRecordSummary})
+	for i := 1; i <= 8; i++ {
+		fake.put(t, c.ResultsTable, models.PlayerResult{
+			SessionID: "s-1",
+			RecordKey: models.RankRecordKey(i),
+			UserID:    fmt.Sprintf("u-%d", i),
+			Rank:      int64(i),
+		})
+	}
+
+	all, err := c.GetSessionRankings(context.Background(), "s-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 8 {
+		t.Fatalf("got %d rankings, want 8", len(all))
+	}
+
+	var paged []models.PlayerResult
+	cursor := ""
+	for {
+		page, next, err := c.GetSessionRankingsPage(context.Background(), "s-1", 3, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paged = append(paged, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(paged) != 8 {
+		t.Fatalf("got %d rankings across pages, want 8", len(paged))
+	}
+	for i, r := range paged {
+		if r.Rank != int64(i+1) {
+			t.Fatalf("position %d: got rank %d, want %d", i, r.Rank, i+1)
+		}
+	}
+
+	top, err := c.GetFinalLeaderboard(context.Background(), "s-1", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 5 || top[4].Rank != 5 {
+		t.Errorf("got top %v, want ranks 1 to 5", top)
+	}
+}
+
+func TestListQuizAndHostResultsAreNotTruncated(t *testing.T) {
+	c, fake := newFakeClient(fakePageSize)
+	for i := 0; i < 10; i++ {
+		fake.put(t, c.ResultsTable, models.PlayerResult{
+			SessionID:  fmt.Sprintf("s-%02d", i),
+			RecordKey:  models.RankRecordKey(1),
+			UserID:     "u-1",
+			QuizID:     "quiz-1",
+			HostUserID: "host-1",
+			FinishedAt: int64(1000 + i),
+		})
+	}
+	// Anonymous players carry no quizId or hostUserId and stay out of both indexes
+	fake.put(t, c.ResultsTable, models.PlayerResult{SessionID: "s-00", RecordKey: models.RankRecordKey(2), UserID: "anon-1"})
+
+	byQuiz, err := c.ListQuizResults(context.Background(), "quiz-1", time.Unix(1002, 0), time.Unix(1009, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byQuiz) != 8 {
+		t.Errorf("got %d quiz results, want 8", len(byQuiz))
+	}
+
+	byHost, err := c.ListHostResults(context.Background(), "host-1", time.Unix(0, 0), time.Unix(2000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byHost) != 10 {
+		t.Errorf("got %d host results, want 10", len(byHost))
+	}
+}
+
+func TestGetPlayerResultReadsOneItem(t *testing.T) {
+	c, fake := newFakeClient(fakePageSize)
+	ctx := context.Background()
+	result := &models.SessionResult{SessionID: "s-1", QuizID: "quiz-1", HostUserID: "host", EndedAt: time.Unix(1000, 0)}
+	var rankings []models.PlayerResult
+	for i := 1; i <= 10; i++ {
+		rankings = append(rankings, models.PlayerResult{UserID: fmt.Sprintf("u-%02d", i), Rank: int64(i), Score: float64(100 - i)})
+	}
+	rankings = append(rankings, models.PlayerResult{UserID: "anon-1", Rank: 11})
+	if err := c.PutSessionResult(ctx, result, rankings); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.queries, fake.gets = 0, 0
+	got, err := c.GetPlayerResult(ctx, "s-1", "u-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.UserID != "u-07" || got.Rank != 7 || got.Score != 93 {
+		t.Errorf("got %+v, want u-07 ranked 7 with 93 points", got)
+	}
+	if fake.gets != 1 || fake.queries != 0 {
+		t.Errorf("got %d gets and %d queries, want a single GetItem", fake.gets, fake.queries)
+	}
+	if got, err := c.GetPlayerResult(ctx, "s-1", "anon-1"); err != nil || got != nil {
+		t.Errorf("GetPlayerResult(anon-1) = %+v, %v; want nil, nil", got, err)
+	}
+
+	// The copies by user stay out of the all-time index and the rankings
+	all, err := c.ListQuizResults(ctx, "quiz-1", time.Unix(0, 0), time.Unix(2000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 10 {
+		t.Errorf("got %d quiz results, want each of the 10 signed-in players once", len(all))
+	}
+	standings, err := c.GetSessionRankings(ctx, "s-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(standings) != 11 {
+		t.Errorf("got %d rankings, want 11", len(standings))
+	}
+}
+
+func TestListQuizzesByHostCursorRoundTrip(t *testing.T) {
+	c, fake := newFakeClient(fakePageSize)
+	for i := 0; i < 10; i++ {
+		title := fmt.Sprintf("Quiz %d", i)
+		var tags []string
+		if i%3 == 0 {
+			tags = []string{"math"}
+		}
+		fake.put(t, c.QuizzesTable, models.Quiz{
+			QuizID:     fmt.Sprintf("quiz-%d", i),
+			HostUserID: "host-1",
+			Title:      title,
+			TitleLower: "quiz " + fmt.Sprint(i),
+			Tags:       tags,
+		})
+	}
+	fake.put(t, c.QuizzesTable, models.Quiz{QuizID: "other", HostUserID: "host-2", Title: "Other", TitleLower: "other"})
+
+	var ids []string
+	cursor := ""
+	for {
+		quizzes, next, err := c.ListQuizzesByHost(context.Background(), "host-1", models.QuizFilter{}, 4, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, q := range quizzes {
+			ids = append(ids, q.QuizID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(ids) != 10 {
+		t.Fatalf("got %d quizzes across pages, want 10: %v", len(ids), ids)
+	}
+	if ids[0] != "quiz-9" {
+		t.Errorf("got %s first, want the most recent quiz-9", ids[0])
+	}
+
+	tagged, _, err := c.ListQuizzesByHost(context.Background(), "host-1", models.QuizFilter{Tag: "Math"}, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagged) != 4 {
+		t.Errorf("got %d quizzes tagged math, want 4", len(tagged))
+	}
+}
+
+func TestTouchSessionExtendsPINReservation(t *testing.T) {
+	ctx := context.Background()
+	c, fake := newFakeClient(10)
+	// Both the session and its PIN reservation are about to expire
+	soon := time.Now().Add(time.Minute).Unix()
+	fake.put(t, "sessions", &models.Session{SessionID: "s-1", PIN: "123456", Status: models.SessionStatusActive, TTL: soon})
+	fake.put(t, "pins", &models.PINReservation{PIN: "123456", SessionID: "s-1", TTL: soon})
+
+	before := time.Now()
+	if err := c.TouchSession(ctx, "s-1", before); err != nil {
+		t.Fatal(err)
+	}
+	want := before.Add(c.SessionTTL).Unix()
+	if session, _ := c.GetSession(ctx, "s-1"); session.TTL < want {
+		t.Errorf("got session ttl %d, want at least %d", session.TTL, want)
+	}
+	reservation, err := c.getPINReservation(ctx, "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reservation == nil || reservation.SessionID != "s-1" || reservation.TTL < want {
+		t.Errorf("got reservation %+v, want s-1's with ttl at least %d", reservation, want)
+	}
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// maxPINAttempts is the number of random PINs tried before giving up.
+const maxPINAttempts = 10
+
+// ErrPINTaken is returned when a PIN is already reserved by another live session.
+var ErrPINTaken = errors.New("pin is already reserved")
+
+// AllocatePIN reserves a random, currently unused 6-digit PIN for the given session.
+// Reservation is a conditional put, so two concurrent creates can never receive the same PIN.
+func (c *Client) AllocatePIN(ctx context.Context, sessionID string) (string, error) {
+	return allocatePIN(ctx, func(pin string) error {
+		return c.ReservePIN(ctx, pin, sessionID)
+	})
+}
+
+// allocatePIN tries random PINs with reserve until one succeeds, retrying on ErrPINTaken.
+// It is shared by every storage backend.
+func allocatePIN(ctx context.Context, reserve func(pin string) error) (string, error) {
+	for attempt := 0; attempt < maxPINAttempts; attempt++ {
+		pin, err := randomPIN()
+		if err != nil {
+			return "", err
+		}
+		err = reserve(pin)
+		if err == nil {
+			return pin, nil
+		}
+		if !errors.Is(err, ErrPINTaken) {
+			return "", err
+		}
+		observability.Debug(ctx, "PIN collision, retrying", "pin", pin, "attempt", attempt+1)
+	}
+	return "", fmt.Errorf("failed to allocate unique PIN after %d attempts", maxPINAttempts)
+}
+
+// holdPIN keeps a live session's PIN reserved. extend pushes the reservation's expiry forward
+// and reports whether the reservation still belonged to the session; if it didn't (it lapsed
+// and was removed or claimed), reserve claims the PIN again unless another session holds it.
+// It is shared by the storage backends.
+func holdPIN(extend func() (bool, error), reserve func() error) error {
+	held, err := extend()
+	if err != nil || held {
+		return err
+	}
+	if err := reserve(); err != nil && !errors.Is(err, ErrPINTaken) {
+		return err
+	}
+	return nil
+}
+
+// ReservePIN claims a PIN for a session for at most SessionTTL. The put succeeds only
+// if the PIN is free or its previous reservation has expired; otherwise ErrPINTaken is returned.
+func (c *Client) ReservePIN(ctx context.Context, pin, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "reserving PIN", "pin", pin, "sessionId", sessionID)
+
+	now := time.Now().UTC()
+	reservation := &models.PINReservation{
+		PIN:        pin,
+		SessionID:  sessionID,
+		ReservedAt: now,
+		TTL:        now.Add(c.SessionTTL).Unix(),
+	}
+
+	item, err := attributevalue.MarshalMap(reservation)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DDB.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.PinsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pin) OR #ttl < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": "ttl",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: int64ToString(now.Unix())},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrPINTaken
+	}
+	return err
+}
+
+// ReleasePIN frees a PIN so it can be reused. It only deletes the reservation if it
+// still belongs to the given session, so a late release never frees a reused PIN.
+func (c *Client) ReleasePIN(ctx context.Context, pin, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "releasing PIN", "pin", pin, "sessionId", sessionID)
+
+	_, err := c.DDB.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.PinsTable),
+		Key: map[string]types.AttributeValue{
+			"pin": &types.AttributeValueMemberS{Value: pin},
+		},
+		ConditionExpression: aws.String("sessionId = :sid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sid": &types.AttributeValueMemberS{Value: sessionID},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+	return err
+}
+
+// getPINReservation returns the current reservation for a PIN, or nil if none exists
+// or it has expired but not yet been removed by DynamoDB TTL.
+func (c *Client) getPINReservation(ctx context.Context, pin string) (*models.PINReservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := c.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(c.PinsTable),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"pin": &types.AttributeValueMemberS{Value: pin},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var reservation models.PINReservation
+	if err := attributevalue.UnmarshalMap(result.Item, &reservation); err != nil {
+		return nil, err
+	}
+	if reservation.TTL < time.Now().Unix() {
+		return nil, nil
+	}
+	return &reservation, nil
+}
+
+// randomPIN returns a uniformly distributed 6-digit PIN using crypto/rand.
+func randomPIN() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate PIN: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
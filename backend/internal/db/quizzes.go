@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,6 +15,10 @@ import (
 	"kahootclone/internal/observability"
 )
 
+// ErrVersionConflict is returned when a quiz was changed or deleted since the version the
+// caller read.
+var ErrVersionConflict = errors.New("quiz was modified concurrently")
+
 // CreateQuiz stores a new quiz in DynamoDB.
 func (c *Client) CreateQuiz(ctx context.Context, quiz *models.Quiz) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -20,6 +26,10 @@ func (c *Client) CreateQuiz(ctx context.Context, quiz *models.Quiz) error {
 
 	observability.Debug(ctx, "creating quiz", "quizId", quiz.QuizID)
 
+	if quiz.Version == 0 {
+		quiz.Version = 1
+	}
+	quiz.TitleLower = strings.ToLower(quiz.Title)
 	item, err := attributevalue.MarshalMap(quiz)
 	if err != nil {
 		return err
@@ -60,28 +70,145 @@ func (c *Client) GetQuiz(ctx context.Context, quizID string) (*models.Quiz, erro
 	return &quiz, nil
 }
 
-// ListQuizzesByHost retrieves all quizzes for a given host.
-// Uses a scan with filter — acceptable for low-volume use; consider GSI for production scale.
-func (c *Client) ListQuizzesByHost(ctx context.Context, hostUserID string) ([]models.Quiz, error) {
+// UpdateQuiz replaces a quiz if its stored version is still expectedVersion, and bumps
+// quiz.Version. Returns ErrVersionConflict if the quiz was changed or deleted in the meantime.
+// Quizzes created before versioning have no version attribute and match expectedVersion 0.
+func (c *Client) UpdateQuiz(ctx context.Context, quiz *models.Quiz, expectedVersion int64) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	observability.Debug(ctx, "listing quizzes by host", "hostUserId", hostUserID)
+	observability.Debug(ctx, "updating quiz", "quizId", quiz.QuizID, "version", expectedVersion)
 
-	result, err := c.DDB.Scan(ctx, &dynamodb.ScanInput{
-		TableName:        aws.String(c.QuizzesTable),
-		FilterExpression: aws.String("hostUserId = :uid"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":uid": &types.AttributeValueMemberS{Value: hostUserID},
+	quiz.Version = expectedVersion + 1
+	quiz.TitleLower = strings.ToLower(quiz.Title)
+	item, err := attributevalue.MarshalMap(quiz)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(c.QuizzesTable),
+		Item:      item,
+	}
+	if expectedVersion == 0 {
+		input.ConditionExpression = aws.String("attribute_exists(quizId) AND attribute_not_exists(version)")
+	} else {
+		input.ConditionExpression = aws.String("version = :version")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: int64ToString(expectedVersion)},
+		}
+	}
+
+	_, err = c.DDB.PutItem(ctx, input)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+// DeleteQuiz deletes a quiz. Deleting a quiz that does not exist is not an error.
+func (c *Client) DeleteQuiz(ctx context.Context, quizID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "deleting quiz", "quizId", quizID)
+
+	_, err := c.DDB.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.QuizzesTable),
+		Key: map[string]types.AttributeValue{
+			"quizId": &types.AttributeValueMemberS{Value: quizID},
+		},
+	})
+	return err
+}
+
+// PutQuizVersion stores an immutable snapshot of a quiz under its current version, for
+// sessions to play and for reports on them. Versions are never overwritten, since every
+// update bumps the version; storing one that already exists is a no-op.
+func (c *Client) PutQuizVersion(ctx context.Context, quiz *models.Quiz) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "putting quiz version", "quizId", quiz.QuizID, "version", quiz.Version)
+
+	item, err := attributevalue.MarshalMap(quiz)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DDB.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.QuizVersionsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(quizId)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+	return err
+}
+
+// GetQuizVersion retrieves a stored quiz snapshot. Returns nil if it does not exist.
+func (c *Client) GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "getting quiz version", "quizId", quizID, "version", version)
+
+	result, err := c.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.QuizVersionsTable),
+		Key: map[string]types.AttributeValue{
+			"quizId":  &types.AttributeValueMemberS{Value: quizID},
+			"version": &types.AttributeValueMemberN{Value: int64ToString(version)},
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
+	if result.Item == nil {
+		return nil, nil
+	}
 
-	var quizzes []models.Quiz
-	if err := attributevalue.UnmarshalListOfMaps(result.Items, &quizzes); err != nil {
+	var quiz models.Quiz
+	if err := attributevalue.UnmarshalMap(result.Item, &quiz); err != nil {
 		return nil, err
 	}
-	return quizzes, nil
+	return &quiz, nil
+}
+
+// ListQuizzesByHost returns up to limit of a host's quizzes matching filter, most recently
+// updated first, starting at cursor, and the cursor of the next page ("" on the last page).
+// A limit of 0 or less returns every remaining quiz. Uses the hostUserId-index GSI.
+func (c *Client) ListQuizzesByHost(ctx context.Context, hostUserID string, filter models.QuizFilter, limit int, cursor string) ([]models.Quiz, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "listing quizzes by host", "hostUserId", hostUserID, "limit", limit)
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.QuizzesTable),
+		IndexName:              aws.String("hostUserId-index"),
+		KeyConditionExpression: aws.String("hostUserId = :uid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: hostUserID},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+
+	filter = filter.Normalize()
+	var conditions []string
+	if filter.Title != "" {
+		conditions = append(conditions, "contains(titleLower, :title)")
+		input.ExpressionAttributeValues[":title"] = &types.AttributeValueMemberS{Value: filter.Title}
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, "contains(tags, :tag)")
+		input.ExpressionAttributeValues[":tag"] = &types.AttributeValueMemberS{Value: filter.Tag}
+	}
+	if len(conditions) > 0 {
+		input.FilterExpression = aws.String(strings.Join(conditions, " AND "))
+	}
+
+	return queryPage[models.Quiz](ctx, c.DDB, input, limit, cursor)
 }
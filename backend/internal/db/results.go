@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// batchWriteLimit is the maximum number of items DynamoDB accepts per BatchWriteItem call.
+const batchWriteLimit = 25
+
+// PutSessionResult stores the final summary and every player's ranking for a finished session.
+// Rankings must be in leaderboard order.
+// Rankings are written before the summary, so a readable summary implies complete rankings.
+// Each signed-in player's ranking is also copied under UserRecordKey for GetPlayerResult; the
+// copy leaves out the index attributes so the all-time indexes hold each result once.
+func (c *Client) PutSessionResult(ctx context.Context, result *models.SessionResult, rankings []models.PlayerResult) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "putting session result", "sessionId", result.SessionID, "players", len(rankings))
+
+	requests := make([]types.WriteRequest, 0, len(rankings))
+	for i := range rankings {
+		rankings[i].SessionID = result.SessionID
+		rankings[i].RecordKey = models.RankRecordKey(i + 1)
+		if !models.IsAnonymous(rankings[i].UserID) {
+			rankings[i].QuizID = result.QuizID
+			rankings[i].HostUserID = result.HostUserID
+			rankings[i].FinishedAt = result.EndedAt.Unix()
+		}
+
+		item, err := attributevalue.MarshalMap(rankings[i])
+		if err != nil {
+			return err
+		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+
+		if !models.IsAnonymous(rankings[i].UserID) {
+			byUser := rankings[i]
+			byUser.RecordKey = models.UserRecordKey(byUser.UserID)
+			byUser.QuizID, byUser.HostUserID, byUser.FinishedAt = "", "", 0
+			item, err := attributevalue.MarshalMap(byUser)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+	}
+	if err := c.batchWrite(ctx, c.ResultsTable, requests); err != nil {
+		return fmt.Errorf("failed to write rankings: %w", err)
+	}
+
+	result.RecordKey = models.ResultRecordSummary
+	item, err := attributevalue.MarshalMap(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DDB.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.ResultsTable),
+		Item:      item,
+	})
+	return err
+}
+
+// GetSessionResult retrieves the persisted summary of a finished session.
+// Returns nil if no result has been stored.
+func (c *Client) GetSessionResult(ctx context.Context, sessionID string) (*models.SessionResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "getting session result", "sessionId", sessionID)
+
+	result, err := c.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(c.ResultsTable),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"sessionId": &types.AttributeValueMemberS{Value: sessionID},
+			"recordKey": &types.AttributeValueMemberS{Value: models.ResultRecordSummary},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var sessionResult models.SessionResult
+	if err := attributevalue.UnmarshalMap(result.Item, &sessionResult); err != nil {
+		return nil, err
+	}
+	return &sessionResult, nil
+}
+
+// GetSessionRankings returns the persisted final rankings of a session ordered by rank.
+// A limit of 0 or less returns every player.
+func (c *Client) GetSessionRankings(ctx context.Context, sessionID string, limit int) ([]models.PlayerResult, error) {
+	rankings, _, err := c.GetSessionRankingsPage(ctx, sessionID, limit, "")
+	return rankings, err
+}
+
+// GetSessionRankingsPage returns up to limit persisted rankings of a session ordered by rank,
+// starting at cursor, and the cursor of the next page ("" on the last page).
+// A limit of 0 or less returns every remaining player.
+func (c *Client) GetSessionRankingsPage(ctx context.Context, sessionID string, limit int, cursor string) ([]models.PlayerResult, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "getting session rankings", "sessionId", sessionID, "limit", limit)
+
+	return queryPage[models.PlayerResult](ctx, c.DDB, &dynamodb.QueryInput{
+		TableName:              aws.String(c.ResultsTable),
+		KeyConditionExpression: aws.String("sessionId = :sid AND begins_with(recordKey, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sid":    &types.AttributeValueMemberS{Value: sessionID},
+			":prefix": &types.AttributeValueMemberS{Value: models.ResultRecordRankPrefix},
+		},
+	}, limit, cursor)
+}
+
+// GetFinalLeaderboard returns the top n persisted rankings of a finished session
+// in leaderboard display form.
+func (c *Client) GetFinalLeaderboard(ctx context.Context, sessionID string, n int) ([]models.PlayerScore, error) {
+	rankings, err := c.GetSessionRankings(ctx, sessionID, n)
+	if err != nil {
+		return nil, err
+	}
+	return rankingsToScores(rankings), nil
+}
+
+// GetPlayerResult returns one signed-in player's final standing in a session, or nil if they
+// have none, from the copy PutSessionResult keeps under UserRecordKey.
+func (c *Client) GetPlayerResult(ctx context.Context, sessionID, userID string) (*models.PlayerResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "getting player result", "sessionId", sessionID, "userId", userID)
+
+	result, err := c.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.ResultsTable),
+		Key: map[string]types.AttributeValue{
+			"sessionId": &types.AttributeValueMemberS{Value: sessionID},
+			"recordKey": &types.AttributeValueMemberS{Value: models.UserRecordKey(userID)},
+		},
+	})
+	if err != nil || result.Item == nil {
+		return nil, err
+	}
+
+	var playerResult models.PlayerResult
+	if err := attributevalue.UnmarshalMap(result.Item, &playerResult); err != nil {
+		return nil, err
+	}
+	return &playerResult, nil
+}
+
+// rankingsToScores converts persisted rankings into leaderboard display form.
+func rankingsToScores(rankings []models.PlayerResult) []models.PlayerScore {
+	leaderboard := make([]models.PlayerScore, len(rankings))
+	for i, r := range rankings {
+		leaderboard[i] = r.PlayerScore()
+	}
+	return leaderboard
+}
+
+// ListQuizResults returns every authenticated player's result from sessions of a quiz
+// that finished within [from, to], oldest session first, using the quizId-finishedAt-index GSI.
+func (c *Client) ListQuizResults(ctx context.Context, quizID string, from, to time.Time) ([]models.PlayerResult, error) {
+	return c.listPlayerResults(ctx, "quizId-finishedAt-index", "quizId", quizID, from, to)
+}
+
+// ListHostResults returns every authenticated player's result from sessions run by a host
+// that finished within [from, to], oldest session first, using the hostUserId-finishedAt-index GSI.
+func (c *Client) ListHostResults(ctx context.Context, hostUserID string, from, to time.Time) ([]models.PlayerResult, error) {
+	return c.listPlayerResults(ctx, "hostUserId-finishedAt-index", "hostUserId", hostUserID, from, to)
+}
+
+// listPlayerResults queries one of the finishedAt-keyed results indexes for a time window.
+func (c *Client) listPlayerResults(ctx context.Context, index, keyAttr, keyValue string, from, to time.Time) ([]models.PlayerResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "listing player results", "index", index, keyAttr, keyValue, "from", from, "to", to)
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.ResultsTable),
+		IndexName:              aws.String(index),
+		KeyConditionExpression: aws.String("#key = :key AND finishedAt BETWEEN :from AND :to"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": keyAttr,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":key":  &types.AttributeValueMemberS{Value: keyValue},
+			":from": &types.AttributeValueMemberN{Value: int64ToString(from.Unix())},
+			":to":   &types.AttributeValueMemberN{Value: int64ToString(to.Unix())},
+		},
+	}
+
+	return queryAll[models.PlayerResult](ctx, c.DDB, input)
+}
+
+// batchWrite writes requests in chunks of batchWriteLimit, retrying unprocessed items.
+func (c *Client) batchWrite(ctx context.Context, table string, requests []types.WriteRequest) error {
+	for start := 0; start < len(requests); start += batchWriteLimit {
+		end := start + batchWriteLimit
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		pending := map[string][]types.WriteRequest{table: requests[start:end]}
+		for attempt := 0; len(pending[table]) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt > 5 {
+					return fmt.Errorf("%d items still unprocessed after retries", len(pending[table]))
+				}
+				time.Sleep(time.Duration(attempt*attempt) * 50 * time.Millisecond)
+			}
+
+			out, err := c.DDB.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+			if err != nil {
+				return err
+			}
+			pending = out.UnprocessedItems
+		}
+	}
+	return nil
+}
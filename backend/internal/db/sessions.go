@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -21,6 +22,10 @@ func (c *Client) CreateSession(ctx context.Context, session *models.Session) err
 
 	observability.Debug(ctx, "creating session", "sessionId", session.SessionID, "pin", session.PIN)
 
+	now := time.Now()
+	session.LastActivityAt = now.Unix()
+	session.TTL = now.Add(c.SessionTTL).Unix()
+
 	item, err := attributevalue.MarshalMap(session)
 	if err != nil {
 		return err
@@ -61,34 +66,27 @@ func (c *Client) GetSession(ctx context.Context, sessionID string) (*models.Sess
 	return &session, nil
 }
 
-// GetSessionByPIN looks up a session by its 6-digit PIN using a GSI.
+// GetSessionByPIN resolves a 6-digit PIN to its live session via the PIN reservation.
+// Returns nil if the PIN is not reserved or the session it points to has finished.
 func (c *Client) GetSessionByPIN(ctx context.Context, pin string) (*models.Session, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
 	observability.Debug(ctx, "getting session by PIN", "pin", pin)
 
-	result, err := c.DDB.Query(ctx, &dynamodb.QueryInput{
-		TableName:              aws.String(c.SessionsTable),
-		IndexName:              aws.String("pin-index"),
-		KeyConditionExpression: aws.String("pin = :pin"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pin": &types.AttributeValueMemberS{Value: pin},
-		},
-		Limit: aws.Int32(1),
-	})
+	reservation, err := c.getPINReservation(ctx, pin)
 	if err != nil {
 		return nil, err
 	}
-	if len(result.Items) == 0 {
+	if reservation == nil {
 		return nil, nil
 	}
 
-	var session models.Session
-	if err := attributevalue.UnmarshalMap(result.Items[0], &session); err != nil {
+	session, err := c.GetSession(ctx, reservation.SessionID)
+	if err != nil {
 		return nil, err
 	}
-	return &session, nil
+	if session == nil || session.Status == models.SessionStatusFinished {
+		return nil, nil
+	}
+	return session, nil
 }
 
 // UpdateSessionStatus atomically updates the status and related fields of a session.
@@ -98,23 +96,32 @@ func (c *Client) UpdateSessionStatus(ctx context.Context, sessionID string, stat
 
 	observability.Debug(ctx, "updating session status", "sessionId", sessionID, "status", status)
 
-	updateExpr := "SET #status = :status, currentQuestionIndex = :idx"
+	// Every state change counts as activity and pushes the expiry forward; finished
+	// sessions are kept for the answer retention period instead.
+	now := time.Now()
+	ttl := now.Add(c.SessionTTL)
+	if status == models.SessionStatusFinished {
+		ttl = now.Add(c.AnswerRetention)
+	}
+
+	updateExpr := "SET #status = :status, currentQuestionIndex = :idx, lastActivityAt = :activity, #ttl = :ttl"
 	exprAttrNames := map[string]string{
 		"#status": "status",
+		"#ttl":    "ttl",
 	}
 	exprAttrValues := map[string]types.AttributeValue{
-		":status": &types.AttributeValueMemberS{Value: string(status)},
-		":idx":    &types.AttributeValueMemberN{Value: intToString(questionIndex)},
+		":status":   &types.AttributeValueMemberS{Value: string(status)},
+		":idx":      &types.AttributeValueMemberN{Value: intToString(questionIndex)},
+		":activity": &types.AttributeValueMemberN{Value: int64ToString(now.Unix())},
+		":ttl":      &types.AttributeValueMemberN{Value: int64ToString(ttl.Unix())},
 	}
 
 	if status == models.SessionStatusActive {
-		now := time.Now().UTC().Format(time.RFC3339)
-		updateExpr += ", startedAt = :startedAt"
-		exprAttrValues[":startedAt"] = &types.AttributeValueMemberS{Value: now}
+		updateExpr += ", startedAt = if_not_exists(startedAt, :startedAt)"
+		exprAttrValues[":startedAt"] = &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)}
 	} else if status == models.SessionStatusFinished {
-		now := time.Now().UTC().Format(time.RFC3339)
 		updateExpr += ", endedAt = :endedAt"
-		exprAttrValues[":endedAt"] = &types.AttributeValueMemberS{Value: now}
+		exprAttrValues[":endedAt"] = &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)}
 	}
 
 	_, err := c.DDB.UpdateItem(ctx, &dynamodb.UpdateItemInput{
@@ -129,6 +136,174 @@ func (c *Client) UpdateSessionStatus(ctx context.Context, sessionID string, stat
 	return err
 }
 
+// ListIdleSessions returns sessions in the given status whose last activity is older than cutoff,
+// using the status-index GSI.
+func (c *Client) ListIdleSessions(ctx context.Context, status models.SessionStatus, cutoff time.Time) ([]models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "listing idle sessions", "status", status, "cutoff", cutoff)
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.SessionsTable),
+		IndexName:              aws.String("status-index"),
+		KeyConditionExpression: aws.String("#status = :status AND lastActivityAt < :cutoff"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(status)},
+			":cutoff": &types.AttributeValueMemberN{Value: int64ToString(cutoff.Unix())},
+		},
+	}
+
+	return queryAll[models.Session](ctx, c.DDB, input)
+}
+
+// HasLiveSession reports whether an unfinished, unexpired session is playing the quiz.
+// Queries the quizId-createdAt-index GSI newest first, so only the quiz's own sessions are
+// read and a live one, being recent, is normally on the first page. The status and TTL
+// checks are filters, so the query sets no Limit: a Limit would count the finished sessions
+// it skips.
+func (c *Client) HasLiveSession(ctx context.Context, quizID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "checking for live sessions", "quizId", quizID)
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.SessionsTable),
+		IndexName:              aws.String("quizId-createdAt-index"),
+		KeyConditionExpression: aws.String("quizId = :qid"),
+		FilterExpression:       aws.String("#status <> :finished AND #ttl > :now"),
+		ScanIndexForward:       aws.Bool(false),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+			"#ttl":    "ttl",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":qid":      &types.AttributeValueMemberS{Value: quizID},
+			":finished": &types.AttributeValueMemberS{Value: string(models.SessionStatusFinished)},
+			":now":      &types.AttributeValueMemberN{Value: int64ToString(time.Now().Unix())},
+		},
+	}
+	err := queryEach(ctx, c.DDB, input, func(models.Session) error {
+		return errStopIteration
+	})
+	if errors.Is(err, errStopIteration) {
+		return true, nil
+	}
+	return false, err
+}
+
+// FinishIdleSession marks a session FINISHED only if it is still unfinished and has had no
+// activity since cutoff. Returns false if the session was finished or touched in the meantime.
+func (c *Client) FinishIdleSession(ctx context.Context, sessionID string, cutoff time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	observability.Debug(ctx, "finishing idle session", "sessionId", sessionID)
+
+	now := time.Now()
+	_, err := c.DDB.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.SessionsTable),
+		Key: map[string]types.AttributeValue{
+			"sessionId": &types.AttributeValueMemberS{Value: sessionID},
+		},
+		UpdateExpression:    aws.String("SET #status = :finished, currentQuestionIndex = :idx, endedAt = :endedAt, #ttl = :ttl"),
+		ConditionExpression: aws.String("#status <> :finished AND lastActivityAt < :cutoff"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+			"#ttl":    "ttl",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":finished": &types.AttributeValueMemberS{Value: string(models.SessionStatusFinished)},
+			":idx":      &types.AttributeValueMemberN{Value: intToString(-1)},
+			":endedAt":  &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)},
+			":ttl":      &types.AttributeValueMemberN{Value: int64ToString(now.Add(c.AnswerRetention).Unix())},
+			":cutoff":   &types.AttributeValueMemberN{Value: int64ToString(cutoff.Unix())},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// TouchSession records activity on an unfinished session whose last activity is older than
+// before, pushing its expiry and its PIN reservation's forward. Sessions touched since then, finished or missing are
+// left alone, so callers can touch on every join or answer without a write each time.
+func (c *Client) TouchSession(ctx context.Context, sessionID string, before time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	ttl := now.Add(c.SessionTTL).Unix()
+	result, err := c.DDB.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.SessionsTable),
+		Key: map[string]types.AttributeValue{
+			"sessionId": &types.AttributeValueMemberS{Value: sessionID},
+		},
+		UpdateExpression:    aws.String("SET lastActivityAt = :activity, #ttl = :ttl"),
+		ConditionExpression: aws.String("attribute_exists(sessionId) AND #status <> :finished AND lastActivityAt < :before"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+			"#ttl":    "ttl",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":finished": &types.AttributeValueMemberS{Value: string(models.SessionStatusFinished)},
+			":activity": &types.AttributeValueMemberN{Value: int64ToString(now.Unix())},
+			":ttl":      &types.AttributeValueMemberN{Value: int64ToString(ttl)},
+			":before":   &types.AttributeValueMemberN{Value: int64ToString(before.Unix())},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Players join by PIN, so its reservation has to live as long as the session
+	pin, _ := result.Attributes["pin"].(*types.AttributeValueMemberS)
+	if pin == nil || pin.Value == "" {
+		return nil
+	}
+	return holdPIN(func() (bool, error) {
+		_, err := c.DDB.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(c.PinsTable),
+			Key: map[string]types.AttributeValue{
+				"pin": &types.AttributeValueMemberS{Value: pin.Value},
+			},
+			UpdateExpression:    aws.String("SET #ttl = :ttl"),
+			ConditionExpression: aws.String("sessionId = :sid"),
+			ExpressionAttributeNames: map[string]string{
+				"#ttl": "ttl",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":sid": &types.AttributeValueMemberS{Value: sessionID},
+				":ttl": &types.AttributeValueMemberN{Value: int64ToString(ttl)},
+			},
+		})
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return err == nil, err
+	}, func() error {
+		return c.ReservePIN(ctx, pin.Value, sessionID)
+	})
+}
+
 func intToString(i int) string {
 	return fmt.Sprintf("%d", i)
 }
+
+func int64ToString(i int64) string {
+	return fmt.Sprintf("%d", i)
+}
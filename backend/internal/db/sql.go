@@ -0,0 +1,450 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+	_ "modernc.org/sqlite"             // registers the "sqlite" driver
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+)
+
+// SQLStore is a Store backed by SQLite (single node) or Postgres (shared deployments),
+// for self-hosting without AWS. Each table keeps the columns used for lookups and ordering
+// alongside the full record as JSON, so model fields can be added without a migration.
+// Expiry fields are stored as in DynamoDB but expired rows are not purged automatically.
+type SQLStore struct {
+	DB      *sql.DB
+	Dialect string // BackendSQLite or BackendPostgres
+
+	SessionTTL      time.Duration
+	AnswerRetention time.Duration
+}
+
+// NewSQLStore opens the database named by cfg.DatabaseURL and applies pending migrations.
+func NewSQLStore(ctx context.Context, cfg *config.Config) (*SQLStore, error) {
+	driver := "sqlite"
+	if cfg.StorageBackend == BackendPostgres {
+		driver = "pgx"
+	}
+
+	sqlDB, err := sql.Open(driver, cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StorageBackend == BackendSQLite {
+		// SQLite allows a single writer; one connection serializes transactions instead of
+		// failing them with SQLITE_BUSY.
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.StorageBackend, err)
+	}
+
+	s := &SQLStore{
+		DB:              sqlDB,
+		Dialect:         cfg.StorageBackend,
+		SessionTTL:      cfg.SessionTTL,
+		AnswerRetention: cfg.AnswerRetention,
+	}
+	if err := s.migrate(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	slog.Info("SQL store initialized", "backend", cfg.StorageBackend)
+	return s, nil
+}
+
+// Close closes the database.
+func (s *SQLStore) Close() error {
+	return s.DB.Close()
+}
+
+// migrations are applied in order; each entry is one schema version and must never change
+// once released. Column types are chosen to mean the same thing in SQLite and Postgres.
+var migrations = [][]string{
+	// 1: initial schema
+	{
+		`CREATE TABLE quizzes (
+			quiz_id      TEXT PRIMARY KEY,
+			host_user_id TEXT NOT NULL,
+			created_at   BIGINT NOT NULL,
+			data         TEXT NOT NULL
+		)`,
+		`CREATE INDEX quizzes_host_user_id_idx ON quizzes (host_user_id, created_at)`,
+
+		`CREATE TABLE sessions (
+			session_id       TEXT PRIMARY KEY,
+			status           TEXT NOT NULL,
+			last_activity_at BIGINT NOT NULL,
+			data             TEXT NOT NULL
+		)`,
+		`CREATE INDEX sessions_status_idx ON sessions (status, last_activity_at)`,
+
+		`CREATE TABLE pins (
+			pin         TEXT PRIMARY KEY,
+			session_id  TEXT NOT NULL,
+			reserved_at BIGINT NOT NULL,
+			expires_at  BIGINT NOT NULL
+		)`,
+
+		`CREATE TABLE connections (
+			session_id    TEXT NOT NULL,
+			connection_id TEXT NOT NULL,
+			user_id       TEXT NOT NULL,
+			role          TEXT NOT NULL,
+			data          TEXT NOT NULL,
+			PRIMARY KEY (session_id, connection_id)
+		)`,
+		`CREATE INDEX connections_connection_id_idx ON connections (connection_id)`,
+
+		`CREATE TABLE answers (
+			session_id  TEXT NOT NULL,
+			user_id     TEXT NOT NULL,
+			question_id TEXT NOT NULL,
+			data        TEXT NOT NULL,
+			PRIMARY KEY (session_id, user_id, question_id)
+		)`,
+
+		`CREATE TABLE session_results (
+			session_id TEXT PRIMARY KEY,
+			data       TEXT NOT NULL
+		)`,
+		`CREATE TABLE player_results (
+			session_id   TEXT NOT NULL,
+			position     INTEGER NOT NULL,
+			quiz_id      TEXT,
+			host_user_id TEXT,
+			finished_at  BIGINT,
+			data         TEXT NOT NULL,
+			PRIMARY KEY (session_id, position)
+		)`,
+		`CREATE INDEX player_results_quiz_idx ON player_results (quiz_id, finished_at)`,
+		`CREATE INDEX player_results_host_idx ON player_results (host_user_id, finished_at)`,
+	},
+	// 2: list a host's quizzes by last update
+	{
+		`ALTER TABLE quizzes ADD COLUMN updated_at BIGINT NOT NULL DEFAULT 0`,
+		`UPDATE quizzes SET updated_at = created_at`,
+		`DROP INDEX quizzes_host_user_id_idx`,
+		`CREATE INDEX quizzes_host_updated_idx ON quizzes (host_user_id, updated_at)`,
+	},
+	// 3: immutable quiz snapshots played by sessions
+	{
+		`CREATE TABLE quiz_versions (
+			quiz_id TEXT NOT NULL,
+			version BIGINT NOT NULL,
+			data    TEXT NOT NULL,
+			PRIMARY KEY (quiz_id, version)
+		)`,
+	},
+	// 4: authenticated players' answers by user, for learner history
+	{
+		`ALTER TABLE answers ADD COLUMN history_user_id TEXT`,
+		`ALTER TABLE answers ADD COLUMN answered_at BIGINT NOT NULL DEFAULT 0`,
+		`CREATE INDEX answers_history_user_idx ON answers (history_user_id, answered_at)`,
+	},
+	// 5: question bank
+	{
+		`CREATE TABLE bank_questions (
+			question_id  TEXT PRIMARY KEY,
+			host_user_id TEXT NOT NULL,
+			updated_at   BIGINT NOT NULL,
+			data         TEXT NOT NULL
+		)`,
+		`CREATE INDEX bank_questions_host_updated_idx ON bank_questions (host_user_id, updated_at)`,
+	},
+	// 6: live sessions by quiz; existing rows are filled in by backfillSessionQuizIDs
+	{
+		`ALTER TABLE sessions ADD COLUMN quiz_id TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX sessions_quiz_status_idx ON sessions (quiz_id, status)`,
+	},
+	// 7: a player's standing by user; existing rows are filled in by backfillPlayerResultUserIDs
+	{
+		`ALTER TABLE player_results ADD COLUMN user_id TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX player_results_session_user_idx ON player_results (session_id, user_id)`,
+	},
+}
+
+// backfills fill in columns that a migration adds but cannot compute in SQL portable between
+// SQLite and Postgres. Each runs in its version's transaction after the statements.
+var backfills = map[int]func(ctx context.Context, tx *sql.Tx, s *SQLStore) error{
+	6: backfillSessionQuizIDs,
+	7: backfillPlayerResultUserIDs,
+}
+
+// backfillSessionQuizIDs copies each session's quizId from its JSON into the quiz_id column.
+func backfillSessionQuizIDs(ctx context.Context, tx *sql.Tx, s *SQLStore) error {
+	rows, err := tx.QueryContext(ctx, `SELECT data FROM sessions`)
+	if err != nil {
+		return err
+	}
+	var sessions []models.Session
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return err
+		}
+		var session models.Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			rows.Close()
+			return err
+		}
+		sessions = append(sessions, session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Rows are read in full first: Postgres can't run a statement while a result set is open
+	for _, session := range sessions {
+		if _, err := tx.ExecContext(ctx, s.rebind(`UPDATE sessions SET quiz_id = ? WHERE session_id = ?`),
+			session.QuizID, session.SessionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillPlayerResultUserIDs copies each standing's userId from its JSON into the user_id
+// column.
+func backfillPlayerResultUserIDs(ctx context.Context, tx *sql.Tx, s *SQLStore) error {
+	rows, err := tx.QueryContext(ctx, `SELECT session_id, position, data FROM player_results`)
+	if err != nil {
+		return err
+	}
+	type standing struct {
+		sessionID string
+		position  int
+		userID    string
+	}
+	var standings []standing
+	for rows.Next() {
+		var st standing
+		var data string
+		if err := rows.Scan(&st.sessionID, &st.position, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		var r models.PlayerResult
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			rows.Close()
+			return err
+		}
+		st.userID = r.UserID
+		standings = append(standings, st)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, st := range standings {
+		if _, err := tx.ExecContext(ctx, s.rebind(`UPDATE player_results SET user_id = ? WHERE session_id = ? AND position = ?`),
+			st.userID, st.sessionID, st.position); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate applies every migration newer than the recorded schema version, each in its own
+// transaction.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	if _, err := s.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := s.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		err := s.withTx(ctx, func(tx *sql.Tx) error {
+			for _, stmt := range migrations[i] {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			if backfill, ok := backfills[version]; ok {
+				if err := backfill(ctx, tx, s); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`),
+				version, time.Now().Unix())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		slog.Info("applied database migration", "version", version)
+	}
+	return nil
+}
+
+// withTx runs fn in a transaction, committing if it returns nil and rolling back otherwise.
+func (s *SQLStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebind rewrites ? placeholders to the $n form Postgres expects.
+func (s *SQLStore) rebind(query string) string {
+	if s.Dialect != BackendPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// forUpdate returns the row-locking clause for read-modify-write transactions. SQLite needs
+// none because its single connection already serializes transactions.
+func (s *SQLStore) forUpdate() string {
+	if s.Dialect == BackendPostgres {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// queryJSON runs a query whose single column is a JSON record and decodes every row into T.
+func queryJSON[T any](ctx context.Context, s *SQLStore, query string, args ...any) ([]T, error) {
+	var out []T
+	err := eachJSON(ctx, s, func(v T) error {
+		out = append(out, v)
+		return nil
+	}, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// errStopIteration ends an eachJSON loop early without an error.
+var errStopIteration = errors.New("stop iteration")
+
+// eachJSON runs a query selecting a data column and calls fn with each row decoded, reading
+// rows as fn consumes them. It stops at fn's first error.
+func eachJSON[T any](ctx context.Context, s *SQLStore, fn func(T) error, query string, args ...any) error {
+	rows, err := s.DB.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		var v T
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// queryJSONPage runs an ordered query for the window selected by an offset cursor and limit.
+// It returns the rows, the window's offset, and the cursor of the next window ("" on the last
+// one). One extra row is read to tell whether another window exists.
+func queryJSONPage[T any](ctx context.Context, s *SQLStore, query string, limit int, cursor string, args ...any) ([]T, int, string, error) {
+	offset, err := DecodeOffsetCursor(cursor)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit+1, offset)
+	} else if offset > 0 {
+		// SQLite only accepts OFFSET after a LIMIT; -1 means no limit.
+		if s.Dialect == BackendSQLite {
+			query += ` LIMIT -1`
+		}
+		query += ` OFFSET ?`
+		args = append(args, offset)
+	}
+
+	rows, err := queryJSON[T](ctx, s, query, args...)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if limit > 0 && len(rows) > limit {
+		return rows[:limit], offset, EncodeOffsetCursor(offset + limit), nil
+	}
+	return rows, offset, "", nil
+}
+
+// queryRower and execer are satisfied by both *sql.DB and *sql.Tx.
+type (
+	queryRower interface {
+		QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	}
+	execer interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	}
+)
+
+// getJSON runs a single-row query for a JSON record on q, which may be the database or a
+// transaction. Returns nil if no row matches.
+func getJSON[T any](ctx context.Context, s *SQLStore, q queryRower, query string, args ...any) (*T, error) {
+	var data string
+	err := q.QueryRowContext(ctx, s.rebind(query), args...).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var v T
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// toJSON encodes a record for a data column.
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
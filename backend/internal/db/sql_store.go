@@ -0,0 +1,777 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// --- Quizzes ---
+
+// CreateQuiz stores a quiz, replacing any quiz with the same ID.
+func (s *SQLStore) CreateQuiz(ctx context.Context, quiz *models.Quiz) error {
+	observability.Debug(ctx, "creating quiz", "quizId", quiz.QuizID)
+
+	if quiz.Version == 0 {
+		quiz.Version = 1
+	}
+	return putQuiz(ctx, s, s.DB, quiz)
+}
+
+// UpdateQuiz replaces a quiz if its stored version is still expectedVersion, and bumps
+// quiz.Version. Returns ErrVersionConflict if the quiz was changed or deleted in the meantime.
+func (s *SQLStore) UpdateQuiz(ctx context.Context, quiz *models.Quiz, expectedVersion int64) error {
+	observability.Debug(ctx, "updating quiz", "quizId", quiz.QuizID, "version", expectedVersion)
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := getJSON[models.Quiz](ctx, s, tx,
+			`SELECT data FROM quizzes WHERE quiz_id = ?`+s.forUpdate(), quiz.QuizID)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+		quiz.Version = expectedVersion + 1
+		return putQuiz(ctx, s, tx, quiz)
+	})
+}
+
+// DeleteQuiz deletes a quiz. Deleting a quiz that does not exist is not an error.
+func (s *SQLStore) DeleteQuiz(ctx context.Context, quizID string) error {
+	observability.Debug(ctx, "deleting quiz", "quizId", quizID)
+
+	_, err := s.DB.ExecContext(ctx, s.rebind(`DELETE FROM quizzes WHERE quiz_id = ?`), quizID)
+	return err
+}
+
+// PutQuizVersion stores an immutable snapshot of a quiz under its current version.
+// Storing a version that already exists is a no-op.
+func (s *SQLStore) PutQuizVersion(ctx context.Context, quiz *models.Quiz) error {
+	observability.Debug(ctx, "putting quiz version", "quizId", quiz.QuizID, "version", quiz.Version)
+
+	data, err := toJSON(quiz)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, s.rebind(`
+		INSERT INTO quiz_versions (quiz_id, version, data) VALUES (?, ?, ?)
+		ON CONFLICT (quiz_id, version) DO NOTHING`),
+		quiz.QuizID, quiz.Version, data)
+	return err
+}
+
+// GetQuizVersion retrieves a stored quiz snapshot. Returns nil if it does not exist.
+func (s *SQLStore) GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error) {
+	observability.Debug(ctx, "getting quiz version", "quizId", quizID, "version", version)
+	return getJSON[models.Quiz](ctx, s, s.DB,
+		`SELECT data FROM quiz_versions WHERE quiz_id = ? AND version = ?`, quizID, version)
+}
+
+// putQuiz upserts a quiz row using db, which may be the store or a transaction.
+func putQuiz(ctx context.Context, s *SQLStore, db execer, quiz *models.Quiz) error {
+	data, err := toJSON(quiz)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, s.rebind(`
+		INSERT INTO quizzes (quiz_id, host_user_id, created_at, updated_at, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (quiz_id) DO UPDATE SET
+			host_user_id = excluded.host_user_id, created_at = excluded.created_at,
+			updated_at = excluded.updated_at, data = excluded.data`),
+		quiz.QuizID, quiz.HostUserID, quiz.CreatedAt.UnixNano(), quiz.UpdatedAt.UnixNano(), data)
+	return err
+}
+
+// GetQuiz retrieves a quiz by its ID. Returns nil if it does not exist.
+func (s *SQLStore) GetQuiz(ctx context.Context, quizID string) (*models.Quiz, error) {
+	observability.Debug(ctx, "getting quiz", "quizId", quizID)
+	return getJSON[models.Quiz](ctx, s, s.DB, `SELECT data FROM quizzes WHERE quiz_id = ?`, quizID)
+}
+
+// ListQuizzesByHost returns up to limit of a host's quizzes matching filter, most recently
+// updated first, starting at cursor, and the cursor of the next page ("" on the last page).
+// A limit of 0 or less returns every remaining quiz.
+func (s *SQLStore) ListQuizzesByHost(ctx context.Context, hostUserID string, filter models.QuizFilter, limit int, cursor string) ([]models.Quiz, string, error) {
+	observability.Debug(ctx, "listing quizzes by host", "hostUserId", hostUserID, "limit", limit)
+
+	const query = `SELECT data FROM quizzes WHERE host_user_id = ? ORDER BY updated_at DESC, quiz_id`
+	filter = filter.Normalize()
+	if filter == (models.QuizFilter{}) {
+		quizzes, _, next, err := queryJSONPage[models.Quiz](ctx, s, query, limit, cursor, hostUserID)
+		return quizzes, next, err
+	}
+
+	// Titles and tags live in the JSON data, so filtered listings are matched here; a host's
+	// quizzes are few enough to read in full.
+	all, err := queryJSON[models.Quiz](ctx, s, query, hostUserID)
+	if err != nil {
+		return nil, "", err
+	}
+	var quizzes []models.Quiz
+	for i := range all {
+		if filter.Matches(&all[i]) {
+			quizzes = append(quizzes, all[i])
+		}
+	}
+	return OffsetPage(quizzes, limit, cursor)
+}
+
+// --- Question bank ---
+
+// CreateBankQuestion stores a new question in the question bank.
+func (s *SQLStore) CreateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	observability.Debug(ctx, "creating bank question", "questionId", question.QuestionID)
+
+	data, err := toJSON(question)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, s.rebind(`
+		INSERT INTO bank_questions (question_id, host_user_id, updated_at, data) VALUES (?, ?, ?, ?)`),
+		question.QuestionID, question.HostUserID, question.UpdatedAt.UnixNano(), data)
+	return err
+}
+
+// GetBankQuestion retrieves a bank question by its ID. Returns nil if it does not exist.
+func (s *SQLStore) GetBankQuestion(ctx context.Context, questionID string) (*models.BankQuestion, error) {
+	observability.Debug(ctx, "getting bank question", "questionId", questionID)
+	return getJSON[models.BankQuestion](ctx, s, s.DB,
+		`SELECT data FROM bank_questions WHERE question_id = ?`, questionID)
+}
+
+// GetBankQuestions retrieves the bank questions with the given IDs, in no particular order.
+// IDs that don't exist are skipped.
+func (s *SQLStore) GetBankQuestions(ctx context.Context, questionIDs []string) ([]models.BankQuestion, error) {
+	observability.Debug(ctx, "getting bank questions", "count", len(questionIDs))
+
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(questionIDs)), ", ")
+	args := make([]any, len(questionIDs))
+	for i, id := range questionIDs {
+		args[i] = id
+	}
+	return queryJSON[models.BankQuestion](ctx, s,
+		`SELECT data FROM bank_questions WHERE question_id IN (`+placeholders+`)`, args...)
+}
+
+// UpdateBankQuestion replaces a bank question. Returns ErrBankQuestionNotFound if it was
+// deleted in the meantime.
+func (s *SQLStore) UpdateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	observability.Debug(ctx, "updating bank question", "questionId", question.QuestionID)
+
+	data, err := toJSON(question)
+	if err != nil {
+		return err
+	}
+	res, err := s.DB.ExecContext(ctx, s.rebind(`
+		UPDATE bank_questions SET host_user_id = ?, updated_at = ?, data = ? WHERE question_id = ?`),
+		question.HostUserID, question.UpdatedAt.UnixNano(), data, question.QuestionID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrBankQuestionNotFound
+	}
+	return nil
+}
+
+// DeleteBankQuestion deletes a bank question. Deleting a question that does not exist is not
+// an error.
+func (s *SQLStore) DeleteBankQuestion(ctx context.Context, questionID string) error {
+	observability.Debug(ctx, "deleting bank question", "questionId", questionID)
+
+	_, err := s.DB.ExecContext(ctx, s.rebind(`DELETE FROM bank_questions WHERE question_id = ?`), questionID)
+	return err
+}
+
+// ListBankQuestions returns up to limit of a host's bank questions matching filter, most
+// recently updated first, starting at cursor, and the cursor of the next page ("" on the
+// last page). A limit of 0 or less returns every remaining question.
+func (s *SQLStore) ListBankQuestions(ctx context.Context, hostUserID string, filter models.BankFilter, limit int, cursor string) ([]models.BankQuestion, string, error) {
+	observability.Debug(ctx, "listing bank questions", "hostUserId", hostUserID, "limit", limit)
+
+	const query = `SELECT data FROM bank_questions WHERE host_user_id = ? ORDER BY updated_at DESC, question_id`
+	filter = filter.Normalize()
+	if filter == (models.BankFilter{}) {
+		questions, _, next, err := queryJSONPage[models.BankQuestion](ctx, s, query, limit, cursor, hostUserID)
+		return questions, next, err
+	}
+
+	// Text and tags live in the JSON data, so filtered listings are matched here as for
+	// quizzes.
+	var questions []models.BankQuestion
+	err := eachJSON(ctx, s, func(q models.BankQuestion) error {
+		if filter.Matches(&q) {
+			questions = append(questions, q)
+		}
+		return nil
+	}, query, hostUserID)
+	if err != nil {
+		return nil, "", err
+	}
+	return OffsetPage(questions, limit, cursor)
+}
+
+// --- Sessions ---
+
+// CreateSession stores a new session.
+func (s *SQLStore) CreateSession(ctx context.Context, session *models.Session) error {
+	observability.Debug(ctx, "creating session", "sessionId", session.SessionID, "pin", session.PIN)
+
+	now := time.Now()
+	session.LastActivityAt = now.Unix()
+	session.TTL = now.Add(s.SessionTTL).Unix()
+	return putSession(ctx, s, s.DB, session)
+}
+
+// GetSession retrieves a session by its ID. Returns nil if it does not exist.
+func (s *SQLStore) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	observability.Debug(ctx, "getting session", "sessionId", sessionID)
+	return getJSON[models.Session](ctx, s, s.DB, `SELECT data FROM sessions WHERE session_id = ?`, sessionID)
+}
+
+// GetSessionByPIN resolves a PIN to its live session via the PIN reservation.
+// Returns nil if the PIN is not reserved or the session it points to has finished.
+func (s *SQLStore) GetSessionByPIN(ctx context.Context, pin string) (*models.Session, error) {
+	observability.Debug(ctx, "getting session by PIN", "pin", pin)
+
+	session, err := getJSON[models.Session](ctx, s, s.DB, `
+		SELECT s.data FROM pins p JOIN sessions s ON s.session_id = p.session_id
+		WHERE p.pin = ? AND p.expires_at >= ?`, pin, time.Now().Unix())
+	if err != nil || session == nil {
+		return nil, err
+	}
+	if session.Status == models.SessionStatusFinished {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// UpdateSessionStatus updates the status and related fields of a session.
+func (s *SQLStore) UpdateSessionStatus(ctx context.Context, sessionID string, status models.SessionStatus, questionIndex int) error {
+	observability.Debug(ctx, "updating session status", "sessionId", sessionID, "status", status)
+
+	_, err := s.updateSession(ctx, sessionID, func(session *models.Session, exists bool) bool {
+		// Every state change counts as activity and pushes the expiry forward; finished
+		// sessions are kept for the answer retention period instead.
+		now := time.Now()
+		ttl := now.Add(s.SessionTTL)
+		if status == models.SessionStatusFinished {
+			ttl = now.Add(s.AnswerRetention)
+		}
+
+		session.Status = status
+		session.CurrentQuestionIndex = questionIndex
+		session.LastActivityAt = now.Unix()
+		session.TTL = ttl.Unix()
+
+		stamp := now.UTC().Truncate(time.Second)
+		if status == models.SessionStatusActive && session.StartedAt == nil {
+			session.StartedAt = &stamp
+		} else if status == models.SessionStatusFinished {
+			session.EndedAt = &stamp
+		}
+		return true
+	})
+	return err
+}
+
+// ListIdleSessions returns sessions in the given status whose last activity is older than cutoff.
+func (s *SQLStore) ListIdleSessions(ctx context.Context, status models.SessionStatus, cutoff time.Time) ([]models.Session, error) {
+	observability.Debug(ctx, "listing idle sessions", "status", status, "cutoff", cutoff)
+	return queryJSON[models.Session](ctx, s, `
+		SELECT data FROM sessions WHERE status = ? AND last_activity_at < ?
+		ORDER BY last_activity_at`, string(status), cutoff.Unix())
+}
+
+// HasLiveSession reports whether an unfinished, unexpired session is playing the quiz.
+func (s *SQLStore) HasLiveSession(ctx context.Context, quizID string) (bool, error) {
+	observability.Debug(ctx, "checking for live sessions", "quizId", quizID)
+
+	sessions, err := queryJSON[models.Session](ctx, s, `SELECT data FROM sessions WHERE quiz_id = ? AND status IN (?, ?)`,
+		quizID, string(models.SessionStatusLobby), string(models.SessionStatusActive))
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().Unix()
+	for _, session := range sessions {
+		if session.TTL > now {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FinishIdleSession marks a session FINISHED only if it is still unfinished and has had no
+// activity since cutoff. Returns false if the session was finished or touched in the meantime.
+func (s *SQLStore) FinishIdleSession(ctx context.Context, sessionID string, cutoff time.Time) (bool, error) {
+	observability.Debug(ctx, "finishing idle session", "sessionId", sessionID)
+
+	return s.updateSession(ctx, sessionID, func(session *models.Session, exists bool) bool {
+		if !exists || session.Status == models.SessionStatusFinished || session.LastActivityAt >= cutoff.Unix() {
+			return false
+		}
+		now := time.Now()
+		endedAt := now.UTC().Truncate(time.Second)
+		session.Status = models.SessionStatusFinished
+		session.CurrentQuestionIndex = -1
+		session.EndedAt = &endedAt
+		session.TTL = now.Add(s.AnswerRetention).Unix()
+		return true
+	})
+}
+
+// TouchSession records activity on an unfinished session whose last activity is older than
+// before, pushing its expiry and its PIN reservation's forward.
+func (s *SQLStore) TouchSession(ctx context.Context, sessionID string, before time.Time) error {
+	now := time.Now()
+	var pin string
+	touched, err := s.updateSession(ctx, sessionID, func(session *models.Session, exists bool) bool {
+		if !exists || session.Status == models.SessionStatusFinished || session.LastActivityAt >= before.Unix() {
+			return false
+		}
+		session.LastActivityAt = now.Unix()
+		session.TTL = now.Add(s.SessionTTL).Unix()
+		pin = session.PIN
+		return true
+	})
+	if err != nil || !touched || pin == "" {
+		return err
+	}
+
+	// Players join by PIN, so its reservation has to live as long as the session
+	return holdPIN(func() (bool, error) {
+		result, err := s.DB.ExecContext(ctx, s.rebind(`UPDATE pins SET expires_at = ? WHERE pin = ? AND session_id = ?`),
+			now.Add(s.SessionTTL).Unix(), pin, sessionID)
+		if err != nil {
+			return false, err
+		}
+		n, err := result.RowsAffected()
+		return n > 0, err
+	}, func() error {
+		return s.ReservePIN(ctx, pin, sessionID)
+	})
+}
+
+// updateSession applies fn to a session inside a transaction with the row locked, and writes
+// the result if fn returns true. Like a DynamoDB update, a missing session is passed to fn as
+// a zero Session with exists false, and created. Returns whether the session was written.
+func (s *SQLStore) updateSession(ctx context.Context, sessionID string, fn func(session *models.Session, exists bool) bool) (bool, error) {
+	written := false
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		session, err := getJSON[models.Session](ctx, s, tx,
+			`SELECT data FROM sessions WHERE session_id = ?`+s.forUpdate(), sessionID)
+		if err != nil {
+			return err
+		}
+		exists := session != nil
+		if !exists {
+			session = &models.Session{SessionID: sessionID}
+		}
+		if !fn(session, exists) {
+			return nil
+		}
+		written = true
+		return putSession(ctx, s, tx, session)
+	})
+	return written, err
+}
+
+// putSession upserts a session row using db, which may be the store or a transaction.
+func putSession(ctx context.Context, s *SQLStore, db execer, session *models.Session) error {
+	data, err := toJSON(session)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, s.rebind(`
+		INSERT INTO sessions (session_id, quiz_id, status, last_activity_at, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (session_id) DO UPDATE SET
+			status = excluded.status, last_activity_at = excluded.last_activity_at, data = excluded.data`),
+		session.SessionID, session.QuizID, string(session.Status), session.LastActivityAt, data)
+	return err
+}
+
+// --- PINs ---
+
+// AllocatePIN reserves a random, currently unused 6-digit PIN for the given session.
+func (s *SQLStore) AllocatePIN(ctx context.Context, sessionID string) (string, error) {
+	return allocatePIN(ctx, func(pin string) error {
+		return s.ReservePIN(ctx, pin, sessionID)
+	})
+}
+
+// ReservePIN claims a PIN for a session for at most SessionTTL. A single upsert that only
+// overwrites expired reservations makes the claim atomic; otherwise ErrPINTaken is returned.
+func (s *SQLStore) ReservePIN(ctx context.Context, pin, sessionID string) error {
+	observability.Debug(ctx, "reserving PIN", "pin", pin, "sessionId", sessionID)
+
+	now := time.Now()
+	result, err := s.DB.ExecContext(ctx, s.rebind(`
+		INSERT INTO pins (pin, session_id, reserved_at, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (pin) DO UPDATE SET
+			session_id = excluded.session_id, reserved_at = excluded.reserved_at, expires_at = excluded.expires_at
+		WHERE pins.expires_at < ?`),
+		pin, sessionID, now.Unix(), now.Add(s.SessionTTL).Unix(), now.Unix())
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPINTaken
+	}
+	return nil
+}
+
+// ReleasePIN frees a PIN if its reservation still belongs to the given session.
+func (s *SQLStore) ReleasePIN(ctx context.Context, pin, sessionID string) error {
+	observability.Debug(ctx, "releasing PIN", "pin", pin, "sessionId", sessionID)
+
+	_, err := s.DB.ExecContext(ctx, s.rebind(`DELETE FROM pins WHERE pin = ? AND session_id = ?`), pin, sessionID)
+	return err
+}
+
+// --- Connections ---
+
+// PutConnection registers a WebSocket connection.
+func (s *SQLStore) PutConnection(ctx context.Context, player *models.Player) error {
+	observability.Debug(ctx, "putting connection",
+		"sessionId", player.SessionID,
+		"connectionId", player.ConnectionID,
+		"nickname", player.Nickname,
+	)
+
+	player.TTL = time.Now().Add(24 * time.Hour).Unix()
+	data, err := toJSON(player)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, s.rebind(`
+		INSERT INTO connections (session_id, connection_id, user_id, role, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (session_id, connection_id) DO UPDATE SET
+			user_id = excluded.user_id, role = excluded.role, data = excluded.data`),
+		player.SessionID, player.ConnectionID, player.UserID, string(player.Role), data)
+	return err
+}
+
+// DeleteConnection removes a WebSocket connection.
+func (s *SQLStore) DeleteConnection(ctx context.Context, sessionID, connectionID string) error {
+	observability.Debug(ctx, "deleting connection", "sessionId", sessionID, "connectionId", connectionID)
+
+	_, err := s.DB.ExecContext(ctx, s.rebind(`DELETE FROM connections WHERE session_id = ? AND connection_id = ?`),
+		sessionID, connectionID)
+	return err
+}
+
+// GetConnectionsBySession returns all connections for a given session, ordered by connection ID.
+func (s *SQLStore) GetConnectionsBySession(ctx context.Context, sessionID string) ([]models.Player, error) {
+	observability.Debug(ctx, "getting connections by session", "sessionId", sessionID)
+	return queryJSON[models.Player](ctx, s,
+		`SELECT data FROM connections WHERE session_id = ? ORDER BY connection_id`, sessionID)
+}
+
+// GetPlayerCountBySession returns the number of players (non-host) connected to a session.
+func (s *SQLStore) GetPlayerCountBySession(ctx context.Context, sessionID string) (int, error) {
+	var count int
+	err := s.DB.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM connections WHERE session_id = ? AND role = ?`),
+		sessionID, string(models.PlayerRolePlayer)).Scan(&count)
+	return count, err
+}
+
+// GetSessionByConnectionID finds the connection record, and so the session, of a connection ID.
+func (s *SQLStore) GetSessionByConnectionID(ctx context.Context, connectionID string) (*models.Player, error) {
+	observability.Debug(ctx, "getting session by connectionId", "connectionId", connectionID)
+
+	player, err := getJSON[models.Player](ctx, s, s.DB,
+		`SELECT data FROM connections WHERE connection_id = ? LIMIT 1`, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if player == nil {
+		return nil, fmt.Errorf("connection %s not found", connectionID)
+	}
+	return player, nil
+}
+
+// GetConnectionByUserID finds a specific player's connection in a session.
+func (s *SQLStore) GetConnectionByUserID(ctx context.Context, sessionID, userID string) (*models.Player, error) {
+	player, err := getJSON[models.Player](ctx, s, s.DB, `
+		SELECT data FROM connections WHERE session_id = ? AND user_id = ?
+		ORDER BY connection_id LIMIT 1`, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if player == nil {
+		return nil, fmt.Errorf("user %s not found in session %s", userID, sessionID)
+	}
+	return player, nil
+}
+
+// --- Answers ---
+
+// PutAnswer records a player's answer to a question. The insert is keyed on
+// (session, user, question), so of several concurrent submissions exactly one is recorded;
+// the others get ErrAlreadyAnswered.
+func (s *SQLStore) PutAnswer(ctx context.Context, answer *models.Answer) error {
+	observability.Debug(ctx, "putting answer",
+		"sessionId", answer.SessionID,
+		"userId", answer.UserID,
+		"questionId", answer.QuestionID,
+	)
+
+	answer.TTL = time.Now().Add(s.SessionTTL + s.AnswerRetention).Unix()
+	data, err := toJSON(answer)
+	if err != nil {
+		return err
+	}
+	// Anonymous players are left out of the history index
+	var historyUserID sql.NullString
+	if !models.IsAnonymous(answer.UserID) {
+		historyUserID = sql.NullString{String: answer.UserID, Valid: true}
+	}
+	result, err := s.DB.ExecContext(ctx, s.rebind(`
+		INSERT INTO answers (session_id, user_id, question_id, history_user_id, answered_at, data)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (session_id, user_id, question_id) DO NOTHING`),
+		answer.SessionID, answer.UserID, answer.QuestionID, historyUserID, answer.AnsweredAt.UnixMilli(), data)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAlreadyAnswered
+	}
+	return nil
+}
+
+// GetAnswersBySession retrieves all answers for a given session.
+func (s *SQLStore) GetAnswersBySession(ctx context.Context, sessionID string) ([]models.Answer, error) {
+	observability.Debug(ctx, "getting answers by session", "sessionId", sessionID)
+	return queryJSON[models.Answer](ctx, s,
+		`SELECT data FROM answers WHERE session_id = ? ORDER BY user_id, question_id`, sessionID)
+}
+
+// EachAnswerBySession calls fn for every answer of a session, ordered by user and question,
+// as rows are read.
+func (s *SQLStore) EachAnswerBySession(ctx context.Context, sessionID string, fn func(models.Answer) error) error {
+	observability.Debug(ctx, "iterating answers by session", "sessionId", sessionID)
+	return eachJSON(ctx, s, fn,
+		`SELECT data FROM answers WHERE session_id = ? ORDER BY user_id, question_id`, sessionID)
+}
+
+// GetAnswersByUser retrieves one player's answers in a session.
+func (s *SQLStore) GetAnswersByUser(ctx context.Context, sessionID, userID string) ([]models.Answer, error) {
+	observability.Debug(ctx, "getting answers by user", "sessionId", sessionID, "userId", userID)
+	return queryJSON[models.Answer](ctx, s,
+		`SELECT data FROM answers WHERE session_id = ? AND user_id = ? ORDER BY question_id`, sessionID, userID)
+}
+
+// ListAnsweredSessions returns the IDs of up to limit sessions an authenticated player
+// answered in, most recently answered first, starting at cursor, and the cursor of the next
+// page ("" on the last page).
+func (s *SQLStore) ListAnsweredSessions(ctx context.Context, userID string, limit int, cursor string) ([]string, string, error) {
+	observability.Debug(ctx, "listing answered sessions", "userId", userID, "limit", limit)
+
+	offset, err := DecodeOffsetCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := s.DB.QueryContext(ctx, s.rebind(`
+		SELECT session_id FROM answers WHERE history_user_id = ?
+		GROUP BY session_id ORDER BY MAX(answered_at) DESC, session_id
+		LIMIT ? OFFSET ?`), userID, limit+1, offset)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var sessionIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, "", err
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	if len(sessionIDs) > limit {
+		return sessionIDs[:limit], EncodeOffsetCursor(offset + limit), nil
+	}
+	return sessionIDs, "", nil
+}
+
+// GetAnswer retrieves a specific player's answer to a specific question. Returns nil if none exists.
+func (s *SQLStore) GetAnswer(ctx context.Context, sessionID, userID, questionID string) (*models.Answer, error) {
+	return getJSON[models.Answer](ctx, s, s.DB, `
+		SELECT data FROM answers WHERE session_id = ? AND user_id = ? AND question_id = ?`,
+		sessionID, userID, questionID)
+}
+
+// --- Results ---
+
+// PutSessionResult stores the final summary and every player's ranking for a finished session
+// in one transaction. Rankings must be in leaderboard order.
+func (s *SQLStore) PutSessionResult(ctx context.Context, result *models.SessionResult, rankings []models.PlayerResult) error {
+	observability.Debug(ctx, "putting session result", "sessionId", result.SessionID, "players", len(rankings))
+
+	result.RecordKey = models.ResultRecordSummary
+	summary, err := toJSON(result)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		// Replace rather than merge, in case the result is saved twice with fewer players
+		if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM player_results WHERE session_id = ?`), result.SessionID); err != nil {
+			return err
+		}
+
+		stmt, err := tx.PrepareContext(ctx, s.rebind(`
+			INSERT INTO player_results (session_id, position, user_id, quiz_id, host_user_id, finished_at, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i := range rankings {
+			rankings[i].SessionID = result.SessionID
+			rankings[i].RecordKey = models.RankRecordKey(i + 1)
+
+			// Anonymous players are left out of the all-time leaderboard indexes
+			var quizID, hostUserID sql.NullString
+			var finishedAt sql.NullInt64
+			if !models.IsAnonymous(rankings[i].UserID) {
+				rankings[i].QuizID = result.QuizID
+				rankings[i].HostUserID = result.HostUserID
+				rankings[i].FinishedAt = result.EndedAt.Unix()
+				quizID = sql.NullString{String: result.QuizID, Valid: true}
+				hostUserID = sql.NullString{String: result.HostUserID, Valid: true}
+				finishedAt = sql.NullInt64{Int64: rankings[i].FinishedAt, Valid: true}
+			}
+
+			data, err := toJSON(rankings[i])
+			if err != nil {
+				return err
+			}
+			if _, err := stmt.ExecContext(ctx, result.SessionID, i+1, rankings[i].UserID, quizID, hostUserID, finishedAt, data); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, s.rebind(`
+			INSERT INTO session_results (session_id, data) VALUES (?, ?)
+			ON CONFLICT (session_id) DO UPDATE SET data = excluded.data`),
+			result.SessionID, summary)
+		return err
+	})
+}
+
+// GetSessionResult retrieves the stored summary of a finished session.
+// Returns nil if no result has been stored.
+func (s *SQLStore) GetSessionResult(ctx context.Context, sessionID string) (*models.SessionResult, error) {
+	observability.Debug(ctx, "getting session result", "sessionId", sessionID)
+
+	result, err := getJSON[models.SessionResult](ctx, s, s.DB,
+		`SELECT data FROM session_results WHERE session_id = ?`, sessionID)
+	if result != nil {
+		result.RecordKey = models.ResultRecordSummary
+	}
+	return result, err
+}
+
+// GetSessionRankings returns the stored final rankings of a session ordered by rank.
+// A limit of 0 or less returns every player.
+func (s *SQLStore) GetSessionRankings(ctx context.Context, sessionID string, limit int) ([]models.PlayerResult, error) {
+	rankings, _, err := s.GetSessionRankingsPage(ctx, sessionID, limit, "")
+	return rankings, err
+}
+
+// GetSessionRankingsPage returns up to limit stored rankings of a session ordered by rank,
+// starting at cursor, and the cursor of the next page ("" on the last page).
+// A limit of 0 or less returns every remaining player.
+func (s *SQLStore) GetSessionRankingsPage(ctx context.Context, sessionID string, limit int, cursor string) ([]models.PlayerResult, string, error) {
+	observability.Debug(ctx, "getting session rankings", "sessionId", sessionID, "limit", limit)
+
+	rankings, offset, next, err := queryJSONPage[models.PlayerResult](ctx, s,
+		`SELECT data FROM player_results WHERE session_id = ? ORDER BY position`, limit, cursor, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range rankings {
+		rankings[i].RecordKey = models.RankRecordKey(offset + i + 1)
+	}
+	return rankings, next, nil
+}
+
+// GetFinalLeaderboard returns the top n stored rankings of a finished session
+// in leaderboard display form.
+func (s *SQLStore) GetFinalLeaderboard(ctx context.Context, sessionID string, n int) ([]models.PlayerScore, error) {
+	rankings, err := s.GetSessionRankings(ctx, sessionID, n)
+	if err != nil {
+		return nil, err
+	}
+	return rankingsToScores(rankings), nil
+}
+
+// GetPlayerResult returns one player's final standing in a session, or nil if they have none.
+// Standings are found by the session_id, user_id index.
+func (s *SQLStore) GetPlayerResult(ctx context.Context, sessionID, userID string) (*models.PlayerResult, error) {
+	observability.Debug(ctx, "getting player result", "sessionId", sessionID, "userId", userID)
+
+	var position int
+	var data string
+	err := s.DB.QueryRowContext(ctx, s.rebind(`
+		SELECT position, data FROM player_results WHERE session_id = ? AND user_id = ?`),
+		sessionID, userID).Scan(&position, &data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r models.PlayerResult
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		return nil, err
+	}
+	r.RecordKey = models.RankRecordKey(position)
+	return &r, nil
+}
+
+// ListQuizResults returns every authenticated player's result from sessions of a quiz
+// that finished within [from, to], oldest session first.
+func (s *SQLStore) ListQuizResults(ctx context.Context, quizID string, from, to time.Time) ([]models.PlayerResult, error) {
+	observability.Debug(ctx, "listing player results", "quizId", quizID, "from", from, "to", to)
+	return queryJSON[models.PlayerResult](ctx, s, `
+		SELECT data FROM player_results WHERE quiz_id = ? AND finished_at BETWEEN ? AND ?
+		ORDER BY finished_at, session_id, position`, quizID, from.Unix(), to.Unix())
+}
+
+// ListHostResults returns every authenticated player's result from sessions run by a host
+// that finished within [from, to], oldest session first.
+func (s *SQLStore) ListHostResults(ctx context.Context, hostUserID string, from, to time.Time) ([]models.PlayerResult, error) {
+	observability.Debug(ctx, "listing player results", "hostUserId", hostUserID, "from", from, "to", to)
+	return queryJSON[models.PlayerResult](ctx, s, `
+		SELECT data FROM player_results WHERE host_user_id = ? AND finished_at BETWEEN ? AND ?
+		ORDER BY finished_at, session_id, position`, hostUserID, from.Unix(), to.Unix())
+}
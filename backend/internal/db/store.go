@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+)
+
+// Storage backends selectable with STORAGE_BACKEND.
+const (
+	BackendDynamoDB = "dynamodb"
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+)
+
+// QuizRepository stores quizzes.
+type QuizRepository interface {
+	CreateQuiz(ctx context.Context, quiz *models.Quiz) error
+	GetQuiz(ctx context.Context, quizID string) (*models.Quiz, error)
+	UpdateQuiz(ctx context.Context, quiz *models.Quiz, expectedVersion int64) error
+	DeleteQuiz(ctx context.Context, quizID string) error
+	PutQuizVersion(ctx context.Context, quiz *models.Quiz) error
+	GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error)
+	ListQuizzesByHost(ctx context.Context, hostUserID string, filter models.QuizFilter, limit int, cursor string) ([]models.Quiz, string, error)
+}
+
+// QuestionBankRepository stores hosts' reusable questions.
+type QuestionBankRepository interface {
+	CreateBankQuestion(ctx context.Context, question *models.BankQuestion) error
+	GetBankQuestion(ctx context.Context, questionID string) (*models.BankQuestion, error)
+	GetBankQuestions(ctx context.Context, questionIDs []string) ([]models.BankQuestion, error)
+	UpdateBankQuestion(ctx context.Context, question *models.BankQuestion) error
+	DeleteBankQuestion(ctx context.Context, questionID string) error
+	ListBankQuestions(ctx context.Context, hostUserID string, filter models.BankFilter, limit int, cursor string) ([]models.BankQuestion, string, error)
+}
+
+// SessionRepository stores game sessions and the PIN reservations that point to them.
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
+	GetSessionByPIN(ctx context.Context, pin string) (*models.Session, error)
+	UpdateSessionStatus(ctx context.Context, sessionID string, status models.SessionStatus, questionIndex int) error
+	ListIdleSessions(ctx context.Context, status models.SessionStatus, cutoff time.Time) ([]models.Session, error)
+	FinishIdleSession(ctx context.Context, sessionID string, cutoff time.Time) (bool, error)
+	TouchSession(ctx context.Context, sessionID string, before time.Time) error
+	HasLiveSession(ctx context.Context, quizID string) (bool, error)
+
+	AllocatePIN(ctx context.Context, sessionID string) (string, error)
+	ReservePIN(ctx context.Context, pin, sessionID string) error
+	ReleasePIN(ctx context.Context, pin, sessionID string) error
+}
+
+// ConnectionRepository stores the WebSocket connections of each session.
+type ConnectionRepository interface {
+	PutConnection(ctx context.Context, player *models.Player) error
+	DeleteConnection(ctx context.Context, sessionID, connectionID string) error
+	GetConnectionsBySession(ctx context.Context, sessionID string) ([]models.Player, error)
+	GetPlayerCountBySession(ctx context.Context, sessionID string) (int, error)
+	GetSessionByConnectionID(ctx context.Context, connectionID string) (*models.Player, error)
+	GetConnectionByUserID(ctx context.Context, sessionID, userID string) (*models.Player, error)
+}
+
+// AnswerRepository stores players' answers.
+type AnswerRepository interface {
+	PutAnswer(ctx context.Context, answer *models.Answer) error
+	GetAnswersBySession(ctx context.Context, sessionID string) ([]models.Answer, error)
+	// EachAnswerBySession calls fn for every answer of a session in the same order as
+	// GetAnswersBySession, reading them a page at a time; it stops at fn's first error.
+	// fn must not use the store, which may be holding a connection open for the rows.
+	EachAnswerBySession(ctx context.Context, sessionID string, fn func(models.Answer) error) error
+	GetAnswersByUser(ctx context.Context, sessionID, userID string) ([]models.Answer, error)
+	ListAnsweredSessions(ctx context.Context, userID string, limit int, cursor string) ([]string, string, error)
+	GetAnswer(ctx context.Context, sessionID, userID, questionID string) (*models.Answer, error)
+}
+
+// ResultRepository stores the final results of finished sessions.
+type ResultRepository interface {
+	PutSessionResult(ctx context.Context, result *models.SessionResult, rankings []models.PlayerResult) error
+	GetSessionResult(ctx context.Context, sessionID string) (*models.SessionResult, error)
+	GetSessionRankings(ctx context.Context, sessionID string, limit int) ([]models.PlayerResult, error)
+	GetSessionRankingsPage(ctx context.Context, sessionID string, limit int, cursor string) ([]models.PlayerResult, string, error)
+	GetFinalLeaderboard(ctx context.Context, sessionID string, n int) ([]models.PlayerScore, error)
+	GetPlayerResult(ctx context.Context, sessionID, userID string) (*models.PlayerResult, error)
+	ListQuizResults(ctx context.Context, quizID string, from, to time.Time) ([]models.PlayerResult, error)
+	ListHostResults(ctx context.Context, hostUserID string, from, to time.Time) ([]models.PlayerResult, error)
+}
+
+// Store is the full persistence API used by the game engine and the API handlers.
+type Store interface {
+	QuizRepository
+	QuestionBankRepository
+	SessionRepository
+	ConnectionRepository
+	AnswerRepository
+	ResultRepository
+}
+
+var (
+	_ Store = (*Client)(nil)
+	_ Store = (*SQLStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// NewStore returns the storage backend selected by cfg.StorageBackend.
+func NewStore(ctx context.Context, cfg *config.Config) (Store, error) {
+	switch cfg.StorageBackend {
+	case BackendDynamoDB:
+		return NewClient(ctx, cfg)
+	case BackendSQLite, BackendPostgres:
+		return NewSQLStore(ctx, cfg)
+	case BackendMemory:
+		return NewMemoryStore(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
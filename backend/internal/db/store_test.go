@@ -0,0 +1,777 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kahootclone/internal/config"
+	"kahootclone/internal/models"
+)
+
+// The contract tests run the same checks against every Store, so the backends agree on
+// errors, ordering and pagination. The DynamoDB Client runs against fakeDynamo, and Postgres
+// runs only when TEST_POSTGRES_URL points at a database the tests may create schemas in.
+
+func testStoreConfig() *config.Config {
+	return &config.Config{SessionTTL: time.Hour, AnswerRetention: 24 * time.Hour}
+}
+
+func TestMemoryStoreContract(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) Store {
+		return NewMemoryStore(testStoreConfig())
+	})
+}
+
+func TestSQLiteStoreContract(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) Store {
+		cfg := testStoreConfig()
+		cfg.StorageBackend = BackendSQLite
+		cfg.DatabaseURL = filepath.Join(t.TempDir(), "quiz.db")
+		s, err := NewSQLStore(context.Background(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+func TestDynamoClientContract(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) Store {
+		c, _ := newFakeClient(100)
+		return c
+	})
+}
+
+func TestPostgresStoreContract(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_URL")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_URL not set")
+	}
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	n := 0
+	runStoreContract(t, func(t *testing.T) Store {
+		// Each subtest migrates its own schema, so runs start empty and leave nothing behind
+		n++
+		schema := fmt.Sprintf("store_contract_%d_%d", time.Now().UnixNano(), n)
+		if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+		cfg := testStoreConfig()
+		cfg.StorageBackend = BackendPostgres
+		cfg.DatabaseURL = withSearchPath(t, dsn, schema)
+		s, err := NewSQLStore(context.Background(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+// withSearchPath sets the search_path of a URL or key=value Postgres DSN.
+func withSearchPath(t *testing.T, dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func runStoreContract(t *testing.T, newStore func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s Store)
+	}{
+		{"Quizzes", testStoreQuizzes},
+		{"QuizVersions", testStoreQuizVersions},
+		{"ListQuizzesByHost", testStoreListQuizzes},
+		{"BankQuestions", testStoreBankQuestions},
+		{"SessionsAndPINs", testStoreSessions},
+		{"IdleSessions", testStoreIdleSessions},
+		{"TouchHoldsPIN", testStoreTouchHoldsPIN},
+		{"HasLiveSession", testStoreHasLiveSession},
+		{"Connections", testStoreConnections},
+		{"Answers", testStoreAnswers},
+		{"AnsweredSessions", testStoreAnsweredSessions},
+		{"Results", testStoreResults},
+		{"InvalidCursor", testStoreInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testStoreQuizzes(t *testing.T, s Store) {
+	ctx := context.Background()
+	if q, err := s.GetQuiz(ctx, "missing"); err != nil || q != nil {
+		t.Fatalf("GetQuiz(missing) = %v, %v; want nil, nil", q, err)
+	}
+
+	quiz := &models.Quiz{QuizID: "quiz-1", HostUserID: "host-1", Title: "First", UpdatedAt: time.Now().UTC()}
+	if err := s.CreateQuiz(ctx, quiz); err != nil {
+		t.Fatal(err)
+	}
+	if quiz.Version != 1 {
+		t.Errorf("created quiz has version %d, want 1", quiz.Version)
+	}
+
+	quiz.Title = "Second"
+	if err := s.UpdateQuiz(ctx, quiz, 1); err != nil {
+		t.Fatal(err)
+	}
+	if quiz.Version != 2 {
+		t.Errorf("updated quiz has version %d, want 2", quiz.Version)
+	}
+	got, err := s.GetQuiz(ctx, "quiz-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Second" || got.Version != 2 {
+		t.Errorf("got %q at version %d, want Second at version 2", got.Title, got.Version)
+	}
+
+	// A writer holding the old version loses
+	stale := *got
+	stale.Title = "Stale"
+	if err := s.UpdateQuiz(ctx, &stale, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("stale update: got %v, want ErrVersionConflict", err)
+	}
+
+	if err := s.DeleteQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteQuiz(ctx, "quiz-1"); err != nil {
+		t.Errorf("deleting a missing quiz: %v", err)
+	}
+	if err := s.UpdateQuiz(ctx, got, 2); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("update after delete: got %v, want ErrVersionConflict", err)
+	}
+}
+
+func testStoreQuizVersions(t *testing.T, s Store) {
+	ctx := context.Background()
+	quiz := &models.Quiz{QuizID: "quiz-1", Title: "v1", Version: 1}
+	if err := s.PutQuizVersion(ctx, quiz); err != nil {
+		t.Fatal(err)
+	}
+	// Snapshots are immutable: a second put of the same version is ignored
+	again := &models.Quiz{QuizID: "quiz-1", Title: "changed", Version: 1}
+	if err := s.PutQuizVersion(ctx, again); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetQuizVersion(ctx, "quiz-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Title != "v1" {
+		t.Errorf("got snapshot %+v, want the first v1", got)
+	}
+	if got, err := s.GetQuizVersion(ctx, "quiz-1", 2); err != nil || got != nil {
+		t.Errorf("GetQuizVersion(missing) = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func testStoreListQuizzes(t *testing.T, s Store) {
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		var tags []string
+		if i%2 == 0 {
+			tags = []string{"math"}
+		}
+		quiz := &models.Quiz{
+			QuizID:     fmt.Sprintf("quiz-%d", i),
+			HostUserID: "host-1",
+			Title:      fmt.Sprintf("Quiz %d", i),
+			Tags:       tags,
+			UpdatedAt:  base.Add(time.Duration(i) * time.Minute),
+		}
+		if err := s.CreateQuiz(ctx, quiz); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CreateQuiz(ctx, &models.Quiz{QuizID: "other", HostUserID: "host-2", Title: "Quiz other", UpdatedAt: base}); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 7 {
+			t.Fatal("pagination did not terminate")
+		}
+		quizzes, next, err := s.ListQuizzesByHost(ctx, "host-1", models.QuizFilter{}, 3, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, q := range quizzes {
+			ids = append(ids, q.QuizID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	want := []string{"quiz-6", "quiz-5", "quiz-4", "quiz-3", "quiz-2", "quiz-1", "quiz-0"}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("got %v, want most recently updated first %v", ids, want)
+	}
+
+	tagged, _, err := s.ListQuizzesByHost(ctx, "host-1", models.QuizFilter{Tag: " MATH "}, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagged) != 4 {
+		t.Errorf("got %d quizzes tagged math, want 4", len(tagged))
+	}
+	titled, _, err := s.ListQuizzesByHost(ctx, "host-1", models.QuizFilter{Title: "quiz 3"}, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(titled) != 1 || titled[0].QuizID != "quiz-3" {
+		t.Errorf("got %v for title filter, want quiz-3", titled)
+	}
+}
+
+func testStoreBankQuestions(t *testing.T, s Store) {
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		q := &models.BankQuestion{
+			Question:   models.Question{QuestionID: fmt.Sprintf("bq-%d", i), Text: fmt.Sprintf("Question %d", i)},
+			HostUserID: "host-1",
+			Tags:       []string{"geo"},
+			UpdatedAt:  base.Add(time.Duration(i) * time.Minute),
+		}
+		if err := s.CreateBankQuestion(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.GetBankQuestions(ctx, []string{"bq-0", "bq-2", "missing", "bq-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d bank questions, want 2 with missing and duplicate IDs skipped", len(got))
+	}
+
+	missing := &models.BankQuestion{Question: models.Question{QuestionID: "missing"}, HostUserID: "host-1"}
+	if err := s.UpdateBankQuestion(ctx, missing); !errors.Is(err, ErrBankQuestionNotFound) {
+		t.Errorf("updating a missing question: got %v, want ErrBankQuestionNotFound", err)
+	}
+
+	if err := s.DeleteBankQuestion(ctx, "bq-1"); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := s.GetBankQuestion(ctx, "bq-1"); err != nil || q != nil {
+		t.Errorf("GetBankQuestion(deleted) = %v, %v; want nil, nil", q, err)
+	}
+
+	listed, next, err := s.ListBankQuestions(ctx, "host-1", models.BankFilter{Tag: "geo"}, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].QuestionID != "bq-2" || next == "" {
+		t.Fatalf("got %v and cursor %q, want bq-2 and a next page", listed, next)
+	}
+	listed, next, err = s.ListBankQuestions(ctx, "host-1", models.BankFilter{Tag: "geo"}, 1, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].QuestionID != "bq-0" || next != "" {
+		t.Errorf("got %v and cursor %q, want bq-0 on the last page", listed, next)
+	}
+}
+
+func testStoreSessions(t *testing.T, s Store) {
+	ctx := context.Background()
+	pin, err := s.AllocatePIN(ctx, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pin) != 6 {
+		t.Errorf("got PIN %q, want 6 digits", pin)
+	}
+	if err := s.ReservePIN(ctx, pin, "s-2"); !errors.Is(err, ErrPINTaken) {
+		t.Errorf("reserving a taken PIN: got %v, want ErrPINTaken", err)
+	}
+
+	session := &models.Session{SessionID: "s-1", PIN: pin, QuizID: "quiz-1", HostUserID: "host-1", Status: models.SessionStatusLobby, CreatedAt: time.Now().UTC()}
+	if err := s.CreateSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	if session.LastActivityAt == 0 || session.TTL <= time.Now().Unix() {
+		t.Errorf("created session has lastActivityAt %d and ttl %d, want both set", session.LastActivityAt, session.TTL)
+	}
+
+	byPIN, err := s.GetSessionByPIN(ctx, pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byPIN == nil || byPIN.SessionID != "s-1" {
+		t.Fatalf("GetSessionByPIN = %+v, want s-1", byPIN)
+	}
+
+	if err := s.UpdateSessionStatus(ctx, "s-1", models.SessionStatusActive, 0); err != nil {
+		t.Fatal(err)
+	}
+	active, _ := s.GetSession(ctx, "s-1")
+	if active.Status != models.SessionStatusActive || active.StartedAt == nil {
+		t.Errorf("got status %s and startedAt %v, want ACTIVE with a start time", active.Status, active.StartedAt)
+	}
+
+	if err := s.UpdateSessionStatus(ctx, "s-1", models.SessionStatusFinished, -1); err != nil {
+		t.Fatal(err)
+	}
+	finished, _ := s.GetSession(ctx, "s-1")
+	if finished.EndedAt == nil || finished.StartedAt == nil {
+		t.Errorf("finished session has startedAt %v and endedAt %v, want both", finished.StartedAt, finished.EndedAt)
+	}
+	// A finished session no longer resolves by PIN, even while the PIN is reserved
+	if byPIN, err := s.GetSessionByPIN(ctx, pin); err != nil || byPIN != nil {
+		t.Errorf("GetSessionByPIN(finished) = %v, %v; want nil, nil", byPIN, err)
+	}
+
+	// Only the owning session can release the PIN
+	if err := s.ReleasePIN(ctx, pin, "s-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReservePIN(ctx, pin, "s-2"); !errors.Is(err, ErrPINTaken) {
+		t.Errorf("PIN released by another session: got %v, want ErrPINTaken", err)
+	}
+	if err := s.ReleasePIN(ctx, pin, "s-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReservePIN(ctx, pin, "s-2"); err != nil {
+		t.Errorf("reserving a released PIN: %v", err)
+	}
+}
+
+func testStoreIdleSessions(t *testing.T, s Store) {
+	ctx := context.Background()
+	for _, id := range []string{"s-1", "s-2"} {
+		if err := s.CreateSession(ctx, &models.Session{SessionID: id, QuizID: "quiz-1", Status: models.SessionStatusLobby}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	future := time.Now().Add(time.Minute)
+	idle, err := s.ListIdleSessions(ctx, models.SessionStatusLobby, future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idle) != 2 {
+		t.Fatalf("got %d idle sessions, want 2", len(idle))
+	}
+	if idle, _ := s.ListIdleSessions(ctx, models.SessionStatusLobby, time.Now().Add(-time.Minute)); len(idle) != 0 {
+		t.Errorf("got %d sessions idle since before they were created", len(idle))
+	}
+
+	if ok, err := s.FinishIdleSession(ctx, "s-1", time.Now().Add(-time.Minute)); err != nil || ok {
+		t.Errorf("finishing a recently active session = %v, %v; want false, nil", ok, err)
+	}
+	if ok, err := s.FinishIdleSession(ctx, "s-1", future); err != nil || !ok {
+		t.Fatalf("finishing an idle session = %v, %v; want true, nil", ok, err)
+	}
+	if ok, err := s.FinishIdleSession(ctx, "s-1", future); err != nil || ok {
+		t.Errorf("finishing a finished session = %v, %v; want false, nil", ok, err)
+	}
+	finished, _ := s.GetSession(ctx, "s-1")
+	if finished.Status != models.SessionStatusFinished || finished.CurrentQuestionIndex != -1 || finished.EndedAt == nil {
+		t.Errorf("got %+v, want a FINISHED session at index -1 with an end time", finished)
+	}
+	if ok, err := s.FinishIdleSession(ctx, "missing", future); err != nil || ok {
+		t.Errorf("finishing a missing session = %v, %v; want false, nil", ok, err)
+	}
+
+	// Touching records activity on live sessions only, and never creates one
+	before := time.Now().Unix()
+	if err := s.TouchSession(ctx, "s-2", future); err != nil {
+		t.Fatal(err)
+	}
+	if touched, _ := s.GetSession(ctx, "s-2"); touched.LastActivityAt < before || touched.Status != models.SessionStatusLobby {
+		t.Errorf("got %+v, want a LOBBY session active since %d", touched, before)
+	}
+	if err := s.TouchSession(ctx, "s-1", future); err != nil {
+		t.Fatal(err)
+	}
+	if touched, _ := s.GetSession(ctx, "s-1"); touched.Status != models.SessionStatusFinished || touched.TTL != finished.TTL {
+		t.Errorf("touching a finished session changed it to %+v", touched)
+	}
+	if err := s.TouchSession(ctx, "missing", future); err != nil {
+		t.Fatal(err)
+	}
+	if missing, _ := s.GetSession(ctx, "missing"); missing != nil {
+		t.Errorf("touching a missing session created %+v", missing)
+	}
+}
+
+func testStoreTouchHoldsPIN(t *testing.T, s Store) {
+	ctx := context.Background()
+	pin, err := s.AllocatePIN(ctx, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateSession(ctx, &models.Session{SessionID: "s-1", PIN: pin, QuizID: "quiz-1", Status: models.SessionStatusLobby}); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+
+	// A reservation removed while the session was still live is taken back on the next touch
+	if err := s.ReleasePIN(ctx, pin, "s-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TouchSession(ctx, "s-1", future); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReservePIN(ctx, pin, "s-2"); !errors.Is(err, ErrPINTaken) {
+		t.Errorf("reserving the PIN of a touched session: got %v, want ErrPINTaken", err)
+	}
+	if byPIN, err := s.GetSessionByPIN(ctx, pin); err != nil || byPIN == nil || byPIN.SessionID != "s-1" {
+		t.Errorf("GetSessionByPIN after a touch = %+v, %v; want s-1", byPIN, err)
+	}
+
+	// but a PIN another session reserved in the meantime stays theirs
+	if err := s.ReleasePIN(ctx, pin, "s-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReservePIN(ctx, pin, "s-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TouchSession(ctx, "s-1", future.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReleasePIN(ctx, pin, "s-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReservePIN(ctx, pin, "s-3"); err != nil {
+		t.Errorf("reserving the PIN s-2 released: %v, want s-1's touch to have left it alone", err)
+	}
+}
+
+func testStoreHasLiveSession(t *testing.T, s Store) {
+	ctx := context.Background()
+	if live, err := s.HasLiveSession(ctx, "quiz-1"); err != nil || live {
+		t.Fatalf("HasLiveSession with no sessions = %v, %v; want false, nil", live, err)
+	}
+
+	if err := s.CreateSession(ctx, &models.Session{SessionID: "other", QuizID: "quiz-2", Status: models.SessionStatusActive}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateSession(ctx, &models.Session{SessionID: "s-1", QuizID: "quiz-1", Status: models.SessionStatusLobby}); err != nil {
+		t.Fatal(err)
+	}
+	if live, err := s.HasLiveSession(ctx, "quiz-1"); err != nil || !live {
+		t.Errorf("HasLiveSession with a lobby = %v, %v; want true, nil", live, err)
+	}
+
+	if err := s.UpdateSessionStatus(ctx, "s-1", models.SessionStatusActive, 0); err != nil {
+		t.Fatal(err)
+	}
+	if live, err := s.HasLiveSession(ctx, "quiz-1"); err != nil || !live {
+		t.Errorf("HasLiveSession with an active session = %v, %v; want true, nil", live, err)
+	}
+
+	if err := s.UpdateSessionStatus(ctx, "s-1", models.SessionStatusFinished, -1); err != nil {
+		t.Fatal(err)
+	}
+	if live, err := s.HasLiveSession(ctx, "quiz-1"); err != nil || live {
+		t.Errorf("HasLiveSession after the game finished = %v, %v; want false, nil", live, err)
+	}
+}
+
+func testStoreConnections(t *testing.T, s Store) {
+	ctx := context.Background()
+	players := []models.Player{
+		{SessionID: "s-1", ConnectionID: "c-host", UserID: "host-1", Role: models.PlayerRoleHost},
+		{SessionID: "s-1", ConnectionID: "c-1", UserID: "u-1", Nickname: "Ann", Role: models.PlayerRolePlayer},
+		{SessionID: "s-1", ConnectionID: "c-2", UserID: "u-2", Nickname: "Bob", Role: models.PlayerRolePlayer},
+		{SessionID: "s-2", ConnectionID: "c-3", UserID: "u-1", Role: models.PlayerRolePlayer},
+	}
+	for i := range players {
+		if err := s.PutConnection(ctx, &players[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conns, err := s.GetConnectionsBySession(ctx, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 3 {
+		t.Errorf("got %d connections, want 3", len(conns))
+	}
+	if n, _ := s.GetPlayerCountBySession(ctx, "s-1"); n != 2 {
+		t.Errorf("got %d players, want 2 without the host", n)
+	}
+
+	byConn, err := s.GetSessionByConnectionID(ctx, "c-3")
+	if err != nil || byConn.SessionID != "s-2" {
+		t.Errorf("GetSessionByConnectionID(c-3) = %+v, %v; want session s-2", byConn, err)
+	}
+	if _, err := s.GetSessionByConnectionID(ctx, "missing"); err == nil {
+		t.Error("got no error for a missing connection")
+	}
+	byUser, err := s.GetConnectionByUserID(ctx, "s-1", "u-2")
+	if err != nil || byUser.Nickname != "Bob" {
+		t.Errorf("GetConnectionByUserID(u-2) = %+v, %v; want Bob", byUser, err)
+	}
+
+	if err := s.DeleteConnection(ctx, "s-1", "c-1"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := s.GetPlayerCountBySession(ctx, "s-1"); n != 1 {
+		t.Errorf("got %d players after a disconnect, want 1", n)
+	}
+	if _, err := s.GetConnectionByUserID(ctx, "s-1", "u-1"); err == nil {
+		t.Error("found a deleted connection")
+	}
+}
+
+func putAnswer(t *testing.T, s Store, sessionID, userID, questionID string, at time.Time) error {
+	t.Helper()
+	return s.PutAnswer(context.Background(), &models.Answer{
+		SessionID:        sessionID,
+		UserIDQuestionID: userID + "#" + questionID,
+		UserID:           userID,
+		QuestionID:       questionID,
+		SelectedOptionID: "o-1",
+		AnsweredAt:       at.UTC(),
+	})
+}
+
+func testStoreAnswers(t *testing.T, s Store) {
+	ctx := context.Background()
+	now := time.Now()
+	for _, a := range []struct{ user, question string }{
+		{"u-2", "q-1"}, {"u-1", "q-2"}, {"u-1", "q-1"},
+	} {
+		if err := putAnswer(t, s, "s-1", a.user, a.question, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := putAnswer(t, s, "s-1", "u-1", "q-1", now); !errors.Is(err, ErrAlreadyAnswered) {
+		t.Errorf("second answer: got %v, want ErrAlreadyAnswered", err)
+	}
+
+	answers, err := s.GetAnswersBySession(ctx, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, a := range answers {
+		keys = append(keys, a.UserIDQuestionID)
+	}
+	if want := "[u-1#q-1 u-1#q-2 u-2#q-1]"; fmt.Sprint(keys) != want {
+		t.Errorf("got %v, want %s ordered by userId#questionId", keys, want)
+	}
+
+	var each []string
+	err = s.EachAnswerBySession(ctx, "s-1", func(a models.Answer) error {
+		each = append(each, a.UserIDQuestionID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(each) != fmt.Sprint(keys) {
+		t.Errorf("EachAnswerBySession visited %v, want %v", each, keys)
+	}
+	stop := errors.New("stop")
+	if err := s.EachAnswerBySession(ctx, "s-1", func(models.Answer) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("got %v, want the callback's error", err)
+	}
+
+	byUser, err := s.GetAnswersByUser(ctx, "s-1", "u-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byUser) != 2 || byUser[0].QuestionID != "q-1" {
+		t.Errorf("got %v, want u-1's two answers ordered by question", byUser)
+	}
+
+	got, err := s.GetAnswer(ctx, "s-1", "u-2", "q-1")
+	if err != nil || got == nil || got.SelectedOptionID != "o-1" {
+		t.Errorf("GetAnswer = %+v, %v; want u-2's answer", got, err)
+	}
+	if got, err := s.GetAnswer(ctx, "s-1", "u-2", "q-2"); err != nil || got != nil {
+		t.Errorf("GetAnswer(missing) = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func testStoreAnsweredSessions(t *testing.T, s Store) {
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour)
+	for i, sessionID := range []string{"s-1", "s-2", "s-3"} {
+		at := base.Add(time.Duration(i) * time.Minute)
+		if err := putAnswer(t, s, sessionID, "u-1", "q-1", at); err != nil {
+			t.Fatal(err)
+		}
+		if err := putAnswer(t, s, sessionID, "u-1", "q-2", at.Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := putAnswer(t, s, "s-4", "anon-1", "q-1", base); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, next, err := s.ListAnsweredSessions(ctx, "u-1", 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if want := "[s-3 s-2 s-1]"; fmt.Sprint(ids) != want {
+		t.Errorf("got %v, want %s, most recent first", ids, want)
+	}
+
+	anon, _, err := s.ListAnsweredSessions(ctx, "anon-1", 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anon) != 0 {
+		t.Errorf("got history %v for an anonymous player, want none", anon)
+	}
+}
+
+func testStoreResults(t *testing.T, s Store) {
+	ctx := context.Background()
+	if r, err := s.GetSessionResult(ctx, "s-1"); err != nil || r != nil {
+		t.Fatalf("GetSessionResult(missing) = %v, %v; want nil, nil", r, err)
+	}
+
+	ended := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, sessionID := range []string{"s-1", "s-2"} {
+		result := &models.SessionResult{
+			SessionID:   sessionID,
+			QuizID:      "quiz-1",
+			QuizTitle:   "Capitals",
+			HostUserID:  "host-1",
+			PlayerCount: 3,
+			EndedAt:     ended.Add(time.Duration(i) * time.Hour),
+		}
+		rankings := []models.PlayerResult{
+			{UserID: "u-1", Nickname: "Ann", Score: 2000, Rank: 1},
+			{UserID: "anon-1", Nickname: "Guest", Score: 1000, Rank: 2},
+			{UserID: "u-2", Nickname: "Bob", Score: 1000, Rank: 2},
+		}
+		if err := s.PutSessionResult(ctx, result, rankings); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := s.GetSessionResult(ctx, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.QuizTitle != "Capitals" || result.PlayerCount != 3 {
+		t.Errorf("got result %+v", result)
+	}
+
+	var paged []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, next, err := s.GetSessionRankingsPage(ctx, "s-1", 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range page {
+			paged = append(paged, r.UserID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if want := "[u-1 anon-1 u-2]"; fmt.Sprint(paged) != want {
+		t.Errorf("got rankings %v, want %s in stored order", paged, want)
+	}
+
+	top, err := s.GetFinalLeaderboard(ctx, "s-1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0].UserID != "u-1" || top[1].Rank != 2 {
+		t.Errorf("got final leaderboard %+v", top)
+	}
+
+	player, err := s.GetPlayerResult(ctx, "s-1", "u-2")
+	if err != nil || player == nil || player.Score != 1000 {
+		t.Errorf("GetPlayerResult(u-2) = %+v, %v", player, err)
+	}
+	if player, err := s.GetPlayerResult(ctx, "s-1", "missing"); err != nil || player != nil {
+		t.Errorf("GetPlayerResult(missing) = %v, %v; want nil, nil", player, err)
+	}
+
+	// Anonymous players are left out of the all-time indexes; the window is inclusive
+	byQuiz, err := s.ListQuizResults(ctx, "quiz-1", ended, ended.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byQuiz) != 4 || byQuiz[0].SessionID != "s-1" || byQuiz[3].SessionID != "s-2" {
+		t.Errorf("got %d quiz results %+v, want 4, oldest session first", len(byQuiz), byQuiz)
+	}
+	for _, r := range byQuiz {
+		if models.IsAnonymous(r.UserID) {
+			t.Errorf("anonymous player %s listed in all-time results", r.UserID)
+		}
+	}
+	byHost, err := s.ListHostResults(ctx, "host-1", ended.Add(time.Minute), ended.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byHost) != 2 {
+		t.Errorf("got %d host results in the window, want 2", len(byHost))
+	}
+}
+
+func testStoreInvalidCursor(t *testing.T, s Store) {
+	ctx := context.Background()
+	if _, _, err := s.ListQuizzesByHost(ctx, "host-1", models.QuizFilter{}, 10, "!!!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("ListQuizzesByHost: got %v, want ErrInvalidCursor", err)
+	}
+	if _, _, err := s.GetSessionRankingsPage(ctx, "s-1", 10, "!!!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("GetSessionRankingsPage: got %v, want ErrInvalidCursor", err)
+	}
+	if _, _, err := s.ListAnsweredSessions(ctx, "u-1", 10, "!!!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("ListAnsweredSessions: got %v, want ErrInvalidCursor", err)
+	}
+}
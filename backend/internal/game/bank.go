@@ -0,0 +1,167 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// LinkBankQuestions copies the current content of the bank questions that questions
+// reference into them, keeping each quiz question's own ID. Only the host's own bank
+// questions can be referenced. A question whose bank question is gone keeps its last copy
+// and is unlinked, unless it has no text to keep: then the error wraps
+// db.ErrBankQuestionNotFound and names the question.
+func LinkBankQuestions(ctx context.Context, store db.QuestionBankRepository, hostUserID string, questions []models.Question) error {
+	var ids []string
+	for _, q := range questions {
+		if q.BankQuestionID != "" {
+			ids = append(ids, q.BankQuestionID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	found, err := store.GetBankQuestions(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get bank questions: %w", err)
+	}
+	bank := make(map[string]*models.BankQuestion, len(found))
+	for i := range found {
+		if found[i].HostUserID == hostUserID {
+			bank[found[i].QuestionID] = &found[i]
+		}
+	}
+
+	for i := range questions {
+		q := &questions[i]
+		if q.BankQuestionID == "" {
+			continue
+		}
+		if b, ok := bank[q.BankQuestionID]; ok {
+			*q = b.QuizQuestion(q.QuestionID)
+			continue
+		}
+		if q.Text == "" {
+			return fmt.Errorf("Question %d: %w", i+1, db.ErrBankQuestionNotFound)
+		}
+		q.BankQuestionID = ""
+	}
+	return nil
+}
+
+// MaxPoolQuestions is the most bank questions a quiz's rules may match together, so the
+// pool frozen into its snapshot stays well inside DynamoDB's item size limit.
+const MaxPoolQuestions = 300
+
+// PoolError explains why a quiz's rules can't draw from the question bank.
+type PoolError struct {
+	Message string
+}
+
+func (e *PoolError) Error() string { return e.Message }
+
+// rulePool returns the bank questions quiz's rules can draw, ordered by ID. Questions the quiz
+// links directly are left out. Returns a *PoolError if a rule matches fewer questions than
+// it draws, including after the earlier rules it overlaps have drawn theirs, or the rules
+// match more than MaxPoolQuestions.
+func rulePool(ctx context.Context, store db.QuestionBankRepository, quiz *models.Quiz) ([]models.PoolQuestion, error) {
+	if len(quiz.Rules) == 0 {
+		return nil, nil
+	}
+
+	linked := make(map[string]bool)
+	for _, q := range quiz.Questions {
+		if q.BankQuestionID != "" {
+			linked[q.BankQuestionID] = true
+		}
+	}
+
+	// One listing per tag the rules name, or a single one of the whole bank if any rule
+	// takes every tag
+	var tags []string
+	seen := make(map[string]bool)
+	for _, r := range quiz.Rules {
+		tag := strings.ToLower(strings.TrimSpace(r.Tag))
+		if tag == "" {
+			tags = []string{""}
+			break
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	byID := make(map[string]models.PoolQuestion)
+	for _, tag := range tags {
+		questions, _, err := store.ListBankQuestions(ctx, quiz.HostUserID, models.BankFilter{Tag: tag}, 0, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bank questions: %w", err)
+		}
+		for i := range questions {
+			p := questions[i].PoolQuestion()
+			if linked[p.QuestionID] {
+				continue
+			}
+			for j := range quiz.Rules {
+				if quiz.Rules[j].Matches(&p) {
+					byID[p.QuestionID] = p
+					break
+				}
+			}
+		}
+		if len(byID) > MaxPoolQuestions {
+			return nil, &PoolError{fmt.Sprintf("The rules match more than %d bank questions; narrow them by tag or difficulty", MaxPoolQuestions)}
+		}
+	}
+
+	pool := make([]models.PoolQuestion, 0, len(byID))
+	for _, p := range byID {
+		pool = append(pool, p)
+	}
+	sort.Slice(pool, func(i, j int) bool { return pool[i].QuestionID < pool[j].QuestionID })
+
+	matches := make([][]bool, len(quiz.Rules))
+	for i := range quiz.Rules {
+		matches[i] = make([]bool, len(pool))
+		matched := 0
+		for j := range pool {
+			if quiz.Rules[i].Matches(&pool[j]) {
+				matches[i][j] = true
+				matched++
+			}
+		}
+		if matched < quiz.Rules[i].Count {
+			return nil, &PoolError{fmt.Sprintf("Rule %d draws %d questions but only %d bank questions match it", i+1, quiz.Rules[i].Count, matched)}
+		}
+
+		// Earlier rules draw first, so with an unlucky seed they take as many of this rule's
+		// questions as they can: each at most its count of the questions both match, and
+		// together at most the questions any of them match
+		taken, shared := 0, 0
+		for k := 0; k < i; k++ {
+			overlap := 0
+			for j := range pool {
+				if matches[i][j] && matches[k][j] {
+					overlap++
+				}
+			}
+			taken += min(overlap, quiz.Rules[k].Count)
+		}
+		for j := range pool {
+			if matches[i][j] && slices.ContainsFunc(matches[:i], func(m []bool) bool { return m[j] }) {
+				shared++
+			}
+		}
+		if left := matched - min(taken, shared); left < quiz.Rules[i].Count {
+			return nil, &PoolError{fmt.Sprintf("Rule %d draws %d questions but earlier rules can take all but %d of the %d bank questions that match it", i+1, quiz.Rules[i].Count, left, matched)}
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"kahootclone/internal/models"
+)
+
+func TestRulePoolRejectsRulesLeftShortByEarlierOnes(t *testing.T) {
+	ctx := context.Background()
+	_, store := newTestEngine(t, "test")
+
+	// b01-b03 are math of difficulty 1-2, b04 math of difficulty 3; b05 is tagged a and c,
+	// b06-b09 c, and b10 has difficulty 1 and no tags
+	bank := []struct {
+		id         string
+		difficulty int
+		tags       []string
+	}{
+		{"b01", 1, []string{"math"}},
+		{"b02", 1, []string{"math"}},
+		{"b03", 2, []string{"math"}},
+		{"b04", 3, []string{"math"}},
+		{"b05", 1, []string{"a", "c"}},
+		{"b06", 0, []string{"c"}},
+		{"b07", 0, []string{"c"}},
+		{"b08", 0, []string{"c"}},
+		{"b09", 0, []string{"c"}},
+		{"b10", 1, nil},
+	}
+	for _, b := range bank {
+		err := store.CreateBankQuestion(ctx, &models.BankQuestion{
+			Question: models.Question{
+				QuestionID:      b.id,
+				Text:            b.id,
+				Options:         []models.Option{{ID: "a", Text: "a"}, {ID: "b", Text: "b"}},
+				CorrectOptionID: "a",
+			},
+			HostUserID: "host",
+			Difficulty: b.difficulty,
+			Tags:       b.tags,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		rules   []models.QuestionRule
+		wantErr string
+	}{
+		{
+			// Rule 1 can take two of the three questions rule 2 matches
+			name:    "overlap leaves a later rule short",
+			rules:   []models.QuestionRule{{Tag: "math", Count: 2}, {Tag: "math", MinDifficulty: 1, MaxDifficulty: 2, Count: 2}},
+			wantErr: "Rule 2 draws 2 questions but earlier rules can take all but 1 of the 3 bank questions that match it",
+		},
+		{
+			// Drawing the narrower rule first leaves the wider one two of its four
+			name:  "narrower rule first",
+			rules: []models.QuestionRule{{Tag: "math", MinDifficulty: 1, MaxDifficulty: 2, Count: 2}, {Tag: "math", Count: 2}},
+		},
+		{
+			// Rules 1 and 2 can each take b05, but only one of them can have it
+			name:  "earlier rules sharing a question",
+			rules: []models.QuestionRule{{Tag: "a", Count: 1}, {MinDifficulty: 1, MaxDifficulty: 1, Count: 1}, {Tag: "c", Count: 4}},
+		},
+		{
+			name:    "too few matches on its own",
+			rules:   []models.QuestionRule{{Tag: "c", Count: 6}},
+			wantErr: "Rule 1 draws 6 questions but only 5 bank questions match it",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz := &models.Quiz{QuizID: "quiz", HostUserID: "host", Rules: tt.rules}
+			pool, err := rulePool(ctx, store, quiz)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(pool) == 0 {
+					t.Error("got an empty pool")
+				}
+				return
+			}
+			var poolErr *PoolError
+			if !errors.As(err, &poolErr) {
+				t.Fatalf("got %v, want a *PoolError", err)
+			}
+			if poolErr.Message != tt.wantErr {
+				t.Errorf("got %q, want %q", poolErr.Message, tt.wantErr)
+			}
+		})
+	}
+}
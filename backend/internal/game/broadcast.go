@@ -18,13 +18,13 @@ import (
 // In local mode, it uses the gorilla/websocket Hub.
 // In production mode, it would use the API Gateway Management API.
 type Broadcaster struct {
-	DB  *db.Client
+	DB  db.Store
 	Hub *Hub // non-nil in local mode
 	Env string
 }
 
 // NewBroadcaster creates a new Broadcaster.
-func NewBroadcaster(dbClient *db.Client, env string) *Broadcaster {
+func NewBroadcaster(dbClient db.Store, env string) *Broadcaster {
 	return &Broadcaster{
 		DB:  dbClient,
 		Env: env,
@@ -89,6 +89,60 @@ func (b *Broadcaster) BroadcastToSession(ctx context.Context, sessionID string,
 	return nil
 }
 
+// personalizedSendWorkers bounds concurrent sends when every player gets their own payload.
+const personalizedSendWorkers = 64
+
+// SendPersonalized sends each player in a session their own message. build is called once per
+// player connection with the player's userId and returns the payload, or false to skip them.
+// Connections are listed once and payloads are marshalled and sent by a bounded worker pool,
+// so thousands of players cost one connection lookup rather than one per player.
+func (b *Broadcaster) SendPersonalized(ctx context.Context, sessionID string, build func(userID string) (models.WSOutbound, bool)) error {
+	observability.Debug(ctx, "sending personalized messages", "sessionId", sessionID)
+
+	// connectionID -> userID for every player in the session
+	var members map[string]string
+	if b.Env == "local" && b.Hub != nil {
+		members = b.Hub.SessionMembers(sessionID)
+	} else {
+		connections, err := b.DB.GetConnectionsBySession(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get connections: %w", err)
+		}
+		members = make(map[string]string, len(connections))
+		for _, conn := range connections {
+			if conn.Role == models.PlayerRolePlayer {
+				members[conn.ConnectionID] = conn.UserID
+			}
+		}
+	}
+
+	jobs := make(chan [2]string)
+	var wg sync.WaitGroup
+	for w := 0; w < personalizedSendWorkers && w < len(members); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				cid, userID := job[0], job[1]
+				payload, ok := build(userID)
+				if !ok {
+					continue
+				}
+				if sendErr := b.SendToConnection(ctx, cid, payload); sendErr != nil {
+					observability.Warn(ctx, "failed to send to connection", "connectionId", cid, "error", sendErr.Error())
+				}
+			}
+		}()
+	}
+	for cid, userID := range members {
+		jobs <- [2]string{cid, userID}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return nil
+}
+
 // SendToPlayer sends a message to a specific player in a session.
 func (b *Broadcaster) SendToPlayer(ctx context.Context, sessionID, userID string, payload models.WSOutbound) error {
 	conn, err := b.DB.GetConnectionByUserID(ctx, sessionID, userID)
@@ -111,6 +165,7 @@ type Hub struct {
 type Connection struct {
 	ID        string
 	SessionID string
+	UserID    string
 	Conn      *websocket.Conn
 	mu        sync.Mutex
 }
@@ -124,13 +179,14 @@ func NewHub() *Hub {
 }
 
 // Register adds a connection to the hub.
-func (h *Hub) Register(connectionID, sessionID string, conn *websocket.Conn) {
+func (h *Hub) Register(connectionID, sessionID, userID string, conn *websocket.Conn) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	h.connections[connectionID] = &Connection{
 		ID:        connectionID,
 		SessionID: sessionID,
+		UserID:    userID,
 		Conn:      conn,
 	}
 
@@ -163,6 +219,32 @@ func (h *Hub) Unregister(connectionID string) {
 	slog.Info("WS connection unregistered", "connectionId", connectionID, "sessionId", conn.SessionID)
 }
 
+// SessionMembers returns connectionId -> userId for every connection in a session.
+func (h *Hub) SessionMembers(sessionID string) map[string]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	members := make(map[string]string, len(h.sessions[sessionID]))
+	for id := range h.sessions[sessionID] {
+		if conn, ok := h.connections[id]; ok {
+			members[id] = conn.UserID
+		}
+	}
+	return members
+}
+
+// UserID returns the userId a connection registered with.
+func (h *Hub) UserID(connectionID string) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	conn, ok := h.connections[connectionID]
+	if !ok {
+		return "", false
+	}
+	return conn.UserID, true
+}
+
 // SendToConnection sends a message to a specific connection.
 func (h *Hub) SendToConnection(connectionID string, data []byte) error {
 	h.mu.RLock()
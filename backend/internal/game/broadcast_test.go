@@ -0,0 +1,109 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// localSocket connects a client to the engine's hub the way the local server's /ws handler
+// does, registering the connection in the hub and the store, and returns the client end.
+func localSocket(t *testing.T, engine *Engine, store db.Store, player models.Player) *websocket.Conn {
+	t.Helper()
+	registered := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		engine.Broadcaster.Hub.Register(player.ConnectionID, player.SessionID, player.UserID, conn)
+		close(registered)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	<-registered
+
+	if err := store.PutConnection(context.Background(), &player); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// readMessage reads from a client until a message of type typ arrives and decodes its payload.
+func readMessage(t *testing.T, conn *websocket.Conn, typ string, payload any) {
+	t.Helper()
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var msg struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for %s: %v", typ, err)
+		}
+		if msg.Type == typ {
+			if err := json.Unmarshal(msg.Payload, payload); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+}
+
+func TestStandingsArePersonal(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newTestEngine(t, "local")
+	engine.Broadcaster.SetHub(NewHub())
+
+	localSocket(t, engine, store, models.Player{SessionID: "s", ConnectionID: "host-c", UserID: "host", Role: models.PlayerRoleHost})
+	players := map[string]*websocket.Conn{
+		"c1": localSocket(t, engine, store, models.Player{SessionID: "s", ConnectionID: "c1", UserID: "u1", Role: models.PlayerRolePlayer}),
+		"c2": localSocket(t, engine, store, models.Player{SessionID: "s", ConnectionID: "c2", UserID: "u2", Role: models.PlayerRolePlayer}),
+	}
+	for cid := range players {
+		if err := engine.HandleJoinSession(ctx, cid, models.JoinSessionPayload{SessionID: "s", Nickname: cid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := engine.HandleStartGame(ctx, "host-c", models.StartGamePayload{SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both answer correctly; c1 is faster and earns more points
+	for cid, ms := range map[string]int64{"c1": 1000, "c2": 15000} {
+		err := engine.HandleSubmitAnswer(ctx, cid, models.SubmitAnswerPayload{QuestionID: "q1", SelectedOptionID: "a", TimeTakenMs: ms})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := engine.HandleNextQuestion(ctx, "host-c", models.NextQuestionPayload{SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second models.PlayerStandingPayload
+	readMessage(t, players["c1"], models.WSTypePlayerStanding, &first)
+	readMessage(t, players["c2"], models.WSTypePlayerStanding, &second)
+	if first.Rank != 1 || second.Rank != 2 {
+		t.Errorf("got ranks %d and %d, want 1 for c1 and 2 for c2", first.Rank, second.Rank)
+	}
+	if first.Score <= second.Score {
+		t.Errorf("got scores %v and %v, want c1 ahead", first.Score, second.Score)
+	}
+}
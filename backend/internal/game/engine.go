@@ -3,14 +3,16 @@ package game
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
-
+	"kahootclone/internal/auth"
 	"kahootclone/internal/cache"
 	"kahootclone/internal/db"
+	"kahootclone/internal/media"
 	"kahootclone/internal/models"
 	"kahootclone/internal/observability"
 )
@@ -27,18 +29,29 @@ const (
 	StateFinished       GameState = "FINISHED"
 )
 
+// nearbyPlayers is how many players above and below are included in a player's personal results.
+const nearbyPlayers = 2
+
 // Engine manages the game state machine.
 type Engine struct {
-	DB          *db.Client
-	Cache       *cache.RedisClient
+	DB          db.Store
+	Cache       cache.Leaderboard
+	Sessions    cache.SessionCache // optional; nil reads everything from DB
 	Broadcaster *Broadcaster
+
+	// Media signs the URLs of question media; nil sends questions without their media.
+	Media       media.BlobStore
+	MediaURLTTL time.Duration
+
+	touched sync.Map // sessionId -> time.Time this process last touched it, see touchSession
 }
 
 // NewEngine creates a new game engine.
-func NewEngine(dbClient *db.Client, cacheClient *cache.RedisClient, broadcaster *Broadcaster) *Engine {
+func NewEngine(dbClient db.Store, cacheClient cache.Leaderboard, sessionCache cache.SessionCache, broadcaster *Broadcaster) *Engine {
 	return &Engine{
 		DB:          dbClient,
 		Cache:       cacheClient,
+		Sessions:    sessionCache,
 		Broadcaster: broadcaster,
 	}
 }
@@ -77,12 +90,7 @@ func (e *Engine) HandleJoinSession(ctx context.Context, connectionID string, pay
 		return fmt.Errorf("session is full (max 2000 players)")
 	}
 
-	// Extract userId from context (set by auth middleware or WS auth)
-	claims := getClaimsFromContext(ctx)
-	userID := "anonymous"
-	if claims != nil {
-		userID = claims.UserID
-	}
+	userID := e.playerIdentity(ctx, connectionID)
 
 	// Register connection
 	player := &models.Player{
@@ -96,6 +104,8 @@ func (e *Engine) HandleJoinSession(ctx context.Context, connectionID string, pay
 	if err := e.DB.PutConnection(ctx, player); err != nil {
 		return fmt.Errorf("failed to register connection: %w", err)
 	}
+	e.cacheConnection(ctx, player)
+	e.touchSession(ctx, session)
 
 	// Initialize score in leaderboard
 	if err := e.Cache.UpsertScore(ctx, payload.SessionID, userID, 0); err != nil {
@@ -140,19 +150,17 @@ func (e *Engine) HandleStartGame(ctx context.Context, connectionID string, paylo
 		return fmt.Errorf("only the host can start the game")
 	}
 
-	// Get quiz
-	quiz, err := e.DB.GetQuiz(ctx, session.QuizID)
+	// Get the quiz snapshot this session plays
+	quiz, err := e.sessionQuiz(ctx, session)
 	if err != nil {
-		return fmt.Errorf("failed to get quiz: %w", err)
-	}
-	if quiz == nil {
-		return fmt.Errorf("quiz not found")
+		return err
 	}
 
 	// Update session status
 	if err := e.DB.UpdateSessionStatus(ctx, payload.SessionID, models.SessionStatusActive, 0); err != nil {
 		return fmt.Errorf("failed to update session status: %w", err)
 	}
+	e.invalidateSession(ctx, payload.SessionID)
 
 	// Broadcast game started
 	if err := e.Broadcaster.BroadcastToSession(ctx, payload.SessionID, models.WSOutbound{
@@ -172,13 +180,14 @@ func (e *Engine) HandleStartGame(ctx context.Context, connectionID string, paylo
 func (e *Engine) HandleSubmitAnswer(ctx context.Context, connectionID string, payload models.SubmitAnswerPayload) error {
 	observability.Info(ctx, "answer submitted", "connectionId", connectionID, "questionId", payload.QuestionID)
 
-	// Find the player's connection info
-	conn, err := e.DB.GetSessionByConnectionID(ctx, connectionID)
+	// Find the player's connection info. This is the hot path of a game, so the
+	// connection, session and quiz are read through the session cache.
+	conn, err := e.cachedConnection(ctx, connectionID)
 	if err != nil {
 		return fmt.Errorf("failed to find connection: %w", err)
 	}
 
-	session, err := e.DB.GetSession(ctx, conn.SessionID)
+	session, err := e.cachedSession(ctx, conn.SessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get session: %w", err)
 	}
@@ -186,19 +195,10 @@ func (e *Engine) HandleSubmitAnswer(ctx context.Context, connectionID string, pa
 		return fmt.Errorf("game is not active")
 	}
 
-	// Check if already answered
-	existing, err := e.DB.GetAnswer(ctx, conn.SessionID, conn.UserID, payload.QuestionID)
-	if err != nil {
-		return fmt.Errorf("failed to check existing answer: %w", err)
-	}
-	if existing != nil {
-		return fmt.Errorf("already answered this question")
-	}
-
 	// Get quiz for correct answer
-	quiz, err := e.DB.GetQuiz(ctx, session.QuizID)
+	quiz, err := e.sessionQuiz(ctx, session)
 	if err != nil {
-		return fmt.Errorf("failed to get quiz: %w", err)
+		return err
 	}
 
 	// Find the current question
@@ -216,7 +216,8 @@ func (e *Engine) HandleSubmitAnswer(ctx context.Context, connectionID string, pa
 	// Calculate score
 	isCorrect := payload.SelectedOptionID == question.CorrectOptionID
 	timeLimitMs := int64(question.TimeLimitSeconds * 1000)
-	pointsEarned := CalculateScore(isCorrect, payload.TimeTakenMs, timeLimitMs, question.Points)
+	timeTakenMs := ClampTimeTaken(payload.TimeTakenMs, timeLimitMs)
+	pointsEarned := CalculateScore(isCorrect, timeTakenMs, timeLimitMs, question.Points)
 
 	// Store answer
 	answer := &models.Answer{
@@ -226,24 +227,39 @@ func (e *Engine) HandleSubmitAnswer(ctx context.Context, connectionID string, pa
 		UserID:           conn.UserID,
 		SelectedOptionID: payload.SelectedOptionID,
 		IsCorrect:        isCorrect,
-		TimeTakenMs:      payload.TimeTakenMs,
+		TimeTakenMs:      timeTakenMs,
 		PointsEarned:     pointsEarned,
 		AnsweredAt:       time.Now().UTC(),
 	}
-	if err := e.DB.PutAnswer(ctx, answer); err != nil {
+	// The write is conditional, so a repeat or concurrent submission is rejected here
+	err = e.DB.PutAnswer(ctx, answer)
+	if errors.Is(err, db.ErrAlreadyAnswered) {
+		return fmt.Errorf("already answered this question")
+	}
+	if err != nil {
 		return fmt.Errorf("failed to store answer: %w", err)
 	}
+	e.touchSession(ctx, session)
 
-	// Update leaderboard
+	// Update leaderboard, rebuilding it from the answers table if Redis lost the session
 	if pointsEarned > 0 {
-		if err := e.Cache.IncrementScore(ctx, conn.SessionID, conn.UserID, float64(pointsEarned)); err != nil {
+		if err := e.countAnswer(ctx, conn.SessionID, conn.UserID, payload.QuestionID, pointsEarned, timeTakenMs); err != nil {
 			slog.Warn("failed to update leaderboard", "error", err.Error())
 		}
 	}
 
-	// Get updated rank and total score
-	rank, _ := e.Cache.GetPlayerRank(ctx, conn.SessionID, conn.UserID)
+	// Get updated rank, total score, movement since the last question and nearby players.
+	// Answers without points don't write the leaderboard, so a lost one shows up here.
+	rank, err := e.Cache.GetPlayerRank(ctx, conn.SessionID, conn.UserID)
+	if errors.Is(err, cache.ErrPlayerNotFound) {
+		if err := e.ensureLeaderboard(ctx, conn.SessionID); err != nil {
+			slog.Warn("failed to check leaderboard", "error", err.Error())
+		}
+		rank, _ = e.Cache.GetPlayerRank(ctx, conn.SessionID, conn.UserID)
+	}
 	totalScore, _ := e.Cache.GetPlayerScore(ctx, conn.SessionID, conn.UserID)
+	previousRank, _ := e.Cache.GetPreviousRank(ctx, conn.SessionID, conn.UserID)
+	nearby, _ := e.Cache.GetAroundPlayer(ctx, conn.SessionID, conn.UserID, nearbyPlayers)
 
 	// Send personal result to the player
 	return e.Broadcaster.SendToConnection(ctx, connectionID, models.WSOutbound{
@@ -253,6 +269,8 @@ func (e *Engine) HandleSubmitAnswer(ctx context.Context, connectionID string, pa
 			PointsEarned:  pointsEarned,
 			TotalScore:    int(totalScore),
 			Rank:          rank,
+			RankChange:    models.RankChange(previousRank, rank),
+			Nearby:        nearby,
 			CorrectOption: question.CorrectOptionID,
 		},
 	})
@@ -276,14 +294,17 @@ func (e *Engine) HandleNextQuestion(ctx context.Context, connectionID string, pa
 		return fmt.Errorf("failed to get session: %w", err)
 	}
 
-	quiz, err := e.DB.GetQuiz(ctx, session.QuizID)
+	quiz, err := e.sessionQuiz(ctx, session)
 	if err != nil {
-		return fmt.Errorf("failed to get quiz: %w", err)
+		return err
 	}
 
 	nextIndex := session.CurrentQuestionIndex + 1
 
 	// Send current question's end results first
+	if err := e.ensureLeaderboard(ctx, payload.SessionID); err != nil {
+		slog.Warn("failed to check leaderboard", "error", err.Error())
+	}
 	leaderboard, _ := e.Cache.GetTopN(ctx, payload.SessionID, 10)
 	currentQuestion := quiz.Questions[session.CurrentQuestionIndex]
 	_ = e.Broadcaster.BroadcastToSession(ctx, payload.SessionID, models.WSOutbound{
@@ -294,6 +315,11 @@ func (e *Engine) HandleNextQuestion(ctx context.Context, connectionID string, pa
 		},
 	})
 
+	// Tell every player where they stand and remember ranks for the next question's deltas
+	if err := e.sendStandings(ctx, payload.SessionID); err != nil {
+		slog.Warn("failed to send player standings", "error", err.Error())
+	}
+
 	if nextIndex >= len(quiz.Questions) {
 		return e.endGame(ctx, payload.SessionID)
 	}
@@ -302,6 +328,7 @@ func (e *Engine) HandleNextQuestion(ctx context.Context, connectionID string, pa
 	if err := e.DB.UpdateSessionStatus(ctx, payload.SessionID, models.SessionStatusActive, nextIndex); err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
+	e.invalidateSession(ctx, payload.SessionID)
 
 	return e.sendQuestion(ctx, payload.SessionID, quiz, nextIndex)
 }
@@ -371,30 +398,150 @@ func (e *Engine) HandleMessage(ctx context.Context, connectionID string, rawMess
 	}
 }
 
+// sendStandings sends each player their rank, rank change and nearby players at question close,
+// then snapshots the ranks. The full leaderboard is read once and sliced per player in memory.
+func (e *Engine) sendStandings(ctx context.Context, sessionID string) error {
+	count, err := e.Cache.GetPlayerCount(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get player count: %w", err)
+	}
+	if count == 0 {
+		return nil
+	}
+	leaderboard, err := e.Cache.GetTopN(ctx, sessionID, int(count))
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	previousRanks, err := e.Cache.GetPreviousRanks(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get previous ranks: %w", err)
+	}
+
+	index := make(map[string]int, len(leaderboard))
+	for i, p := range leaderboard {
+		index[p.UserID] = i
+	}
+
+	err = e.Broadcaster.SendPersonalized(ctx, sessionID, func(userID string) (models.WSOutbound, bool) {
+		i, ok := index[userID]
+		if !ok {
+			return models.WSOutbound{}, false
+		}
+		me := leaderboard[i]
+		return models.WSOutbound{
+			Type: models.WSTypePlayerStanding,
+			Payload: models.PlayerStandingPayload{
+				Rank:         me.Rank,
+				PreviousRank: previousRanks[userID],
+				RankChange:   models.RankChange(previousRanks[userID], me.Rank),
+				Score:        me.Score,
+				Nearby:       models.Nearby(leaderboard, i, nearbyPlayers),
+			},
+		}, true
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.Cache.SnapshotRanks(ctx, sessionID, leaderboard)
+}
+
 func (e *Engine) sendQuestion(ctx context.Context, sessionID string, quiz *models.Quiz, index int) error {
 	q := quiz.Questions[index]
 
+	// correctOptionId is NOT included in QuestionPayload
+	options := make([]models.OptionPayload, len(q.Options))
+	for i, o := range q.Options {
+		options[i] = models.OptionPayload{ID: o.ID, Text: o.Text, Media: e.mediaPayload(ctx, o.Media)}
+	}
 	return e.Broadcaster.BroadcastToSession(ctx, sessionID, models.WSOutbound{
 		Type: models.WSTypeQuestion,
 		Payload: models.QuestionPayload{
 			QuestionIndex:  index,
 			TotalQuestions: len(quiz.Questions),
 			Text:           q.Text,
-			Options:        q.Options, // correctOptionId is NOT included in QuestionPayload
+			Media:          e.mediaPayload(ctx, q.Media),
+			Options:        options,
 			TimeLimitMs:    q.TimeLimitSeconds * 1000,
 			Points:         q.Points,
 		},
 	})
 }
 
+// mediaPayload turns a media attachment into signed URLs players' devices can fetch. Media
+// that can't be signed is left out, with a warning, rather than holding up the question.
+func (e *Engine) mediaPayload(ctx context.Context, m *models.Media) *models.MediaPayload {
+	if m == nil {
+		return nil
+	}
+	p := &models.MediaPayload{
+		Type:         m.Type,
+		URL:          m.URL,
+		ContentType:  m.ContentType,
+		Alt:          m.Alt,
+		StartSeconds: m.StartSeconds,
+		EndSeconds:   m.EndSeconds,
+	}
+	if m.Type == models.MediaVideo {
+		return p
+	}
+	if e.Media == nil {
+		observability.Warn(ctx, "question media skipped: no media store is configured", "key", m.Key)
+		return nil
+	}
+	var err error
+	if p.URL, err = e.Media.SignedURL(ctx, m.Key, e.MediaURLTTL); err != nil {
+		observability.Warn(ctx, "failed to sign media URL", "key", m.Key, "error", err.Error())
+		return nil
+	}
+	if m.Type == models.MediaImage {
+		if p.ThumbnailURL, err = e.Media.SignedURL(ctx, media.ThumbnailKey(m.Key), e.MediaURLTTL); err != nil {
+			p.ThumbnailURL = ""
+		}
+	}
+	return p
+}
+
 func (e *Engine) endGame(ctx context.Context, sessionID string) error {
 	observability.Info(ctx, "ending game", "sessionId", sessionID)
 
+	session, err := e.DB.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return fmt.Errorf("session not found")
+	}
+
 	if err := e.DB.UpdateSessionStatus(ctx, sessionID, models.SessionStatusFinished, -1); err != nil {
 		return fmt.Errorf("failed to update session status: %w", err)
 	}
+	e.invalidateSession(ctx, sessionID)
+	e.touched.Delete(sessionID)
+
+	return e.finalizeSession(ctx, session)
+}
+
+// finalizeSession runs the post-game steps for a session already marked FINISHED:
+// it frees the PIN, persists the results, announces the final leaderboard and clears the Redis keys.
+func (e *Engine) finalizeSession(ctx context.Context, session *models.Session) error {
+	sessionID := session.SessionID
 
-	leaderboard, _ := e.Cache.GetTopN(ctx, sessionID, 100)
+	// Free the PIN for reuse now that the session is finished
+	if err := e.DB.ReleasePIN(ctx, session.PIN, sessionID); err != nil {
+		slog.Warn("failed to release session PIN", "sessionId", sessionID, "error", err.Error())
+	}
+
+	// Persist the full ranking before the Redis keys go away. If this fails the keys
+	// are left in place (they expire via TTL) so the result can still be recovered.
+	leaderboard, saveErr := e.saveSessionResult(ctx, session)
+	if saveErr != nil {
+		observability.Error(ctx, "failed to save session result", "sessionId", sessionID, "error", saveErr.Error())
+		leaderboard, _ = e.Cache.GetTopN(ctx, sessionID, 100)
+	}
+	if len(leaderboard) > 100 {
+		leaderboard = leaderboard[:100]
+	}
 
 	if err := e.Broadcaster.BroadcastToSession(ctx, sessionID, models.WSOutbound{
 		Type: models.WSTypeGameOver,
@@ -406,33 +553,30 @@ func (e *Engine) endGame(ctx context.Context, sessionID string) error {
 	}
 
 	// Clean up Redis (async-safe — if it fails, TTL will eventually clean it)
-	go func() {
-		_ = e.Cache.DeleteSession(context.Background(), sessionID)
-	}()
+	if saveErr == nil {
+		go func() {
+			_ = e.Cache.DeleteSession(context.Background(), sessionID)
+		}()
+	}
 
 	return nil
 }
 
-// getClaimsFromContext is a helper to pull auth claims from context.
-// This is set by the auth middleware or WebSocket authentication.
-func getClaimsFromContext(ctx context.Context) *Claims {
-	type claimsKey string
-	claims, _ := ctx.Value(claimsKey("userClaims")).(*Claims)
-	return claims
-}
-
-// Claims mirrors auth.Claims for use within the game package.
-type Claims struct {
-	UserID   string
-	Email    string
-	Username string
-	Role     string
-}
-
-// GenerateSessionPIN generates a random 6-digit PIN.
-func GenerateSessionPIN() string {
-	id := uuid.New()
-	// Use first 6 hex chars converted to digits
-	pin := fmt.Sprintf("%06d", int(id.ID())%1000000)
-	return pin
+// playerIdentity returns the userId of the player on a connection. The identity is fixed when
+// the socket connects: it comes from the connection record written at $connect, or from the
+// hub in local mode, and falls back to the auth claims in ctx. A connection with none of
+// these gets its own anonymous ID, so players who don't sign in never share a userId.
+func (e *Engine) playerIdentity(ctx context.Context, connectionID string) string {
+	if conn, err := e.DB.GetSessionByConnectionID(ctx, connectionID); err == nil && conn.UserID != "" {
+		return conn.UserID
+	}
+	if e.Broadcaster != nil && e.Broadcaster.Hub != nil {
+		if userID, ok := e.Broadcaster.Hub.UserID(connectionID); ok && userID != "" {
+			return userID
+		}
+	}
+	if claims := auth.GetClaims(ctx); claims != nil && claims.UserID != "" {
+		return claims.UserID
+	}
+	return models.NewAnonymousUserID()
 }
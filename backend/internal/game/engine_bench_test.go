@@ -0,0 +1,139 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kahootclone/internal/cache"
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// slowStore adds a fixed latency to the reads on the answer path and counts them, standing
+// in for a DynamoDB round trip.
+type slowStore struct {
+	db.Store
+	latency time.Duration
+	reads   atomic.Int64
+}
+
+func (s *slowStore) read() {
+	s.reads.Add(1)
+	time.Sleep(s.latency)
+}
+
+func (s *slowStore) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	s.read()
+	return s.Store.GetSession(ctx, sessionID)
+}
+
+func (s *slowStore) GetSessionByConnectionID(ctx context.Context, connectionID string) (*models.Player, error) {
+	s.read()
+	return s.Store.GetSessionByConnectionID(ctx, connectionID)
+}
+
+func (s *slowStore) GetQuiz(ctx context.Context, quizID string) (*models.Quiz, error) {
+	s.read()
+	return s.Store.GetQuiz(ctx, quizID)
+}
+
+func (s *slowStore) GetQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error) {
+	s.read()
+	return s.Store.GetQuizVersion(ctx, quizID, version)
+}
+
+func (s *slowStore) GetAnswer(ctx context.Context, sessionID, userID, questionID string) (*models.Answer, error) {
+	s.read()
+	return s.Store.GetAnswer(ctx, sessionID, userID, questionID)
+}
+
+const (
+	benchPlayers     = 2000
+	benchReadLatency = 2 * time.Millisecond
+)
+
+// BenchmarkSubmitAnswer measures the store reads and latency of one answer with 2000 players
+// in an active session and a memory store with 2ms reads. Answers go to the players in turn,
+// so each player answers once per question and later questions find their connection cached.
+func BenchmarkSubmitAnswer(b *testing.B) {
+	b.Run("no cache", func(b *testing.B) { benchmarkSubmitAnswer(b, false) })
+	b.Run("with cache", func(b *testing.B) { benchmarkSubmitAnswer(b, true) })
+}
+
+func benchmarkSubmitAnswer(b *testing.B, withCache bool) {
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	ctx := context.Background()
+	cfg := &config.Config{SessionTTL: time.Hour}
+	store := &slowStore{Store: db.NewMemoryStore(cfg), latency: benchReadLatency}
+
+	// One question per round of answers, so no player answers the same question twice
+	quiz := &models.Quiz{QuizID: "quiz", Title: "Bench", Version: 1}
+	for i := 0; i <= b.N/benchPlayers; i++ {
+		id := fmt.Sprintf("q%d", i)
+		quiz.Questions = append(quiz.Questions, models.Question{
+			QuestionID:       id,
+			Text:             id,
+			Options:          []models.Option{{ID: "a", Text: "a"}, {ID: "b", Text: "b"}},
+			CorrectOptionID:  "a",
+			TimeLimitSeconds: models.DefaultTimeLimitSeconds,
+			Points:           models.DefaultPoints,
+		})
+	}
+	if err := store.PutQuizVersion(ctx, quiz); err != nil {
+		b.Fatal(err)
+	}
+	session := &models.Session{
+		SessionID:   "session",
+		PIN:         "123456",
+		QuizID:      quiz.QuizID,
+		QuizVersion: quiz.Version,
+		Status:      models.SessionStatusActive,
+		CreatedAt:   time.Now().UTC(),
+		TTL:         time.Now().Add(time.Hour).Unix(),
+	}
+	if err := store.CreateSession(ctx, session); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < benchPlayers; i++ {
+		err := store.PutConnection(ctx, &models.Player{
+			SessionID:    session.SessionID,
+			ConnectionID: fmt.Sprintf("conn-%d", i),
+			UserID:       fmt.Sprintf("user-%d", i),
+			Nickname:     fmt.Sprintf("player %d", i),
+			Role:         models.PlayerRolePlayer,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	var sessions cache.SessionCache
+	if withCache {
+		sessions = cache.NewMemorySessionCache(cfg)
+	}
+	engine := NewEngine(store, cache.NewMemoryLeaderboard(cfg), sessions, NewBroadcaster(store, "bench"))
+	store.reads.Store(0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := engine.HandleSubmitAnswer(ctx, fmt.Sprintf("conn-%d", i%benchPlayers), models.SubmitAnswerPayload{
+			QuestionID:       fmt.Sprintf("q%d", i/benchPlayers),
+			SelectedOptionID: "a",
+			TimeTakenMs:      1000,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(store.reads.Load())/float64(b.N), "reads/answer")
+}
@@ -0,0 +1,137 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kahootclone/internal/cache"
+	"kahootclone/internal/config"
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// newTestEngine returns an engine on in-memory backends with a lobby session "s" playing quiz
+// "quiz", whose questions are q1 and q2 with correct option "a".
+func newTestEngine(t *testing.T, env string) (*Engine, *db.MemoryStore) {
+	t.Helper()
+	ctx := context.Background()
+	cfg := &config.Config{SessionTTL: time.Hour}
+	store := db.NewMemoryStore(cfg)
+
+	quiz := &models.Quiz{QuizID: "quiz", Title: "Test", Version: 1}
+	for _, id := range []string{"q1", "q2"} {
+		quiz.Questions = append(quiz.Questions, models.Question{
+			QuestionID:       id,
+			Text:             id,
+			Options:          []models.Option{{ID: "a", Text: "a"}, {ID: "b", Text: "b"}},
+			CorrectOptionID:  "a",
+			TimeLimitSeconds: models.DefaultTimeLimitSeconds,
+			Points:           models.DefaultPoints,
+		})
+	}
+	if err := store.PutQuizVersion(ctx, quiz); err != nil {
+		t.Fatal(err)
+	}
+	err := store.CreateSession(ctx, &models.Session{
+		SessionID:   "s",
+		PIN:         "123456",
+		QuizID:      quiz.QuizID,
+		QuizVersion: quiz.Version,
+		HostUserID:  "host",
+		Status:      models.SessionStatusLobby,
+		CreatedAt:   time.Now().UTC(),
+		TTL:         time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewEngine(store, cache.NewMemoryLeaderboard(cfg), cache.NewMemorySessionCache(cfg), NewBroadcaster(store, env))
+	return engine, store
+}
+
+func TestJoinKeepsIdentityFromConnect(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newTestEngine(t, "test")
+
+	// $connect recorded the signed-in user before the player sent join_session
+	err := store.PutConnection(ctx, &models.Player{SessionID: "s", ConnectionID: "c1", UserID: "user-1", Role: models.PlayerRolePlayer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.HandleJoinSession(ctx, "c1", models.JoinSessionPayload{SessionID: "s", Nickname: "One"}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := store.GetSessionByConnectionID(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.UserID != "user-1" || conn.Nickname != "One" {
+		t.Errorf("got connection %+v, want user-1 named One", conn)
+	}
+	top, _ := engine.Cache.GetTopN(ctx, "s", 0)
+	if len(top) != 1 || top[0].UserID != "user-1" {
+		t.Errorf("got leaderboard %+v, want user-1 only", top)
+	}
+}
+
+func TestAnonymousPlayersGetDistinctIdentities(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newTestEngine(t, "test")
+
+	for _, cid := range []string{"c1", "c2"} {
+		if err := engine.HandleJoinSession(ctx, cid, models.JoinSessionPayload{SessionID: "s", Nickname: cid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, _ := store.GetSessionByConnectionID(ctx, "c1")
+	b, _ := store.GetSessionByConnectionID(ctx, "c2")
+	if !models.IsAnonymous(a.UserID) || !models.IsAnonymous(b.UserID) {
+		t.Errorf("got userIds %q and %q, want anonymous IDs", a.UserID, b.UserID)
+	}
+	if a.UserID == b.UserID {
+		t.Errorf("both players got userId %q", a.UserID)
+	}
+	if count, _ := engine.Cache.GetPlayerCount(ctx, "s"); count != 2 {
+		t.Errorf("got %d players on the leaderboard, want 2", count)
+	}
+}
+
+// touchCounter counts the activity writes the engine asks the store for.
+type touchCounter struct {
+	db.Store
+	touches int
+}
+
+func (s *touchCounter) TouchSession(ctx context.Context, sessionID string, before time.Time) error {
+	s.touches++
+	return s.Store.TouchSession(ctx, sessionID, before)
+}
+
+func TestJoinsTouchSessionAtMostOncePerInterval(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newTestEngine(t, "test")
+	counter := &touchCounter{Store: store}
+	engine.DB = counter
+
+	for _, cid := range []string{"c1", "c2", "c3"} {
+		if err := engine.HandleJoinSession(ctx, cid, models.JoinSessionPayload{SessionID: "s", Nickname: cid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counter.touches != 1 {
+		t.Errorf("got %d touches for three joins, want 1", counter.touches)
+	}
+
+	// Once the interval has passed, the next join records activity again
+	engine.touched.Store("s", time.Now().Add(-sessionTouchInterval))
+	if err := engine.HandleJoinSession(ctx, "c4", models.JoinSessionPayload{SessionID: "s", Nickname: "c4"}); err != nil {
+		t.Fatal(err)
+	}
+	if counter.touches != 2 {
+		t.Errorf("got %d touches after the interval, want 2", counter.touches)
+	}
+}
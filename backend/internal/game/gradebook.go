@@ -0,0 +1,102 @@
+package game
+
+import (
+	"context"
+	"fmt"
+
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// SessionGradebook returns the gradebook of a finished session. The quiz and the persisted
+// rankings are read here; the rows are built from the session's answers as the gradebook is
+// written, see gradebookRows. Returns ErrQuizNotFound if the quiz the session played no
+// longer exists.
+func SessionGradebook(ctx context.Context, store db.Store, session *models.Session) (*models.Gradebook, error) {
+	quiz, err := playedQuiz(ctx, store, session)
+	if err != nil {
+		return nil, err
+	}
+	players, err := finalScores(ctx, store, session.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &models.Gradebook{
+		SessionID: session.SessionID,
+		QuizTitle: quiz.Title,
+		EndedAt:   session.EndedAt,
+		Questions: make([]models.GradebookQuestion, len(quiz.Questions)),
+	}
+	column := make(map[string]int, len(quiz.Questions))
+	for i, q := range quiz.Questions {
+		g.Questions[i] = models.GradebookQuestion{QuestionID: q.QuestionID, Text: q.Text, Points: q.Points}
+		column[q.QuestionID] = i
+	}
+	g.EachRow = func(fn func(models.GradebookRow) error) error {
+		return gradebookRows(ctx, store, session.SessionID, column, players, fn)
+	}
+	return g, nil
+}
+
+// gradebookRows calls fn with a row per player, built while the session's answers are read.
+// Answers come ordered by userId, so a player's row is complete, and handed to fn, when the
+// next player's first answer arrives; only one row is held at a time. Rows are therefore in
+// userId order, with the rank column giving the standings. Players who answered but aren't
+// ranked have no rank; ranked players who never answered come last, in rank order.
+func gradebookRows(ctx context.Context, store db.AnswerRepository, sessionID string, column map[string]int, players []models.PlayerScore, fn func(models.GradebookRow) error) error {
+	ranked := make(map[string]int, len(players))
+	for i, p := range players {
+		ranked[p.UserID] = i
+	}
+	written := make([]bool, len(players))
+
+	row := models.GradebookRow{Cells: make([]models.GradeCell, len(column))}
+	current := ""
+	flush := func() error {
+		if current == "" {
+			return nil
+		}
+		row.Player = models.PlayerScore{UserID: current}
+		if i, ok := ranked[current]; ok {
+			row.Player = players[i]
+			written[i] = true
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+		clear(row.Cells)
+		return nil
+	}
+
+	err := store.EachAnswerBySession(ctx, sessionID, func(a models.Answer) error {
+		j, ok := column[a.QuestionID]
+		if !ok {
+			return nil
+		}
+		if a.UserID != current {
+			if err := flush(); err != nil {
+				return err
+			}
+			current = a.UserID
+		}
+		row.Cells[j] = models.GradeCell{Answered: true, Correct: a.IsCorrect, Points: int32(a.PointsEarned)}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write gradebook rows: %w", err)
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	for i, p := range players {
+		if written[i] {
+			continue
+		}
+		if err := fn(models.GradebookRow{Player: p, Cells: row.Cells}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
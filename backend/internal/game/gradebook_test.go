@@ -0,0 +1,90 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kahootclone/internal/models"
+)
+
+func TestSessionGradebookStreamsRows(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newTestEngine(t, "test")
+	session, _ := store.GetSession(ctx, "s")
+
+	// b is ranked first, a second; c answered but isn't ranked; d is ranked but never answered
+	rankings := []models.PlayerResult{
+		{SessionID: "s", UserID: "b", Nickname: "Bea", Score: 1900, Rank: 1},
+		{SessionID: "s", UserID: "a", Nickname: "Al", Score: 900, Rank: 2},
+		{SessionID: "s", UserID: "d", Nickname: "Dee", Rank: 3},
+	}
+	result := &models.SessionResult{SessionID: "s", QuizID: session.QuizID, QuizVersion: session.QuizVersion, EndedAt: time.Now().UTC()}
+	if err := store.PutSessionResult(ctx, result, rankings); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []struct {
+		userID, questionID string
+		correct            bool
+		points             int
+	}{
+		{"a", "q2", true, 900},
+		{"b", "q1", true, 1000},
+		{"b", "q2", true, 900},
+		{"c", "q1", false, 0},
+		{"c", "gone", true, 1000}, // a question no longer in the quiz
+	} {
+		err := store.PutAnswer(ctx, &models.Answer{
+			SessionID:        "s",
+			UserIDQuestionID: a.userID + "#" + a.questionID,
+			QuestionID:       a.questionID,
+			UserID:           a.userID,
+			IsCorrect:        a.correct,
+			PointsEarned:     a.points,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := SessionGradebook(ctx, engine.DB, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Questions) != 2 {
+		t.Fatalf("got %d questions, want 2", len(g.Questions))
+	}
+
+	type line struct {
+		userID string
+		rank   int64
+		cells  [2]models.GradeCell
+	}
+	var got []line
+	err = g.EachRow(func(row models.GradebookRow) error {
+		got = append(got, line{row.Player.UserID, row.Player.Rank, [2]models.GradeCell(row.Cells)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answered := func(correct bool, points int32) models.GradeCell {
+		return models.GradeCell{Answered: true, Correct: correct, Points: points}
+	}
+	// Answerers in userId order, then ranked players without answers
+	want := []line{
+		{"a", 2, [2]models.GradeCell{{}, answered(true, 900)}},
+		{"b", 1, [2]models.GradeCell{answered(true, 1000), answered(true, 900)}},
+		{"c", 0, [2]models.GradeCell{answered(false, 0), {}}},
+		{"d", 3, [2]models.GradeCell{}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got rows %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
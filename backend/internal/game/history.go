@@ -0,0 +1,187 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// Defaults and bounds for a player's session history. Each entry takes a few reads, so
+// pages are smaller than other lists'.
+const (
+	DefaultHistoryLimit = 20
+	MaxHistoryLimit     = 50
+)
+
+var (
+	// ErrNotPlayed is returned by ReviewSession when the player neither answered in nor
+	// finished the session.
+	ErrNotPlayed = errors.New("player did not play in this session")
+	// ErrSessionNotFinished is returned by ReviewSession until the session's results are saved.
+	ErrSessionNotFinished = errors.New("session has not finished")
+)
+
+// ParseHistoryLimit parses the limit query parameter of a history request.
+// An empty limit means DefaultHistoryLimit.
+func ParseHistoryLimit(limitParam string) (int, error) {
+	return parseLimit(limitParam, DefaultHistoryLimit, MaxHistoryLimit)
+}
+
+// PlayerHistory returns up to limit sessions an authenticated player answered in, most
+// recent first, starting at cursor, and the cursor of the next page ("" on the last page).
+// History lasts as long as the player's answers, i.e. ANSWER_RETENTION. Sessions that
+// neither finished nor still exist are left out, so a page may be short. Returns
+// db.ErrInvalidCursor for a cursor that was not issued by this endpoint.
+func PlayerHistory(ctx context.Context, store db.Store, userID string, limit int, cursor string) ([]models.PlayedSession, string, error) {
+	sessionIDs, next, err := store.ListAnsweredSessions(ctx, userID, limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	history := make([]models.PlayedSession, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		played, _, err := playedSession(ctx, store, sessionID, userID)
+		if err != nil {
+			return nil, "", err
+		}
+		if played != nil {
+			history = append(history, *played)
+		}
+	}
+	return history, next, nil
+}
+
+// ReviewSession returns a finished session as the player played it: every question of the
+// quiz with the option they chose and the correct one. Returns ErrSessionNotFinished before
+// the session's results are saved and ErrNotPlayed if the player took no part in it.
+func ReviewSession(ctx context.Context, store db.Store, sessionID, userID string) (*models.SessionReview, error) {
+	played, result, err := playedSession(ctx, store, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if played == nil || (played.Rank == 0 && played.AnsweredCount == 0) {
+		return nil, ErrNotPlayed
+	}
+	if result == nil {
+		return nil, ErrSessionNotFinished
+	}
+	answers, err := store.GetAnswersByUser(ctx, sessionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+	byQuestion := make(map[string]models.Answer, len(answers))
+	for _, a := range answers {
+		byQuestion[a.QuestionID] = a
+	}
+
+	review := &models.SessionReview{PlayedSession: *played}
+	quiz, err := playedQuiz(ctx, store, &models.Session{QuizID: result.QuizID, QuizVersion: result.QuizVersion, QuizSeed: result.QuizSeed})
+	switch {
+	case err == nil:
+		for i, q := range quiz.Questions {
+			rq := models.ReviewedQuestion{
+				QuestionID:      q.QuestionID,
+				QuestionIndex:   i,
+				Text:            q.Text,
+				Options:         make([]models.OptionPayload, len(q.Options)),
+				CorrectOptionID: q.CorrectOptionID,
+			}
+			for j, o := range q.Options {
+				rq.Options[j] = models.OptionPayload{ID: o.ID, Text: o.Text}
+			}
+			review.Questions = append(review.Questions, reviewAnswer(rq, byQuestion))
+		}
+	case errors.Is(err, ErrQuizNotFound):
+		// The quiz was deleted; the result summary still has each question's text and answer
+		for _, q := range result.Questions {
+			rq := models.ReviewedQuestion{
+				QuestionID:      q.QuestionID,
+				QuestionIndex:   q.QuestionIndex,
+				Text:            q.Text,
+				CorrectOptionID: q.CorrectOptionID,
+			}
+			review.Questions = append(review.Questions, reviewAnswer(rq, byQuestion))
+		}
+	default:
+		return nil, err
+	}
+	return review, nil
+}
+
+// reviewAnswer fills in the player's answer to a reviewed question, if they gave one.
+func reviewAnswer(rq models.ReviewedQuestion, byQuestion map[string]models.Answer) models.ReviewedQuestion {
+	if a, ok := byQuestion[rq.QuestionID]; ok {
+		rq.SelectedOptionID = a.SelectedOptionID
+		rq.IsCorrect = a.IsCorrect
+		rq.PointsEarned = a.PointsEarned
+		rq.TimeTakenMs = a.TimeTakenMs
+	}
+	return rq
+}
+
+// playedSession builds a player's history entry for a session, and returns the session's
+// saved result if it has finished. Finished sessions take the entry from the player's
+// standing; live ones, and players missing from the standings, from their answers. Returns
+// nil if the session neither finished nor still exists.
+func playedSession(ctx context.Context, store db.Store, sessionID, userID string) (*models.PlayedSession, *models.SessionResult, error) {
+	result, err := store.GetSessionResult(ctx, sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session result: %w", err)
+	}
+
+	played := &models.PlayedSession{SessionID: sessionID}
+	if result != nil {
+		endedAt := result.EndedAt
+		played.QuizID = result.QuizID
+		played.QuizTitle = result.QuizTitle
+		played.Status = models.SessionStatusFinished
+		played.EndedAt = &endedAt
+		played.PlayerCount = result.PlayerCount
+
+		standing, err := store.GetPlayerResult(ctx, sessionID, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get player result: %w", err)
+		}
+		if standing != nil {
+			played.Nickname = standing.Nickname
+			played.Rank = standing.Rank
+			played.Score = standing.Score
+			played.AnsweredCount = standing.AnsweredCount
+			played.CorrectCount = standing.CorrectCount
+			return played, result, nil
+		}
+	} else {
+		session, err := store.GetSession(ctx, sessionID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get session: %w", err)
+		}
+		if session == nil {
+			return nil, nil, nil
+		}
+		played.QuizID = session.QuizID
+		played.Status = session.Status
+		played.EndedAt = session.EndedAt
+		quiz, err := playedQuiz(ctx, store, session)
+		if err != nil && !errors.Is(err, ErrQuizNotFound) {
+			return nil, nil, err
+		}
+		if quiz != nil {
+			played.QuizTitle = quiz.Title
+		}
+	}
+
+	answers, err := store.GetAnswersByUser(ctx, sessionID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+	for _, a := range answers {
+		played.AnsweredCount++
+		played.Score += float64(a.PointsEarned)
+		if a.IsCorrect {
+			played.CorrectCount++
+		}
+	}
+	return played, result, nil
+}
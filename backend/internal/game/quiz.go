@@ -0,0 +1,96 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// ErrQuizNotFound is returned by sessionQuiz and the session reports when neither the snapshot nor
+// the quiz exists.
+var ErrQuizNotFound = errors.New("quiz not found")
+
+// SnapshotQuiz stores the quiz's current version as an immutable snapshot for a new session
+// to play, and returns the quiz as snapshotted. Quizzes created before versioning are first
+// given version 1, so every session played from now on records a non-zero QuizVersion.
+// Questions linked to the question bank are refreshed first; if a bank question changed
+// since the quiz was saved, the quiz is saved again with the new copy, so the snapshot gets
+// a version of its own and sessions already played keep the questions they were asked.
+// The bank questions the quiz's rules can draw are frozen into the snapshot's Pool the same
+// way: a version is snapshotted again under a new number when its pool changes. Returns a
+// *PoolError if the rules can't be drawn, and nil if the quiz was deleted in the meantime.
+func SnapshotQuiz(ctx context.Context, store db.Store, quiz *models.Quiz) (*models.Quiz, error) {
+	var pool []models.PoolQuestion
+	for attempt := 1; ; attempt++ {
+		updated := *quiz
+		updated.Questions = append([]models.Question(nil), quiz.Questions...)
+		if err := LinkBankQuestions(ctx, store, quiz.HostUserID, updated.Questions); err != nil {
+			return nil, err
+		}
+		var err error
+		if pool, err = rulePool(ctx, store, &updated); err != nil {
+			return nil, err
+		}
+
+		changed := quiz.Version == 0 || !models.SameQuestions(quiz.Questions, updated.Questions)
+		if !changed && len(quiz.Rules) > 0 {
+			taken, err := store.GetQuizVersion(ctx, quiz.QuizID, quiz.Version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get quiz version: %w", err)
+			}
+			changed = taken != nil && !models.SamePool(taken.Pool, pool)
+		}
+		if !changed {
+			break
+		}
+
+		err = store.UpdateQuiz(ctx, &updated, quiz.Version)
+		if err == nil {
+			quiz = &updated
+			break
+		}
+		if !errors.Is(err, db.ErrVersionConflict) || attempt == 3 {
+			return nil, fmt.Errorf("failed to version quiz: %w", err)
+		}
+		// Someone else versioned or edited it first; start again from what they wrote
+		current, err := store.GetQuiz(ctx, quiz.QuizID)
+		if err != nil || current == nil {
+			return nil, err
+		}
+		quiz = current
+	}
+
+	snapshot := *quiz
+	snapshot.Pool = pool
+	if err := store.PutQuizVersion(ctx, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot quiz: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// sessionQuiz returns the quiz a session plays: the snapshot taken when it was created, so
+// edits made mid-game don't change questions or correct answers under players. Sessions
+// created before snapshots fall back to the live quiz, which is never cached since it can
+// change.
+func (e *Engine) sessionQuiz(ctx context.Context, session *models.Session) (*models.Quiz, error) {
+	var quiz *models.Quiz
+	var err error
+	if session.QuizVersion > 0 {
+		quiz, err = e.cachedQuizVersion(ctx, session.QuizID, session.QuizVersion)
+	} else {
+		quiz, err = e.DB.GetQuiz(ctx, session.QuizID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz: %w", err)
+	}
+	if quiz == nil {
+		return nil, ErrQuizNotFound
+	}
+	if len(quiz.Rules) > 0 {
+		quiz = quiz.Drawn(session.QuizSeed)
+	}
+	return quiz, nil
+}
@@ -0,0 +1,96 @@
+package game
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// sessionTouchInterval is how often joins and answers record activity on a session. It only
+// needs to be well under the idle timeout, and it keeps a busy game from writing the session
+// on every answer.
+const sessionTouchInterval = time.Minute
+
+// touchSession records player activity so the reaper spares a session that joins or answers
+// keep alive although the host hasn't changed its state. Each process touches a session at
+// most once per sessionTouchInterval, and the store skips the write if another process did.
+func (e *Engine) touchSession(ctx context.Context, session *models.Session) {
+	now := time.Now()
+	if last, ok := e.touched.Load(session.SessionID); ok && now.Sub(last.(time.Time)) < sessionTouchInterval {
+		return
+	}
+	e.touched.Store(session.SessionID, now)
+	if err := e.DB.TouchSession(ctx, session.SessionID, now.Add(-sessionTouchInterval)); err != nil {
+		slog.Warn("failed to record session activity", "sessionId", session.SessionID, "error", err.Error())
+	}
+}
+
+// ReapIdleSessions finalizes LOBBY and ACTIVE sessions that have had no activity for
+// longer than idleTimeout: each is marked FINISHED, its PIN released and its Redis keys removed.
+// Returns the number of sessions reaped.
+func (e *Engine) ReapIdleSessions(ctx context.Context, idleTimeout time.Duration) (int, error) {
+	cutoff := time.Now().Add(-idleTimeout)
+	reaped := 0
+
+	for _, status := range []models.SessionStatus{models.SessionStatusLobby, models.SessionStatusActive} {
+		sessions, err := e.DB.ListIdleSessions(ctx, status, cutoff)
+		if err != nil {
+			return reaped, err
+		}
+
+		for i := range sessions {
+			session := &sessions[i]
+
+			// Conditional update: skip sessions that became active again since the query
+			finished, err := e.DB.FinishIdleSession(ctx, session.SessionID, cutoff)
+			if err != nil {
+				slog.Warn("failed to finish idle session", "sessionId", session.SessionID, "error", err.Error())
+				continue
+			}
+			if !finished {
+				continue
+			}
+			e.invalidateSession(ctx, session.SessionID)
+			e.touched.Delete(session.SessionID)
+
+			observability.Info(ctx, "reaped idle session",
+				"sessionId", session.SessionID,
+				"status", status,
+				"lastActivityAt", time.Unix(session.LastActivityAt, 0).UTC().Format(time.RFC3339),
+			)
+
+			if err := e.finalizeSession(ctx, session); err != nil {
+				slog.Warn("failed to finalize reaped session", "sessionId", session.SessionID, "error", err.Error())
+			}
+			reaped++
+		}
+	}
+
+	return reaped, nil
+}
+
+// RunReaper calls ReapIdleSessions every interval until ctx is cancelled.
+// Used by the local server; in AWS the reap_sessions Lambda runs on a schedule instead.
+func (e *Engine) RunReaper(ctx context.Context, interval, idleTimeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := e.ReapIdleSessions(ctx, idleTimeout)
+			if err != nil {
+				slog.Warn("idle session reaper failed", "error", err.Error())
+				continue
+			}
+			if count > 0 {
+				slog.Info("idle session reaper finished", "reaped", count)
+			}
+		}
+	}
+}
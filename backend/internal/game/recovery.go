@@ -0,0 +1,179 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"kahootclone/internal/cache"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// ErrRebuildInProgress is returned when another rebuild of the same leaderboard is running.
+var ErrRebuildInProgress = errors.New("leaderboard rebuild already in progress")
+
+// RebuildLeaderboard recomputes every player's score from the answers table and replaces
+// the session's Redis leaderboard and nickname hash with the result. DynamoDB is the source
+// of truth, so a rebuild is always safe to repeat. Answers submitted while the rebuild holds
+// the lock are queued rather than counted, and added afterwards if the read missed them.
+// Returns the leaderboard as rebuilt from the read.
+func (e *Engine) RebuildLeaderboard(ctx context.Context, sessionID string) ([]models.PlayerScore, error) {
+	token, err := e.Cache.AcquireRebuildLock(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire rebuild lock: %w", err)
+	}
+	if token == "" {
+		return nil, ErrRebuildInProgress
+	}
+	finished := false
+	defer func() {
+		if !finished {
+			_ = e.Cache.ReleaseRebuildLock(context.Background(), sessionID, token)
+		}
+	}()
+
+	observability.Info(ctx, "rebuilding leaderboard from answers", "sessionId", sessionID)
+
+	answers, err := e.DB.GetAnswersBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+	connections, err := e.DB.GetConnectionsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connections: %w", err)
+	}
+
+	scores := ScoresFromAnswers(answers, connections, e.Cache.Mode())
+
+	if err := e.Cache.ReplaceSession(ctx, sessionID, scores); err != nil {
+		return nil, fmt.Errorf("failed to write rebuilt leaderboard: %w", err)
+	}
+
+	counted := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		counted[a.UserID+"#"+a.QuestionID] = true
+	}
+	late, err := e.countQueuedAnswers(ctx, sessionID, token, counted)
+	if err != nil {
+		// The leaderboard may lack queued answers; dropping it makes the next answer rebuild it
+		_ = e.Cache.DeleteSession(context.Background(), sessionID)
+		return nil, fmt.Errorf("failed to count answers submitted during the rebuild: %w", err)
+	}
+	finished = true
+
+	observability.Info(ctx, "leaderboard rebuilt",
+		"sessionId", sessionID,
+		"players", len(scores),
+		"answers", len(answers),
+		"lateAnswers", late,
+	)
+	return scores, nil
+}
+
+// countQueuedAnswers finishes a rebuild, adding the answers queued while it held the lock
+// that its read of the answers table missed. Answers already counted are skipped, so each
+// is added once. Returns the number of answers added.
+func (e *Engine) countQueuedAnswers(ctx context.Context, sessionID, token string, counted map[string]bool) (int, error) {
+	added := 0
+	for {
+		pending, err := e.Cache.FinishRebuild(ctx, sessionID, token)
+		if err != nil || len(pending) == 0 {
+			return added, err
+		}
+		for _, p := range pending {
+			key := p.UserID + "#" + p.QuestionID
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+
+			// The answer was stored before it was queued, so it is there to read
+			a, err := e.DB.GetAnswer(ctx, sessionID, p.UserID, p.QuestionID)
+			if err != nil {
+				return added, err
+			}
+			if a == nil || a.PointsEarned <= 0 {
+				continue
+			}
+			if err := e.Cache.IncrementScore(ctx, sessionID, a.UserID, float64(a.PointsEarned), a.TimeTakenMs); err != nil {
+				return added, err
+			}
+			added++
+		}
+	}
+}
+
+// ScoresFromAnswers computes a ranked leaderboard from stored answers. Connected players
+// who never answered are included with zero points; nicknames come from the connections.
+// Only correct answers add to a player's cumulative time, matching the live leaderboard,
+// and ties are ordered and ranked exactly as the Redis leaderboard does.
+func ScoresFromAnswers(answers []models.Answer, connections []models.Player, mode models.RankMode) []models.PlayerScore {
+	byUser := make(map[string]*models.PlayerScore)
+	player := func(userID string) *models.PlayerScore {
+		p, ok := byUser[userID]
+		if !ok {
+			p = &models.PlayerScore{UserID: userID}
+			byUser[userID] = p
+		}
+		return p
+	}
+
+	for _, c := range connections {
+		if c.Role != models.PlayerRolePlayer {
+			continue
+		}
+		// Players who joined but never answered still appear with zero points
+		p := player(c.UserID)
+		if c.Nickname != "" {
+			p.Nickname = c.Nickname
+		}
+	}
+	for _, a := range answers {
+		p := player(a.UserID)
+		if a.PointsEarned > 0 {
+			p.Score += float64(a.PointsEarned)
+			p.TotalTimeMs += a.TimeTakenMs
+		}
+	}
+
+	scores := make([]models.PlayerScore, 0, len(byUser))
+	for _, p := range byUser {
+		scores = append(scores, *p)
+	}
+	models.SortAndRank(scores, mode)
+	return scores
+}
+
+// ensureLeaderboard rebuilds a session's leaderboard if its Redis keys have been lost. A
+// rebuild already running elsewhere is left to finish.
+func (e *Engine) ensureLeaderboard(ctx context.Context, sessionID string) error {
+	exists, err := e.Cache.SessionExists(ctx, sessionID)
+	if err != nil || exists {
+		return err
+	}
+
+	slog.Warn("leaderboard missing from Redis mid-game, rebuilding", "sessionId", sessionID)
+	if _, err := e.RebuildLeaderboard(ctx, sessionID); err != nil && !errors.Is(err, ErrRebuildInProgress) {
+		return err
+	}
+	return nil
+}
+
+// countAnswer adds a stored answer's points to the leaderboard. If the leaderboard has been
+// lost, it is rebuilt from the answers table instead, which already holds the answer. The
+// leaderboard was found missing with no rebuild running, so a rebuild that has taken the lock
+// since reads the answer too, and is left to count it.
+func (e *Engine) countAnswer(ctx context.Context, sessionID, userID, questionID string, points int, timeMs int64) error {
+	err := e.Cache.CountAnswer(ctx, sessionID, userID, questionID, float64(points), timeMs)
+	if !errors.Is(err, cache.ErrSessionMissing) {
+		return err
+	}
+
+	slog.Warn("leaderboard missing from Redis mid-game, rebuilding", "sessionId", sessionID)
+	if _, err := e.RebuildLeaderboard(ctx, sessionID); err != nil && !errors.Is(err, ErrRebuildInProgress) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"context"
+	"testing"
+
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// afterReadStore runs afterRead once, right after the next read of a session's answers.
+type afterReadStore struct {
+	db.Store
+	afterRead func()
+}
+
+func (s *afterReadStore) GetAnswersBySession(ctx context.Context, sessionID string) ([]models.Answer, error) {
+	answers, err := s.Store.GetAnswersBySession(ctx, sessionID)
+	if hook := s.afterRead; hook != nil {
+		s.afterRead = nil
+		hook()
+	}
+	return answers, err
+}
+
+func TestAnswerDuringRebuildIsCounted(t *testing.T) {
+	ctx := context.Background()
+	engine, store := newTestEngine(t, "test")
+	hooked := &afterReadStore{Store: store}
+	engine.DB = hooked
+
+	if err := store.PutConnection(ctx, &models.Player{SessionID: "s", ConnectionID: "host-c", UserID: "host", Role: models.PlayerRoleHost}); err != nil {
+		t.Fatal(err)
+	}
+	for _, cid := range []string{"c1", "c2"} {
+		if err := engine.HandleJoinSession(ctx, cid, models.JoinSessionPayload{SessionID: "s", Nickname: cid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := engine.HandleStartGame(ctx, "host-c", models.StartGamePayload{SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The leaderboard is lost; c1's answer rebuilds it, and c2 answers after the rebuild has
+	// read the answers but before it has written the leaderboard
+	if err := engine.Cache.DeleteSession(ctx, "s"); err != nil {
+		t.Fatal(err)
+	}
+	hooked.afterRead = func() {
+		err := engine.HandleSubmitAnswer(ctx, "c2", models.SubmitAnswerPayload{QuestionID: "q1", SelectedOptionID: "a", TimeTakenMs: 2000})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	err := engine.HandleSubmitAnswer(ctx, "c1", models.SubmitAnswerPayload{QuestionID: "q1", SelectedOptionID: "a", TimeTakenMs: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every stored answer is on the leaderboard exactly once
+	answers, _ := store.GetAnswersBySession(ctx, "s")
+	connections, _ := store.GetConnectionsBySession(ctx, "s")
+	want := ScoresFromAnswers(answers, connections, engine.Cache.Mode())
+	got, _ := engine.Cache.GetTopN(ctx, "s", 0)
+	if len(got) != len(want) {
+		t.Fatalf("got leaderboard %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].UserID != want[i].UserID || got[i].Score != want[i].Score || got[i].Rank != want[i].Rank {
+			t.Errorf("position %d: got %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+	for _, p := range got {
+		if p.Score == 0 {
+			t.Errorf("%s has no points, want both answers counted", p.UserID)
+		}
+	}
+}
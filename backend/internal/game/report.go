@@ -0,0 +1,263 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+)
+
+// SessionReport loads a session's answers and players and builds its post-game report.
+// Finished sessions take their players from the persisted rankings and stream their answers
+// through the report; live ones rank the players from their answers using mode. Returns
+// ErrQuizNotFound if the quiz the session played no longer exists.
+func SessionReport(ctx context.Context, store db.Store, session *models.Session, mode models.RankMode) (*models.SessionReport, error) {
+	quiz, err := playedQuiz(ctx, store, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status != models.SessionStatusFinished {
+		answers, err := store.GetAnswersBySession(ctx, session.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get answers: %w", err)
+		}
+		connections, err := store.GetConnectionsBySession(ctx, session.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get connections: %w", err)
+		}
+		return BuildReport(session, quiz, answers, ScoresFromAnswers(answers, connections, mode)), nil
+	}
+
+	players, err := finalScores(ctx, store, session.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	b := newReportBuilder(session, quiz)
+	err = store.EachAnswerBySession(ctx, session.SessionID, func(a models.Answer) error {
+		b.add(a)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+	return b.build(players), nil
+}
+
+// playedQuiz loads the quiz a session played, preferring its snapshot, with the questions
+// its rules drew.
+func playedQuiz(ctx context.Context, store db.QuizRepository, session *models.Session) (*models.Quiz, error) {
+	var quiz *models.Quiz
+	var err error
+	if session.QuizVersion > 0 {
+		quiz, err = store.GetQuizVersion(ctx, session.QuizID, session.QuizVersion)
+	} else {
+		quiz, err = store.GetQuiz(ctx, session.QuizID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz: %w", err)
+	}
+	if quiz == nil {
+		return nil, ErrQuizNotFound
+	}
+	if len(quiz.Rules) > 0 {
+		quiz = quiz.Drawn(session.QuizSeed)
+	}
+	return quiz, nil
+}
+
+// finalScores returns a finished session's persisted leaderboard.
+func finalScores(ctx context.Context, store db.ResultRepository, sessionID string) ([]models.PlayerScore, error) {
+	rankings, err := store.GetSessionRankings(ctx, sessionID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rankings: %w", err)
+	}
+	players := make([]models.PlayerScore, len(rankings))
+	for i, r := range rankings {
+		players[i] = r.PlayerScore()
+	}
+	return players, nil
+}
+
+// BuildReport analyses a session's answers question by question and player by player.
+// players is the ranked leaderboard; players who answered but are missing from it are added
+// at the end without a rank.
+//
+// Finished sessions don't record how far the game got, so a question counts as played once
+// anyone answered it or a later question; a live session has played up to its current question.
+func BuildReport(session *models.Session, quiz *models.Quiz, answers []models.Answer, players []models.PlayerScore) *models.SessionReport {
+	b := newReportBuilder(session, quiz)
+	for _, a := range answers {
+		b.add(a)
+	}
+	return b.build(players)
+}
+
+// reportBuilder accumulates a report one answer at a time, keeping only per-question and
+// per-player totals.
+type reportBuilder struct {
+	report       *models.SessionReport
+	quiz         *models.Quiz
+	indexByID    map[string]int
+	totals       map[string]*playerTotals
+	answerers    []string // users in the order their first answer was seen
+	optionCounts []map[string]int
+	questionTime []int64
+	answered     int
+	correct      int
+}
+
+// playerTotals is one player's running totals in a reportBuilder.
+type playerTotals struct {
+	answered, correct int
+	timeMs            int64
+}
+
+func newReportBuilder(session *models.Session, quiz *models.Quiz) *reportBuilder {
+	b := &reportBuilder{
+		report: &models.SessionReport{
+			SessionID:     session.SessionID,
+			QuizID:        session.QuizID,
+			QuizVersion:   session.QuizVersion,
+			QuizTitle:     quiz.Title,
+			Status:        session.Status,
+			StartedAt:     session.StartedAt,
+			EndedAt:       session.EndedAt,
+			QuestionCount: len(quiz.Questions),
+			Questions:     make([]models.QuestionReport, len(quiz.Questions)),
+		},
+		quiz:         quiz,
+		indexByID:    make(map[string]int, len(quiz.Questions)),
+		totals:       make(map[string]*playerTotals),
+		optionCounts: make([]map[string]int, len(quiz.Questions)),
+		questionTime: make([]int64, len(quiz.Questions)),
+	}
+	for i, q := range quiz.Questions {
+		b.indexByID[q.QuestionID] = i
+		b.optionCounts[i] = make(map[string]int)
+	}
+	if session.Status == models.SessionStatusActive {
+		b.report.QuestionsPlayed = session.CurrentQuestionIndex + 1
+	}
+	return b
+}
+
+// add counts one answer; answers to questions not in the quiz are ignored.
+func (b *reportBuilder) add(a models.Answer) {
+	i, ok := b.indexByID[a.QuestionID]
+	if !ok {
+		return
+	}
+	b.report.QuestionsPlayed = max(b.report.QuestionsPlayed, i+1)
+	qr := &b.report.Questions[i]
+	qr.AnswerCount++
+	b.optionCounts[i][a.SelectedOptionID]++
+	b.questionTime[i] += a.TimeTakenMs
+
+	t, ok := b.totals[a.UserID]
+	if !ok {
+		t = &playerTotals{}
+		b.totals[a.UserID] = t
+		b.answerers = append(b.answerers, a.UserID)
+	}
+	t.answered++
+	t.timeMs += a.TimeTakenMs
+	b.answered++
+	if a.IsCorrect {
+		qr.CorrectCount++
+		t.correct++
+		b.correct++
+	}
+}
+
+// build finishes the report for the ranked players once every answer has been added.
+func (b *reportBuilder) build(players []models.PlayerScore) *models.SessionReport {
+	report := b.report
+	report.QuestionsPlayed = min(report.QuestionsPlayed, len(b.quiz.Questions))
+
+	// Players who answered but aren't on the leaderboard (e.g. disconnected from a live game)
+	listed := make(map[string]bool, len(players))
+	for _, p := range players {
+		listed[p.UserID] = true
+	}
+	for _, userID := range b.answerers {
+		if !listed[userID] {
+			listed[userID] = true
+			players = append(players, models.PlayerScore{UserID: userID})
+		}
+	}
+	report.PlayerCount = len(players)
+
+	for i, q := range b.quiz.Questions {
+		qr := &report.Questions[i]
+		qr.QuestionID = q.QuestionID
+		qr.QuestionIndex = i
+		qr.Text = q.Text
+		qr.CorrectOptionID = q.CorrectOptionID
+		qr.Played = i < report.QuestionsPlayed
+		if qr.Played {
+			qr.UnansweredCount = max(report.PlayerCount-qr.AnswerCount, 0)
+		}
+		qr.Accuracy = ratio(qr.CorrectCount, qr.AnswerCount)
+		if qr.AnswerCount > 0 {
+			qr.AverageTimeMs = b.questionTime[i] / int64(qr.AnswerCount)
+		}
+
+		qr.Options = make([]models.OptionReport, len(q.Options))
+		confused := 0
+		for j, o := range q.Options {
+			count := b.optionCounts[i][o.ID]
+			qr.Options[j] = models.OptionReport{
+				OptionID:  o.ID,
+				Text:      o.Text,
+				IsCorrect: o.ID == q.CorrectOptionID,
+				Count:     count,
+				Share:     ratio(count, qr.AnswerCount),
+			}
+			// Ties go to the earlier option
+			if o.ID != q.CorrectOptionID && count > confused {
+				confused = count
+				qr.MostConfusedOptionID = o.ID
+			}
+		}
+	}
+
+	var totalScore float64
+	report.Players = make([]models.PlayerReport, len(players))
+	for i, p := range players {
+		pr := models.PlayerReport{
+			UserID:          p.UserID,
+			Nickname:        p.Nickname,
+			Rank:            p.Rank,
+			Score:           p.Score,
+			UnansweredCount: report.QuestionsPlayed,
+		}
+		if t, ok := b.totals[p.UserID]; ok {
+			pr.AnsweredCount = t.answered
+			pr.CorrectCount = t.correct
+			pr.UnansweredCount = max(report.QuestionsPlayed-t.answered, 0)
+			pr.AverageTimeMs = t.timeMs / int64(t.answered)
+		}
+		// Unanswered questions count against the player; see models.SessionReport
+		pr.Accuracy = ratio(pr.CorrectCount, report.QuestionsPlayed)
+		report.Players[i] = pr
+		totalScore += p.Score
+	}
+
+	report.Completion = ratio(b.answered, report.PlayerCount*report.QuestionsPlayed)
+	report.Accuracy = ratio(b.correct, b.answered)
+	if report.PlayerCount > 0 {
+		report.AverageScore = math.Round(totalScore/float64(report.PlayerCount)*10) / 10
+	}
+	return report
+}
+
+// ratio returns n/d rounded to three decimals, or 0 when d is 0.
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(d)*1000) / 1000
+}
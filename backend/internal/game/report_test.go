@@ -0,0 +1,163 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"kahootclone/internal/models"
+)
+
+// reportQuiz has three questions with options a (correct), b and c.
+func reportQuiz() *models.Quiz {
+	quiz := &models.Quiz{QuizID: "quiz", Title: "Report"}
+	for _, id := range []string{"q1", "q2", "q3"} {
+		quiz.Questions = append(quiz.Questions, models.Question{
+			QuestionID:      id,
+			Text:            id,
+			Options:         []models.Option{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}, {ID: "c", Text: "C"}},
+			CorrectOptionID: "a",
+		})
+	}
+	return quiz
+}
+
+// reportAnswers has p1, p2 and p3 on the leaderboard and p4, who answered but isn't. Nobody
+// answered q3, and one answer is to a question no longer in the quiz.
+func reportAnswers() ([]models.Answer, []models.PlayerScore) {
+	answer := func(userID, questionID, optionID string, ms int64) models.Answer {
+		return models.Answer{
+			SessionID:        "s",
+			UserIDQuestionID: userID + "#" + questionID,
+			UserID:           userID,
+			QuestionID:       questionID,
+			SelectedOptionID: optionID,
+			IsCorrect:        optionID == "a",
+			TimeTakenMs:      ms,
+		}
+	}
+	answers := []models.Answer{
+		answer("p1", "q1", "a", 1000),
+		answer("p2", "q1", "b", 2000),
+		answer("p3", "q1", "c", 3000),
+		answer("p4", "q1", "a", 4000),
+		answer("p1", "q2", "c", 1000),
+		answer("p2", "q2", "c", 3000),
+		answer("p1", "gone", "a", 500),
+	}
+	players := []models.PlayerScore{
+		{UserID: "p1", Nickname: "One", Score: 1500, Rank: 1},
+		{UserID: "p2", Nickname: "Two", Score: 700, Rank: 2},
+		{UserID: "p3", Nickname: "Three", Score: 200, Rank: 3},
+	}
+	return answers, players
+}
+
+// questionLine is the part of a QuestionReport the tests check, with option counts in
+// option order.
+type questionLine struct {
+	played       bool
+	answers      int
+	correct      int
+	unanswered   int
+	accuracy     float64
+	avgTimeMs    int64
+	counts       [3]int
+	shares       [3]float64
+	mostConfused string
+}
+
+func questionLines(report *models.SessionReport) []questionLine {
+	var out []questionLine
+	for _, q := range report.Questions {
+		l := questionLine{q.Played, q.AnswerCount, q.CorrectCount, q.UnansweredCount, q.Accuracy, q.AverageTimeMs, [3]int{}, [3]float64{}, q.MostConfusedOptionID}
+		for i, o := range q.Options {
+			l.counts[i] = o.Count
+			l.shares[i] = o.Share
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+func TestBuildReportFinishedSession(t *testing.T) {
+	answers, players := reportAnswers()
+	session := &models.Session{SessionID: "s", QuizID: "quiz", Status: models.SessionStatusFinished}
+	report := BuildReport(session, reportQuiz(), answers, players)
+
+	// Nobody reached q3, so two questions were played
+	if report.QuestionCount != 3 || report.QuestionsPlayed != 2 || report.PlayerCount != 4 {
+		t.Errorf("got %d questions, %d played, %d players, want 3, 2 and 4", report.QuestionCount, report.QuestionsPlayed, report.PlayerCount)
+	}
+	// 6 answers out of 4 players × 2 questions, 2 of them correct; scores average 2400 / 4
+	if report.Completion != 0.75 || report.Accuracy != 0.333 || report.AverageScore != 600 {
+		t.Errorf("got completion %v, accuracy %v, average score %v, want 0.75, 0.333 and 600", report.Completion, report.Accuracy, report.AverageScore)
+	}
+
+	want := []questionLine{
+		// b and c were each chosen once; the tie goes to the earlier option
+		{true, 4, 2, 0, 0.5, 2500, [3]int{2, 1, 1}, [3]float64{0.5, 0.25, 0.25}, "b"},
+		{true, 2, 0, 2, 0, 2000, [3]int{0, 0, 2}, [3]float64{0, 0, 1}, "c"},
+		{false, 0, 0, 0, 0, 0, [3]int{}, [3]float64{}, ""},
+	}
+	if got := questionLines(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("got questions\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Player accuracy is out of the 2 questions played, so p4's one correct answer is 0.5
+	wantPlayers := []models.PlayerReport{
+		{UserID: "p1", Nickname: "One", Rank: 1, Score: 1500, AnsweredCount: 2, CorrectCount: 1, UnansweredCount: 0, Accuracy: 0.5, AverageTimeMs: 1000},
+		{UserID: "p2", Nickname: "Two", Rank: 2, Score: 700, AnsweredCount: 2, CorrectCount: 0, UnansweredCount: 0, Accuracy: 0, AverageTimeMs: 2500},
+		{UserID: "p3", Nickname: "Three", Rank: 3, Score: 200, AnsweredCount: 1, CorrectCount: 0, UnansweredCount: 1, Accuracy: 0, AverageTimeMs: 3000},
+		{UserID: "p4", AnsweredCount: 1, CorrectCount: 1, UnansweredCount: 1, Accuracy: 0.5, AverageTimeMs: 4000},
+	}
+	if !reflect.DeepEqual(report.Players, wantPlayers) {
+		t.Errorf("got players\n%+v\nwant\n%+v", report.Players, wantPlayers)
+	}
+}
+
+func TestBuildReportLiveSession(t *testing.T) {
+	answers, players := reportAnswers()
+	// The game is showing q3, which nobody has answered yet
+	session := &models.Session{SessionID: "s", QuizID: "quiz", Status: models.SessionStatusActive, CurrentQuestionIndex: 2}
+	report := BuildReport(session, reportQuiz(), answers, players)
+
+	if report.QuestionsPlayed != 3 {
+		t.Errorf("got %d questions played, want 3", report.QuestionsPlayed)
+	}
+	// 6 answers out of 4 players × 3 questions; the answer accuracy doesn't change
+	if report.Completion != 0.5 || report.Accuracy != 0.333 {
+		t.Errorf("got completion %v and accuracy %v, want 0.5 and 0.333", report.Completion, report.Accuracy)
+	}
+	if q3 := questionLines(report)[2]; !q3.played || q3.unanswered != 4 || q3.mostConfused != "" {
+		t.Errorf("got q3 %+v, want played with 4 unanswered and no confused option", q3)
+	}
+
+	type player struct {
+		userID     string
+		unanswered int
+		accuracy   float64
+	}
+	want := []player{{"p1", 1, 0.333}, {"p2", 1, 0}, {"p3", 2, 0}, {"p4", 2, 0.333}}
+	var got []player
+	for _, p := range report.Players {
+		got = append(got, player{p.UserID, p.UnansweredCount, p.Accuracy})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got players %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildReportLobby(t *testing.T) {
+	_, players := reportAnswers()
+	session := &models.Session{SessionID: "s", QuizID: "quiz", Status: models.SessionStatusLobby}
+	report := BuildReport(session, reportQuiz(), nil, players)
+
+	if report.QuestionsPlayed != 0 || report.Completion != 0 || report.Accuracy != 0 {
+		t.Errorf("got %d played, completion %v, accuracy %v, want all 0", report.QuestionsPlayed, report.Completion, report.Accuracy)
+	}
+	for _, p := range report.Players {
+		if p.UnansweredCount != 0 || p.Accuracy != 0 {
+			t.Errorf("got %+v, want nothing unanswered before the game starts", p)
+		}
+	}
+}
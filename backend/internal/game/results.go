@@ -0,0 +1,261 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"kahootclone/internal/cache"
+	"kahootclone/internal/db"
+	"kahootclone/internal/models"
+	"kahootclone/internal/observability"
+)
+
+// saveSessionResult persists the full final ranking and per-question statistics of a
+// finished session, so results survive the Redis cleanup. Returns the full ranking.
+func (e *Engine) saveSessionResult(ctx context.Context, session *models.Session) ([]models.PlayerScore, error) {
+	if err := e.ensureLeaderboard(ctx, session.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to check leaderboard: %w", err)
+	}
+
+	count, err := e.Cache.GetPlayerCount(ctx, session.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player count: %w", err)
+	}
+	leaderboard, err := e.Cache.GetTopN(ctx, session.SessionID, int(count))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	quiz, err := e.sessionQuiz(ctx, session)
+	if err != nil && !errors.Is(err, ErrQuizNotFound) {
+		return nil, err
+	}
+	answers, err := e.DB.GetAnswersBySession(ctx, session.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+
+	result := &models.SessionResult{
+		SessionID:   session.SessionID,
+		QuizID:      session.QuizID,
+		QuizVersion: session.QuizVersion,
+		QuizSeed:    session.QuizSeed,
+		HostUserID:  session.HostUserID,
+		PlayerCount: len(leaderboard),
+		StartedAt:   session.StartedAt,
+		EndedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+	if quiz != nil {
+		result.QuizTitle = quiz.Title
+		result.Questions = buildQuestionStats(quiz, answers)
+	}
+
+	rankings := buildPlayerResults(leaderboard, answers)
+	if err := e.DB.PutSessionResult(ctx, result, rankings); err != nil {
+		return nil, fmt.Errorf("failed to store session result: %w", err)
+	}
+
+	observability.Info(ctx, "session result saved",
+		"sessionId", session.SessionID,
+		"players", len(rankings),
+		"answers", len(answers),
+	)
+	return leaderboard, nil
+}
+
+// buildQuestionStats aggregates answers per question, in quiz order.
+func buildQuestionStats(quiz *models.Quiz, answers []models.Answer) []models.QuestionStats {
+	stats := make([]models.QuestionStats, len(quiz.Questions))
+	byID := make(map[string]*models.QuestionStats, len(quiz.Questions))
+	totalTime := make(map[string]int64, len(quiz.Questions))
+
+	for i, q := range quiz.Questions {
+		stats[i] = models.QuestionStats{
+			QuestionID:      q.QuestionID,
+			QuestionIndex:   i,
+			Text:            q.Text,
+			CorrectOptionID: q.CorrectOptionID,
+			OptionCounts:    make(map[string]int, len(q.Options)),
+		}
+		byID[q.QuestionID] = &stats[i]
+	}
+
+	for _, a := range answers {
+		s, ok := byID[a.QuestionID]
+		if !ok {
+			continue
+		}
+		s.AnswerCount++
+		if a.IsCorrect {
+			s.CorrectCount++
+		}
+		s.OptionCounts[a.SelectedOptionID]++
+		totalTime[a.QuestionID] += a.TimeTakenMs
+	}
+
+	for i := range stats {
+		if stats[i].AnswerCount > 0 {
+			stats[i].AverageTimeMs = totalTime[stats[i].QuestionID] / int64(stats[i].AnswerCount)
+		}
+	}
+	return stats
+}
+
+// buildPlayerResults combines the final leaderboard with each player's answer counts.
+func buildPlayerResults(leaderboard []models.PlayerScore, answers []models.Answer) []models.PlayerResult {
+	answered := make(map[string]int)
+	correct := make(map[string]int)
+	for _, a := range answers {
+		answered[a.UserID]++
+		if a.IsCorrect {
+			correct[a.UserID]++
+		}
+	}
+
+	rankings := make([]models.PlayerResult, len(leaderboard))
+	for i, p := range leaderboard {
+		rankings[i] = models.PlayerResult{
+			UserID:        p.UserID,
+			Nickname:      p.Nickname,
+			Score:         p.Score,
+			TotalTimeMs:   p.TotalTimeMs,
+			Rank:          p.Rank,
+			AnsweredCount: answered[p.UserID],
+			CorrectCount:  correct[p.UserID],
+		}
+	}
+	return rankings
+}
+
+// AggregateResults combines per-session results into an all-time leaderboard keyed by
+// authenticated user ID. Scores and correct-answer time are summed across sessions, anonymous
+// players are skipped, and each player keeps the nickname from their most recent session.
+// Results must be ordered oldest session first.
+func AggregateResults(results []models.PlayerResult, mode models.RankMode) []models.AllTimeScore {
+	byUser := make(map[string]*models.AllTimeScore)
+	for _, r := range results {
+		if models.IsAnonymous(r.UserID) {
+			continue
+		}
+		a, ok := byUser[r.UserID]
+		if !ok {
+			a = &models.AllTimeScore{PlayerScore: models.PlayerScore{UserID: r.UserID}}
+			byUser[r.UserID] = a
+		}
+		if r.Nickname != "" {
+			a.Nickname = r.Nickname
+		}
+		a.Score += r.Score
+		a.TotalTimeMs += r.TotalTimeMs
+		a.SessionsPlayed++
+		a.AnsweredCount += r.AnsweredCount
+		a.CorrectCount += r.CorrectCount
+	}
+
+	// Order and rank through the same rules as a single session's leaderboard
+	scores := make([]models.PlayerScore, 0, len(byUser))
+	for _, a := range byUser {
+		scores = append(scores, a.PlayerScore)
+	}
+	models.SortAndRank(scores, mode)
+
+	leaderboard := make([]models.AllTimeScore, len(scores))
+	for i, p := range scores {
+		a := byUser[p.UserID]
+		a.PlayerScore = p
+		leaderboard[i] = *a
+	}
+	return leaderboard
+}
+
+// Defaults and bounds for all-time leaderboard queries.
+const (
+	DefaultAllTimeLimit = 100
+	MaxAllTimeLimit     = 1000
+)
+
+// ParseAllTimeQuery parses the from, to and limit query parameters of an all-time leaderboard
+// request. from and to are RFC 3339 timestamps; an empty from means the beginning of time and
+// an empty to means now. An empty limit means DefaultAllTimeLimit.
+func ParseAllTimeQuery(fromParam, toParam, limitParam string) (from, to time.Time, limit int, err error) {
+	from = time.Unix(0, 0).UTC()
+	to = time.Now().UTC()
+	limit = DefaultAllTimeLimit
+
+	if fromParam != "" {
+		if from, err = time.Parse(time.RFC3339, fromParam); err != nil {
+			return from, to, limit, fmt.Errorf("from must be an RFC 3339 timestamp")
+		}
+	}
+	if toParam != "" {
+		if to, err = time.Parse(time.RFC3339, toParam); err != nil {
+			return from, to, limit, fmt.Errorf("to must be an RFC 3339 timestamp")
+		}
+	}
+	if to.Before(from) {
+		return from, to, limit, fmt.Errorf("to must not be before from")
+	}
+	if limit, err = parseLimit(limitParam, DefaultAllTimeLimit, MaxAllTimeLimit); err != nil {
+		return from, to, limit, err
+	}
+	return from, to, limit, nil
+}
+
+// Defaults and bounds for paginated list endpoints.
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// ParsePageLimit parses the limit query parameter of a paginated list request.
+// An empty limit means DefaultPageLimit.
+func ParsePageLimit(limitParam string) (int, error) {
+	return parseLimit(limitParam, DefaultPageLimit, MaxPageLimit)
+}
+
+func parseLimit(limitParam string, def, max int) (int, error) {
+	if limitParam == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 1 || limit > max {
+		return def, fmt.Errorf("limit must be between 1 and %d", max)
+	}
+	return limit, nil
+}
+
+// SessionLeaderboardPage returns up to limit entries of a session's leaderboard starting at
+// cursor, and the cursor of the next page ("" on the last page). Finished sessions are read
+// from their persisted results, live ones from lb. Returns db.ErrInvalidCursor for a cursor
+// that was not issued by this endpoint.
+func SessionLeaderboardPage(ctx context.Context, store db.ResultRepository, lb cache.Leaderboard, session *models.Session, limit int, cursor string) ([]models.PlayerScore, string, error) {
+	if session.Status == models.SessionStatusFinished {
+		// Live leaderboards are deleted once a game ends; serve the persisted result
+		rankings, next, err := store.GetSessionRankingsPage(ctx, session.SessionID, limit, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		scores := make([]models.PlayerScore, len(rankings))
+		for i, r := range rankings {
+			scores[i] = r.PlayerScore()
+		}
+		return scores, next, nil
+	}
+
+	offset, err := db.DecodeOffsetCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	// Read one extra entry to tell whether another page exists
+	scores, err := lb.GetRange(ctx, session.SessionID, offset, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(scores) > limit {
+		return scores[:limit], db.EncodeOffsetCursor(offset + limit), nil
+	}
+	return scores, "", nil
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"kahootclone/internal/models"
+)
+
+func TestAggregateResults(t *testing.T) {
+	// Oldest session first. u1 and u2 finish on 1500 points, u2 faster; u3 and u4 finish on
+	// 1000 points in the same time, so the higher userId goes first
+	results := []models.PlayerResult{
+		{SessionID: "s1", UserID: "u1", Nickname: "Ann", Score: 1000, TotalTimeMs: 3000, AnsweredCount: 2, CorrectCount: 1},
+		{SessionID: "s1", UserID: "u2", Nickname: "Bo", Score: 500, TotalTimeMs: 1000, AnsweredCount: 2, CorrectCount: 1},
+		{SessionID: "s1", UserID: "u3", Nickname: "Cy", Score: 800, TotalTimeMs: 2000, AnsweredCount: 2, CorrectCount: 1},
+		{SessionID: "s1", UserID: "anon-1", Nickname: "Guest", Score: 5000, TotalTimeMs: 100, AnsweredCount: 2, CorrectCount: 2},
+		{SessionID: "s2", UserID: "u1", Nickname: "Annie", Score: 500, TotalTimeMs: 2000, AnsweredCount: 2, CorrectCount: 1},
+		{SessionID: "s2", UserID: "u2", Score: 1000, TotalTimeMs: 2000, AnsweredCount: 2, CorrectCount: 2},
+		{SessionID: "s2", UserID: "u3", Nickname: "Cy", Score: 200, TotalTimeMs: 500, AnsweredCount: 1, CorrectCount: 1},
+		{SessionID: "s2", UserID: "u4", Nickname: "Di", Score: 1000, TotalTimeMs: 2500, AnsweredCount: 2, CorrectCount: 2},
+	}
+
+	type entry struct {
+		userID, nickname string
+		score            float64
+		timeMs           int64
+		rank             int64
+		sessions         int
+		answered         int
+		correct          int
+	}
+	tests := []struct {
+		mode models.RankMode
+		want []entry
+	}{
+		{models.RankModeTiebreak, []entry{
+			{"u2", "Bo", 1500, 3000, 1, 2, 4, 3},
+			{"u1", "Annie", 1500, 5000, 2, 2, 4, 2},
+			{"u4", "Di", 1000, 2500, 3, 1, 2, 2},
+			{"u3", "Cy", 1000, 2500, 4, 2, 3, 2},
+		}},
+		{models.RankModeDense, []entry{
+			{"u2", "Bo", 1500, 3000, 1, 2, 4, 3},
+			{"u1", "Annie", 1500, 5000, 1, 2, 4, 2},
+			{"u4", "Di", 1000, 2500, 2, 1, 2, 2},
+			{"u3", "Cy", 1000, 2500, 2, 2, 3, 2},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got := AggregateResults(results, tt.mode)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d players %+v, want %d without the anonymous player", len(got), got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				e := entry{g.UserID, g.Nickname, g.Score, g.TotalTimeMs, g.Rank, g.SessionsPlayed, g.AnsweredCount, g.CorrectCount}
+				if e != w {
+					t.Errorf("position %d: got %+v, want %+v", i+1, e, w)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAllTimeQuery(t *testing.T) {
+	from := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 31, 17, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                    string
+		fromParam, toParam, lim string
+		wantFrom, wantTo        time.Time
+		wantLimit               int
+		wantErr                 bool
+	}{
+		{name: "window", fromParam: "2026-01-05T09:00:00Z", toParam: "2026-03-31T17:30:00Z", lim: "10", wantFrom: from, wantTo: to, wantLimit: 10},
+		{name: "offset timestamps", fromParam: "2026-01-05T10:00:00+01:00", toParam: "2026-03-31T17:30:00Z", wantFrom: from, wantTo: to, wantLimit: DefaultAllTimeLimit},
+		{name: "empty window", fromParam: "2026-03-31T17:30:00Z", toParam: "2026-03-31T17:30:00Z", wantFrom: to, wantTo: to, wantLimit: DefaultAllTimeLimit},
+		{name: "maximum limit", toParam: "2026-03-31T17:30:00Z", lim: "1000", wantFrom: time.Unix(0, 0), wantTo: to, wantLimit: MaxAllTimeLimit},
+		{name: "from after to", fromParam: "2026-03-31T17:30:01Z", toParam: "2026-03-31T17:30:00Z", wantErr: true},
+		{name: "from after now", fromParam: "2999-01-01T00:00:00Z", wantErr: true},
+		{name: "bad from", fromParam: "2026-01-05", wantErr: true},
+		{name: "bad to", toParam: "yesterday", wantErr: true},
+		{name: "limit over maximum", lim: "1001", wantErr: true},
+		{name: "zero limit", lim: "0", wantErr: true},
+		{name: "non-numeric limit", lim: "ten", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrom, gotTo, gotLimit, err := ParseAllTimeQuery(tt.fromParam, tt.toParam, tt.lim)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got from %v, to %v, limit %d, want an error", gotFrom, gotTo, gotLimit)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !gotFrom.Equal(tt.wantFrom) || !gotTo.Equal(tt.wantTo) || gotLimit != tt.wantLimit {
+				t.Errorf("got from %v, to %v, limit %d, want %v, %v, %d", gotFrom, gotTo, gotLimit, tt.wantFrom, tt.wantTo, tt.wantLimit)
+			}
+		})
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		before := time.Now()
+		gotFrom, gotTo, gotLimit, err := ParseAllTimeQuery("", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotFrom.Equal(time.Unix(0, 0)) {
+			t.Errorf("got from %v, want the Unix epoch", gotFrom)
+		}
+		if gotTo.Before(before) || gotTo.After(time.Now()) {
+			t.Errorf("got to %v, want now", gotTo)
+		}
+		if gotLimit != DefaultAllTimeLimit {
+			t.Errorf("got limit %d, want %d", gotLimit, DefaultAllTimeLimit)
+		}
+	})
+}
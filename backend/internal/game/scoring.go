@@ -12,13 +12,7 @@ func CalculateScore(isCorrect bool, timeTakenMs int64, timeLimitMs int64, basePo
 		return basePoints
 	}
 
-	// Clamp timeTaken to timeLimitMs
-	if timeTakenMs < 0 {
-		timeTakenMs = 0
-	}
-	if timeTakenMs > timeLimitMs {
-		timeTakenMs = timeLimitMs
-	}
+	timeTakenMs = ClampTimeTaken(timeTakenMs, timeLimitMs)
 
 	// Full points for first half of time, then linear decay
 	timeRatio := float64(timeTakenMs) / float64(timeLimitMs)
@@ -26,3 +20,15 @@ func CalculateScore(isCorrect bool, timeTakenMs int64, timeLimitMs int64, basePo
 
 	return basePoints + bonus
 }
+
+// ClampTimeTaken limits a client-reported answer time to [0, timeLimitMs], so it can
+// neither inflate the score nor win leaderboard tie-breaks.
+func ClampTimeTaken(timeTakenMs int64, timeLimitMs int64) int64 {
+	if timeTakenMs < 0 {
+		return 0
+	}
+	if timeLimitMs > 0 && timeTakenMs > timeLimitMs {
+		return timeLimitMs
+	}
+	return timeTakenMs
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"context"
+	"log/slog"
+
+	"kahootclone/internal/models"
+)
+
+// The helpers below read through and write to the engine's session cache. Cache errors are
+// logged and treated as misses: the database stays the source of truth, so a cache outage
+// only costs extra reads.
+
+// cachedConnection returns the connection record of a connection ID.
+func (e *Engine) cachedConnection(ctx context.Context, connectionID string) (*models.Player, error) {
+	if e.Sessions != nil {
+		conn, err := e.Sessions.GetConnection(ctx, connectionID)
+		if err != nil {
+			slog.Warn("failed to read cached connection", "connectionId", connectionID, "error", err.Error())
+		} else if conn != nil {
+			return conn, nil
+		}
+	}
+
+	conn, err := e.DB.GetSessionByConnectionID(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	e.cacheConnection(ctx, conn)
+	return conn, nil
+}
+
+// cacheConnection stores a connection record just written or read from the database.
+func (e *Engine) cacheConnection(ctx context.Context, player *models.Player) {
+	if e.Sessions == nil {
+		return
+	}
+	if err := e.Sessions.SetConnection(ctx, player); err != nil {
+		slog.Warn("failed to cache connection", "connectionId", player.ConnectionID, "error", err.Error())
+	}
+}
+
+// cachedSession returns a session, or nil if it doesn't exist. The cached copy may be
+// stale for a moment after a state change made by another process, so only paths that
+// tolerate that (answer submission) use it; host actions read the database directly.
+func (e *Engine) cachedSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	if e.Sessions != nil {
+		session, err := e.Sessions.GetSession(ctx, sessionID)
+		if err != nil {
+			slog.Warn("failed to read cached session", "sessionId", sessionID, "error", err.Error())
+		} else if session != nil {
+			return session, nil
+		}
+	}
+
+	session, err := e.DB.GetSession(ctx, sessionID)
+	if err != nil || session == nil {
+		return session, err
+	}
+	if e.Sessions != nil {
+		if err := e.Sessions.SetSession(ctx, session); err != nil {
+			slog.Warn("failed to cache session", "sessionId", sessionID, "error", err.Error())
+		}
+	}
+	return session, nil
+}
+
+// invalidateSession drops the cached session after its state changed in the database.
+func (e *Engine) invalidateSession(ctx context.Context, sessionID string) {
+	if e.Sessions == nil {
+		return
+	}
+	if err := e.Sessions.InvalidateSession(ctx, sessionID); err != nil {
+		slog.Warn("failed to invalidate cached session", "sessionId", sessionID, "error", err.Error())
+	}
+}
+
+// cachedQuizVersion returns a quiz snapshot, or nil if it doesn't exist. Snapshots never
+// change, so they are cached for the session lifetime without invalidation.
+func (e *Engine) cachedQuizVersion(ctx context.Context, quizID string, version int64) (*models.Quiz, error) {
+	if e.Sessions != nil {
+		quiz, err := e.Sessions.GetQuizVersion(ctx, quizID, version)
+		if err != nil {
+			slog.Warn("failed to read cached quiz", "quizId", quizID, "version", version, "error", err.Error())
+		} else if quiz != nil {
+			return quiz, nil
+		}
+	}
+
+	quiz, err := e.DB.GetQuizVersion(ctx, quizID, version)
+	if err != nil || quiz == nil {
+		return quiz, err
+	}
+	if e.Sessions != nil {
+		if err := e.Sessions.SetQuizVersion(ctx, quiz); err != nil {
+			slog.Warn("failed to cache quiz", "quizId", quizID, "version", version, "error", err.Error())
+		}
+	}
+	return quiz, nil
+}
@@ -0,0 +1,143 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileStore is a BlobStore in a local directory, for the local server and self-hosted
+// deployments. Content types are derived from the key's extension, so keys should keep one.
+// Its signed URLs point at the server's /media/ route, which ServeHTTP answers.
+type FileStore struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewFileStore returns a store rooted at dir, creating it if needed. Signed URLs start with
+// baseURL and are signed with signingKey.
+func NewFileStore(dir, baseURL string, signingKey []byte) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), signingKey: signingKey}, nil
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes a file, replacing any with the same key.
+func (s *FileStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	return s.PutReader(ctx, key, contentType, bytes.NewReader(data))
+}
+
+// PutReader writes a file from r, replacing any with the same key. The file is written to a
+// temporary name first so readers never see it half-written.
+func (s *FileStore) PutReader(ctx context.Context, key, contentType string, r io.Reader) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentTypeOf(key), nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// SignedURL returns a /media/ link to the file that expires after ttl.
+func (s *FileStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{"expires": {expires}, "sig": {s.sign(key, expires)}}
+	return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode(), nil
+}
+
+func (s *FileStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves the files behind signed URLs. It expects the whole request path to be the
+// key, e.g. /media/3f2c….png, and answers 403 for a missing, wrong or expired signature.
+func (s *FileStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	expires := r.URL.Query().Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix ||
+		!hmac.Equal([]byte(s.sign(key, expires)), []byte(r.URL.Query().Get("sig"))) {
+		http.Error(w, "invalid or expired link", http.StatusForbidden)
+		return
+	}
+	data, contentType, err := s.Get(r.Context(), key)
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "invalid media key", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(max(unix-time.Now().Unix(), 0), 10))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
@@ -0,0 +1,348 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kahootclone/internal/models"
+)
+
+// Limits on uploaded files.
+const (
+	MaxUploadSize      = 4 << 20 // API Gateway and Lambda cap request bodies at 6MB before base64
+	MaxImageDimension  = 1600    // longer image sides are scaled down to this
+	ThumbnailDimension = 320
+	maxImagePixels     = 25_000_000 // decoding a larger image would need too much memory
+	jpegQuality        = 85
+)
+
+var (
+	// ErrTooLarge is returned by Prepare for a file over MaxUploadSize.
+	ErrTooLarge = fmt.Errorf("file is too large; the limit is %d MB", MaxUploadSize>>20)
+	// ErrUnsupportedType is returned by Prepare for a file that isn't a supported image or audio clip.
+	ErrUnsupportedType = errors.New("file must be a PNG, JPEG or GIF image, or an MP3, Ogg, WAV, M4A or WebM audio clip")
+)
+
+// File is an uploaded image or audio clip, checked and ready to store. Images are
+// re-encoded, which drops their metadata, scaled down to MaxImageDimension and given a
+// thumbnail; audio is stored as uploaded.
+type File struct {
+	Type        string // models.MediaImage or models.MediaAudio
+	ContentType string
+	Ext         string
+	Data        []byte
+	Thumbnail   []byte // images only, in the same format as Data
+}
+
+// Prepare identifies an uploaded file by its content, not its name, and prepares it for storage.
+func Prepare(data []byte) (*File, error) {
+	if len(data) > MaxUploadSize {
+		return nil, ErrTooLarge
+	}
+	if f := sniffAudio(data); f != nil {
+		return f, nil
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("image must be at most %d megapixels", maxImagePixels/1_000_000)
+	}
+	switch format {
+	case "jpeg":
+		return prepareJPEG(data)
+	case "png":
+		return preparePNG(data)
+	case "gif":
+		return prepareGIF(data)
+	}
+	return nil, ErrUnsupportedType
+}
+
+// Key returns a new store key for the file.
+func (f *File) Key() string {
+	return models.MediaKeyPrefix + uuid.New().String() + f.Ext
+}
+
+// ThumbnailKey returns the key an image's thumbnail is stored under, next to the image.
+func ThumbnailKey(key string) string {
+	if i := strings.LastIndex(key, "."); i > strings.LastIndex(key, "/") {
+		return key[:i] + ".thumb" + key[i:]
+	}
+	return key + ".thumb"
+}
+
+// Put stores a prepared file and its thumbnail under key, leaving neither behind on failure.
+func Put(ctx context.Context, store BlobStore, key string, f *File) error {
+	if err := store.Put(ctx, key, f.ContentType, f.Data); err != nil {
+		return err
+	}
+	if f.Thumbnail != nil {
+		if err := store.Put(ctx, ThumbnailKey(key), f.ContentType, f.Thumbnail); err != nil {
+			store.Delete(ctx, key)
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a file stored by Put, with its thumbnail if it has one.
+func Delete(ctx context.Context, store BlobStore, key string) error {
+	if err := store.Delete(ctx, ThumbnailKey(key)); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return store.Delete(ctx, key)
+}
+
+func prepareJPEG(data []byte) (*File, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JPEG image: %w", err)
+	}
+	// Re-encoding drops the EXIF orientation, so apply it to the pixels
+	full := orient(scaleDown(img, MaxImageDimension), jpegOrientation(data))
+	thumb := scaleDown(full, ThumbnailDimension)
+
+	var out, thumbOut bytes.Buffer
+	if err := jpeg.Encode(&out, full, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	if err := jpeg.Encode(&thumbOut, thumb, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return &File{Type: models.MediaImage, ContentType: "image/jpeg", Ext: ".jpg", Data: out.Bytes(), Thumbnail: thumbOut.Bytes()}, nil
+}
+
+func preparePNG(data []byte) (*File, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PNG image: %w", err)
+	}
+	var out, thumbOut bytes.Buffer
+	if err := png.Encode(&out, scaleDown(img, MaxImageDimension)); err != nil {
+		return nil, err
+	}
+	if err := png.Encode(&thumbOut, scaleDown(img, ThumbnailDimension)); err != nil {
+		return nil, err
+	}
+	return &File{Type: models.MediaImage, ContentType: "image/png", Ext: ".png", Data: out.Bytes(), Thumbnail: thumbOut.Bytes()}, nil
+}
+
+// prepareGIF re-encodes a GIF frame by frame. Animated GIFs can't be scaled without
+// recompositing every frame, so they must already fit within MaxImageDimension.
+func prepareGIF(data []byte) (*File, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(g.Image) == 0 {
+		return nil, errors.New("invalid GIF image")
+	}
+	var out bytes.Buffer
+	if len(g.Image) == 1 {
+		if err := gif.Encode(&out, scaleDown(g.Image[0], MaxImageDimension), nil); err != nil {
+			return nil, err
+		}
+	} else {
+		if g.Config.Width > MaxImageDimension || g.Config.Height > MaxImageDimension {
+			return nil, fmt.Errorf("animated GIFs must be at most %d pixels wide and high", MaxImageDimension)
+		}
+		if err := gif.EncodeAll(&out, g); err != nil {
+			return nil, err
+		}
+	}
+	// The first frame may cover only part of the canvas
+	first := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	draw.Draw(first, first.Bounds(), g.Image[0], image.Point{}, draw.Over)
+	var thumbOut bytes.Buffer
+	if err := gif.Encode(&thumbOut, scaleDown(first, ThumbnailDimension), nil); err != nil {
+		return nil, err
+	}
+	return &File{Type: models.MediaImage, ContentType: "image/gif", Ext: ".gif", Data: out.Bytes(), Thumbnail: thumbOut.Bytes()}, nil
+}
+
+// scaleDown shrinks img so neither side exceeds maxDim, averaging the source pixels each
+// target pixel covers. Images that already fit are returned as they are.
+func scaleDown(img image.Image, maxDim int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxDim && h <= maxDim {
+		return img
+	}
+	nw, nh := maxDim, max(h*maxDim/w, 1)
+	if h > w {
+		nw, nh = max(w*maxDim/h, 1), maxDim
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, (y+1)*h/nh
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, (x+1)*w/nw
+			// RGBA is alpha-premultiplied, so a plain average weights colors by coverage
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += uint64(row[i])
+					sum[1] += uint64(row[i+1])
+					sum[2] += uint64(row[i+2])
+					sum[3] += uint64(row[i+3])
+				}
+			}
+			n := uint64((x1 - x0) * (y1 - y0))
+			p := dst.Pix[y*dst.Stride+x*4:]
+			for i := range sum {
+				p[i] = uint8((sum[i] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// orient turns an image as its EXIF orientation (1–8) says it should be displayed.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs a quarter turn clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs a quarter turn anticlockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads the orientation tag from a JPEG's EXIF segment, or returns 1 (as
+// stored) when there is none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) { // image data starts, or a broken segment
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation finds the orientation tag (0x0112) in the first IFD of a TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
+
+// sniffAudio recognizes the audio formats browsers play, by their leading bytes.
+func sniffAudio(data []byte) *File {
+	audio := func(contentType, ext string) *File {
+		return &File{Type: models.MediaAudio, ContentType: contentType, Ext: ext, Data: data}
+	}
+	switch {
+	case len(data) < 12:
+		return nil
+	case bytes.HasPrefix(data, []byte("ID3")),
+		// An MPEG audio frame header; layer bits of 00 would be AAC in ADTS instead
+		data[0] == 0xFF && data[1]&0xE0 == 0xE0 && data[1]&0x06 != 0:
+		return audio("audio/mpeg", ".mp3")
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return audio("audio/ogg", ".ogg")
+	case bytes.HasPrefix(data, []byte("RIFF")) && string(data[8:12]) == "WAVE":
+		return audio("audio/wav", ".wav")
+	case string(data[4:8]) == "ftyp" && (string(data[8:12]) == "M4A " || string(data[8:12]) == "M4B "):
+		return audio("audio/mp4", ".m4a")
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}) && bytes.Contains(data[:min(len(data), 64)], []byte("webm")):
+		return audio("audio/webm", ".weba")
+	}
+	return nil
+}
+
+// Store puts a prepared upload under a new key and returns its attachment and signed
+// preview URLs valid for ttl.
+func Store(ctx context.Context, store BlobStore, f *File, alt string, ttl time.Duration) (*models.UploadedMedia, error) {
+	key := f.Key()
+	if err := Put(ctx, store, key, f); err != nil {
+		return nil, err
+	}
+	out := &models.UploadedMedia{
+		Media:   models.Media{Type: f.Type, Key: key, ContentType: f.ContentType, Alt: alt},
+		Preview: models.MediaPayload{Type: f.Type, ContentType: f.ContentType, Alt: alt},
+	}
+	var err error
+	if out.Preview.URL, err = store.SignedURL(ctx, key, ttl); err == nil && f.Thumbnail != nil {
+		out.Preview.ThumbnailURL, err = store.SignedURL(ctx, ThumbnailKey(key), ttl)
+	}
+	if err != nil {
+		Delete(ctx, store, key)
+		return nil, err
+	}
+	return out, nil
+}
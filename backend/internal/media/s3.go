@@ -0,0 +1,298 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// unsignedPayload is the payload hash S3 accepts for presigned URLs.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// maxPresignTTL is the longest lifetime S3 allows a presigned URL.
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// S3Store is a BlobStore in an S3 bucket or an S3-compatible service such as MinIO. Requests
+// are signed with SigV4 using the default AWS credential chain.
+type S3Store struct {
+	bucket   string
+	region   string
+	endpoint string // custom endpoint, addressed path-style; empty for AWS
+	creds    aws.CredentialsProvider
+	signer   *v4.Signer
+	http     *http.Client
+}
+
+// NewS3Store returns a store for bucket. When endpoint is set, requests go there with the
+// bucket in the path instead of to AWS.
+func NewS3Store(ctx context.Context, bucket, region, endpoint string) (*S3Store, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{
+		bucket:   bucket,
+		region:   region,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		creds:    awsCfg.Credentials,
+		signer: v4.NewSigner(func(o *v4.SignerOptions) {
+			o.DisableURIPathEscaping = true // S3 object paths are escaped once, not twice
+		}),
+		http: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// objectURL returns the URL of the object at key.
+func (s *S3Store) objectURL(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	escaped := (&url.URL{Path: key}).EscapedPath()
+	if s.endpoint != "" {
+		return s.endpoint + "/" + url.PathEscape(s.bucket) + "/" + escaped, nil
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, escaped), nil
+}
+
+// do signs and sends a request for the object at key, with query parameters if any.
+func (s *S3Store) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", s.region, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.http.Do(req)
+}
+
+// s3Error turns an unsuccessful response into an error, keeping the start of S3's message.
+func s3Error(op, key string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s %s: %s: %s", op, key, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func (s *S3Store) Put(ctx context.Context, key, contentType string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, http.Header{"Content-Type": {contentType}}, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("put", key, resp)
+	}
+	return nil
+}
+
+// s3PartSize is the size of every part of a multipart upload but the last. S3 requires at
+// least 5 MiB.
+const s3PartSize = 8 << 20
+
+// PutReader uploads a file from r one part at a time with a multipart upload. A file that
+// fits in one part is sent with a single PUT instead. If reading r or sending a part fails,
+// the upload is aborted so S3 doesn't keep the parts.
+func (s *S3Store) PutReader(ctx context.Context, key, contentType string, r io.Reader) error {
+	buf := make([]byte, s3PartSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return s.Put(ctx, key, contentType, buf[:n])
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := s.createMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+	var parts []s3CompletedPart
+	for {
+		etag, err := s.uploadPart(ctx, key, uploadID, len(parts)+1, buf[:n])
+		if err != nil {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return err
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: len(parts) + 1, ETag: etag})
+
+		n, err = io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return err
+		}
+	}
+	if err := s.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		s.abortMultipartUpload(ctx, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+// s3CompletedPart is one part listed in a CompleteMultipartUpload request.
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *S3Store) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, http.Header{"Content-Type": {contentType}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", s3Error("create multipart upload", key, resp)
+	}
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("s3 create multipart upload %s: %w", key, err)
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart sends one part and returns its ETag.
+func (s *S3Store) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	resp, err := s.do(ctx, http.MethodPut, key, query, nil, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", s3Error("upload part", key, resp)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *S3Store) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []s3CompletedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, http.Header{"Content-Type": {"application/xml"}}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("complete multipart upload", key, resp)
+	}
+	// S3 can report a failure to assemble the parts in the body of a 200 response
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(result, []byte("<Error>")) {
+		return fmt.Errorf("s3 complete multipart upload %s: %s", key, strings.TrimSpace(string(result)))
+	}
+	return nil
+}
+
+// abortMultipartUpload discards an upload's parts. It runs even if ctx was canceled, and its
+// own failure is only logged: the bucket's lifecycle rule removes parts left behind.
+func (s *S3Store) abortMultipartUpload(ctx context.Context, key, uploadID string) {
+	resp, err := s.do(context.WithoutCancel(ctx), http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		slog.Warn("failed to abort multipart upload", "key", key, "error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		slog.Warn("failed to abort multipart upload", "key", key, "error", s3Error("abort multipart upload", key, resp).Error())
+	}
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, string, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, "", ErrNotFound
+	default:
+		return nil, "", s3Error("get", key, resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// Delete removes an object. S3 doesn't report whether the object existed, so unlike
+// FileStore this never returns ErrNotFound.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s3Error("delete", key, resp)
+	}
+	return nil
+}
+
+// SignedURL returns a presigned GET URL, capped at the seven days S3 allows.
+func (s *S3Store) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	q := req.URL.Query()
+	q.Set("X-Amz-Expires", strconv.FormatInt(int64(min(ttl, maxPresignTTL)/time.Second), 10))
+	req.URL.RawQuery = q.Encode()
+
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	signed, _, err := s.signer.PresignHTTP(ctx, creds, req, unsignedPayload, "s3", s.region, time.Now())
+	return signed, err
+}
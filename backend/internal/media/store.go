@@ -0,0 +1,81 @@
+// Package media stores the images, audio clips and other files attached to questions.
+package media
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"kahootclone/internal/config"
+)
+
+// ErrNotFound is returned by Get and Delete for a key with no file.
+var ErrNotFound = errors.New("media not found")
+
+// BlobStore keeps media files by key. Keys are slash-separated relative paths such as
+// "media/3f2c….png".
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// PutReader stores a file read from r until EOF, holding only part of it in memory at a
+	// time. The file is not stored if reading r fails.
+	PutReader(ctx context.Context, key, contentType string, r io.Reader) error
+	Get(ctx context.Context, key string) (data []byte, contentType string, err error)
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL returns a URL that fetches the file without credentials until ttl has passed.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New returns the media store configured by MEDIA_BACKEND, or nil when none is configured.
+func New(cfg *config.Config) (BlobStore, error) {
+	switch cfg.MediaBackend {
+	case "file":
+		signingKey := []byte(cfg.MediaSigningKey)
+		if len(signingKey) == 0 {
+			// Links then stop working when the process restarts, which is fine for local use
+			signingKey = make([]byte, 32)
+			if _, err := rand.Read(signingKey); err != nil {
+				return nil, err
+			}
+		}
+		return NewFileStore(cfg.MediaDir, cfg.MediaBaseURL, signingKey)
+	case "s3":
+		return NewS3Store(context.Background(), cfg.MediaBucket, cfg.MediaRegion, cfg.MediaEndpoint)
+	}
+	return nil, nil
+}
+
+// contentTypes maps the extensions of the files the store holds to their content types, so
+// stores that derive types from keys don't depend on the system's MIME tables.
+var contentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".gif":  "image/gif",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".m4a":  "audio/mp4",
+	".weba": "audio/webm",
+}
+
+// contentTypeOf returns the content type of a key by its extension.
+func contentTypeOf(key string) string {
+	if t, ok := contentTypes[strings.ToLower(path.Ext(key))]; ok {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// validKey reports whether a key is a clean relative path that can't escape the store.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("invalid media key %q", key)
+	}
+	return nil
+}
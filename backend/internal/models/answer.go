@@ -13,4 +13,9 @@ type Answer struct {
 	TimeTakenMs      int64     `json:"timeTakenMs" dynamodbav:"timeTakenMs"`
 	PointsEarned     int       `json:"pointsEarned" dynamodbav:"pointsEarned"`
 	AnsweredAt       time.Time `json:"answeredAt" dynamodbav:"answeredAt"`
+	TTL              int64     `json:"ttl" dynamodbav:"ttl"` // Unix timestamp for DynamoDB TTL
+
+	// Denormalized UserID for authenticated players only, so that anonymous players are left
+	// out of the answers-by-user index that learner history is read from.
+	HistoryUserID string `json:"-" dynamodbav:"historyUserId,omitempty"`
 }
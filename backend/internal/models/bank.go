@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BankQuestion is a reusable question in a host's question bank. Its QuestionID is the ID
+// quiz questions reference through BankQuestionID.
+type BankQuestion struct {
+	Question
+	HostUserID string    `json:"hostUserId" dynamodbav:"hostUserId"`
+	Subject    string    `json:"subject,omitempty" dynamodbav:"subject,omitempty"`
+	Difficulty int       `json:"difficulty,omitempty" dynamodbav:"difficulty,omitempty"` // 1 (easiest) to MaxDifficulty, 0 if unrated
+	Tags       []string  `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
+	CreatedAt  time.Time `json:"createdAt" dynamodbav:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt" dynamodbav:"updatedAt"`
+
+	// TextLower is the lowercased question text, stored so DynamoDB filters can match text
+	// case-insensitively. Set by the store on write.
+	TextLower string `json:"-" dynamodbav:"textLower,omitempty"`
+}
+
+// Limits on question bank fields.
+const (
+	MaxDifficulty    = 5
+	MaxSubjectLength = 100
+)
+
+// ValidateBankQuestion checks a bank question's fields. Unlike quiz questions, which may be
+// drafts, bank questions must be playable as they are: quizzes copy them unchecked.
+func ValidateBankQuestion(q *BankQuestion) error {
+	if strings.TrimSpace(q.Text) == "" {
+		return errors.New("Text is required")
+	}
+	if len(q.Options) < 2 {
+		return errors.New("At least two options are required")
+	}
+	correct := false
+	for _, o := range q.Options {
+		if o.ID == q.CorrectOptionID {
+			correct = true
+		}
+	}
+	if !correct {
+		return errors.New("Correct option must be one of the options")
+	}
+	if q.TimeLimitSeconds < MinTimeLimitSeconds || q.TimeLimitSeconds > MaxTimeLimitSeconds {
+		return fmt.Errorf("Time limit must be from %d to %d seconds", MinTimeLimitSeconds, MaxTimeLimitSeconds)
+	}
+	if q.Points < 0 || q.Points > MaxPoints {
+		return fmt.Errorf("Points must be from 0 to %d", MaxPoints)
+	}
+	if len(q.Subject) > MaxSubjectLength {
+		return fmt.Errorf("Subject must be at most %d characters", MaxSubjectLength)
+	}
+	if q.Difficulty < 0 || q.Difficulty > MaxDifficulty {
+		return fmt.Errorf("Difficulty must be from 1 to %d, or 0 for unrated", MaxDifficulty)
+	}
+	return validateQuestionMedia("Question", q.Question)
+}
+
+// QuizQuestion returns a copy of the bank question to place in a quiz under questionID.
+func (b *BankQuestion) QuizQuestion(questionID string) Question {
+	q := b.Question
+	q.QuestionID = questionID
+	q.BankQuestionID = b.QuestionID
+	q.Options = append([]Option(nil), b.Options...)
+	return q
+}
+
+// BankFilter narrows a question bank listing. Empty fields match every question.
+type BankFilter struct {
+	Text string // case-insensitive substring of the question text
+	Tag  string // exact tag, compared after normalization
+}
+
+// Normalize lowercases the filter so it can be compared with TextLower and normalized tags.
+func (f BankFilter) Normalize() BankFilter {
+	return BankFilter{
+		Text: strings.ToLower(strings.TrimSpace(f.Text)),
+		Tag:  strings.ToLower(strings.TrimSpace(f.Tag)),
+	}
+}
+
+// Matches reports whether q passes a normalized filter.
+func (f BankFilter) Matches(q *BankQuestion) bool {
+	if f.Text != "" && !strings.Contains(strings.ToLower(q.Text), f.Text) {
+		return false
+	}
+	if f.Tag != "" {
+		for _, t := range q.Tags {
+			if t == f.Tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// BankQuestionInput is the body of a request creating or replacing a bank question.
+type BankQuestionInput struct {
+	Text             string   `json:"text"`
+	Options          []Option `json:"options"`
+	CorrectOptionID  string   `json:"correctOptionId"`
+	TimeLimitSeconds int      `json:"timeLimitSeconds"`
+	Points           int      `json:"points"`
+	Media            *Media   `json:"media"`
+	Subject          string   `json:"subject"`
+	Difficulty       int      `json:"difficulty"`
+	Tags             []string `json:"tags"`
+}
+
+// Apply normalizes the input, copies it onto q and validates the result. Options must
+// already have IDs. A missing time limit defaults to DefaultTimeLimitSeconds.
+func (in *BankQuestionInput) Apply(q *BankQuestion) error {
+	q.Text = strings.TrimSpace(in.Text)
+	q.Options = in.Options
+	q.CorrectOptionID = in.CorrectOptionID
+	q.TimeLimitSeconds = in.TimeLimitSeconds
+	if q.TimeLimitSeconds == 0 {
+		q.TimeLimitSeconds = DefaultTimeLimitSeconds
+	}
+	q.Points = in.Points
+	q.Media = in.Media
+	q.Subject = strings.TrimSpace(in.Subject)
+	q.Difficulty = in.Difficulty
+	q.Tags = NormalizeTags(in.Tags)
+
+	if err := ValidateBankQuestion(q); err != nil {
+		return err
+	}
+	return ValidateTags(q.Tags)
+}
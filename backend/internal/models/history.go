@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// PlayedSession is one session in an authenticated player's history. Rank is set once the
+// session has finished; until then Score is the sum of the player's points so far.
+type PlayedSession struct {
+	SessionID     string        `json:"sessionId"`
+	QuizID        string        `json:"quizId"`
+	QuizTitle     string        `json:"quizTitle"`
+	Status        SessionStatus `json:"status"`
+	EndedAt       *time.Time    `json:"endedAt,omitempty"`
+	PlayerCount   int           `json:"playerCount,omitempty"` // finished sessions only
+	Nickname      string        `json:"nickname,omitempty"`
+	Rank          int64         `json:"rank,omitempty"`
+	Score         float64       `json:"score"`
+	AnsweredCount int           `json:"answeredCount"`
+	CorrectCount  int           `json:"correctCount"`
+}
+
+// SessionReview is a finished session as one player played it: each question with the
+// option they chose beside the correct one.
+type SessionReview struct {
+	PlayedSession
+	Questions []ReviewedQuestion `json:"questions"`
+}
+
+// ReviewedQuestion is one question of a SessionReview. SelectedOptionID is empty if the
+// player didn't answer it. Options are missing when the quiz has since been deleted.
+type ReviewedQuestion struct {
+	QuestionID       string          `json:"questionId"`
+	QuestionIndex    int             `json:"questionIndex"`
+	Text             string          `json:"text"`
+	Options          []OptionPayload `json:"options,omitempty"`
+	CorrectOptionID  string          `json:"correctOptionId"`
+	SelectedOptionID string          `json:"selectedOptionId,omitempty"`
+	IsCorrect        bool            `json:"isCorrect"`
+	PointsEarned     int             `json:"pointsEarned"`
+	TimeTakenMs      int64           `json:"timeTakenMs,omitempty"`
+}
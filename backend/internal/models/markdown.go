@@ -0,0 +1,423 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Markdown quiz is a text file for quizzes kept under version control: front matter naming
+// the quiz, then one "##" heading per question followed by its attributes and a task list of
+// options, with [x] marking the correct one.
+//
+//	---
+//	title: World Capitals
+//	description: A warm-up round
+//	tags: geography, europe
+//	---
+//
+//	## What is the capital of France?
+//	time: 30
+//	points: 500
+//
+//	- [ ] Berlin
+//	- [x] Paris
+//	- [ ] Rome
+//
+// Front matter keys are title, description (a "|" block may span several indented lines),
+// tags (comma-separated, optionally in brackets) and id, the quiz a push updates. A "# Title"
+// heading may stand in for the title, and text before the first question for the description.
+//
+// Question text is the heading plus any paragraphs under it; a heading of just "Question" or
+// "Question 2:" leaves the text to the paragraphs. Lines in a paragraph join with spaces and
+// paragraphs with line breaks, as Markdown renders them. time (seconds) and points default
+// to DefaultTimeLimitSeconds and DefaultPoints. Options may continue on indented lines.
+
+// MarkdownIssue is a problem at a position in a Markdown quiz.
+type MarkdownIssue struct {
+	Line    int    `json:"line"`   // 1-based
+	Column  int    `json:"column"` // 1-based, in characters
+	Message string `json:"message"`
+}
+
+func (i MarkdownIssue) Error() string {
+	return fmt.Sprintf("%d:%d: %s", i.Line, i.Column, i.Message)
+}
+
+// MarkdownError lists every problem found in a Markdown quiz.
+type MarkdownError struct {
+	Issues []MarkdownIssue
+}
+
+func (e *MarkdownError) Error() string {
+	switch len(e.Issues) {
+	case 1:
+		return e.Issues[0].Error()
+	case 2:
+		return e.Issues[0].Error() + " (and 1 more problem)"
+	default:
+		return fmt.Sprintf("%s (and %d more problems)", e.Issues[0].Error(), len(e.Issues)-1)
+	}
+}
+
+var (
+	mdQuestionLabel = regexp.MustCompile(`(?i)^question(\s+\d+)?\s*(:|\.|$)\s*`)
+	mdAttribute     = regexp.MustCompile(`(?i)^(time|points)\s*:\s*(.*)$`)
+	mdListItem      = regexp.MustCompile(`^[-*+](\s+|$)`)
+	mdCheckbox      = regexp.MustCompile(`^\[([ xX])\](\s+|$)`)
+	mdFrontMatter   = regexp.MustCompile(`^([A-Za-z_]+)\s*:\s*(.*)$`)
+)
+
+// mdQuestion is a question being parsed.
+type mdQuestion struct {
+	line, column int
+	paragraphs   [][]string
+	options      []mdOption
+	attrs        map[string]bool
+	q            Question
+}
+
+type mdOption struct {
+	line, column int
+	text         []string
+	correct      bool
+}
+
+// mdParser holds the state of ParseMarkdownQuiz.
+type mdParser struct {
+	quiz           *Quiz
+	issues         []MarkdownIssue
+	description    []string // text before the first question
+	hasDescription bool     // set by front matter
+	tagsLine       int
+	cur            *mdQuestion
+	inOption       bool // the last line belonged to an option, so indented lines continue it
+}
+
+func (p *mdParser) issue(line, column int, format string, args ...any) {
+	p.issues = append(p.issues, MarkdownIssue{Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// charColumn returns the 1-based character column of byte offset i in line.
+func charColumn(line string, i int) int {
+	return utf8.RuneCountInString(line[:i]) + 1
+}
+
+// ParseMarkdownQuiz reads a quiz in the Markdown format described above. Questions and
+// options get IDs from their position (q1, q1-2, ...), so an unchanged file parses to
+// identical questions every time. Problems are returned together as a *MarkdownError; the
+// result passes ValidateQuiz and ValidateTags when there are none.
+func ParseMarkdownQuiz(src []byte) (*Quiz, error) {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+	lines := strings.Split(text, "\n")
+
+	p := &mdParser{quiz: &Quiz{}}
+	start := p.frontMatter(lines)
+	for i := start; i < len(lines); i++ {
+		p.line(i+1, strings.TrimRight(lines[i], " \t"))
+	}
+	p.finishQuestion()
+
+	if !p.hasDescription {
+		p.quiz.Description = joinParagraphs(splitParagraphs(p.description))
+	}
+	if p.quiz.Title == "" {
+		p.issue(1, 1, "title is required: add title: to the front matter or a # heading")
+	}
+	if len(p.quiz.Questions) == 0 && len(p.issues) == 0 {
+		p.issue(len(lines), 1, "at least one question is required; start each with a ## heading")
+	}
+	p.quiz.Tags = NormalizeTags(p.quiz.Tags)
+	if err := ValidateTags(p.quiz.Tags); err != nil {
+		p.issue(p.tagsLine, 1, "%v", err)
+	}
+
+	if len(p.issues) > 0 {
+		// Questions are checked when they end, so put problems back in file order
+		sort.SliceStable(p.issues, func(i, j int) bool {
+			a, b := p.issues[i], p.issues[j]
+			return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+		})
+		return nil, &MarkdownError{Issues: p.issues}
+	}
+	return p.quiz, nil
+}
+
+// frontMatter parses the front matter, if the file starts with it, and returns the index of
+// the first line after it.
+func (p *mdParser) frontMatter(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	seen := make(map[string]bool)
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		n := i + 1
+		if line == "---" || line == "..." {
+			return i + 1
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		m := mdFrontMatter.FindStringSubmatchIndex(line)
+		if m == nil {
+			p.issue(n, 1, "front matter lines must be key: value")
+			continue
+		}
+		key := strings.ToLower(line[m[2]:m[3]])
+		value := unquote(strings.TrimSpace(line[m[4]:m[5]]))
+		if seen[key] {
+			p.issue(n, 1, "%s is set twice", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "title":
+			p.quiz.Title = value
+		case "id":
+			p.quiz.QuizID = value
+		case "description":
+			p.hasDescription = true
+			if value == "|" || value == ">" {
+				// A block of indented lines
+				var block []string
+				for i+1 < len(lines) && (strings.HasPrefix(lines[i+1], " ") || strings.HasPrefix(lines[i+1], "\t") || strings.TrimSpace(lines[i+1]) == "") {
+					i++
+					block = append(block, strings.TrimSpace(lines[i]))
+				}
+				if value == "|" {
+					value = strings.TrimSpace(strings.Join(block, "\n"))
+				} else {
+					value = joinParagraphs(splitParagraphs(block))
+				}
+			}
+			p.quiz.Description = value
+		case "tags":
+			p.tagsLine = n
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			for _, t := range strings.Split(value, ",") {
+				p.quiz.Tags = append(p.quiz.Tags, unquote(strings.TrimSpace(t)))
+			}
+		default:
+			p.issue(n, 1, "unknown front matter key %q; use title, description, tags or id", key)
+		}
+	}
+	p.issue(1, 1, "front matter is missing its closing ---")
+	return len(lines)
+}
+
+// line handles one line of the body.
+func (p *mdParser) line(n int, line string) {
+	trimmed := strings.TrimSpace(line)
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+	switch {
+	case trimmed == "":
+		p.inOption = false
+		if p.cur != nil && len(p.cur.options) == 0 {
+			p.cur.paragraphs = append(p.cur.paragraphs, nil)
+		} else if p.cur == nil {
+			p.description = append(p.description, "")
+		}
+
+	case strings.HasPrefix(trimmed, "<!--") && strings.HasSuffix(trimmed, "-->"):
+		// Comments are notes for the author
+
+	case indent == 0 && (trimmed == "#" || strings.HasPrefix(trimmed, "# ")):
+		title := strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+		switch {
+		case p.cur != nil || p.quiz.Title != "":
+			p.issue(n, 1, "only the quiz title may use a # heading; start questions with ##")
+		case title == "":
+			p.issue(n, 1, "title heading is empty")
+		default:
+			p.quiz.Title = title
+		}
+
+	case indent == 0 && (trimmed == "##" || strings.HasPrefix(trimmed, "## ")):
+		p.finishQuestion()
+		heading := strings.TrimSpace(strings.TrimPrefix(trimmed, "##"))
+		heading = mdQuestionLabel.ReplaceAllString(heading, "")
+		p.cur = &mdQuestion{line: n, column: 1, attrs: make(map[string]bool)}
+		p.cur.paragraphs = [][]string{nil}
+		if heading != "" {
+			p.cur.paragraphs[0] = append(p.cur.paragraphs[0], heading)
+		}
+
+	case indent < 2 && mdListItem.MatchString(trimmed):
+		p.option(n, line, indent)
+
+	case p.cur != nil && indent == 0 && mdAttribute.MatchString(trimmed):
+		p.attribute(n, line)
+
+	case p.inOption && indent >= 2:
+		opt := &p.cur.options[len(p.cur.options)-1]
+		opt.text = append(opt.text, trimmed)
+
+	default:
+		p.text(n, indent, line)
+	}
+}
+
+// text handles a line of paragraph text.
+func (p *mdParser) text(n, indent int, line string) {
+	p.inOption = false
+	if p.cur == nil {
+		if p.hasDescription {
+			p.issue(n, indent+1, "text before the first question; start questions with ##")
+			return
+		}
+		p.description = append(p.description, strings.TrimSpace(line))
+		return
+	}
+	if len(p.cur.options) > 0 {
+		p.issue(n, charColumn(line, indent), "question text must come before the options")
+		return
+	}
+	last := len(p.cur.paragraphs) - 1
+	p.cur.paragraphs[last] = append(p.cur.paragraphs[last], strings.TrimSpace(line))
+}
+
+// option handles a "- [x] text" list item.
+func (p *mdParser) option(n int, line string, indent int) {
+	if p.cur == nil {
+		p.issue(n, indent+1, "options must follow a ## question heading")
+		return
+	}
+	item := line[indent+1:]
+	content := indent + 1 + (len(item) - len(strings.TrimLeft(item, " \t")))
+	item = strings.TrimSpace(item)
+
+	m := mdCheckbox.FindStringSubmatchIndex(item)
+	if m == nil {
+		p.issue(n, charColumn(line, content), "options need a checkbox: - [ ] for a wrong option, - [x] for the correct one")
+		p.inOption = false
+		return
+	}
+	opt := mdOption{line: n, column: charColumn(line, content), correct: item[m[2]:m[3]] != " "}
+	if text := strings.TrimSpace(item[m[1]:]); text != "" {
+		opt.text = []string{text}
+	}
+	p.cur.options = append(p.cur.options, opt)
+	p.inOption = true
+}
+
+// attribute handles a "time:" or "points:" line.
+func (p *mdParser) attribute(n int, line string) {
+	p.inOption = false
+	m := mdAttribute.FindStringSubmatchIndex(line)
+	key := strings.ToLower(line[m[2]:m[3]])
+	raw := line[m[4]:m[5]]
+	at := charColumn(line, m[4])
+	if p.cur.attrs[key] {
+		p.issue(n, 1, "%s is set twice", key)
+		return
+	}
+	p.cur.attrs[key] = true
+
+	switch key {
+	case "time":
+		v, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(raw), "s"))
+		if err != nil || v < MinTimeLimitSeconds || v > MaxTimeLimitSeconds {
+			p.issue(n, at, "time must be a whole number of seconds from %d to %d", MinTimeLimitSeconds, MaxTimeLimitSeconds)
+			return
+		}
+		p.cur.q.TimeLimitSeconds = v
+	case "points":
+		v, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || v < 0 || v > MaxPoints {
+			p.issue(n, at, "points must be a whole number from 0 to %d", MaxPoints)
+			return
+		}
+		p.cur.q.Points = v
+	}
+}
+
+// finishQuestion checks the question being parsed and adds it to the quiz.
+func (p *mdParser) finishQuestion() {
+	cur := p.cur
+	p.cur, p.inOption = nil, false
+	if cur == nil {
+		return
+	}
+	number := len(p.quiz.Questions) + 1
+
+	q := cur.q
+	q.QuestionID = fmt.Sprintf("q%d", number)
+	q.Text = joinParagraphs(cur.paragraphs)
+	if !cur.attrs["time"] {
+		q.TimeLimitSeconds = DefaultTimeLimitSeconds
+	}
+	if !cur.attrs["points"] {
+		q.Points = DefaultPoints
+	}
+	if q.Text == "" {
+		p.issue(cur.line, cur.column, "question text is required")
+	}
+
+	correct := 0
+	for i, o := range cur.options {
+		opt := Option{ID: fmt.Sprintf("%s-%d", q.QuestionID, i+1), Text: joinParagraphs([][]string{o.text})}
+		if opt.Text == "" {
+			p.issue(o.line, o.column, "option text is required")
+		}
+		if o.correct {
+			correct++
+			if correct == 2 {
+				p.issue(o.line, o.column, "only one option may be marked correct with [x]")
+			}
+			q.CorrectOptionID = opt.ID
+		}
+		q.Options = append(q.Options, opt)
+	}
+	switch {
+	case len(cur.options) < 2:
+		p.issue(cur.line, cur.column, "at least two options are required")
+	case correct == 0:
+		p.issue(cur.line, cur.column, "no option is marked correct with [x]")
+	}
+	if number > MaxQuizQuestions {
+		p.issue(cur.line, cur.column, "maximum %d questions per quiz", MaxQuizQuestions)
+	}
+
+	// Questions with problems are kept too, so the numbering of later ones doesn't shift;
+	// the quiz isn't returned while there are problems
+	p.quiz.Questions = append(p.quiz.Questions, q)
+}
+
+// splitParagraphs splits lines into paragraphs at blank lines.
+func splitParagraphs(lines []string) [][]string {
+	paragraphs := [][]string{nil}
+	for _, l := range lines {
+		if l == "" {
+			paragraphs = append(paragraphs, nil)
+			continue
+		}
+		paragraphs[len(paragraphs)-1] = append(paragraphs[len(paragraphs)-1], l)
+	}
+	return paragraphs
+}
+
+// joinParagraphs joins the lines of each paragraph with spaces and paragraphs with line
+// breaks, dropping empty ones.
+func joinParagraphs(paragraphs [][]string) string {
+	var out []string
+	for _, para := range paragraphs {
+		if s := strings.TrimSpace(strings.Join(para, " ")); s != "" {
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// unquote strips one pair of matching quotes from a front matter value.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// mdQuestionWant builds the question ParseMarkdownQuiz should produce at position number,
+// with options numbered from 1 and the option at correct (1-based) marked correct.
+func mdQuestionWant(number int, text string, timeLimit, points, correct int, options ...string) Question {
+	q := Question{
+		QuestionID:       fmt.Sprintf("q%d", number),
+		Text:             text,
+		TimeLimitSeconds: timeLimit,
+		Points:           points,
+	}
+	for i, o := range options {
+		id := fmt.Sprintf("q%d-%d", number, i+1)
+		q.Options = append(q.Options, Option{ID: id, Text: o})
+		if i+1 == correct {
+			q.CorrectOptionID = id
+		}
+	}
+	return q
+}
+
+const mdFrontMatterQuiz = `---
+title: "World Capitals"
+description: |
+  First line
+  second line
+
+  Third
+tags: [Geography, 'europe', geography]
+id: quiz-1
+---
+
+## Question 1: What is the capital of France?
+time: 30s
+points: 500
+
+- [ ] Berlin
+- [x] Paris,
+  the city of light
+- [ ] Rome
+
+## Question 2
+Which city
+is older?
+
+Pick one.
+
+- [X] Athens
+- [ ] Ottawa
+
+## question 3. Über the Alps
+- [ ] Zürich
+- [x] Genève
+`
+
+func TestParseMarkdownQuiz(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want *Quiz
+	}{
+		{
+			name: "front matter",
+			src:  mdFrontMatterQuiz,
+			want: &Quiz{
+				QuizID:      "quiz-1",
+				Title:       "World Capitals",
+				Description: "First line\nsecond line\n\nThird",
+				Tags:        []string{"geography", "europe"},
+				Questions: []Question{
+					mdQuestionWant(1, "What is the capital of France?", 30, 500, 2, "Berlin", "Paris, the city of light", "Rome"),
+					mdQuestionWant(2, "Which city is older?\nPick one.", DefaultTimeLimitSeconds, DefaultPoints, 1, "Athens", "Ottawa"),
+					mdQuestionWant(3, "Über the Alps", DefaultTimeLimitSeconds, DefaultPoints, 2, "Zürich", "Genève"),
+				},
+			},
+		},
+		{
+			name: "folded description",
+			src:  "---\ntitle: T\ndescription: >\n  First line\n  second line\n\n  Third\ntags: a, b\n---\n## Q?\n- [x] a\n- [ ] b\n",
+			want: &Quiz{
+				Title:       "T",
+				Description: "First line second line\nThird",
+				Tags:        []string{"a", "b"},
+				Questions:   []Question{mdQuestionWant(1, "Q?", DefaultTimeLimitSeconds, DefaultPoints, 1, "a", "b")},
+			},
+		},
+		{
+			name: "title heading and description paragraphs",
+			src:  "\ufeff# World Capitals\r\n\r\nA warm-up\r\nround\r\n\r\n## Questions about Paris\r\n- [x] a\r\n- [ ] b\r\n",
+			want: &Quiz{
+				Title:       "World Capitals",
+				Description: "A warm-up round",
+				Questions:   []Question{mdQuestionWant(1, "Questions about Paris", DefaultTimeLimitSeconds, DefaultPoints, 1, "a", "b")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMarkdownQuiz([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownQuizIssues(t *testing.T) {
+	timeRange := fmt.Sprintf("time must be a whole number of seconds from %d to %d", MinTimeLimitSeconds, MaxTimeLimitSeconds)
+	pointsRange := fmt.Sprintf("points must be a whole number from 0 to %d", MaxPoints)
+
+	tests := []struct {
+		name string
+		src  string
+		want []MarkdownIssue
+	}{
+		{
+			name: "unclosed front matter",
+			src:  "---\ntitle: T\n## Q?\n- [x] a\n- [ ] b\n",
+			want: []MarkdownIssue{
+				{1, 1, "front matter is missing its closing ---"},
+				{4, 1, "front matter lines must be key: value"},
+				{5, 1, "front matter lines must be key: value"},
+			},
+		},
+		{
+			name: "front matter keys",
+			src:  "---\ntitle: T\ntitle: U\nauthor: me\n---\n## Q?\n- [x] a\n- [ ] b\n",
+			want: []MarkdownIssue{
+				{3, 1, "title is set twice"},
+				{4, 1, `unknown front matter key "author"; use title, description, tags or id`},
+			},
+		},
+		{
+			name: "attributes",
+			src: "# T\n" +
+				"## One\n" + // 2
+				"time: 30\n" +
+				"time: 40\n" + // 4: duplicate
+				"points: 99999\n" + // 5: out of range, value at column 9
+				"- [x] a\n- [ ] b\n" +
+				"## Two\n" + // 8
+				"TIME:   4\n" + // 9: out of range, value at column 9
+				"points: -1\n" + // 10
+				"points: 10\n" + // 11: duplicate even though the first was rejected
+				"- [x] a\n- [ ] b\n",
+			want: []MarkdownIssue{
+				{4, 1, "time is set twice"},
+				{5, 9, pointsRange},
+				{9, 9, timeRange},
+				{10, 9, pointsRange},
+				{11, 1, "points is set twice"},
+			},
+		},
+		{
+			// Columns count characters, so lines of multibyte text report the same columns
+			// as ASCII ones
+			name: "options",
+			src: "# Städte\n" +
+				"## Größte Stadt?\n" + // 2
+				"- [x] München\n" +
+				"- [X] Köln\n" + // 4: second correct option, text at column 3
+				"Straße\n" + // 5: text after the options
+				" - Düsseldorf\n" + // 6: no checkbox; content at column 4
+				"- [ ]\n" + // 7: no text
+				"## Frage\n" + // 8
+				"- [ ] ä\n" +
+				"- [ ] ö\n" + // no correct option
+				"##\n" + // 11: no text, too few options
+				"- [x] ü\n",
+			want: []MarkdownIssue{
+				{4, 3, "only one option may be marked correct with [x]"},
+				{5, 1, "question text must come before the options"},
+				{6, 4, "options need a checkbox: - [ ] for a wrong option, - [x] for the correct one"},
+				{7, 3, "option text is required"},
+				{8, 1, "no option is marked correct with [x]"},
+				{11, 1, "question text is required"},
+				{11, 1, "at least two options are required"},
+			},
+		},
+		{
+			// The # heading stands in for the title the front matter left out
+			name: "text and headings out of place",
+			src:  "---\ndescription: d\n---\nStray text\n- [x] early\n# Title\n## Q?\n# Late title\n- [x] a\n- [ ] b\n",
+			want: []MarkdownIssue{
+				{4, 1, "text before the first question; start questions with ##"},
+				{5, 1, "options must follow a ## question heading"},
+				{8, 1, "only the quiz title may use a # heading; start questions with ##"},
+			},
+		},
+		{
+			name: "no questions",
+			src:  "# Title\n\nJust a description.\n",
+			want: []MarkdownIssue{{4, 1, "at least one question is required; start each with a ## heading"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMarkdownQuiz([]byte(tt.src))
+			var mdErr *MarkdownError
+			if !errors.As(err, &mdErr) {
+				t.Fatalf("got %v, want a *MarkdownError", err)
+			}
+			if !reflect.DeepEqual(mdErr.Issues, tt.want) {
+				t.Errorf("got issues\n%v\nwant\n%v", mdErr.Issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownQuizIDsAreStable(t *testing.T) {
+	first, err := ParseMarkdownQuiz([]byte(mdFrontMatterQuiz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ParseMarkdownQuiz([]byte(mdFrontMatterQuiz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("parsing the same file twice gave\n%+v\nand\n%+v", first, second)
+	}
+	if q := first.Questions[0]; q.QuestionID != "q1" || q.Options[1].ID != "q1-2" || q.CorrectOptionID != "q1-2" {
+		t.Errorf("got question %q with options %+v, want q1 with q1-2 correct", q.QuestionID, q.Options)
+	}
+}
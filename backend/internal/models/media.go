@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Media types. Images and audio clips are uploaded to the media store; videos are linked.
+const (
+	MediaImage = "image"
+	MediaAudio = "audio"
+	MediaVideo = "video"
+)
+
+// MediaKeyPrefix starts the key of every file in the media store.
+const MediaKeyPrefix = "media/"
+
+// Limits on media fields.
+const (
+	MaxMediaAltLength = 500
+	MaxMediaURLLength = 2048
+)
+
+// Media is a file attached to a question or option. Images and audio clips are kept in the
+// media store under Key; a video is a link to a hosted video, optionally played from
+// StartSeconds to EndSeconds.
+type Media struct {
+	Type         string `json:"type" dynamodbav:"type"`
+	Key          string `json:"key,omitempty" dynamodbav:"key,omitempty"`
+	ContentType  string `json:"contentType,omitempty" dynamodbav:"contentType,omitempty"`
+	URL          string `json:"url,omitempty" dynamodbav:"url,omitempty"` // videos only
+	Alt          string `json:"alt,omitempty" dynamodbav:"alt,omitempty"` // text description for screen readers
+	StartSeconds int    `json:"startSeconds,omitempty" dynamodbav:"startSeconds,omitempty"`
+	EndSeconds   int    `json:"endSeconds,omitempty" dynamodbav:"endSeconds,omitempty"` // 0 plays to the end
+}
+
+// Validate checks a media attachment's fields for its type.
+func (m *Media) Validate() error {
+	switch m.Type {
+	case MediaImage, MediaAudio:
+		if !strings.HasPrefix(m.Key, MediaKeyPrefix) || strings.Contains(m.Key, "..") {
+			return errors.New("key must be the key of an uploaded file")
+		}
+		if m.URL != "" {
+			return errors.New("url is only allowed on videos")
+		}
+	case MediaVideo:
+		if m.Key != "" {
+			return errors.New("videos are linked by url, not uploaded")
+		}
+		if len(m.URL) > MaxMediaURLLength {
+			return fmt.Errorf("url must be at most %d characters", MaxMediaURLLength)
+		}
+		u, err := url.Parse(m.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("url must be an http or https link")
+		}
+	default:
+		return fmt.Errorf("type must be %q, %q or %q", MediaImage, MediaAudio, MediaVideo)
+	}
+	if m.Type == MediaImage && (m.StartSeconds != 0 || m.EndSeconds != 0) {
+		return errors.New("images can't have a start or end time")
+	}
+	if m.StartSeconds < 0 || m.EndSeconds < 0 {
+		return errors.New("start and end times can't be negative")
+	}
+	if m.EndSeconds != 0 && m.EndSeconds <= m.StartSeconds {
+		return errors.New("end time must be after the start time")
+	}
+	if len(m.Alt) > MaxMediaAltLength {
+		return fmt.Errorf("alt text must be at most %d characters", MaxMediaAltLength)
+	}
+	return nil
+}
+
+// validateQuestionMedia checks the media on a question and its options. label names the
+// question in errors, e.g. "Question 3".
+func validateQuestionMedia(label string, q Question) error {
+	if q.Media != nil {
+		if err := q.Media.Validate(); err != nil {
+			return fmt.Errorf("%s media: %v", label, err)
+		}
+	}
+	for i, o := range q.Options {
+		if o.Media == nil {
+			continue
+		}
+		if o.Media.Type == MediaVideo {
+			return fmt.Errorf("%s option %d media: options can't have videos", label, i+1)
+		}
+		if err := o.Media.Validate(); err != nil {
+			return fmt.Errorf("%s option %d media: %v", label, i+1, err)
+		}
+	}
+	return nil
+}
+
+// MediaPayload is a media attachment as sent to players: store keys are replaced by signed
+// URLs their devices can fetch directly.
+type MediaPayload struct {
+	Type         string `json:"type"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"` // images only
+	ContentType  string `json:"contentType,omitempty"`
+	Alt          string `json:"alt,omitempty"`
+	StartSeconds int    `json:"startSeconds,omitempty"`
+	EndSeconds   int    `json:"endSeconds,omitempty"`
+}
+
+// OptionPayload is an answer option as sent to players.
+type OptionPayload struct {
+	ID    string        `json:"id"`
+	Text  string        `json:"text"`
+	Media *MediaPayload `json:"media,omitempty"`
+}
+
+// UploadedMedia is the response to a media upload: the attachment to put on a question or
+// option, and signed URLs to preview it with in the editor.
+type UploadedMedia struct {
+	Media   Media        `json:"media"`
+	Preview MediaPayload `json:"preview"`
+}
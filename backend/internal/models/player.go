@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // PlayerRole represents whether a connection belongs to a host or player.
 type PlayerRole string
@@ -24,8 +30,83 @@ type Player struct {
 
 // PlayerScore is used for leaderboard display.
 type PlayerScore struct {
-	UserID   string  `json:"userId"`
-	Nickname string  `json:"nickname"`
-	Score    float64 `json:"score"`
-	Rank     int64   `json:"rank"`
+	UserID      string  `json:"userId"`
+	Nickname    string  `json:"nickname"`
+	Score       float64 `json:"score"`
+	TotalTimeMs int64   `json:"totalTimeMs"` // cumulative time of correct answers, used to break ties
+	Rank        int64   `json:"rank"`
+}
+
+// AnonymousUserPrefix prefixes the generated IDs of players who join without signing in.
+const AnonymousUserPrefix = "anon-"
+
+// IsAnonymous reports whether userID belongs to a player who did not sign in.
+func IsAnonymous(userID string) bool {
+	return strings.HasPrefix(userID, AnonymousUserPrefix)
+}
+
+// NewAnonymousUserID generates the userId of a player who connects without signing in.
+func NewAnonymousUserID() string {
+	return AnonymousUserPrefix + uuid.New().String()[:8]
+}
+
+// RankMode selects how players with equal scores are ranked.
+type RankMode string
+
+const (
+	// RankModeTiebreak gives every player a unique rank; equal scores are ordered by
+	// cumulative answer time, fastest first.
+	RankModeTiebreak RankMode = "tiebreak"
+	// RankModeDense gives players with equal scores the same rank, with no gaps (1, 1, 2).
+	RankModeDense RankMode = "dense"
+)
+
+// SortAndRank orders scores the same way the Redis leaderboard does — score descending,
+// then cumulative time ascending, then userId descending — and assigns ranks using mode.
+func SortAndRank(scores []PlayerScore, mode RankMode) {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		if scores[i].TotalTimeMs != scores[j].TotalTimeMs {
+			return scores[i].TotalTimeMs < scores[j].TotalTimeMs
+		}
+		return scores[i].UserID > scores[j].UserID
+	})
+	AssignRanks(scores, 1, mode)
+}
+
+// AssignRanks sets ranks on an already ordered slice whose first entry has rank firstRank.
+func AssignRanks(scores []PlayerScore, firstRank int64, mode RankMode) {
+	rank := firstRank
+	for i := range scores {
+		if i > 0 {
+			if mode != RankModeDense || scores[i].Score != scores[i-1].Score {
+				rank++
+			}
+		}
+		scores[i].Rank = rank
+	}
+}
+
+// RankChange returns how many places a player moved up since previousRank
+// (negative when they dropped). Returns 0 when there is no previous rank.
+func RankChange(previousRank, rank int64) int64 {
+	if previousRank <= 0 || rank <= 0 {
+		return 0
+	}
+	return previousRank - rank
+}
+
+// Nearby returns the entry at index together with up to n entries above and below it.
+func Nearby(scores []PlayerScore, index, n int) []PlayerScore {
+	start := index - n
+	if start < 0 {
+		start = 0
+	}
+	end := index + n + 1
+	if end > len(scores) {
+		end = len(scores)
+	}
+	return scores[start:end]
 }
@@ -1,18 +1,142 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
 
 // Quiz represents a quiz created by a host.
 type Quiz struct {
-	QuizID      string     `json:"quizId" dynamodbav:"quizId"`
-	HostUserID  string     `json:"hostUserId" dynamodbav:"hostUserId"`
-	Title       string     `json:"title" dynamodbav:"title"`
-	Description string     `json:"description" dynamodbav:"description"`
-	Questions   []Question `json:"questions" dynamodbav:"questions"`
-	CreatedAt   time.Time  `json:"createdAt" dynamodbav:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt" dynamodbav:"updatedAt"`
+	QuizID      string         `json:"quizId" dynamodbav:"quizId"`
+	HostUserID  string         `json:"hostUserId" dynamodbav:"hostUserId"`
+	Title       string         `json:"title" dynamodbav:"title"`
+	Description string         `json:"description" dynamodbav:"description"`
+	Tags        []string       `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
+	Questions   []Question     `json:"questions" dynamodbav:"questions"`
+	Rules       []QuestionRule `json:"rules,omitempty" dynamodbav:"rules,omitempty"` // draw more questions from the question bank when a session starts
+	Pool        []PoolQuestion `json:"pool,omitempty" dynamodbav:"pool,omitempty"`   // bank questions the rules can draw; set only on snapshots, by SnapshotQuiz
+	CreatedAt   time.Time      `json:"createdAt" dynamodbav:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt" dynamodbav:"updatedAt"`
+	Version     int64          `json:"version" dynamodbav:"version,omitempty"` // incremented on every update, for optimistic locking
+
+	// TitleLower is the lowercased title, stored so DynamoDB filters can match titles
+	// case-insensitively. Set by the store on write.
+	TitleLower string `json:"-" dynamodbav:"titleLower,omitempty"`
+}
+
+// SameQuestions reports whether two question lists are identical, so an edit that only
+// touches a quiz's title, description or tags can be told apart from one that changes play.
+func SameQuestions(a, b []Question) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// MaxQuizQuestions is the most questions a quiz may have.
+const MaxQuizQuestions = 100
+
+// ValidateQuiz checks the fields every quiz needs, whichever way it was created or edited.
+// Questions drawn by rules count towards MaxQuizQuestions.
+func ValidateQuiz(title string, questions []Question, rules []QuestionRule) error {
+	if title == "" {
+		return errors.New("Title is required")
+	}
+	if err := validateRules(rules); err != nil {
+		return err
+	}
+	total := len(questions)
+	for _, r := range rules {
+		total += r.Count
+	}
+	if total == 0 {
+		return errors.New("At least one question is required")
+	}
+	if total > MaxQuizQuestions {
+		return fmt.Errorf("Maximum %d questions per quiz", MaxQuizQuestions)
+	}
+	for i, q := range questions {
+		if err := validateQuestionMedia(fmt.Sprintf("Question %d", i+1), q); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// Limits on quiz and question bank tags.
+const (
+	MaxQuizTags  = 20
+	MaxTagLength = 32
+)
+
+// NormalizeTags lowercases and trims tags, dropping empty ones and duplicates while keeping
+// the first occurrence's position.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// ValidateTags checks normalized tags against MaxQuizTags and MaxTagLength.
+func ValidateTags(tags []string) error {
+	if len(tags) > MaxQuizTags {
+		return fmt.Errorf("maximum %d tags", MaxQuizTags)
+	}
+	for _, t := range tags {
+		if len(t) > MaxTagLength {
+			return fmt.Errorf("tags must be at most %d characters", MaxTagLength)
+		}
+	}
+	return nil
+}
+
+// QuizFilter narrows a quiz listing. Empty fields match every quiz.
+type QuizFilter struct {
+	Title string // case-insensitive substring of the title
+	Tag   string // exact tag, compared after normalization
+}
+
+// Normalize lowercases the filter so it can be compared with TitleLower and normalized tags.
+func (f QuizFilter) Normalize() QuizFilter {
+	return QuizFilter{
+		Title: strings.ToLower(strings.TrimSpace(f.Title)),
+		Tag:   strings.ToLower(strings.TrimSpace(f.Tag)),
+	}
+}
+
+// Matches reports whether q passes a normalized filter.
+func (f QuizFilter) Matches(q *Quiz) bool {
+	if f.Title != "" && !strings.Contains(strings.ToLower(q.Title), f.Title) {
+		return false
+	}
+	if f.Tag != "" {
+		for _, t := range q.Tags {
+			if t == f.Tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Defaults and bounds for a question's time limit and points, matching the quiz editor.
+const (
+	DefaultTimeLimitSeconds = 20
+	MinTimeLimitSeconds     = 5
+	MaxTimeLimitSeconds     = 120
+	DefaultPoints           = 1000
+	MaxPoints               = 5000
+)
+
 // Question represents a single question within a quiz.
 type Question struct {
 	QuestionID       string   `json:"questionId" dynamodbav:"questionId"`
@@ -21,12 +145,19 @@ type Question struct {
 	CorrectOptionID  string   `json:"correctOptionId" dynamodbav:"correctOptionId"`
 	TimeLimitSeconds int      `json:"timeLimitSeconds" dynamodbav:"timeLimitSeconds"`
 	Points           int      `json:"points" dynamodbav:"points"`
+	Media            *Media   `json:"media,omitempty" dynamodbav:"media,omitempty"`
+
+	// BankQuestionID links the question to one in its host's question bank. The fields above
+	// then hold a copy of the bank question, refreshed when the quiz is saved and when a
+	// session snapshots it.
+	BankQuestionID string `json:"bankQuestionId,omitempty" dynamodbav:"bankQuestionId,omitempty"`
 }
 
 // Option represents an answer option for a question.
 type Option struct {
-	ID   string `json:"id" dynamodbav:"id"`
-	Text string `json:"text" dynamodbav:"text"`
+	ID    string `json:"id" dynamodbav:"id"`
+	Text  string `json:"text" dynamodbav:"text"`
+	Media *Media `json:"media,omitempty" dynamodbav:"media,omitempty"`
 }
 
 // QuestionPayloadForPlayer is the sanitized question sent to players (no correct answer).
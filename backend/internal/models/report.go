@@ -0,0 +1,110 @@
+package models
+
+import "time"
+
+// SessionReport is the post-game analysis of a session for its host: how the class did on
+// each question and how each player did overall. Rates are fractions between 0 and 1.
+//
+// Accuracy has two denominators on purpose. A question's accuracy, like the session's, is
+// out of the answers given, because the players who didn't answer are reported separately
+// in its UnansweredCount. A player's accuracy is out of the questions played, so a question
+// they let time out counts against them the same as a wrong answer.
+type SessionReport struct {
+	SessionID       string           `json:"sessionId"`
+	QuizID          string           `json:"quizId"`
+	QuizVersion     int64            `json:"quizVersion,omitempty"`
+	QuizTitle       string           `json:"quizTitle"`
+	Status          SessionStatus    `json:"status"`
+	StartedAt       *time.Time       `json:"startedAt,omitempty"`
+	EndedAt         *time.Time       `json:"endedAt,omitempty"`
+	PlayerCount     int              `json:"playerCount"`
+	QuestionCount   int              `json:"questionCount"`
+	QuestionsPlayed int              `json:"questionsPlayed"` // questions shown before the game ended
+	Completion      float64          `json:"completion"`      // answers given out of players × questions played
+	Accuracy        float64          `json:"accuracy"`        // correct answers out of answers given
+	AverageScore    float64          `json:"averageScore"`
+	Questions       []QuestionReport `json:"questions"`
+	Players         []PlayerReport   `json:"players"`
+}
+
+// QuestionReport is how the players answered one question.
+type QuestionReport struct {
+	QuestionID      string         `json:"questionId"`
+	QuestionIndex   int            `json:"questionIndex"`
+	Text            string         `json:"text"`
+	CorrectOptionID string         `json:"correctOptionId"`
+	Played          bool           `json:"played"`
+	AnswerCount     int            `json:"answerCount"`
+	CorrectCount    int            `json:"correctCount"`
+	UnansweredCount int            `json:"unansweredCount"` // players who didn't answer a played question
+	Accuracy        float64        `json:"accuracy"`        // correct answers out of answers given
+	AverageTimeMs   int64          `json:"averageTimeMs"`
+	Options         []OptionReport `json:"options"`
+
+	// MostConfusedOptionID is the wrong option chosen most often, if any player chose one.
+	MostConfusedOptionID string `json:"mostConfusedOptionId,omitempty"`
+}
+
+// OptionReport is how many players chose one option of a question.
+type OptionReport struct {
+	OptionID  string  `json:"optionId"`
+	Text      string  `json:"text"`
+	IsCorrect bool    `json:"isCorrect"`
+	Count     int     `json:"count"`
+	Share     float64 `json:"share"` // of the answers given to the question
+}
+
+// PlayerReport is one player's performance over the whole session.
+type PlayerReport struct {
+	UserID          string  `json:"userId"`
+	Nickname        string  `json:"nickname"`
+	Rank            int64   `json:"rank"`
+	Score           float64 `json:"score"`
+	AnsweredCount   int     `json:"answeredCount"`
+	CorrectCount    int     `json:"correctCount"`
+	UnansweredCount int     `json:"unansweredCount"` // of the questions played
+	Accuracy        float64 `json:"accuracy"`        // correct answers out of questions played
+	AverageTimeMs   int64   `json:"averageTimeMs"`
+}
+
+// Gradebook is a finished session's grades for download: its questions, and a row per
+// player with one GradeCell per question. Rows are produced by EachRow while the session's
+// answers are read, so a download can be written without holding every row in memory.
+type Gradebook struct {
+	SessionID string
+	QuizTitle string
+	EndedAt   *time.Time
+	Questions []GradebookQuestion
+
+	// EachRow calls fn with each player's row in turn and stops at fn's first error. Every
+	// call reads the session's answers again.
+	EachRow func(fn func(GradebookRow) error) error
+}
+
+// GradebookRow is one player's line of a gradebook.
+type GradebookRow struct {
+	Player PlayerScore
+	Cells  []GradeCell // one per question; reused for the next row once fn returns
+}
+
+// GradebookQuestion is one question column of a gradebook.
+type GradebookQuestion struct {
+	QuestionID string
+	Text       string
+	Points     int // points for a correct answer
+}
+
+// GradeCell is how one player did on one question.
+type GradeCell struct {
+	Answered bool
+	Correct  bool
+	Points   int32
+}
+
+// ReportDownload is returned instead of a report export that is too large to send in an API
+// response: a signed link to the file in the media store.
+type ReportDownload struct {
+	URL       string    `json:"url"`
+	FileName  string    `json:"fileName"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
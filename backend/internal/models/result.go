@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Record keys used in the results table (PK: sessionId, SK: recordKey).
+const (
+	ResultRecordSummary    = "SUMMARY"
+	ResultRecordRankPrefix = "RANK#"
+	ResultRecordUserPrefix = "USER#"
+)
+
+// RankRecordKey returns the sort key for a player's result so that items sort by
+// leaderboard position. Position is used rather than rank because dense ranks repeat.
+func RankRecordKey(position int) string {
+	return fmt.Sprintf("%s%06d", ResultRecordRankPrefix, position)
+}
+
+// UserRecordKey returns the sort key of the copy of a player's result kept for looking it up
+// by user.
+func UserRecordKey(userID string) string {
+	return ResultRecordUserPrefix + userID
+}
+
+// SessionResult is the persisted summary of a finished session.
+// Player rankings are stored as separate PlayerResult items under the same sessionId.
+type SessionResult struct {
+	SessionID   string          `json:"sessionId" dynamodbav:"sessionId"`
+	RecordKey   string          `json:"-" dynamodbav:"recordKey"` // always ResultRecordSummary
+	QuizID      string          `json:"quizId" dynamodbav:"quizId"`
+	QuizVersion int64           `json:"quizVersion,omitempty" dynamodbav:"quizVersion,omitempty"`
+	QuizSeed    int64           `json:"quizSeed,omitempty" dynamodbav:"quizSeed,omitempty"`
+	QuizTitle   string          `json:"quizTitle" dynamodbav:"quizTitle"`
+	HostUserID  string          `json:"hostUserId" dynamodbav:"hostUserId"`
+	PlayerCount int             `json:"playerCount" dynamodbav:"playerCount"`
+	Questions   []QuestionStats `json:"questions" dynamodbav:"questions"`
+	StartedAt   *time.Time      `json:"startedAt,omitempty" dynamodbav:"startedAt,omitempty"`
+	EndedAt     time.Time       `json:"endedAt" dynamodbav:"endedAt"`
+}
+
+// QuestionStats summarizes how players answered a single question.
+type QuestionStats struct {
+	QuestionID      string         `json:"questionId" dynamodbav:"questionId"`
+	QuestionIndex   int            `json:"questionIndex" dynamodbav:"questionIndex"`
+	Text            string         `json:"text" dynamodbav:"text"`
+	CorrectOptionID string         `json:"correctOptionId" dynamodbav:"correctOptionId"`
+	AnswerCount     int            `json:"answerCount" dynamodbav:"answerCount"`
+	CorrectCount    int            `json:"correctCount" dynamodbav:"correctCount"`
+	AverageTimeMs   int64          `json:"averageTimeMs" dynamodbav:"averageTimeMs"`
+	OptionCounts    map[string]int `json:"optionCounts" dynamodbav:"optionCounts"` // optionId -> number of players who chose it
+}
+
+// PlayerResult is one player's final standing in a finished session.
+type PlayerResult struct {
+	SessionID     string  `json:"sessionId" dynamodbav:"sessionId"`
+	RecordKey     string  `json:"-" dynamodbav:"recordKey"` // RankRecordKey(position), or UserRecordKey on the copy by user
+	UserID        string  `json:"userId" dynamodbav:"userId"`
+	Nickname      string  `json:"nickname" dynamodbav:"nickname"`
+	Score         float64 `json:"score" dynamodbav:"score"`
+	TotalTimeMs   int64   `json:"totalTimeMs" dynamodbav:"totalTimeMs"`
+	Rank          int64   `json:"rank" dynamodbav:"rank"`
+	AnsweredCount int     `json:"answeredCount" dynamodbav:"answeredCount"`
+	CorrectCount  int     `json:"correctCount" dynamodbav:"correctCount"`
+
+	// Denormalized from the session for authenticated players only, so that anonymous
+	// players are left out of the quizId and hostUserId all-time leaderboard indexes.
+	QuizID     string `json:"quizId,omitempty" dynamodbav:"quizId,omitempty"`
+	HostUserID string `json:"hostUserId,omitempty" dynamodbav:"hostUserId,omitempty"`
+	FinishedAt int64  `json:"finishedAt,omitempty" dynamodbav:"finishedAt,omitempty"` // Unix seconds
+}
+
+// PlayerScore converts a persisted result into the leaderboard display form.
+func (p PlayerResult) PlayerScore() PlayerScore {
+	return PlayerScore{
+		UserID:      p.UserID,
+		Nickname:    p.Nickname,
+		Score:       p.Score,
+		TotalTimeMs: p.TotalTimeMs,
+		Rank:        p.Rank,
+	}
+}
+
+// AllTimeScore is a player's combined standing across several finished sessions.
+type AllTimeScore struct {
+	PlayerScore
+	SessionsPlayed int `json:"sessionsPlayed"`
+	AnsweredCount  int `json:"answeredCount"`
+	CorrectCount   int `json:"correctCount"`
+}
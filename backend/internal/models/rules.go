@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+)
+
+// QuestionRule draws Count random questions from the host's question bank each time a
+// session starts, from those with Tag (any, if empty) and a difficulty from MinDifficulty
+// to MaxDifficulty (0 leaves that end open). Unrated questions only match rules without a
+// difficulty range.
+type QuestionRule struct {
+	Tag           string `json:"tag,omitempty" dynamodbav:"tag,omitempty"`
+	MinDifficulty int    `json:"minDifficulty,omitempty" dynamodbav:"minDifficulty,omitempty"`
+	MaxDifficulty int    `json:"maxDifficulty,omitempty" dynamodbav:"maxDifficulty,omitempty"`
+	Count         int    `json:"count" dynamodbav:"count"`
+}
+
+// Matches reports whether a bank question can be drawn by the rule.
+func (r *QuestionRule) Matches(q *PoolQuestion) bool {
+	if tag := strings.ToLower(strings.TrimSpace(r.Tag)); tag != "" {
+		found := false
+		for _, t := range q.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.MinDifficulty > 0 && (q.Difficulty == 0 || q.Difficulty < r.MinDifficulty) {
+		return false
+	}
+	if r.MaxDifficulty > 0 && (q.Difficulty == 0 || q.Difficulty > r.MaxDifficulty) {
+		return false
+	}
+	return true
+}
+
+// PoolQuestion is a bank question a quiz's rules may draw, as frozen into a quiz snapshot.
+// Its QuestionID and BankQuestionID are both the bank question's ID.
+type PoolQuestion struct {
+	Question
+	Difficulty int      `json:"difficulty,omitempty" dynamodbav:"difficulty,omitempty"`
+	Tags       []string `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
+}
+
+// PoolQuestion returns the bank question as it is frozen into a snapshot's pool.
+func (b *BankQuestion) PoolQuestion() PoolQuestion {
+	return PoolQuestion{
+		Question:   b.QuizQuestion(b.QuestionID),
+		Difficulty: b.Difficulty,
+		Tags:       b.Tags,
+	}
+}
+
+// SamePool reports whether two snapshot pools are identical.
+func SamePool(a, b []PoolQuestion) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// validateRules checks a quiz's rules; index numbers are 1-based in errors.
+func validateRules(rules []QuestionRule) error {
+	for i, r := range rules {
+		if r.Count < 1 {
+			return fmt.Errorf("Rule %d must draw at least one question", i+1)
+		}
+		if len(r.Tag) > MaxTagLength {
+			return fmt.Errorf("Rule %d tag must be at most %d characters", i+1, MaxTagLength)
+		}
+		if r.MinDifficulty < 0 || r.MinDifficulty > MaxDifficulty || r.MaxDifficulty < 0 || r.MaxDifficulty > MaxDifficulty {
+			return fmt.Errorf("Rule %d difficulty must be from 1 to %d", i+1, MaxDifficulty)
+		}
+		if r.MinDifficulty > 0 && r.MaxDifficulty > 0 && r.MinDifficulty > r.MaxDifficulty {
+			return fmt.Errorf("Rule %d minimum difficulty is above its maximum", i+1)
+		}
+	}
+	return nil
+}
+
+// Drawn returns the quiz a session with the given seed plays: its questions followed by
+// those its rules draw from the snapshot's Pool, in rule order. The same snapshot and seed
+// always draw the same questions. A question is drawn at most once, and never when the quiz
+// already links it; a rule left with too few candidates by earlier ones draws fewer. The game
+// package won't snapshot rules where that can happen.
+func (q *Quiz) Drawn(seed int64) *Quiz {
+	drawn := *q
+	drawn.Rules, drawn.Pool = nil, nil
+	drawn.Questions = append([]Question(nil), q.Questions...)
+
+	used := make(map[string]bool, len(q.Questions))
+	for _, fixed := range q.Questions {
+		if fixed.BankQuestionID != "" {
+			used[fixed.BankQuestionID] = true
+		}
+	}
+
+	// PCG's output is fixed for a seed across Go releases, and only its raw output is used,
+	// so a recorded seed draws the same questions for as long as the snapshot exists.
+	rng := rand.NewPCG(uint64(seed), 0)
+	for i := range q.Rules {
+		var candidates []int
+		for j := range q.Pool {
+			if !used[q.Pool[j].QuestionID] && q.Rules[i].Matches(&q.Pool[j]) {
+				candidates = append(candidates, j)
+			}
+		}
+		for k := 0; k < q.Rules[i].Count && k < len(candidates); k++ {
+			pick := k + int(rng.Uint64()%uint64(len(candidates)-k))
+			candidates[k], candidates[pick] = candidates[pick], candidates[k]
+			p := q.Pool[candidates[k]]
+			used[p.QuestionID] = true
+			drawn.Questions = append(drawn.Questions, p.Question)
+		}
+	}
+	return &drawn
+}
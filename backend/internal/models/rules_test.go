@@ -0,0 +1,137 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func poolQuestion(id string, difficulty int, tags ...string) PoolQuestion {
+	return PoolQuestion{
+		Question:   Question{QuestionID: id, BankQuestionID: id, Text: id},
+		Difficulty: difficulty,
+		Tags:       tags,
+	}
+}
+
+// drawQuiz links bank question b02 directly and draws from the rest of the pool: two math
+// questions, then a math question of difficulty 1-2, then any three. The rules overlap, and
+// the pool has exactly enough questions that every rule draws in full.
+func drawQuiz() *Quiz {
+	return &Quiz{
+		QuizID: "quiz",
+		Questions: []Question{
+			{QuestionID: "q1", Text: "fixed"},
+			{QuestionID: "q2", Text: "linked", BankQuestionID: "b02"},
+		},
+		Rules: []QuestionRule{
+			{Tag: "math", Count: 2},
+			{Tag: "Math", MinDifficulty: 1, MaxDifficulty: 2, Count: 1},
+			{Count: 3},
+		},
+		Pool: []PoolQuestion{
+			poolQuestion("b01", 1, "math"),
+			poolQuestion("b02", 1, "math"),
+			poolQuestion("b03", 2, "math"),
+			poolQuestion("b04", 2, "math"),
+			poolQuestion("b05", 3, "math"),
+			poolQuestion("b06", 0, "math"),
+			poolQuestion("b07", 0, "art"),
+			poolQuestion("b08", 0, "art"),
+			poolQuestion("b09", 0, "art"),
+		},
+	}
+}
+
+// drawnIDs returns the IDs of the questions the rules drew.
+func drawnIDs(quiz *Quiz, seed int64) []string {
+	var ids []string
+	for _, q := range quiz.Drawn(seed).Questions[len(quiz.Questions):] {
+		ids = append(ids, q.QuestionID)
+	}
+	return ids
+}
+
+func TestDrawnIsStablePerSeed(t *testing.T) {
+	quiz := drawQuiz()
+	first := quiz.Drawn(42)
+	if again := drawQuiz().Drawn(42); !reflect.DeepEqual(first, again) {
+		t.Errorf("seed 42 drew %v, then %v", drawnIDs(quiz, 42), drawnIDs(drawQuiz(), 42))
+	}
+	if first.Rules != nil || first.Pool != nil {
+		t.Error("drawn quiz kept its rules and pool")
+	}
+	if len(quiz.Questions) != 2 || len(quiz.Pool) != 9 {
+		t.Error("drawing changed the quiz it drew from")
+	}
+
+	differs := false
+	for seed := int64(1); seed <= 20 && !differs; seed++ {
+		differs = !reflect.DeepEqual(drawnIDs(quiz, seed), drawnIDs(quiz, 42))
+	}
+	if !differs {
+		t.Errorf("seeds 1-20 all drew the same questions as seed 42: %v", drawnIDs(quiz, 42))
+	}
+}
+
+func TestDrawnFollowsRules(t *testing.T) {
+	quiz := drawQuiz()
+	pool := make(map[string]*PoolQuestion, len(quiz.Pool))
+	for i := range quiz.Pool {
+		pool[quiz.Pool[i].QuestionID] = &quiz.Pool[i]
+	}
+
+	for seed := int64(0); seed < 200; seed++ {
+		drawn := quiz.Drawn(seed)
+		if len(drawn.Questions) != 8 {
+			t.Fatalf("seed %d: got %d questions, want the 2 fixed ones and 6 drawn", seed, len(drawn.Questions))
+		}
+		if drawn.Questions[0].QuestionID != "q1" || drawn.Questions[1].QuestionID != "q2" {
+			t.Fatalf("seed %d: fixed questions moved: %v", seed, drawnIDs(quiz, seed))
+		}
+
+		ids := drawnIDs(quiz, seed)
+		seen := make(map[string]bool)
+		for i, id := range ids {
+			if id == "b02" {
+				t.Errorf("seed %d drew b02, which the quiz links", seed)
+			}
+			if seen[id] {
+				t.Errorf("seed %d drew %s twice: %v", seed, id, ids)
+			}
+			seen[id] = true
+
+			// Rules draw in order: 2 for the first rule, 1 for the second, 3 for the third
+			rule := &quiz.Rules[2]
+			if i < 2 {
+				rule = &quiz.Rules[0]
+			} else if i == 2 {
+				rule = &quiz.Rules[1]
+			}
+			if !rule.Matches(pool[id]) {
+				t.Errorf("seed %d: question %d (%s) doesn't match its rule %+v", seed, i+1, id, *rule)
+			}
+		}
+		// Unrated b06 and difficulty 3 b05 are outside the second rule's range
+		if ids[2] == "b05" || ids[2] == "b06" {
+			t.Errorf("seed %d: the difficulty 1-2 rule drew %s", seed, ids[2])
+		}
+	}
+}
+
+func TestDrawnRuleWithTooFewCandidatesDrawsFewer(t *testing.T) {
+	quiz := &Quiz{
+		Rules: []QuestionRule{{MinDifficulty: 1, Count: 5}},
+		Pool: []PoolQuestion{
+			poolQuestion("b01", 0),
+			poolQuestion("b02", 4),
+			poolQuestion("b03", 0),
+			poolQuestion("b04", 1),
+		},
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		ids := drawnIDs(quiz, seed)
+		if len(ids) != 2 || ids[0] == ids[1] || (ids[0] != "b02" && ids[0] != "b04") || (ids[1] != "b02" && ids[1] != "b04") {
+			t.Errorf("seed %d drew %v, want only the rated b02 and b04", seed, ids)
+		}
+	}
+}
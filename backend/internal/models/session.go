@@ -16,10 +16,23 @@ type Session struct {
 	SessionID            string        `json:"sessionId" dynamodbav:"sessionId"`
 	PIN                  string        `json:"pin" dynamodbav:"pin"`
 	QuizID               string        `json:"quizId" dynamodbav:"quizId"`
+	QuizVersion          int64         `json:"quizVersion,omitempty" dynamodbav:"quizVersion,omitempty"` // snapshot played by this session; 0 for sessions created before snapshots
+	QuizSeed             int64         `json:"quizSeed,omitempty" dynamodbav:"quizSeed,omitempty"`       // draws the questions of a quiz with rules from its snapshot
 	HostUserID           string        `json:"hostUserId" dynamodbav:"hostUserId"`
 	Status               SessionStatus `json:"status" dynamodbav:"status"`
 	CurrentQuestionIndex int           `json:"currentQuestionIndex" dynamodbav:"currentQuestionIndex"`
 	StartedAt            *time.Time    `json:"startedAt,omitempty" dynamodbav:"startedAt,omitempty"`
 	EndedAt              *time.Time    `json:"endedAt,omitempty" dynamodbav:"endedAt,omitempty"`
 	CreatedAt            time.Time     `json:"createdAt" dynamodbav:"createdAt"`
+	LastActivityAt       int64         `json:"lastActivityAt" dynamodbav:"lastActivityAt"` // Unix timestamp of the last state change, join or answer (recorded at most once a minute), used by the idle reaper
+	TTL                  int64         `json:"ttl" dynamodbav:"ttl"`                       // Unix timestamp for DynamoDB TTL
+}
+
+// PINReservation maps a live session's join PIN to its session.
+// This maps to the DynamoDB pins table; a PIN is reusable once its reservation is released or expired.
+type PINReservation struct {
+	PIN        string    `json:"pin" dynamodbav:"pin"`
+	SessionID  string    `json:"sessionId" dynamodbav:"sessionId"`
+	ReservedAt time.Time `json:"reservedAt" dynamodbav:"reservedAt"`
+	TTL        int64     `json:"ttl" dynamodbav:"ttl"` // Unix timestamp for DynamoDB TTL
 }
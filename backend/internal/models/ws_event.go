@@ -59,21 +59,33 @@ type GameStartedPayload struct {
 
 // QuestionPayload is broadcast when a new question begins.
 type QuestionPayload struct {
-	QuestionIndex  int      `json:"questionIndex"`
-	TotalQuestions int      `json:"totalQuestions"`
-	Text           string   `json:"text"`
-	Options        []Option `json:"options"` // NOTE: never send correctOptionId to players
-	TimeLimitMs    int      `json:"timeLimitMs"`
-	Points         int      `json:"points"`
+	QuestionIndex  int             `json:"questionIndex"`
+	TotalQuestions int             `json:"totalQuestions"`
+	Text           string          `json:"text"`
+	Media          *MediaPayload   `json:"media,omitempty"`
+	Options        []OptionPayload `json:"options"` // NOTE: never send correctOptionId to players
+	TimeLimitMs    int             `json:"timeLimitMs"`
+	Points         int             `json:"points"`
 }
 
 // AnswerResultPayload is sent only to the player who answered.
 type AnswerResultPayload struct {
-	IsCorrect     bool   `json:"isCorrect"`
-	PointsEarned  int    `json:"pointsEarned"`
-	TotalScore    int    `json:"totalScore"`
-	Rank          int64  `json:"rank"`
-	CorrectOption string `json:"correctOptionId"`
+	IsCorrect     bool          `json:"isCorrect"`
+	PointsEarned  int           `json:"pointsEarned"`
+	TotalScore    int           `json:"totalScore"`
+	Rank          int64         `json:"rank"`
+	RankChange    int64         `json:"rankChange"` // places moved up since the last question closed (negative = down)
+	Nearby        []PlayerScore `json:"nearby"`     // the player plus those just above and below
+	CorrectOption string        `json:"correctOptionId"`
+}
+
+// PlayerStandingPayload is sent to each player individually when a question closes.
+type PlayerStandingPayload struct {
+	Rank         int64         `json:"rank"`
+	PreviousRank int64         `json:"previousRank,omitempty"`
+	RankChange   int64         `json:"rankChange"` // places moved up since the previous question closed (negative = down)
+	Score        float64       `json:"score"`
+	Nearby       []PlayerScore `json:"nearby"` // the player plus those just above and below
 }
 
 // QuestionEndedPayload is broadcast to all after the timer expires.
@@ -105,6 +117,7 @@ const (
 	WSTypeQuestion          = "question"
 	WSTypeAnswerResult      = "answer_result"
 	WSTypeQuestionEnded     = "question_ended"
+	WSTypePlayerStanding    = "player_standing"
 	WSTypeLeaderboardUpdate = "leaderboard_update"
 	WSTypeGameOver          = "game_over"
 	WSTypeError             = "error"
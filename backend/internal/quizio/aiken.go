@@ -0,0 +1,157 @@
+package quizio
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"kahootclone/internal/models"
+)
+
+// Aiken is a plain-text format for single-answer multiple choice: a question line, options
+// lettered "A." or "A)" in order, and an "ANSWER: X" line, with blank lines between questions.
+//
+//	What is the capital of France?
+//	A. Berlin
+//	B. Paris
+//	ANSWER: B
+
+var (
+	aikenOption = regexp.MustCompile(`^([A-Za-z])[.)]\s+(.*)$`)
+	aikenAnswer = regexp.MustCompile(`^ANSWER:\s*(.*)$`)
+)
+
+// readAiken reads a quiz from an Aiken file. The format has no title.
+func readAiken(data []byte) (*models.Quiz, error) {
+	var errs ImportError
+	quiz := &models.Quiz{}
+
+	var (
+		q     *models.Question
+		start int // line the current question started on
+	)
+	flush := func(line int, answer string) {
+		defer func() { q = nil }()
+		if len(q.Options) < 2 {
+			errs.add(start, "", "at least two options are required")
+			return
+		}
+		letter := strings.ToUpper(strings.TrimSpace(answer))
+		if len(letter) != 1 {
+			if strings.ContainsAny(letter, ",; ") {
+				errs.add(line, "", "only one correct answer is supported")
+			} else {
+				errs.add(line, "", "ANSWER must be a single option letter")
+			}
+			return
+		}
+		i := int(letter[0]) - 'A'
+		if i < 0 || i >= len(q.Options) {
+			errs.add(line, "", "ANSWER %s is not one of the options", letter)
+			return
+		}
+		q.CorrectOptionID = q.Options[i].ID
+		quiz.Questions = append(quiz.Questions, *q)
+	}
+
+	for i, raw := range strings.Split(normalizeNewlines(data), "\n") {
+		line := i + 1
+		text := strings.TrimSpace(raw)
+		if errs.full() {
+			break
+		}
+		if text == "" {
+			continue
+		}
+
+		if m := aikenAnswer.FindStringSubmatch(text); m != nil {
+			if q == nil {
+				errs.add(line, "", "ANSWER line without a question")
+				continue
+			}
+			flush(line, m[1])
+			continue
+		}
+
+		if q != nil {
+			if m := aikenOption.FindStringSubmatch(text); m != nil {
+				want := byte('A' + len(q.Options))
+				if strings.ToUpper(m[1])[0] != want {
+					errs.add(line, "", "expected option %c", want)
+					continue
+				}
+				addOption(q, m[2], false)
+				continue
+			}
+			if len(q.Options) > 0 {
+				// A new question started before the last one's ANSWER line
+				errs.add(start, "", "question is missing its ANSWER line")
+				q = nil
+			} else {
+				// Question text continued on the next line
+				q.Text += "\n" + text
+				continue
+			}
+		}
+
+		nq := newQuestion(text)
+		q, start = &nq, line
+	}
+	if q != nil && !errs.full() {
+		errs.add(start, "", "question is missing its ANSWER line")
+	}
+
+	if len(errs.Errors) > 0 {
+		return nil, &errs
+	}
+	return quiz, nil
+}
+
+// writeAiken writes a quiz as an Aiken file. The format is lossy: it has no title, time
+// limit or points, so those are left out and come back as defaults on import. Options hold
+// one line each, so their line breaks become spaces. A question keeps its line breaks, minus
+// blank lines, unless a line would read back as an option or ANSWER line, in which case the
+// question is joined onto one line too.
+func writeAiken(quiz *models.Quiz) ([]byte, error) {
+	var b bytes.Buffer
+	for n, q := range quiz.Questions {
+		if len(q.Options) > 26 {
+			return nil, fmt.Errorf("%w: question %d has more options than Aiken can letter", ErrNotExportable, n+1)
+		}
+		correct := correctIndex(q)
+		if correct < 0 {
+			return nil, fmt.Errorf("%w: question %d has no correct option", ErrNotExportable, n+1)
+		}
+		fmt.Fprintln(&b, aikenQuestionText(q.Text))
+		for i, o := range q.Options {
+			fmt.Fprintf(&b, "%c. %s\n", 'A'+i, oneLine(o.Text))
+		}
+		fmt.Fprintf(&b, "ANSWER: %c\n\n", 'A'+correct)
+	}
+	return b.Bytes(), nil
+}
+
+// aikenQuestionText returns question text as readAiken will read it back: its non-blank
+// lines, or the whole text on one line if a continuation line looks like an option or answer.
+func aikenQuestionText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(normalizeNewlines([]byte(text)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(lines) > 0 && (aikenOption.MatchString(line) || aikenAnswer.MatchString(line)) {
+			return oneLine(text)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeNewlines returns text with any byte-order mark removed and line endings as "\n".
+func normalizeNewlines(data []byte) string {
+	s := string(bytes.TrimPrefix(data, utf8BOM))
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
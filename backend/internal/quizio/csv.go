@@ -0,0 +1,38 @@
+package quizio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+
+	"kahootclone/internal/models"
+)
+
+// utf8BOM is written at the start of CSV files by Excel's "CSV UTF-8" export.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// readCSV reads a quiz from a CSV file in the spreadsheet layout.
+func readCSV(data []byte) (*models.Quiz, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	r.FieldsPerRecord = -1 // rows may omit trailing optional columns
+
+	var rows []sheetRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, &ImportError{Errors: []LineError{{Line: parseErr.Line, Message: parseErr.Err.Error()}}}
+			}
+			return nil, err
+		}
+		// Quoted cells can span lines, so ask the reader where the row started
+		line, _ := r.FieldPos(0)
+		rows = append(rows, sheetRow{line: line, cells: record})
+	}
+	return parseSheet(rows)
+}
@@ -0,0 +1,372 @@
+package quizio
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"kahootclone/internal/models"
+)
+
+// GIFT is Moodle's plain-text question format: an optional "::name::", the question text and
+// its answers in braces, with blank lines between questions. "=" marks the correct answer and
+// "~" a wrong one; {T} and {F} are true/false questions.
+//
+//	$CATEGORY: $course$/Geography
+//
+//	::Capitals:: What is the capital of France? {
+//	=Paris
+//	~Berlin
+//	~Rome
+//	}
+
+// giftSpecial are the characters GIFT escapes with a backslash.
+const giftSpecial = `~=#{}:\`
+
+// giftBlock is the text of one question or directive and the line it starts on.
+type giftBlock struct {
+	line int
+	text string
+}
+
+// readGIFT reads a quiz from a GIFT file. The last $CATEGORY directive, if any, names it.
+func readGIFT(data []byte) (*models.Quiz, error) {
+	var errs ImportError
+	quiz := &models.Quiz{}
+
+	for _, block := range splitGIFT(normalizeNewlines(data)) {
+		if errs.full() {
+			break
+		}
+		text := block.text
+		if strings.HasPrefix(text, "$CATEGORY:") {
+			category, rest, _ := strings.Cut(text, "\n")
+			quiz.Title = categoryTitle(strings.TrimPrefix(category, "$CATEGORY:"))
+			if text = strings.TrimSpace(rest); text == "" {
+				continue
+			}
+			block.line++
+		}
+		if q, ok := parseGIFTQuestion(block.line, text, &errs); ok {
+			quiz.Questions = append(quiz.Questions, q)
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return nil, &errs
+	}
+	return quiz, nil
+}
+
+// splitGIFT splits a file into blocks at blank lines outside answer braces, dropping "//"
+// comment lines.
+func splitGIFT(s string) []giftBlock {
+	var (
+		blocks []giftBlock
+		cur    []string
+		start  int
+		depth  int
+	)
+	end := func() {
+		if len(cur) > 0 {
+			blocks = append(blocks, giftBlock{line: start, text: strings.TrimSpace(strings.Join(cur, "\n"))})
+		}
+		cur = nil
+	}
+
+	for i, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if trimmed == "" {
+			if depth == 0 {
+				end()
+			}
+			continue
+		}
+		if len(cur) == 0 {
+			start = i + 1
+		}
+		cur = append(cur, line)
+		for j := 0; j < len(line); j++ {
+			switch line[j] {
+			case '\\':
+				j++
+			case '{':
+				depth++
+			case '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+	}
+	end()
+	return blocks
+}
+
+// parseGIFTQuestion reads one question block, recording its problems in errs.
+func parseGIFTQuestion(line int, s string, errs *ImportError) (models.Question, bool) {
+	var name string
+	if strings.HasPrefix(s, "::") {
+		end := indexUnescaped(s, "::", 2)
+		if end < 0 {
+			errs.add(line, "", "question name is missing its closing ::")
+			return models.Question{}, false
+		}
+		name = strings.TrimSpace(unescapeGIFT(s[2:end]))
+		s = s[end+2:]
+	}
+
+	open := indexUnescaped(s, "{", 0)
+	if open < 0 {
+		errs.add(line, "", "question has no answers in braces; descriptions are not supported")
+		return models.Question{}, false
+	}
+	closing := indexUnescaped(s, "}", open+1)
+	if closing < 0 {
+		errs.add(line, "", "answers are missing their closing }")
+		return models.Question{}, false
+	}
+
+	// Text after the answers makes a fill-in-the-blank question
+	text := s[:open]
+	if after := strings.TrimSpace(s[closing+1:]); after != "" {
+		text = strings.TrimRight(text, " ") + " _____ " + after
+	}
+	text = giftText(text)
+	if text == "" {
+		text = name
+	}
+	if text == "" {
+		errs.add(line, "", "question text is required")
+		return models.Question{}, false
+	}
+
+	body := strings.TrimSpace(s[open+1 : closing])
+	switch {
+	case body == "":
+		errs.add(line, "", "essay questions are not supported")
+		return models.Question{}, false
+	case strings.HasPrefix(body, "#"):
+		errs.add(line, "", "numerical questions are not supported")
+		return models.Question{}, false
+	}
+
+	// {T} or {TRUE}, optionally followed by feedback
+	value, _, _ := strings.Cut(body, "#")
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "T", "TRUE":
+		return trueFalseQuestion(text, true), true
+	case "F", "FALSE":
+		return trueFalseQuestion(text, false), true
+	}
+
+	return parseGIFTAnswers(line, text, body, errs)
+}
+
+// parseGIFTAnswers reads the "=right ~wrong" answers of a multiple-choice question.
+func parseGIFTAnswers(line int, text, body string, errs *ImportError) (models.Question, bool) {
+	type answer struct {
+		text    string
+		correct bool
+		partial bool
+	}
+	var answers []answer
+	wrong := 0
+
+	start := -1
+	var marker byte
+	finish := func(end int) bool {
+		if start < 0 {
+			if strings.TrimSpace(body[:end]) != "" {
+				errs.add(line, "", "answers must start with = or ~")
+				return false
+			}
+			return true
+		}
+		a := body[start:end]
+		if indexUnescaped(a, "->", 0) >= 0 {
+			errs.add(line, "", "matching questions are not supported")
+			return false
+		}
+		if i := indexUnescaped(a, "#", 0); i >= 0 {
+			a = a[:i] // drop feedback
+		}
+
+		weight := -1.0
+		if t := strings.TrimSpace(a); strings.HasPrefix(t, "%") {
+			if end := strings.Index(t[1:], "%"); end >= 0 {
+				if w, err := strconv.ParseFloat(t[1:end+1], 64); err == nil {
+					weight = w
+					a = t[end+2:]
+				}
+			}
+		}
+
+		ans := answer{text: strings.TrimSpace(unescapeGIFT(a))}
+		switch {
+		case marker == '=':
+			ans.correct = true
+		case weight >= 100:
+			ans.correct = true
+		case weight > 0:
+			ans.partial = true
+		default:
+			wrong++
+		}
+		answers = append(answers, ans)
+		return true
+	}
+
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			if !finish(i) {
+				return models.Question{}, false
+			}
+			start, marker = i+1, body[i]
+		}
+	}
+	if !finish(len(body)) {
+		return models.Question{}, false
+	}
+
+	correct := 0
+	for _, a := range answers {
+		if a.partial {
+			errs.add(line, "", "answers with partial credit are not supported; mark one answer correct")
+			return models.Question{}, false
+		}
+		if a.correct {
+			correct++
+		}
+	}
+	switch {
+	case wrong == 0:
+		errs.add(line, "", "short-answer questions are not supported")
+		return models.Question{}, false
+	case correct == 0:
+		errs.add(line, "", "no answer is marked correct with =")
+		return models.Question{}, false
+	case correct > 1:
+		errs.add(line, "", "only one correct answer is supported")
+		return models.Question{}, false
+	}
+
+	q := newQuestion(text)
+	for _, a := range answers {
+		if a.text == "" {
+			errs.add(line, "", "answers can't be empty")
+			return models.Question{}, false
+		}
+		addOption(&q, a.text, a.correct)
+	}
+	return q, true
+}
+
+// giftText turns GIFT question text into plain text, dropping a [format] marker.
+func giftText(s string) string {
+	s = strings.TrimSpace(s)
+	format := ""
+	if strings.HasPrefix(s, "[") {
+		if end := strings.Index(s, "]"); end > 0 {
+			format = strings.ToLower(s[1:end])
+			switch format {
+			case "html", "moodle", "plain", "markdown":
+				s = s[end+1:]
+			}
+		}
+	}
+	s = unescapeGIFT(s)
+	if format == "html" {
+		return htmlToText(s)
+	}
+	return strings.TrimSpace(s)
+}
+
+// writeGIFT writes a quiz as a GIFT file under a category named after its title.
+func writeGIFT(quiz *models.Quiz) ([]byte, error) {
+	var b bytes.Buffer
+	if quiz.Title != "" {
+		fmt.Fprintf(&b, "$CATEGORY: %s\n\n", categoryPath(quiz.Title))
+	}
+	for n, q := range quiz.Questions {
+		fmt.Fprintf(&b, "// question %d\n", n+1)
+		if answer, ok := asTrueFalse(q); ok {
+			fmt.Fprintf(&b, "%s {%s}\n\n", escapeGIFT(q.Text), strings.ToUpper(strconv.FormatBool(answer)))
+			continue
+		}
+
+		correct := correctIndex(q)
+		if correct < 0 {
+			return nil, fmt.Errorf("%w: question %d has no correct option", ErrNotExportable, n+1)
+		}
+		fmt.Fprintf(&b, "%s {\n", escapeGIFT(q.Text))
+		for i, o := range q.Options {
+			marker := '~'
+			if i == correct {
+				marker = '='
+			}
+			fmt.Fprintf(&b, "%c%s\n", marker, escapeGIFT(o.Text))
+		}
+		b.WriteString("}\n\n")
+	}
+	return b.Bytes(), nil
+}
+
+// indexUnescaped returns the index of the first sub in s at or after from that isn't
+// escaped with a backslash, or -1.
+func indexUnescaped(s, sub string, from int) int {
+	for i := from; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeGIFT(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch next := s[i+1]; {
+			case next == 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case strings.IndexByte(giftSpecial, next) >= 0:
+				b.WriteByte(next)
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func escapeGIFT(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+		case strings.IndexByte(giftSpecial, c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
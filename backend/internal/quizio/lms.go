@@ -0,0 +1,117 @@
+package quizio
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"kahootclone/internal/models"
+)
+
+// Helpers shared by the LMS formats (GIFT, Aiken and Moodle XML).
+
+// Option texts of a true/false question.
+const (
+	optionTrue  = "True"
+	optionFalse = "False"
+)
+
+// newQuestion starts an imported question with a fresh ID and the default timing and points.
+func newQuestion(text string) models.Question {
+	return models.Question{
+		QuestionID:       uuid.New().String(),
+		Text:             text,
+		TimeLimitSeconds: models.DefaultTimeLimitSeconds,
+		Points:           models.DefaultPoints,
+	}
+}
+
+// addOption appends an option with a fresh ID, marking it correct if asked.
+func addOption(q *models.Question, text string, correct bool) {
+	opt := models.Option{ID: uuid.New().String(), Text: text}
+	q.Options = append(q.Options, opt)
+	if correct {
+		q.CorrectOptionID = opt.ID
+	}
+}
+
+// trueFalseQuestion builds a true/false question as two options, True then False.
+func trueFalseQuestion(text string, answer bool) models.Question {
+	q := newQuestion(text)
+	addOption(&q, optionTrue, answer)
+	addOption(&q, optionFalse, !answer)
+	return q
+}
+
+// asTrueFalse reports whether q is a true/false question, as built by trueFalseQuestion or
+// written by hand with those two options in either order, and which answer is correct.
+func asTrueFalse(q models.Question) (answer, ok bool) {
+	if len(q.Options) != 2 {
+		return false, false
+	}
+	a := strings.TrimSpace(q.Options[0].Text)
+	b := strings.TrimSpace(q.Options[1].Text)
+	correct := correctIndex(q)
+	switch {
+	case strings.EqualFold(a, optionTrue) && strings.EqualFold(b, optionFalse):
+		return correct == 0, correct >= 0
+	case strings.EqualFold(a, optionFalse) && strings.EqualFold(b, optionTrue):
+		return correct == 1, correct >= 0
+	}
+	return false, false
+}
+
+// correctIndex returns the index of q's correct option, or -1.
+func correctIndex(q models.Question) int {
+	for i, o := range q.Options {
+		if o.ID == q.CorrectOptionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// categoryPrefix roots exported question categories in the importing course, as Moodle does.
+const categoryPrefix = "$course$/"
+
+// categoryPath names the question category a quiz is exported to. Moodle writes a slash
+// inside a category name as "//".
+func categoryPath(title string) string {
+	return categoryPrefix + strings.ReplaceAll(title, "/", "//")
+}
+
+// categoryTitle recovers a quiz title from a question category path: its last segment, unless
+// that is one of Moodle's built-in context or "top" categories.
+func categoryTitle(path string) string {
+	segments := strings.Split(strings.ReplaceAll(strings.TrimSpace(path), "//", "\x00"), "/")
+	last := strings.TrimSpace(strings.ReplaceAll(segments[len(segments)-1], "\x00", "/"))
+	if last == "top" || strings.HasPrefix(last, "$") {
+		return ""
+	}
+	return last
+}
+
+var (
+	htmlBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</p>\s*<p[^>]*>`)
+	htmlTags   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// htmlToText reduces the HTML that LMSs store question text in to plain text, keeping line
+// and paragraph breaks.
+func htmlToText(s string) string {
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// textToHTML is the inverse of htmlToText for plain text.
+func textToHTML(s string) string {
+	return strings.ReplaceAll(html.EscapeString(s), "\n", "<br>")
+}
+
+// oneLine joins a multi-line text for formats that hold a question or option on one line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
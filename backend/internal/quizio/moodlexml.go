@@ -0,0 +1,284 @@
+package quizio
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"kahootclone/internal/models"
+)
+
+// Moodle XML is Moodle's full question bank format: a <quiz> of <question type="...">
+// elements. Only multichoice and truefalse questions are imported; a category question names
+// the quiz.
+
+type moodleText struct {
+	Format string `xml:"format,attr,omitempty"`
+	Text   string `xml:"text"`
+}
+
+// plain returns the text without the HTML its format may wrap it in.
+func (t moodleText) plain() string {
+	if t.Format == "html" {
+		return htmlToText(t.Text)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+type moodleAnswer struct {
+	Fraction string `xml:"fraction,attr"`
+	moodleText
+}
+
+type moodleQuestion struct {
+	Type         string         `xml:"type,attr"`
+	Category     moodleText     `xml:"category"`
+	Name         moodleText     `xml:"name"`
+	QuestionText moodleText     `xml:"questiontext"`
+	Answers      []moodleAnswer `xml:"answer"`
+}
+
+// readMoodleXML reads a quiz from a Moodle XML file. The last category question, if any,
+// names it.
+func readMoodleXML(data []byte) (*models.Quiz, error) {
+	var errs ImportError
+	quiz := &models.Quiz{}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	sawQuiz := false
+	for !errs.full() {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, _ := dec.InputPos()
+			msg := err.Error()
+			var syntax *xml.SyntaxError
+			if errors.As(err, &syntax) {
+				line, msg = syntax.Line, syntax.Msg
+			}
+			errs.add(line, "", "invalid XML: %s", msg)
+			break
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "quiz":
+			sawQuiz = true
+			continue
+		case "question":
+		default:
+			if !sawQuiz {
+				errs.add(1, "", "file is not Moodle XML: the root element must be <quiz>")
+				return nil, &errs
+			}
+			if err := dec.Skip(); err != nil {
+				line, _ := dec.InputPos()
+				errs.add(line, "", "invalid XML: %v", err)
+			}
+			continue
+		}
+
+		line, _ := dec.InputPos()
+		var mq moodleQuestion
+		if err := dec.DecodeElement(&mq, &start); err != nil {
+			errs.add(line, "", "invalid question: %v", err)
+			break
+		}
+		if mq.Type == "category" {
+			quiz.Title = categoryTitle(mq.Category.Text)
+			continue
+		}
+		if q, ok := parseMoodleQuestion(line, mq, &errs); ok {
+			quiz.Questions = append(quiz.Questions, q)
+		}
+	}
+	if !sawQuiz && len(errs.Errors) == 0 {
+		errs.add(1, "", "file is not Moodle XML: the root element must be <quiz>")
+	}
+
+	if len(errs.Errors) > 0 {
+		return nil, &errs
+	}
+	return quiz, nil
+}
+
+// parseMoodleQuestion converts one question, recording its problems in errs.
+func parseMoodleQuestion(line int, mq moodleQuestion, errs *ImportError) (models.Question, bool) {
+	text := mq.QuestionText.plain()
+	if text == "" {
+		text = mq.Name.plain()
+	}
+	if text == "" {
+		errs.add(line, "", "question text is required")
+		return models.Question{}, false
+	}
+
+	switch mq.Type {
+	case "truefalse":
+		for _, a := range mq.Answers {
+			if moodleFraction(a.Fraction) >= 100 {
+				switch strings.ToLower(a.plain()) {
+				case "true":
+					return trueFalseQuestion(text, true), true
+				case "false":
+					return trueFalseQuestion(text, false), true
+				}
+			}
+		}
+		errs.add(line, "", "true/false question has no correct answer")
+		return models.Question{}, false
+
+	case "multichoice":
+		q := newQuestion(text)
+		credited := 0
+		for _, a := range mq.Answers {
+			answerText := a.plain()
+			if answerText == "" {
+				errs.add(line, "", "answers can't be empty")
+				return models.Question{}, false
+			}
+			fraction := moodleFraction(a.Fraction)
+			if fraction > 0 {
+				credited++
+			}
+			addOption(&q, answerText, fraction > 0)
+		}
+		switch {
+		case len(q.Options) < 2:
+			errs.add(line, "", "at least two answers are required")
+		case credited == 0:
+			errs.add(line, "", "no answer is marked correct")
+		case credited > 1:
+			errs.add(line, "", "only one correct answer is supported")
+		default:
+			return q, true
+		}
+		return models.Question{}, false
+
+	default:
+		errs.add(line, "", "%s questions are not supported", mq.Type)
+		return models.Question{}, false
+	}
+}
+
+// moodleFraction parses an answer's credit percentage, treating anything unreadable as none.
+func moodleFraction(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// The elements Moodle expects when writing; field order is element order.
+type (
+	moodleXMLQuiz struct {
+		XMLName   xml.Name            `xml:"quiz"`
+		Questions []moodleXMLQuestion `xml:"question"`
+	}
+	moodleXMLQuestion struct {
+		Type           string            `xml:"type,attr"`
+		Category       *moodleXMLText    `xml:"category,omitempty"`
+		Name           *moodleXMLText    `xml:"name,omitempty"`
+		QuestionText   *moodleXMLText    `xml:"questiontext,omitempty"`
+		DefaultGrade   string            `xml:"defaultgrade,omitempty"`
+		Single         string            `xml:"single,omitempty"`
+		ShuffleAnswers string            `xml:"shuffleanswers,omitempty"`
+		Numbering      string            `xml:"answernumbering,omitempty"`
+		Answers        []moodleXMLAnswer `xml:"answer"`
+	}
+	moodleXMLText struct {
+		Format string `xml:"format,attr,omitempty"`
+		Text   struct {
+			Value string `xml:",cdata"`
+		} `xml:"text"`
+	}
+	moodleXMLAnswer struct {
+		Fraction string `xml:"fraction,attr"`
+		moodleXMLText
+	}
+)
+
+func newMoodleXMLText(format, text string) *moodleXMLText {
+	t := &moodleXMLText{Format: format}
+	t.Text.Value = text
+	return t
+}
+
+// maxMoodleNameLength is how much of a question's text names it in the question bank.
+const maxMoodleNameLength = 60
+
+// writeMoodleXML writes a quiz as Moodle XML under a category named after its title. Options
+// keep their order, since players see them in the order they were written.
+func writeMoodleXML(quiz *models.Quiz) ([]byte, error) {
+	var doc moodleXMLQuiz
+	if quiz.Title != "" {
+		doc.Questions = append(doc.Questions, moodleXMLQuestion{
+			Type:     "category",
+			Category: newMoodleXMLText("", categoryPath(quiz.Title)),
+		})
+	}
+
+	for n, q := range quiz.Questions {
+		name := []rune(oneLine(q.Text))
+		if len(name) > maxMoodleNameLength {
+			name = append(name[:maxMoodleNameLength-1], '…')
+		}
+		mq := moodleXMLQuestion{
+			Name:         newMoodleXMLText("", string(name)),
+			QuestionText: newMoodleXMLText("html", "<p>"+textToHTML(q.Text)+"</p>"),
+			DefaultGrade: "1",
+		}
+
+		if answer, ok := asTrueFalse(q); ok {
+			mq.Type = "truefalse"
+			mq.Answers = []moodleXMLAnswer{
+				{Fraction: moodleCredit(answer), moodleXMLText: *newMoodleXMLText("moodle_auto_format", "true")},
+				{Fraction: moodleCredit(!answer), moodleXMLText: *newMoodleXMLText("moodle_auto_format", "false")},
+			}
+			doc.Questions = append(doc.Questions, mq)
+			continue
+		}
+
+		correct := correctIndex(q)
+		if correct < 0 {
+			return nil, fmt.Errorf("%w: question %d has no correct option", ErrNotExportable, n+1)
+		}
+		mq.Type = "multichoice"
+		mq.Single = "true"
+		mq.ShuffleAnswers = "0"
+		mq.Numbering = "abc"
+		for i, o := range q.Options {
+			mq.Answers = append(mq.Answers, moodleXMLAnswer{
+				Fraction:      moodleCredit(i == correct),
+				moodleXMLText: *newMoodleXMLText("html", textToHTML(o.Text)),
+			})
+		}
+		doc.Questions = append(doc.Questions, mq)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func moodleCredit(correct bool) string {
+	if correct {
+		return "100"
+	}
+	return "0"
+}
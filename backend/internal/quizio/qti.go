@@ -0,0 +1,771 @@
+package quizio
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"kahootclone/internal/media"
+	"kahootclone/internal/models"
+)
+
+// QTI 2.1 packages: imsmanifest.xml lists resources by type, each assessment item is its own
+// XML file, and images sit beside the items that show them.
+
+const (
+	qtiManifest  = "imsmanifest.xml"
+	qtiNamespace = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+
+	// Resource types carry the QTI version (imsqti_item_xmlv2p1, imsqti_item_xmlv2p2, ...);
+	// choice interactions are the same in every 2.x version.
+	qtiItemType = "imsqti_item_xmlv2p"
+	qtiTestType = "imsqti_test_xmlv2p"
+
+	// maxQTIPartSize caps how much of one package file is decompressed.
+	maxQTIPartSize = 16 << 20
+)
+
+// qtiSkipped are elements whose content isn't shown to the candidate as part of a question.
+var qtiSkipped = map[string]bool{
+	"feedbackInline": true,
+	"feedbackBlock":  true,
+	"modalFeedback":  true,
+	"rubricBlock":    true,
+	"templateInline": true,
+	"templateBlock":  true,
+}
+
+// qtiBlocks are the XHTML elements that start a new line of text.
+var qtiBlocks = map[string]bool{
+	"p": true, "div": true, "prompt": true, "li": true, "ul": true, "ol": true, "blockquote": true,
+	"pre": true, "table": true, "tr": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// qtiPackage is a content package being imported.
+type qtiPackage struct {
+	parts  map[string]*zip.File
+	errs   ImportError
+	media  []MediaFile
+	images map[string]*models.Media // package path -> media already taken from it
+}
+
+// readQTI reads a quiz from a QTI 2.1 content package.
+func readQTI(data []byte) (*models.Quiz, []MediaFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, &ImportError{Errors: []LineError{{Line: 1, Message: "file is not a zip package"}}}
+	}
+	pkg := &qtiPackage{
+		parts:  make(map[string]*zip.File, len(zr.File)),
+		images: make(map[string]*models.Media),
+	}
+	for _, f := range zr.File {
+		pkg.parts[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	raw, err := pkg.read(qtiManifest)
+	if err != nil {
+		return nil, nil, &ImportError{Errors: []LineError{{Line: 1, Message: "package has no " + qtiManifest}}}
+	}
+	var manifest struct {
+		Resources []struct {
+			Type string `xml:"type,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"resources>resource"`
+	}
+	if err := xml.Unmarshal(raw, &manifest); err != nil {
+		pkg.errs.addIn(qtiManifest, 1, "invalid XML: %v", err)
+		return nil, nil, &pkg.errs
+	}
+
+	// Items are read in the order the first test lists them, or in manifest order without one
+	quiz := &models.Quiz{}
+	var items []string
+	var test *qtiTest
+	for _, res := range manifest.Resources {
+		switch {
+		case strings.HasPrefix(res.Type, qtiItemType):
+			items = append(items, path.Clean(res.Href))
+		case strings.HasPrefix(res.Type, qtiTestType) && test == nil:
+			test = pkg.readTest(path.Clean(res.Href))
+		}
+	}
+	if test != nil {
+		quiz.Title = test.title
+		if len(test.items) > 0 {
+			items = test.items
+		}
+	}
+	if len(items) == 0 && len(pkg.errs.Errors) == 0 {
+		pkg.errs.addIn(qtiManifest, 1, "package has no assessment items")
+	}
+
+	for _, href := range items {
+		if pkg.errs.full() {
+			break
+		}
+		q, ok := pkg.readItem(href)
+		if !ok {
+			continue
+		}
+		if test != nil {
+			if limit, ok := test.timeLimits[href]; ok {
+				q.TimeLimitSeconds = limit
+			}
+		}
+		quiz.Questions = append(quiz.Questions, q)
+	}
+
+	if len(pkg.errs.Errors) > 0 {
+		return nil, nil, &pkg.errs
+	}
+	return quiz, pkg.media, nil
+}
+
+// read returns the contents of one file in the package.
+func (pkg *qtiPackage) read(name string) ([]byte, error) {
+	f, ok := pkg.parts[name]
+	if !ok {
+		return nil, fmt.Errorf("package is missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxQTIPartSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxQTIPartSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", name, maxQTIPartSize>>20)
+	}
+	return data, nil
+}
+
+// readTree reads and parses one XML file of the package, recording problems against it.
+func (pkg *qtiPackage) readTree(name string) (*xmlNode, bool) {
+	data, err := pkg.read(name)
+	if err != nil {
+		pkg.errs.addIn(qtiManifest, 1, "%v", err)
+		return nil, false
+	}
+	root, err := parseXMLTree(data)
+	if err != nil {
+		line := 1
+		if syntax, ok := err.(*xml.SyntaxError); ok {
+			line = syntax.Line
+		}
+		pkg.errs.addIn(name, line, "invalid XML: %v", err)
+		return nil, false
+	}
+	return root, true
+}
+
+// qtiTest is what an assessment test says about the quiz.
+type qtiTest struct {
+	title      string
+	items      []string       // item paths in test order
+	timeLimits map[string]int // item path -> seconds, where the test limits an item
+}
+
+// readTest reads an assessment test, returning nil if it can't be read.
+func (pkg *qtiPackage) readTest(href string) *qtiTest {
+	root, ok := pkg.readTree(href)
+	if !ok {
+		return nil
+	}
+	test := &qtiTest{title: strings.TrimSpace(root.attrs["title"]), timeLimits: make(map[string]int)}
+	root.walk(func(n *xmlNode) bool {
+		if n.name != "assessmentItemRef" {
+			return true
+		}
+		item := path.Join(path.Dir(href), n.attrs["href"])
+		test.items = append(test.items, item)
+		for _, c := range n.children {
+			if c.name != "timeLimits" {
+				continue
+			}
+			seconds, err := strconv.ParseFloat(c.attrs["maxTime"], 64)
+			if err == nil && seconds >= models.MinTimeLimitSeconds && seconds <= models.MaxTimeLimitSeconds {
+				test.timeLimits[item] = int(seconds)
+			}
+		}
+		return false
+	})
+	return test
+}
+
+// readItem converts one assessment item, recording its problems against its file.
+func (pkg *qtiPackage) readItem(href string) (models.Question, bool) {
+	root, ok := pkg.readTree(href)
+	if !ok {
+		return models.Question{}, false
+	}
+	if root.name != "assessmentItem" {
+		pkg.errs.addIn(href, root.line, "file is not an assessment item")
+		return models.Question{}, false
+	}
+	before := len(pkg.errs.Errors)
+
+	// The correct response of each response variable
+	correct := make(map[string][]string)
+	var body *xmlNode
+	for _, c := range root.children {
+		switch c.name {
+		case "responseDeclaration":
+			var values []string
+			c.walk(func(n *xmlNode) bool {
+				if n.name == "correctResponse" {
+					for _, v := range n.children {
+						if v.name == "value" {
+							values = append(values, strings.TrimSpace(v.text()))
+						}
+					}
+					return false
+				}
+				return true
+			})
+			correct[c.attrs["identifier"]] = values
+		case "itemBody":
+			body = c
+		}
+	}
+	if body == nil {
+		pkg.errs.addIn(href, root.line, "item has no itemBody")
+		return models.Question{}, false
+	}
+
+	var choice *xmlNode
+	body.walk(func(n *xmlNode) bool {
+		switch {
+		case qtiSkipped[n.name]:
+			return false
+		case n.name == "choiceInteraction":
+			if choice != nil {
+				pkg.errs.addIn(href, n.line, "items with more than one interaction are not supported")
+			}
+			choice = n
+			return false
+		case strings.HasSuffix(n.name, "Interaction"):
+			pkg.errs.addIn(href, n.line, "%s is not supported", n.name)
+			return false
+		}
+		return true
+	})
+	if choice == nil && len(pkg.errs.Errors) == before {
+		pkg.errs.addIn(href, body.line, "item has no choice interaction")
+	}
+	if len(pkg.errs.Errors) > before {
+		return models.Question{}, false
+	}
+
+	// The question is the item body around the interaction, then the interaction's prompt
+	var text qtiText
+	text.render(body)
+	for _, c := range choice.children {
+		if c.name == "prompt" {
+			text.render(c)
+		}
+	}
+	q := newQuestion(text.String())
+	if len(text.images) > 0 {
+		q.Media = pkg.image(href, text.images[0])
+	}
+	if q.Text == "" && q.Media != nil {
+		q.Text = q.Media.Alt
+	}
+	if q.Text == "" {
+		pkg.errs.addIn(href, body.line, "question text is required")
+	}
+
+	answers := correct[choice.attrs["responseIdentifier"]]
+	switch {
+	case len(answers) == 0:
+		pkg.errs.addIn(href, choice.line, "no choice is marked correct")
+	case len(answers) > 1:
+		pkg.errs.addIn(href, choice.line, "only one correct choice is supported")
+	}
+
+	choice.walk(func(n *xmlNode) bool {
+		if n.name != "simpleChoice" {
+			return !qtiSkipped[n.name]
+		}
+		var option qtiText
+		option.render(n)
+		addOption(&q, option.String(), len(answers) == 1 && n.attrs["identifier"] == answers[0])
+		opt := &q.Options[len(q.Options)-1]
+		if len(option.images) > 0 {
+			opt.Media = pkg.image(href, option.images[0])
+		}
+		if opt.Text == "" && opt.Media == nil {
+			pkg.errs.addIn(href, n.line, "choice %s is empty", n.attrs["identifier"])
+		}
+		return false
+	})
+	if len(q.Options) < 2 {
+		pkg.errs.addIn(href, choice.line, "at least two choices are required")
+	} else if len(answers) == 1 && q.CorrectOptionID == "" {
+		pkg.errs.addIn(href, choice.line, "correct response %s is not one of the choices", answers[0])
+	}
+
+	return q, len(pkg.errs.Errors) == before
+}
+
+// image takes an image an item refers to into the imported media, reusing the media of a
+// file that was already taken. It returns nil after recording why the image can't be used.
+func (pkg *qtiPackage) image(href string, img qtiImage) *models.Media {
+	src, err := url.PathUnescape(img.src)
+	if err != nil || src == "" || strings.Contains(src, ":") || strings.HasPrefix(src, "/") {
+		pkg.errs.addIn(href, img.line, "image %s is not in the package", img.src)
+		return nil
+	}
+	name := path.Join(path.Dir(href), src)
+	if m, ok := pkg.images[name]; ok {
+		media := *m
+		media.Alt = img.alt
+		return &media
+	}
+
+	data, err := pkg.read(name)
+	if err != nil {
+		pkg.errs.addIn(href, img.line, "image %s: %v", img.src, err)
+		return nil
+	}
+	// Images are re-encoded and thumbnailed like uploads, which also checks their type
+	file, err := media.Prepare(data)
+	if err == nil && file.Type != models.MediaImage {
+		err = media.ErrUnsupportedType
+	}
+	if errors.Is(err, media.ErrUnsupportedType) {
+		pkg.errs.addIn(href, img.line, "image %s is not a PNG, JPEG or GIF file", img.src)
+		return nil
+	}
+	if err != nil {
+		pkg.errs.addIn(href, img.line, "image %s: %v", img.src, err)
+		return nil
+	}
+
+	m := &models.Media{
+		Type:        models.MediaImage,
+		Key:         file.Key(),
+		ContentType: file.ContentType,
+	}
+	pkg.images[name] = m
+	pkg.media = append(pkg.media, MediaFile{Key: m.Key, ContentType: file.ContentType, Data: file.Data, Thumbnail: file.Thumbnail})
+	media := *m
+	media.Alt = img.alt
+	return &media
+}
+
+// qtiImage is an <img> met while rendering text.
+type qtiImage struct {
+	src, alt string
+	line     int
+}
+
+// qtiText renders XHTML content as plain text, collecting the images in it.
+type qtiText struct {
+	b      strings.Builder
+	images []qtiImage
+}
+
+func (t *qtiText) render(n *xmlNode) {
+	for _, c := range n.children {
+		switch {
+		case c.name == "":
+			t.b.WriteString(c.chars)
+		case c.name == "img":
+			t.images = append(t.images, qtiImage{src: c.attrs["src"], alt: strings.TrimSpace(c.attrs["alt"]), line: c.line})
+		case c.name == "br":
+			t.b.WriteByte('\n')
+		case qtiSkipped[c.name], strings.HasSuffix(c.name, "Interaction"):
+		case qtiBlocks[c.name]:
+			t.b.WriteByte('\n')
+			t.render(c)
+			t.b.WriteByte('\n')
+		default:
+			t.render(c)
+		}
+	}
+}
+
+// String returns the text with runs of spaces collapsed and blank lines dropped.
+func (t *qtiText) String() string {
+	var lines []string
+	for _, line := range strings.Split(t.b.String(), "\n") {
+		if line = oneLine(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// xmlNode is an element or, with an empty name, a run of text in a parsed XML file. Names
+// are local: QTI files use one namespace throughout.
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	chars    string
+	line     int
+	children []*xmlNode
+}
+
+// parseXMLTree parses a whole XML document, returning its root element.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	dec.AutoClose = xml.HTMLAutoClose
+
+	doc := &xmlNode{}
+	stack := []*xmlNode{doc}
+	for {
+		line, _ := dec.InputPos()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: tok.Name.Local, attrs: make(map[string]string, len(tok.Attr)), line: line}
+			for _, a := range tok.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			top.children = append(top.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top.children = append(top.children, &xmlNode{chars: string(tok)})
+		}
+	}
+	for _, n := range doc.children {
+		if n.name != "" {
+			return n, nil
+		}
+	}
+	return nil, &xml.SyntaxError{Msg: "no root element", Line: 1}
+}
+
+// walk calls fn for each element below n in document order, descending into an element's
+// children only when fn returns true.
+func (n *xmlNode) walk(fn func(*xmlNode) bool) {
+	for _, c := range n.children {
+		if c.name != "" && fn(c) {
+			c.walk(fn)
+		}
+	}
+}
+
+// text returns all the text inside n.
+func (n *xmlNode) text() string {
+	var b strings.Builder
+	for _, c := range n.children {
+		if c.name == "" {
+			b.WriteString(c.chars)
+		} else {
+			b.WriteString(c.text())
+		}
+	}
+	return b.String()
+}
+
+// The elements written to a package; field order is element order.
+type (
+	qtiXMLManifest struct {
+		XMLName       xml.Name         `xml:"manifest"`
+		Xmlns         string           `xml:"xmlns,attr"`
+		Identifier    string           `xml:"identifier,attr"`
+		Schema        string           `xml:"metadata>schema"`
+		SchemaVersion string           `xml:"metadata>schemaversion"`
+		Organizations struct{}         `xml:"organizations"`
+		Resources     []qtiXMLResource `xml:"resources>resource"`
+	}
+	qtiXMLResource struct {
+		Identifier   string `xml:"identifier,attr"`
+		Type         string `xml:"type,attr"`
+		Href         string `xml:"href,attr"`
+		Files        []qtiXMLRef
+		Dependencies []qtiXMLRef
+	}
+	qtiXMLRef struct {
+		XMLName       xml.Name
+		Href          string `xml:"href,attr,omitempty"`
+		IdentifierRef string `xml:"identifierref,attr,omitempty"`
+	}
+
+	qtiXMLTest struct {
+		XMLName    xml.Name `xml:"assessmentTest"`
+		Xmlns      string   `xml:"xmlns,attr"`
+		Identifier string   `xml:"identifier,attr"`
+		Title      string   `xml:"title,attr"`
+		Part       struct {
+			Identifier     string `xml:"identifier,attr"`
+			NavigationMode string `xml:"navigationMode,attr"`
+			SubmissionMode string `xml:"submissionMode,attr"`
+			Section        struct {
+				Identifier string          `xml:"identifier,attr"`
+				Title      string          `xml:"title,attr"`
+				Visible    bool            `xml:"visible,attr"`
+				Items      []qtiXMLItemRef `xml:"assessmentItemRef"`
+			} `xml:"assessmentSection"`
+		} `xml:"testPart"`
+	}
+	qtiXMLItemRef struct {
+		Identifier string `xml:"identifier,attr"`
+		Href       string `xml:"href,attr"`
+		TimeLimits *struct {
+			MaxTime int `xml:"maxTime,attr"`
+		} `xml:"timeLimits"`
+	}
+
+	qtiXMLItem struct {
+		XMLName      xml.Name `xml:"assessmentItem"`
+		Xmlns        string   `xml:"xmlns,attr"`
+		Identifier   string   `xml:"identifier,attr"`
+		Title        string   `xml:"title,attr"`
+		Adaptive     bool     `xml:"adaptive,attr"`
+		TimeDep      bool     `xml:"timeDependent,attr"`
+		Response     qtiXMLDeclaration
+		Outcome      qtiXMLDeclaration
+		Body         []qtiXMLBlock           `xml:"itemBody>p"`
+		Interaction  qtiXMLChoiceInteraction `xml:"itemBody>choiceInteraction"`
+		ResponseProc struct {
+			Template string `xml:"template,attr"`
+		} `xml:"responseProcessing"`
+	}
+	qtiXMLDeclaration struct {
+		XMLName     xml.Name
+		Identifier  string        `xml:"identifier,attr"`
+		Cardinality string        `xml:"cardinality,attr"`
+		BaseType    string        `xml:"baseType,attr"`
+		Correct     *qtiXMLValues `xml:"correctResponse"`
+	}
+	qtiXMLValues struct {
+		Values []string `xml:"value"`
+	}
+	qtiXMLBlock struct {
+		Text  string     `xml:",chardata"`
+		Image *qtiXMLImg `xml:"img,omitempty"`
+	}
+	qtiXMLImg struct {
+		Src string `xml:"src,attr"`
+		Alt string `xml:"alt,attr"`
+	}
+	qtiXMLChoiceInteraction struct {
+		ResponseIdentifier string         `xml:"responseIdentifier,attr"`
+		Shuffle            bool           `xml:"shuffle,attr"`
+		MaxChoices         int            `xml:"maxChoices,attr"`
+		Choices            []qtiXMLChoice `xml:"simpleChoice"`
+	}
+	qtiXMLChoice struct {
+		Identifier string     `xml:"identifier,attr"`
+		Text       string     `xml:",chardata"`
+		Image      *qtiXMLImg `xml:"img,omitempty"`
+	}
+)
+
+// qtiWriter collects the files of a package being exported.
+type qtiWriter struct {
+	media  MediaSource
+	files  map[string][]byte
+	order  []string
+	images map[string]string // media key -> package path
+}
+
+func (w *qtiWriter) add(name string, data []byte) {
+	if _, ok := w.files[name]; !ok {
+		w.order = append(w.order, name)
+	}
+	w.files[name] = data
+}
+
+func (w *qtiWriter) addXML(name string, v any) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	w.add(name, append([]byte(xml.Header), append(data, '\n')...))
+	return nil
+}
+
+// image copies a media file into the package once, returning its path there.
+func (w *qtiWriter) image(m *models.Media) (string, error) {
+	if name, ok := w.images[m.Key]; ok {
+		return name, nil
+	}
+	if w.media == nil {
+		return "", fmt.Errorf("no media source for %s", m.Key)
+	}
+	f, err := w.media(m.Key)
+	if err != nil {
+		return "", fmt.Errorf("loading %s: %w", m.Key, err)
+	}
+	name := "images/" + path.Base(m.Key)
+	w.images[m.Key] = name
+	w.add(name, f.Data)
+	return name, nil
+}
+
+// img returns the <img> for a question's or option's media, if it has an image.
+func (w *qtiWriter) img(m *models.Media) (*qtiXMLImg, string, error) {
+	if m == nil || m.Type != models.MediaImage {
+		return nil, "", nil
+	}
+	name, err := w.image(m)
+	if err != nil {
+		return nil, "", err
+	}
+	// Items live in items/, so images are one level up
+	return &qtiXMLImg{Src: "../" + name, Alt: m.Alt}, name, nil
+}
+
+// writeQTI writes a quiz as a QTI 2.1 content package: one item per question, a test that
+// orders them and carries their time limits, and the images they show.
+func writeQTI(quiz *models.Quiz, source MediaSource) ([]byte, error) {
+	w := &qtiWriter{media: source, files: make(map[string][]byte), images: make(map[string]string)}
+
+	manifest := qtiXMLManifest{
+		Xmlns:         "http://www.imsglobal.org/xsd/imscp_v1p1",
+		Identifier:    "manifest",
+		Schema:        "QTIv2.1 Package",
+		SchemaVersion: "1.0.0",
+	}
+	testResource := qtiXMLResource{
+		Identifier: "test",
+		Type:       qtiTestType + "1",
+		Href:       "assessment.xml",
+		Files:      []qtiXMLRef{qtiFileRef("assessment.xml")},
+	}
+
+	test := qtiXMLTest{Xmlns: qtiNamespace, Identifier: "test", Title: quiz.Title}
+	test.Part.Identifier = "part"
+	test.Part.NavigationMode = "linear"
+	test.Part.SubmissionMode = "individual"
+	test.Part.Section.Identifier = "section"
+	test.Part.Section.Title = quiz.Title
+	test.Part.Section.Visible = true
+
+	var itemResources []qtiXMLResource
+	for n, q := range quiz.Questions {
+		correct := correctIndex(q)
+		if correct < 0 {
+			return nil, fmt.Errorf("%w: question %d has no correct option", ErrNotExportable, n+1)
+		}
+
+		id := fmt.Sprintf("item-%d", n+1)
+		href := "items/" + id + ".xml"
+		resource := qtiXMLResource{Identifier: id, Type: qtiItemType + "1", Href: href, Files: []qtiXMLRef{qtiFileRef(href)}}
+
+		item := qtiXMLItem{
+			Xmlns:      qtiNamespace,
+			Identifier: id,
+			Title:      oneLine(q.Text),
+			Response: qtiXMLDeclaration{
+				XMLName:     xml.Name{Local: "responseDeclaration"},
+				Identifier:  "RESPONSE",
+				Cardinality: "single",
+				BaseType:    "identifier",
+				Correct:     &qtiXMLValues{Values: []string{fmt.Sprintf("choice-%d", correct+1)}},
+			},
+			Outcome: qtiXMLDeclaration{
+				XMLName:     xml.Name{Local: "outcomeDeclaration"},
+				Identifier:  "SCORE",
+				Cardinality: "single",
+				BaseType:    "float",
+			},
+			Interaction: qtiXMLChoiceInteraction{ResponseIdentifier: "RESPONSE", MaxChoices: 1},
+		}
+		item.ResponseProc.Template = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+
+		for _, line := range strings.Split(q.Text, "\n") {
+			item.Body = append(item.Body, qtiXMLBlock{Text: line})
+		}
+		img, name, err := w.img(q.Media)
+		if err != nil {
+			return nil, err
+		}
+		if img != nil {
+			item.Body = append(item.Body, qtiXMLBlock{Image: img})
+			resource.Files = append(resource.Files, qtiFileRef(name))
+		}
+
+		for i, o := range q.Options {
+			choice := qtiXMLChoice{Identifier: fmt.Sprintf("choice-%d", i+1), Text: o.Text}
+			img, name, err := w.img(o.Media)
+			if err != nil {
+				return nil, err
+			}
+			if img != nil {
+				choice.Image = img
+				resource.Files = append(resource.Files, qtiFileRef(name))
+			}
+			item.Interaction.Choices = append(item.Interaction.Choices, choice)
+		}
+		if err := w.addXML(href, item); err != nil {
+			return nil, err
+		}
+
+		ref := qtiXMLItemRef{Identifier: id, Href: "items/" + id + ".xml"}
+		if q.TimeLimitSeconds > 0 {
+			ref.TimeLimits = &struct {
+				MaxTime int `xml:"maxTime,attr"`
+			}{q.TimeLimitSeconds}
+		}
+		test.Part.Section.Items = append(test.Part.Section.Items, ref)
+		testResource.Dependencies = append(testResource.Dependencies, qtiXMLRef{
+			XMLName:       xml.Name{Local: "dependency"},
+			IdentifierRef: id,
+		})
+		itemResources = append(itemResources, resource)
+	}
+
+	if err := w.addXML("assessment.xml", test); err != nil {
+		return nil, err
+	}
+	manifest.Resources = append([]qtiXMLResource{testResource}, itemResources...)
+	if err := w.addXML(qtiManifest, manifest); err != nil {
+		return nil, err
+	}
+
+	// The manifest goes first, as some tools expect; timestamps are left unset so the same
+	// quiz always exports to the same bytes
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	names := append([]string{qtiManifest}, w.order...)
+	for i, name := range names {
+		if i > 0 && name == qtiManifest {
+			continue
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(w.files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func qtiFileRef(href string) qtiXMLRef {
+	return qtiXMLRef{XMLName: xml.Name{Local: "file"}, Href: href}
+}
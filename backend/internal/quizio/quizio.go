@@ -0,0 +1,268 @@
+// Package quizio reads and writes quizzes in the file formats teachers already keep
+// questions in: spreadsheets, and the GIFT, Aiken and Moodle XML formats used by learning
+// management systems.
+//
+// Spreadsheets (CSV and XLSX) use one row per question under a header row naming the
+// columns; column order doesn't matter, header names are case-insensitive and spaces count
+// as underscores, and unknown columns are ignored so sheets can carry notes:
+//
+//	question    question text (required)
+//	option_a    first option (required)
+//	option_b    second option (required)
+//	option_c    up to option_f; optional, blank cells are skipped
+//	correct     letter (A-F) or number (1-6) of the correct option (required)
+//	time_limit  seconds to answer, 5-120 (default 20)
+//	points      points for a correct answer, 0-5000 (default 1000)
+//
+// Blank rows are skipped. Only the first worksheet of an XLSX workbook is read.
+//
+// The LMS formats carry more question types than a quiz can play. True/false questions
+// become two options, True and False; other types, and multiple-choice questions with more
+// than one correct answer, are reported as errors at the line the question starts. Time
+// limits and points aren't part of these formats, so imported questions get the defaults
+// and exports leave them out. Aiken is the lossiest: it has no title either, and holds each
+// option on one line.
+//
+// QTI 2.1 content packages are zip files with an imsmanifest.xml listing one assessment item
+// per question and, optionally, an assessment test giving their order, title and time limits.
+// Choice interactions become questions; the images they show are returned as MediaFiles for
+// the caller to store. Other interaction types are reported per item.
+package quizio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"kahootclone/internal/models"
+)
+
+// Formats accepted by Import and Export.
+const (
+	FormatCSV       = "csv"
+	FormatXLSX      = "xlsx"
+	FormatGIFT      = "gift"
+	FormatAiken     = "aiken"
+	FormatMoodleXML = "moodlexml"
+	FormatQTI       = "qti"
+)
+
+// codec reads and writes one format; either function may be nil.
+type codec struct {
+	name        string
+	read        func(data []byte) (*models.Quiz, []MediaFile, error)
+	write       func(quiz *models.Quiz, media MediaSource) ([]byte, error)
+	contentType string
+	extension   string
+	maxSize     int // largest file read; MaxImportSize if zero
+}
+
+var codecs = []codec{
+	{name: FormatCSV, read: textOnly(readCSV)},
+	{name: FormatXLSX, read: textOnly(readXLSX)},
+	{name: FormatGIFT, read: textOnly(readGIFT), write: withoutMedia(writeGIFT), contentType: "text/plain; charset=utf-8", extension: "gift.txt"},
+	{name: FormatAiken, read: textOnly(readAiken), write: withoutMedia(writeAiken), contentType: "text/plain; charset=utf-8", extension: "aiken.txt"},
+	{name: FormatMoodleXML, read: textOnly(readMoodleXML), write: withoutMedia(writeMoodleXML), contentType: "application/xml", extension: "xml"},
+	{name: FormatQTI, read: readQTI, write: writeQTI, contentType: "application/zip", extension: "zip", maxSize: MaxPackageSize},
+}
+
+// textOnly adapts the reader of a format that can't carry media.
+func textOnly(read func(data []byte) (*models.Quiz, error)) func([]byte) (*models.Quiz, []MediaFile, error) {
+	return func(data []byte) (*models.Quiz, []MediaFile, error) {
+		quiz, err := read(data)
+		return quiz, nil, err
+	}
+}
+
+// withoutMedia adapts the writer of a format that can't carry media; questions are written
+// without their images.
+func withoutMedia(write func(quiz *models.Quiz) ([]byte, error)) func(*models.Quiz, MediaSource) ([]byte, error) {
+	return func(quiz *models.Quiz, _ MediaSource) ([]byte, error) {
+		return write(quiz)
+	}
+}
+
+func findCodec(format string) (codec, bool) {
+	for _, c := range codecs {
+		if c.name == strings.ToLower(format) {
+			return c, true
+		}
+	}
+	return codec{}, false
+}
+
+// ImportFormats lists the formats Import reads.
+func ImportFormats() []string {
+	var out []string
+	for _, c := range codecs {
+		if c.read != nil {
+			out = append(out, c.name)
+		}
+	}
+	return out
+}
+
+// ExportFormats lists the formats Export writes.
+func ExportFormats() []string {
+	var out []string
+	for _, c := range codecs {
+		if c.write != nil {
+			out = append(out, c.name)
+		}
+	}
+	return out
+}
+
+// MaxImportSize is the largest file Import reads, except for packages.
+const MaxImportSize = 5 << 20
+
+// MaxPackageSize is the largest content package Import reads, images included.
+const MaxPackageSize = 20 << 20
+
+// maxLineErrors caps how many problems one import reports, so a file in the wrong layout
+// doesn't produce an error per row.
+const maxLineErrors = 50
+
+var (
+	// ErrUnknownFormat is returned by Import and Export for a format they can't handle.
+	ErrUnknownFormat = errors.New("unknown import format")
+	// ErrNotExportable is returned by Export for a quiz the format can't express, such as one
+	// with a question whose correct option isn't set.
+	ErrNotExportable = errors.New("quiz can't be exported")
+	// ErrTooLarge is returned by Import for files over the format's size limit.
+	ErrTooLarge = errors.New("file is too large")
+)
+
+// LineError is a problem with one line (row) of an imported file.
+type LineError struct {
+	File    string `json:"file,omitempty"`   // file within a package, for package formats
+	Line    int    `json:"line"`             // 1-based line or row number
+	Column  string `json:"column,omitempty"` // column name, when the problem is in one cell
+	Message string `json:"message"`
+}
+
+func (e LineError) Error() string {
+	var where string
+	if e.File != "" {
+		where = e.File + ": "
+	}
+	if e.Column != "" {
+		return fmt.Sprintf("%sline %d, %s: %s", where, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%sline %d: %s", where, e.Line, e.Message)
+}
+
+// ImportError lists every problem found in an imported file.
+type ImportError struct {
+	Errors []LineError
+}
+
+func (e *ImportError) Error() string {
+	switch len(e.Errors) {
+	case 1:
+		return e.Errors[0].Error()
+	case 2:
+		return e.Errors[0].Error() + " (and 1 more error)"
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", e.Errors[0].Error(), len(e.Errors)-1)
+	}
+}
+
+// add records a problem.
+func (e *ImportError) add(line int, column, format string, args ...any) {
+	e.Errors = append(e.Errors, LineError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// addIn records a problem in one file of a package.
+func (e *ImportError) addIn(file string, line int, format string, args ...any) {
+	e.Errors = append(e.Errors, LineError{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// full reports whether enough problems have been found to stop reading.
+func (e *ImportError) full() bool {
+	return len(e.Errors) >= maxLineErrors
+}
+
+// MediaFile is an image or other file a quiz refers to by its models.Media key.
+type MediaFile struct {
+	Key         string
+	ContentType string
+	Data        []byte
+	Thumbnail   []byte // imported images only; stored beside the image by media.Put
+}
+
+// MediaSource loads the file stored under a media key, for formats that export media.
+type MediaSource func(key string) (*MediaFile, error)
+
+// Import reads a quiz in the given format, assigning new question and option IDs. Formats
+// without a title leave it empty. Media the file carries is returned under new keys that its
+// questions and options refer to, for the caller to store. Problems in the file are returned
+// as an *ImportError; the quiz as a whole is left for the caller to check with
+// models.ValidateQuiz.
+func Import(format string, r io.Reader) (*models.Quiz, []MediaFile, error) {
+	c, ok := findCodec(format)
+	if !ok || c.read == nil {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	maxSize := c.maxSize
+	if maxSize == 0 {
+		maxSize = MaxImportSize
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) > maxSize {
+		return nil, nil, fmt.Errorf("%w: %s files are limited to %d MB", ErrTooLarge, c.name, maxSize>>20)
+	}
+	return c.read(data)
+}
+
+// File is an exported quiz, ready to download.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Export writes a quiz in the given format. Formats that carry media load it from media;
+// others leave it out.
+func Export(format string, quiz *models.Quiz, media MediaSource) (*File, error) {
+	c, ok := findCodec(format)
+	if !ok || c.write == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	data, err := c.write(quiz, media)
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		Name:        fileName(quiz.Title) + "." + c.extension,
+		ContentType: c.contentType,
+		Data:        data,
+	}, nil
+}
+
+// fileName turns a quiz title into a safe download file name.
+func fileName(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	if name := strings.TrimSuffix(b.String(), "-"); name != "" {
+		return name
+	}
+	return "quiz"
+}
@@ -0,0 +1,224 @@
+package quizio
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"kahootclone/internal/models"
+)
+
+func question(text string, correct int, timeLimit, points int, options ...string) models.Question {
+	q := models.Question{QuestionID: "q-" + text, Text: text, TimeLimitSeconds: timeLimit, Points: points}
+	for i, o := range options {
+		id := "o-" + o
+		q.Options = append(q.Options, models.Option{ID: id, Text: o})
+		if i == correct {
+			q.CorrectOptionID = id
+		}
+	}
+	return q
+}
+
+// roundTripQuiz exercises what the formats can and can't carry: a title, single-answer
+// multiple choice, true/false, non-default timing and points, multi-line text and the
+// characters GIFT escapes.
+func roundTripQuiz() *models.Quiz {
+	return &models.Quiz{
+		Title: "World Capitals",
+		Questions: []models.Question{
+			question("What is the capital of France?", 2, 30, 2000, "Berlin", "Rome", "Paris", "Madrid"),
+			question("The Danube flows through Vienna.", 0, 10, 500, "True", "False"),
+			question("Which city is older?\nPick one.", 1, models.DefaultTimeLimitSeconds, models.DefaultPoints, "Ottawa", "Athens"),
+			question("Is 2+2={4}: yes~no #1?", 0, models.DefaultTimeLimitSeconds, models.DefaultPoints, "a=b", "c:d"),
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		format    string
+		title     bool // the format carries the quiz title
+		timeLimit bool // the format carries time limits
+	}{
+		{format: FormatGIFT, title: true},
+		{format: FormatAiken},
+		{format: FormatMoodleXML, title: true},
+		{format: FormatQTI, title: true, timeLimit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			want := roundTripQuiz()
+			file, err := Export(tt.format, want, nil)
+			if err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			got, _, err := Import(tt.format, bytes.NewReader(file.Data))
+			if err != nil {
+				t.Fatalf("import: %v\n%s", err, file.Data)
+			}
+
+			wantTitle := ""
+			if tt.title {
+				wantTitle = want.Title
+			}
+			if got.Title != wantTitle {
+				t.Errorf("title: got %q, want %q", got.Title, wantTitle)
+			}
+			if len(got.Questions) != len(want.Questions) {
+				t.Fatalf("got %d questions, want %d", len(got.Questions), len(want.Questions))
+			}
+
+			for i, w := range want.Questions {
+				g := got.Questions[i]
+				if g.Text != w.Text {
+					t.Errorf("question %d text: got %q, want %q", i+1, g.Text, w.Text)
+				}
+				if len(g.Options) != len(w.Options) {
+					t.Fatalf("question %d: got %d options, want %d", i+1, len(g.Options), len(w.Options))
+				}
+				for j, o := range w.Options {
+					if g.Options[j].Text != o.Text {
+						t.Errorf("question %d option %d: got %q, want %q", i+1, j+1, g.Options[j].Text, o.Text)
+					}
+				}
+				if correctIndex(g) != correctIndex(w) {
+					t.Errorf("question %d: got correct option %d, want %d", i+1, correctIndex(g), correctIndex(w))
+				}
+
+				wantTime := models.DefaultTimeLimitSeconds
+				if tt.timeLimit {
+					wantTime = w.TimeLimitSeconds
+				}
+				if g.TimeLimitSeconds != wantTime {
+					t.Errorf("question %d time limit: got %d, want %d", i+1, g.TimeLimitSeconds, wantTime)
+				}
+				// None of the formats carry points
+				if g.Points != models.DefaultPoints {
+					t.Errorf("question %d points: got %d, want the default %d", i+1, g.Points, models.DefaultPoints)
+				}
+			}
+		})
+	}
+}
+
+func TestAikenIsLossy(t *testing.T) {
+	quiz := &models.Quiz{
+		Title: "Lost on export",
+		Questions: []models.Question{
+			question("Read this:\n\nA. is not an option here", 0, 30, 2000, "first\nline", "second"),
+		},
+	}
+	file, err := Export(FormatAiken, quiz, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := Import(FormatAiken, bytes.NewReader(file.Data))
+	if err != nil {
+		t.Fatalf("import: %v\n%s", err, file.Data)
+	}
+
+	q := got.Questions[0]
+	if got.Title != "" {
+		t.Errorf("got title %q; Aiken has no title", got.Title)
+	}
+	// A continuation line that looks like an option would be misread, so the question
+	// is joined onto one line
+	if want := "Read this: A. is not an option here"; q.Text != want {
+		t.Errorf("got text %q, want %q", q.Text, want)
+	}
+	if q.Options[0].Text != "first line" {
+		t.Errorf("got option %q, want line breaks joined with spaces", q.Options[0].Text)
+	}
+	if q.TimeLimitSeconds != models.DefaultTimeLimitSeconds || q.Points != models.DefaultPoints {
+		t.Errorf("got time %d and points %d, want the defaults", q.TimeLimitSeconds, q.Points)
+	}
+}
+
+func TestImportRejectsMultipleCorrectAnswers(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{FormatGIFT, "Pick two {\n=a\n=b\n~c\n}\n"},
+		{FormatAiken, "Pick two\nA. a\nB. b\nANSWER: A, B\n"},
+		{FormatMoodleXML, `<quiz><question type="multichoice">
+<questiontext format="html"><text>Pick two</text></questiontext>
+<answer fraction="50"><text>a</text></answer>
+<answer fraction="50"><text>b</text></answer>
+<answer fraction="0"><text>c</text></answer>
+</question></quiz>`},
+		{FormatQTI, string(qtiWithTwoCorrect(t))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			_, _, err := Import(tt.format, strings.NewReader(tt.data))
+			var importErr *ImportError
+			if !errors.As(err, &importErr) {
+				t.Fatalf("got %v, want an *ImportError", err)
+			}
+			if !strings.Contains(importErr.Error(), "correct") {
+				t.Errorf("got %q, want an error about correct answers", importErr.Error())
+			}
+		})
+	}
+}
+
+// qtiWithTwoCorrect exports a one-question package and edits its item to accept two choices.
+func qtiWithTwoCorrect(t *testing.T) []byte {
+	t.Helper()
+	quiz := &models.Quiz{Title: "Multi", Questions: []models.Question{
+		question("Pick two", 0, 20, 1000, "a", "b", "c"),
+	}}
+	file, err := Export(FormatQTI, quiz, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(file.Data), int64(len(file.Data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(f.Name, "items/") {
+			s := strings.Replace(string(data), `cardinality="single"`, `cardinality="multiple"`, 1)
+			s = strings.Replace(s, "<value>choice-1</value>", "<value>choice-1</value><value>choice-2</value>", 1)
+			data = []byte(s)
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestExportRejectsQuestionWithoutCorrectOption(t *testing.T) {
+	q := question("No answer", -1, 20, 1000, "a", "b", "c")
+	quiz := &models.Quiz{Title: "Broken", Questions: []models.Question{q}}
+	for _, format := range ExportFormats() {
+		if _, err := Export(format, quiz, nil); !errors.Is(err, ErrNotExportable) {
+			t.Errorf("%s: got %v, want ErrNotExportable", format, err)
+		}
+	}
+}
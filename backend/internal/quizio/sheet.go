@@ -0,0 +1,183 @@
+package quizio
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"kahootclone/internal/models"
+)
+
+// Spreadsheet column names, after normalizeHeader.
+const (
+	colQuestion  = "question"
+	colCorrect   = "correct"
+	colTimeLimit = "time_limit"
+	colPoints    = "points"
+)
+
+// optionColumns are the option columns in order; the letter in each name identifies the
+// option in the correct column.
+var optionColumns = []string{"option_a", "option_b", "option_c", "option_d", "option_e", "option_f"}
+
+// sheetRow is one row of a spreadsheet with the line it came from.
+type sheetRow struct {
+	line  int
+	cells []string
+}
+
+// blank reports whether every cell in the row is empty.
+func (r sheetRow) blank() bool {
+	for _, c := range r.cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// parseSheet turns spreadsheet rows in the layout documented on the package into a quiz.
+func parseSheet(rows []sheetRow) (*models.Quiz, error) {
+	var errs ImportError
+
+	// The first non-blank row is the header
+	for len(rows) > 0 && rows[0].blank() {
+		rows = rows[1:]
+	}
+	if len(rows) == 0 {
+		errs.add(1, "", "file is empty")
+		return nil, &errs
+	}
+	header := rows[0]
+	columns := make(map[string]int, len(header.cells))
+	for i, name := range header.cells {
+		if name = normalizeHeader(name); name != "" {
+			if _, dup := columns[name]; !dup {
+				columns[name] = i
+			}
+		}
+	}
+	for _, required := range []string{colQuestion, optionColumns[0], optionColumns[1], colCorrect} {
+		if _, ok := columns[required]; !ok {
+			errs.add(header.line, "", "missing %q column", required)
+		}
+	}
+	if len(errs.Errors) > 0 {
+		return nil, &errs
+	}
+
+	quiz := &models.Quiz{}
+	for _, row := range rows[1:] {
+		if row.blank() {
+			continue
+		}
+		q, ok := parseSheetQuestion(row, columns, &errs)
+		if ok {
+			quiz.Questions = append(quiz.Questions, q)
+		} else if errs.full() {
+			break
+		}
+	}
+	if len(errs.Errors) > 0 {
+		return nil, &errs
+	}
+	return quiz, nil
+}
+
+// parseSheetQuestion reads one question row, recording its problems in errs.
+func parseSheetQuestion(row sheetRow, columns map[string]int, errs *ImportError) (models.Question, bool) {
+	cell := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row.cells) {
+			return ""
+		}
+		return strings.TrimSpace(row.cells[i])
+	}
+	before := len(errs.Errors)
+
+	q := newQuestion(cell(colQuestion))
+	if q.Text == "" {
+		errs.add(row.line, colQuestion, "question text is required")
+	}
+
+	// Blank option cells are skipped, so remember where each column's option landed
+	optionAt := make([]int, len(optionColumns))
+	for i, name := range optionColumns {
+		optionAt[i] = -1
+		if text := cell(name); text != "" {
+			optionAt[i] = len(q.Options)
+			addOption(&q, text, false)
+		}
+	}
+	if len(q.Options) < 2 {
+		errs.add(row.line, "", "at least two options are required")
+	}
+
+	if correct := cell(colCorrect); correct == "" {
+		errs.add(row.line, colCorrect, "correct option is required")
+	} else if i, msg := parseCorrect(correct); msg != "" {
+		errs.add(row.line, colCorrect, "%s", msg)
+	} else if optionAt[i] < 0 {
+		errs.add(row.line, colCorrect, "correct option %c is blank", 'A'+i)
+	} else {
+		q.CorrectOptionID = q.Options[optionAt[i]].ID
+	}
+
+	if v := cell(colTimeLimit); v != "" {
+		n, ok := parseWholeNumber(v)
+		if !ok || n < models.MinTimeLimitSeconds || n > models.MaxTimeLimitSeconds {
+			errs.add(row.line, colTimeLimit, "must be a whole number of seconds from %d to %d", models.MinTimeLimitSeconds, models.MaxTimeLimitSeconds)
+		}
+		q.TimeLimitSeconds = n
+	}
+	if v := cell(colPoints); v != "" {
+		n, ok := parseWholeNumber(v)
+		if !ok || n < 0 || n > models.MaxPoints {
+			errs.add(row.line, colPoints, "must be a whole number from 0 to %d", models.MaxPoints)
+		}
+		q.Points = n
+	}
+
+	return q, len(errs.Errors) == before
+}
+
+// parseCorrect reads the correct column as a 0-based option column index, or returns why it
+// can't.
+func parseCorrect(v string) (int, string) {
+	if strings.ContainsAny(v, ",;/ ") {
+		return 0, "only one correct option is supported"
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		if n < 1 || n > len(optionColumns) {
+			return 0, "must be a letter A-F or a number 1-6"
+		}
+		return n - 1, ""
+	}
+	if len(v) == 1 {
+		if c := v[0] | 0x20; c >= 'a' && c < 'a'+byte(len(optionColumns)) {
+			return int(c - 'a'), ""
+		}
+	}
+	if len(v) > 1 && len(v) <= len(optionColumns) && strings.Trim(strings.ToUpper(v), "ABCDEF") == "" {
+		return 0, "only one correct option is supported"
+	}
+	return 0, "must be a letter A-F or a number 1-6"
+}
+
+// parseWholeNumber parses an integer, also accepting the "20.0" spreadsheets sometimes
+// write for whole numbers.
+func parseWholeNumber(v string) (int, bool) {
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, true
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt32 {
+		return 0, false
+	}
+	return int(f), true
+}
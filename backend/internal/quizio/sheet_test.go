@@ -0,0 +1,268 @@
+package quizio
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"kahootclone/internal/models"
+)
+
+// sheetQuestion is the part of an imported question the spreadsheet layout controls.
+type sheetQuestion struct {
+	text      string
+	options   string // option texts joined with "|"
+	correct   string // text of the correct option
+	timeLimit int
+	points    int
+}
+
+func sheetQuestions(quiz *models.Quiz) []sheetQuestion {
+	var out []sheetQuestion
+	for _, q := range quiz.Questions {
+		sq := sheetQuestion{text: q.Text, timeLimit: q.TimeLimitSeconds, points: q.Points}
+		var texts []string
+		for _, o := range q.Options {
+			texts = append(texts, o.Text)
+			if o.ID == q.CorrectOptionID {
+				sq.correct = o.Text
+			}
+		}
+		sq.options = strings.Join(texts, "|")
+		out = append(out, sq)
+	}
+	return out
+}
+
+// checkSheetImport compares what a spreadsheet reader returned with either the questions or
+// the line errors a test expects.
+func checkSheetImport(t *testing.T, quiz *models.Quiz, err error, want []sheetQuestion, wantErrs []LineError) {
+	t.Helper()
+	if wantErrs != nil {
+		var importErr *ImportError
+		if !errors.As(err, &importErr) {
+			t.Fatalf("got %v, want an *ImportError", err)
+		}
+		if !reflect.DeepEqual(importErr.Errors, wantErrs) {
+			t.Errorf("got errors %+v, want %+v", importErr.Errors, wantErrs)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sheetQuestions(quiz); !reflect.DeepEqual(got, want) {
+		t.Errorf("got questions %+v, want %+v", got, want)
+	}
+}
+
+const sheetHeader = "Question,Option A,Option B,Option C,Correct,Time Limit,Points\n"
+
+func TestReadCSV(t *testing.T) {
+	defaults := func(text, options, correct string) sheetQuestion {
+		return sheetQuestion{text, options, correct, models.DefaultTimeLimitSeconds, models.DefaultPoints}
+	}
+	tests := []struct {
+		name     string
+		data     string
+		want     []sheetQuestion
+		wantErrs []LineError
+	}{
+		{
+			name: "byte order mark",
+			data: "\xEF\xBB\xBF" + sheetHeader + "Capital of France?,Rome,Paris,,B,,\n",
+			want: []sheetQuestion{defaults("Capital of France?", "Rome|Paris", "Paris")},
+		},
+		{
+			name: "correct as letter or number",
+			data: sheetHeader + "One?,x,y,z,c,,\nTwo?,x,y,z,2,,\nThree?,x,y,,a,,\n",
+			want: []sheetQuestion{
+				defaults("One?", "x|y|z", "z"),
+				defaults("Two?", "x|y|z", "y"),
+				defaults("Three?", "x|y", "x"),
+			},
+		},
+		{
+			name: "whole numbers written as decimals",
+			data: sheetHeader + "One?,x,y,,A,20.0,500.0\n",
+			want: []sheetQuestion{{"One?", "x|y", "x", 20, 500}},
+		},
+		{
+			name: "more than one correct option",
+			data: sheetHeader + "One?,x,y,z,\"A,B\",,\nTwo?,x,y,z,AB,,\n",
+			wantErrs: []LineError{
+				{Line: 2, Column: colCorrect, Message: "only one correct option is supported"},
+				{Line: 3, Column: colCorrect, Message: "only one correct option is supported"},
+			},
+		},
+		{
+			name: "bad correct and numbers",
+			data: sheetHeader + "One?,x,y,,C,,\nTwo?,x,y,,G,20.5,-1\n",
+			wantErrs: []LineError{
+				{Line: 2, Column: colCorrect, Message: "correct option C is blank"},
+				{Line: 3, Column: colCorrect, Message: "must be a letter A-F or a number 1-6"},
+				{Line: 3, Column: colTimeLimit, Message: fmt.Sprintf("must be a whole number of seconds from %d to %d", models.MinTimeLimitSeconds, models.MaxTimeLimitSeconds)},
+				{Line: 3, Column: colPoints, Message: fmt.Sprintf("must be a whole number from 0 to %d", models.MaxPoints)},
+			},
+		},
+		{
+			// The first row spans lines 2-4, so the second row starts on line 5
+			name: "multi-line cells report the line the row starts on",
+			data: sheetHeader + "\"Which city\nis older?\n\",x,y,,Z,,\nTwo?,x,,,A,,\n",
+			wantErrs: []LineError{
+				{Line: 2, Column: colCorrect, Message: "must be a letter A-F or a number 1-6"},
+				{Line: 5, Message: "at least two options are required"},
+			},
+		},
+		{
+			name: "missing required columns",
+			data: "\n\nQuestion,Option A,Answer\nOne?,x,A\n",
+			wantErrs: []LineError{
+				{Line: 3, Message: `missing "option_b" column`},
+				{Line: 3, Message: `missing "correct" column`},
+			},
+		},
+		{
+			name:     "empty",
+			data:     "\xEF\xBB\xBF\n,,\n",
+			wantErrs: []LineError{{Line: 1, Message: "file is empty"}},
+		},
+		{
+			name:     "unterminated quote",
+			data:     sheetHeader + "\"One?,x,y,,A,,\n",
+			wantErrs: []LineError{{Line: 2, Message: `extraneous or missing " in quoted-field`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz, err := readCSV([]byte(tt.data))
+			checkSheetImport(t, quiz, err, tt.want, tt.wantErrs)
+		})
+	}
+}
+
+func TestReadCSVStopsAtMaxLineErrors(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(sheetHeader)
+	for i := 0; i < maxLineErrors*2; i++ {
+		b.WriteString(",x,y,,A,,\n") // each row is missing its question text
+	}
+	_, err := readCSV([]byte(b.String()))
+	var importErr *ImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("got %v, want an *ImportError", err)
+	}
+	if len(importErr.Errors) != maxLineErrors {
+		t.Errorf("got %d errors, want %d", len(importErr.Errors), maxLineErrors)
+	}
+	if last := importErr.Errors[len(importErr.Errors)-1]; last.Line != maxLineErrors+1 {
+		t.Errorf("got last error on line %d, want %d", last.Line, maxLineErrors+1)
+	}
+}
+
+// xlsxWorkbook packages a worksheet and an optional shared string table as a workbook.
+func xlsxWorkbook(t *testing.T, sheetData, sharedStrings string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Quiz" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData + `</sheetData></worksheet>`,
+	}
+	if sharedStrings != "" {
+		parts["xl/sharedStrings.xml"] = `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + sharedStrings + `</sst>`
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	// Shared strings 0-4 are the header, 5 onwards the questions' text
+	const shared = `<si><t>Question</t></si><si><t>Option A</t></si><si><t>Option B</t></si>` +
+		`<si><t>Option C</t></si><si><t>Correct</t></si>` +
+		`<si><r><t>Capital of </t></r><r><t>France?</t></r></si><si><t>Rome</t></si><si><t>Paris</t></si>`
+	const header = `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c>` +
+		`<c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c></row>`
+	inline := func(ref, text string) string {
+		return `<c r="` + ref + `" t="inlineStr"><is><t>` + text + `</t></is></c>`
+	}
+
+	tests := []struct {
+		name     string
+		sheet    string
+		shared   string
+		want     []sheetQuestion
+		wantErrs []LineError
+	}{
+		{
+			name:   "shared strings and numeric correct",
+			sheet:  header + `<row r="2"><c r="A2" t="s"><v>5</v></c><c r="B2" t="s"><v>6</v></c><c r="C2" t="s"><v>7</v></c><c r="E2"><v>2</v></c></row>`,
+			shared: shared,
+			want:   []sheetQuestion{{"Capital of France?", "Rome|Paris", "Paris", models.DefaultTimeLimitSeconds, models.DefaultPoints}},
+		},
+		{
+			name: "inline strings without refs",
+			sheet: `<row>` + inline("", "Question") + inline("", "Option A") + inline("", "Option B") + inline("", "Correct") + `</row>` +
+				`<row>` + inline("", "One?") + inline("", "x") + inline("", "y") + inline("", "b") + `</row>`,
+			want: []sheetQuestion{{"One?", "x|y", "y", models.DefaultTimeLimitSeconds, models.DefaultPoints}},
+		},
+		{
+			// Blank rows and cells are left out of the sheet, so rows and cells are placed by
+			// their r= references and errors name the sheet's row numbers
+			name: "sparse rows and cells",
+			sheet: header +
+				`<row r="4">` + inline("A4", "One?") + inline("B4", "x") + inline("D4", "z") + `<c r="E4"><v>3</v></c></row>` +
+				`<row r="7">` + inline("A7", "Two?") + inline("C7", "y") + `<c r="E7"><v>1</v></c></row>`,
+			shared: shared,
+			wantErrs: []LineError{
+				{Line: 7, Message: "at least two options are required"},
+				{Line: 7, Column: colCorrect, Message: "correct option A is blank"},
+			},
+		},
+		{
+			name:  "missing required columns",
+			sheet: `<row r="2">` + inline("A2", "Question") + inline("B2", "Option A") + `</row>`,
+			wantErrs: []LineError{
+				{Line: 2, Message: `missing "option_b" column`},
+				{Line: 2, Message: `missing "correct" column`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiz, err := readXLSX(xlsxWorkbook(t, tt.sheet, tt.shared))
+			checkSheetImport(t, quiz, err, tt.want, tt.wantErrs)
+		})
+	}
+
+	t.Run("sparse cells keep their columns", func(t *testing.T) {
+		sheet := header + `<row r="4">` + inline("A4", "One?") + inline("B4", "x") + inline("D4", "z") + `<c r="E4"><v>3</v></c></row>`
+		quiz, err := readXLSX(xlsxWorkbook(t, sheet, shared))
+		checkSheetImport(t, quiz, err, []sheetQuestion{{"One?", "x|z", "z", models.DefaultTimeLimitSeconds, models.DefaultPoints}}, nil)
+	})
+
+	t.Run("not a workbook", func(t *testing.T) {
+		_, err := readXLSX([]byte("Question,Option A\n"))
+		checkSheetImport(t, nil, err, nil, []LineError{{Line: 1, Message: errNotXLSX.Error()}})
+	})
+}
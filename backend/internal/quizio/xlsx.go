@@ -0,0 +1,202 @@
+package quizio
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"kahootclone/internal/models"
+)
+
+// maxXLSXPartSize caps how much of one workbook part is decompressed, so a small upload
+// can't expand into gigabytes of XML.
+const maxXLSXPartSize = 64 << 20
+
+// errNotXLSX is reported for uploads that aren't an Office Open XML workbook.
+var errNotXLSX = errors.New("file is not an XLSX workbook")
+
+// readXLSX reads a quiz from the first worksheet of an XLSX workbook in the spreadsheet layout.
+func readXLSX(data []byte) (*models.Quiz, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, &ImportError{Errors: []LineError{{Line: 1, Message: errNotXLSX.Error()}}}
+	}
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	sheetPath, err := firstSheetPath(parts)
+	if err != nil {
+		return nil, &ImportError{Errors: []LineError{{Line: 1, Message: err.Error()}}}
+	}
+	shared, err := sharedStrings(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodePart(parts, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([]sheetRow, 0, len(sheet.Rows))
+	for i, row := range sheet.Rows {
+		line := row.R
+		if line == 0 {
+			line = i + 1 // the row number is optional when rows are contiguous
+		}
+		var cells []string
+		for j, c := range row.Cells {
+			col := j
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			if col < 0 {
+				continue
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", c.Ref)
+				}
+				cells[col] = shared[n]
+			case "inlineStr":
+				cells[col] = c.Inline.String()
+			case "b":
+				cells[col] = map[string]string{"0": "FALSE", "1": "TRUE"}[c.Value]
+			default: // n, str, e, d: the value is already text
+				cells[col] = c.Value
+			}
+		}
+		rows = append(rows, sheetRow{line: line, cells: cells})
+	}
+	return parseSheet(rows)
+}
+
+// firstSheetPath finds the part holding the workbook's first worksheet.
+func firstSheetPath(parts map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return "", errNotXLSX
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("workbook has no worksheets")
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", errNotXLSX
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		// Targets are relative to xl/ unless absolute within the package
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", errNotXLSX
+}
+
+// sharedStrings reads the workbook's shared string table, which most writers use for all
+// text cells. Workbooks without text have none.
+func sharedStrings(parts map[string]*zip.File) ([]string, error) {
+	if _, ok := parts["xl/sharedStrings.xml"]; !ok {
+		return nil, nil
+	}
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := decodePart(parts, "xl/sharedStrings.xml", &sst); err != nil {
+		return nil, err
+	}
+	out := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		out[i] = item.String()
+	}
+	return out, nil
+}
+
+// xlsxText is a string item: plain text, or rich text split into formatted runs.
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// decodePart unmarshals one XML part of the package.
+func decodePart(parts map[string]*zip.File, name string, v any) error {
+	f, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("workbook is missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v)
+}
+
+// columnIndex converts the letters of a cell reference such as "C12" to a 0-based column
+// index, or -1 if there are none.
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+		n++
+	}
+	if n == 0 || n > 3 {
+		return -1
+	}
+	return col - 1
+}
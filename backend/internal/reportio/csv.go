@@ -0,0 +1,67 @@
+package reportio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"kahootclone/internal/models"
+)
+
+// writeGradebookCSV writes a gradebook as CSV, one player per line after the header, each
+// line written as its row is produced.
+func writeGradebookCSV(w io.Writer, g *models.Gradebook) error {
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	if err := cw.Write(gradebookHeader(g)); err != nil {
+		return err
+	}
+
+	record := make([]string, 0, 5+2*len(g.Questions))
+	err := g.EachRow(func(row models.GradebookRow) error {
+		p := row.Player
+		record = append(record[:0],
+			rankText(p.Rank),
+			safeCell(p.Nickname),
+			safeCell(p.UserID),
+			strconv.FormatFloat(p.Score, 'f', -1, 64),
+			strconv.Itoa(correctCount(row.Cells)),
+		)
+		for _, c := range row.Cells {
+			switch {
+			case !c.Answered:
+				record = append(record, "", "")
+			case c.Correct:
+				record = append(record, "1", strconv.Itoa(int(c.Points)))
+			default:
+				record = append(record, "0", strconv.Itoa(int(c.Points)))
+			}
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// rankText is a player's rank as a cell, blank for players without one.
+func rankText(rank int64) string {
+	if rank <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(rank, 10)
+}
+
+// safeCell stops spreadsheet programs from running a player-chosen value as a formula.
+func safeCell(s string) string {
+	if s != "" && (s[0] == '=' || s[0] == '+' || s[0] == '-' || s[0] == '@' || s[0] == '\t' || s[0] == '\r') {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,264 @@
+package reportio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 page size and margins, in points.
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	pageMargin   = 50.0
+	contentWidth = pageWidth - 2*pageMargin
+)
+
+// Object numbers reserved by pdfWriter; pages and their contents follow.
+const (
+	pdfCatalogObj = 1
+	pdfPagesObj   = 2
+	pdfFontObj    = 3
+	pdfBoldObj    = 4
+	pdfFirstFree  = 5
+)
+
+// pdfWriter writes a PDF 1.4 document a page at a time using the standard Helvetica fonts,
+// so each page is flushed to the underlying writer as soon as it is finished. The page tree
+// is written last, once every page's object number is known. The first write error is kept
+// and returned by close.
+type pdfWriter struct {
+	w       *bufio.Writer
+	n       int64   // bytes written so far
+	offsets []int64 // offsets[i] is where object i+1 starts
+	pages   []int
+	err     error
+}
+
+func newPDFWriter(w io.Writer) *pdfWriter {
+	p := &pdfWriter{w: bufio.NewWriter(w), offsets: make([]int64, pdfFirstFree-1)}
+	p.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	p.object(pdfFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	p.object(pdfBoldObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	return p
+}
+
+func (p *pdfWriter) write(s string) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.WriteString(s)
+	p.n += int64(n)
+	p.err = err
+}
+
+// newObject allocates the next object number.
+func (p *pdfWriter) newObject() int {
+	p.offsets = append(p.offsets, 0)
+	return len(p.offsets)
+}
+
+// object writes object num with the given body.
+func (p *pdfWriter) object(num int, body string) {
+	p.offsets[num-1] = p.n
+	p.write(strconv.Itoa(num) + " 0 obj\n" + body + "\nendobj\n")
+}
+
+// addPage compresses a page's content stream and writes it with its page object.
+func (p *pdfWriter) addPage(content []byte) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(content)
+	zw.Close()
+
+	contentObj := p.newObject()
+	p.object(contentObj, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+	pageObj := p.newObject()
+	p.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObj, pageWidth, pageHeight, pdfFontObj, pdfBoldObj, contentObj))
+	p.pages = append(p.pages, pageObj)
+
+	// Flush finished pages rather than letting them pile up in the buffer
+	if p.err == nil {
+		p.err = p.w.Flush()
+	}
+}
+
+// close writes the page tree, catalog, document info and cross-reference table.
+func (p *pdfWriter) close(title string) error {
+	kids := make([]string, len(p.pages))
+	for i, num := range p.pages {
+		kids[i] = strconv.Itoa(num) + " 0 R"
+	}
+	p.object(pdfPagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	p.object(pdfCatalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObj))
+	infoObj := p.newObject()
+	p.object(infoObj, "<< /Title "+pdfString(title)+" /Producer (kahootclone) >>")
+
+	xref := p.n
+	p.write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(p.offsets)+1))
+	for _, off := range p.offsets {
+		p.write(fmt.Sprintf("%010d 00000 n \n", off))
+	}
+	p.write(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets)+1, pdfCatalogObj, infoObj, xref))
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+// pdfPage builds the content stream of one page. Coordinates are measured from the top-left
+// corner of the page, unlike PDF's own, which start at the bottom left.
+type pdfPage struct {
+	buf bytes.Buffer
+}
+
+// color is an RGB fill color with components between 0 and 1.
+type color struct{ r, g, b float64 }
+
+var (
+	colorText    = color{0.13, 0.13, 0.13}
+	colorMuted   = color{0.45, 0.45, 0.45}
+	colorGrid    = color{0.85, 0.85, 0.85}
+	colorGood    = color{0.18, 0.62, 0.33}
+	colorFair    = color{0.93, 0.65, 0.13}
+	colorPoor    = color{0.84, 0.26, 0.22}
+	colorNeutral = color{0.55, 0.6, 0.7}
+	colorShade   = color{0.95, 0.95, 0.95}
+)
+
+// text draws s with its baseline at y.
+func (pg *pdfPage) text(x, y, size float64, bold bool, c color, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&pg.buf, "BT %.3f %.3f %.3f rg /%s %g Tf %.2f %.2f Td %s Tj ET\n", c.r, c.g, c.b, font, size, x, pageHeight-y, pdfString(s))
+}
+
+// textRight draws s ending at x.
+func (pg *pdfPage) textRight(x, y, size float64, bold bool, c color, s string) {
+	pg.text(x-textWidth(s, size, bold), y, size, bold, c, s)
+}
+
+// rect fills a rectangle whose top-left corner is at x, y.
+func (pg *pdfPage) rect(x, y, w, h float64, c color) {
+	fmt.Fprintf(&pg.buf, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", c.r, c.g, c.b, x, pageHeight-y-h, w, h)
+}
+
+// line strokes a half-point line.
+func (pg *pdfPage) line(x1, y1, x2, y2 float64, c color) {
+	fmt.Fprintf(&pg.buf, "%.3f %.3f %.3f RG 0.5 w %.2f %.2f m %.2f %.2f l S\n", c.r, c.g, c.b, x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+// helveticaWidths are the widths of the printable ASCII characters in Helvetica, in
+// thousandths of the font size.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+// textWidth measures s in points. Characters outside ASCII are taken as a digit's width, and
+// bold text as 5% wider, which is close enough for laying out tables.
+func textWidth(s string, size float64, bold bool) float64 {
+	units := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			units += helveticaWidths[r-32]
+		} else {
+			units += 556
+		}
+	}
+	w := float64(units) * size / 1000
+	if bold {
+		w *= 1.05
+	}
+	return w
+}
+
+// fit shortens s with an ellipsis until it is at most width points wide.
+func fit(s string, size, width float64, bold bool) string {
+	if textWidth(s, size, bold) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ") + "..."
+}
+
+// wrap breaks s into at most maxLines lines of at most width points, breaking between words
+// and shortening the last line if the text doesn't fit.
+func wrap(s string, size, width float64, bold bool, maxLines int) []string {
+	words := strings.Fields(s)
+	var lines []string
+	var line string
+	for i, word := range words {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if line == "" || textWidth(next, size, bold) <= width {
+			line = next
+			continue
+		}
+		if len(lines) == maxLines-1 {
+			line = strings.Join(append([]string{line}, words[i:]...), " ")
+			break
+		}
+		lines = append(lines, fit(line, size, width, bold))
+		line = word
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, fit(line, size, width, bold))
+	}
+	return lines
+}
+
+// winAnsi maps the characters of Windows-1252 that differ from Latin-1 to their codes.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// pdfString encodes s as a PDF literal string in WinAnsiEncoding, the encoding of the
+// standard fonts; characters it lacks are shown as question marks.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		var c byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			c = byte(r)
+		case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+			c = byte(r)
+		case r == '\t' || r == '\n' || r == '\r':
+			c = ' '
+		default:
+			var ok bool
+			if c, ok = winAnsi[r]; !ok {
+				c = '?'
+			}
+		}
+		if c < 32 || c > 126 {
+			fmt.Fprintf(&b, "\\%03o", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
@@ -0,0 +1,144 @@
+// Package reportio writes a finished session's results as downloads for teachers: a
+// gradebook spreadsheet (CSV or XLSX) with one row per player and, for each question, a
+// correct column (1 or 0, blank when unanswered) and a points column; and a printable PDF
+// summary of the session report with its charts drawn in the document.
+//
+// Every format is written straight to an io.Writer as it is generated. Gradebooks are
+// written a row at a time as models.Gradebook.EachRow reads the session's answers, so a
+// download of a session with thousands of players starts right away and neither the grades
+// nor the file are held in memory whole. The PDF is written from a session report, which
+// keeps per-question and per-player totals rather than answers.
+package reportio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"kahootclone/internal/models"
+)
+
+// Formats accepted by NewExport.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// codec writes one format from either a gradebook or a report; exactly one is set.
+type codec struct {
+	name        string
+	gradebook   func(w io.Writer, g *models.Gradebook) error
+	summary     func(w io.Writer, r *models.SessionReport) error
+	contentType string
+	extension   string
+}
+
+var codecs = []codec{
+	{name: FormatCSV, gradebook: writeGradebookCSV, contentType: "text/csv; charset=utf-8", extension: "csv"},
+	{name: FormatXLSX, gradebook: writeGradebookXLSX, contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", extension: "xlsx"},
+	{name: FormatPDF, summary: writeSummaryPDF, contentType: "application/pdf", extension: "pdf"},
+}
+
+// Formats lists the formats NewExport accepts.
+func Formats() []string {
+	out := make([]string, len(codecs))
+	for i, c := range codecs {
+		out[i] = c.name
+	}
+	return out
+}
+
+// ErrUnknownFormat is returned by NewExport for a format it can't write.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Export is a session download in one format. Callers check NeedsGradebook to decide whether
+// to load the session's gradebook or its report, then stream it with the matching method.
+type Export struct {
+	ContentType string
+	codec       codec
+}
+
+// NewExport looks up an export format by name.
+func NewExport(format string) (*Export, error) {
+	for _, c := range codecs {
+		if c.name == strings.ToLower(format) {
+			return &Export{ContentType: c.contentType, codec: c}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+// NeedsGradebook reports whether the format is written from a gradebook by WriteGradebook;
+// otherwise it is written from a session report by WriteReport.
+func (e *Export) NeedsGradebook() bool {
+	return e.codec.gradebook != nil
+}
+
+// FileName names the download after the quiz title.
+func (e *Export) FileName(quizTitle string) string {
+	suffix := "-results"
+	if e.codec.summary != nil {
+		suffix = "-report"
+	}
+	return fileName(quizTitle) + suffix + "." + e.codec.extension
+}
+
+// WriteGradebook streams a gradebook to w.
+func (e *Export) WriteGradebook(w io.Writer, g *models.Gradebook) error {
+	if e.codec.gradebook == nil {
+		return fmt.Errorf("%s exports are written from a session report", e.codec.name)
+	}
+	return e.codec.gradebook(w, g)
+}
+
+// WriteReport streams a session report to w.
+func (e *Export) WriteReport(w io.Writer, r *models.SessionReport) error {
+	if e.codec.summary == nil {
+		return fmt.Errorf("%s exports are written from a gradebook", e.codec.name)
+	}
+	return e.codec.summary(w, r)
+}
+
+// fileName turns a quiz title into a safe download file name.
+func fileName(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	if name := strings.TrimSuffix(b.String(), "-"); name != "" {
+		return name
+	}
+	return "session"
+}
+
+// gradebookHeader returns the column names of a gradebook sheet.
+func gradebookHeader(g *models.Gradebook) []string {
+	header := []string{"Rank", "Nickname", "User ID", "Score", "Correct answers"}
+	for i := range g.Questions {
+		header = append(header, fmt.Sprintf("Q%d correct", i+1), fmt.Sprintf("Q%d points", i+1))
+	}
+	return header
+}
+
+// correctCount counts the correct cells of a gradebook row.
+func correctCount(row []models.GradeCell) int {
+	n := 0
+	for _, c := range row {
+		if c.Correct {
+			n++
+		}
+	}
+	return n
+}
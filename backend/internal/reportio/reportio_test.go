@@ -0,0 +1,194 @@
+package reportio
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kahootclone/internal/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// testGradebook has a ranked player, a player whose nickname looks like a formula, an
+// unranked player and a ranked player who never answered.
+func testGradebook() *models.Gradebook {
+	rows := []models.GradebookRow{
+		{
+			Player: models.PlayerScore{UserID: "u-1", Nickname: "Ada", Score: 1850, Rank: 1},
+			Cells:  []models.GradeCell{{Answered: true, Correct: true, Points: 950}, {Answered: true, Correct: true, Points: 900}},
+		},
+		{
+			Player: models.PlayerScore{UserID: "u-2", Nickname: "=SUM(A1:A9)", Score: 800, Rank: 2},
+			Cells:  []models.GradeCell{{Answered: true, Correct: false}, {Answered: true, Correct: true, Points: 800}},
+		},
+		{
+			Player: models.PlayerScore{UserID: "u-3"},
+			Cells:  []models.GradeCell{{Answered: true, Correct: true, Points: 700}, {}},
+		},
+		{
+			Player: models.PlayerScore{UserID: "u-4", Nickname: "Quiet, \"Q\"", Rank: 3},
+			Cells:  []models.GradeCell{{}, {}},
+		},
+	}
+	return &models.Gradebook{
+		SessionID: "s-1",
+		QuizTitle: "World Capitals",
+		Questions: []models.GradebookQuestion{
+			{QuestionID: "q1", Text: "Capital of France?", Points: 1000},
+			{QuestionID: "q2", Text: "Capital of Italy?", Points: 1000},
+		},
+		EachRow: func(fn func(models.GradebookRow) error) error {
+			for _, row := range rows {
+				if err := fn(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func TestGradebookCSVGolden(t *testing.T) {
+	export, err := NewExport(FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := export.WriteGradebook(&buf, testGradebook()); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "gradebook.csv")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("CSV differs from %s (run with -update to accept):\ngot:\n%s\nwant:\n%s", golden, buf.Bytes(), want)
+	}
+}
+
+// xlsxSheet is the part of a worksheet the test reads back.
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestGradebookXLSXReadsBack(t *testing.T) {
+	export, err := NewExport(FormatXLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := export.WriteGradebook(&buf, testGradebook()); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	parts := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		f, ok := parts[name]
+		if !ok {
+			t.Fatalf("missing part %s", name)
+		}
+		// Every part must be well-formed XML
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := xml.NewDecoder(rc)
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		rc.Close()
+	}
+
+	rc, err := parts["xl/worksheets/sheet1.xml"].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	var sheet xlsxSheet
+	if err := xml.NewDecoder(rc).Decode(&sheet); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, row := range sheet.Rows {
+		for _, c := range row.Cells {
+			if c.Type == "inlineStr" {
+				got[c.Ref] = c.Inline
+			} else {
+				got[c.Ref] = c.Value
+			}
+		}
+	}
+	want := map[string]string{
+		"A1": "Rank", "B1": "Nickname", "E1": "Correct answers", "F1": "Q1 correct", "I1": "Q2 points",
+		"A2": "1", "B2": "Ada", "C2": "u-1", "D2": "1850", "E2": "2", "F2": "1", "G2": "950", "H2": "1", "I2": "900",
+		// Inline strings are never evaluated, so the formula-like nickname is kept as typed
+		"B3": "=SUM(A1:A9)", "E3": "1", "F3": "0", "G3": "0",
+		"C4": "u-3", "D4": "0", "F4": "1", "G4": "700",
+		"A5": "3", "B5": "Quiet, \"Q\"", "E5": "0",
+	}
+	for ref, v := range want {
+		if got[ref] != v {
+			t.Errorf("cell %s: got %q, want %q", ref, got[ref], v)
+		}
+	}
+	// Blank cells are left out: no rank for the unranked player, nothing for unanswered questions
+	for _, ref := range []string{"A4", "H4", "I4", "F5", "G5", "H5", "I5"} {
+		if v, ok := got[ref]; ok {
+			t.Errorf("cell %s: got %q, want it blank", ref, v)
+		}
+	}
+	if len(sheet.Rows) != 5 {
+		t.Errorf("got %d rows, want a header and 4 players", len(sheet.Rows))
+	}
+}
+
+func TestGradebookStopsOnWriteError(t *testing.T) {
+	errFull := errors.New("disk full")
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		export, err := NewExport(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := export.WriteGradebook(failingWriter{errFull}, testGradebook()); !errors.Is(err, errFull) {
+			t.Errorf("%s: got %v, want the write error", format, err)
+		}
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write(p []byte) (int, error) { return 0, w.err }
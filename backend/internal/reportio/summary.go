@@ -0,0 +1,272 @@
+package reportio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"kahootclone/internal/models"
+)
+
+// writeSummaryPDF writes a printable summary of a session report: headline figures, a chart
+// of accuracy by question, how each question's answers split between its options, and the
+// table of players. Pages are written as they fill up.
+func writeSummaryPDF(w io.Writer, r *models.SessionReport) error {
+	l := &summaryLayout{pdf: newPDFWriter(w), title: r.QuizTitle}
+	l.newPage()
+
+	title := r.QuizTitle
+	if title == "" {
+		title = "Untitled quiz"
+	}
+	for _, line := range wrap(title, 20, contentWidth, true, 2) {
+		l.y += 20
+		l.page.text(pageMargin, l.y, 20, true, colorText, line)
+		l.y += 4
+	}
+	subtitle := "Session report"
+	if r.EndedAt != nil {
+		subtitle += " · ended " + r.EndedAt.UTC().Format("2 Jan 2006 15:04 UTC")
+	}
+	l.y += 16
+	l.page.text(pageMargin, l.y, 10, false, colorMuted, subtitle)
+	l.y += 16
+
+	l.stats([]stat{
+		{"Players", strconv.Itoa(r.PlayerCount)},
+		{"Questions played", fmt.Sprintf("%d of %d", r.QuestionsPlayed, r.QuestionCount)},
+		{"Completion", percent(r.Completion)},
+		{"Accuracy", percent(r.Accuracy)},
+		{"Average score", strconv.FormatFloat(r.AverageScore, 'f', -1, 64)},
+	})
+
+	if len(r.Questions) > 0 {
+		l.heading("Accuracy by question", 150)
+		l.accuracyChart(r.Questions)
+
+		l.heading("Questions", 80)
+		for _, q := range r.Questions {
+			l.question(q)
+		}
+	}
+
+	l.heading("Players", 60)
+	l.playerHeader()
+	for i, p := range r.Players {
+		if l.ensure(14) {
+			l.playerHeader()
+		}
+		l.player(i, p)
+	}
+
+	l.finishPage()
+	return l.pdf.close(r.QuizTitle)
+}
+
+// summaryLayout places blocks down the pages of a summary, starting a new page when the next
+// block doesn't fit. y is the top of the free space on the current page.
+type summaryLayout struct {
+	pdf     *pdfWriter
+	page    *pdfPage
+	pageNum int
+	y       float64
+	title   string
+}
+
+// pageBottom is the lowest y a block may reach, leaving room for the footer.
+const pageBottom = pageHeight - pageMargin - 10
+
+func (l *summaryLayout) newPage() {
+	l.pageNum++
+	l.page = &pdfPage{}
+	l.y = pageMargin
+}
+
+// finishPage adds the footer and writes the current page.
+func (l *summaryLayout) finishPage() {
+	footer := fmt.Sprintf("Page %d", l.pageNum)
+	l.page.text(pageMargin, pageHeight-30, 8, false, colorMuted, fit(l.title, 8, contentWidth-60, false))
+	l.page.textRight(pageWidth-pageMargin, pageHeight-30, 8, false, colorMuted, footer)
+	l.pdf.addPage(l.page.buf.Bytes())
+}
+
+// ensure starts a new page unless h points fit on this one, and reports whether it did.
+func (l *summaryLayout) ensure(h float64) bool {
+	if l.y+h <= pageBottom {
+		return false
+	}
+	l.finishPage()
+	l.newPage()
+	return true
+}
+
+// heading starts a section, keeping it on the same page as the next follow points.
+func (l *summaryLayout) heading(text string, follow float64) {
+	l.y += 18
+	l.ensure(24 + follow)
+	l.y += 14
+	l.page.text(pageMargin, l.y, 13, true, colorText, text)
+	l.y += 10
+}
+
+// stat is one headline figure.
+type stat struct {
+	label, value string
+}
+
+// stats draws a row of headline figures.
+func (l *summaryLayout) stats(stats []stat) {
+	const h = 46
+	l.ensure(h)
+	w := contentWidth / float64(len(stats))
+	for i, s := range stats {
+		x := pageMargin + float64(i)*w
+		l.page.rect(x+2, l.y, w-4, h, colorShade)
+		l.page.text(x+10, l.y+16, 8, false, colorMuted, fit(s.label, 8, w-20, false))
+		l.page.text(x+10, l.y+36, 15, true, colorText, fit(s.value, 15, w-20, true))
+	}
+	l.y += h
+}
+
+// accuracyChart draws a bar per question showing the share of correct answers. Questions that
+// weren't played have no bar.
+func (l *summaryLayout) accuracyChart(questions []models.QuestionReport) {
+	const (
+		h      = 110.0
+		axis   = 30.0
+		labels = 14.0
+	)
+	l.y += 8
+	top := l.y
+	x0 := pageMargin + axis
+	chartW := contentWidth - axis
+
+	for _, level := range []float64{0, 0.5, 1} {
+		y := top + h*(1-level)
+		l.page.line(x0, y, pageWidth-pageMargin, y, colorGrid)
+		l.page.textRight(x0-6, y+3, 8, false, colorMuted, percent(level))
+	}
+
+	slot := chartW / float64(len(questions))
+	barW := math.Max(slot*0.7, 0.5)
+	step := int(math.Ceil(float64(len(questions)) / 20))
+	for i, q := range questions {
+		x := x0 + float64(i)*slot + (slot-barW)/2
+		if q.Played && q.AnswerCount > 0 {
+			bh := math.Max(h*q.Accuracy, 0.5)
+			l.page.rect(x, top+h-bh, barW, bh, accuracyColor(q.Accuracy))
+		}
+		if i%step == 0 {
+			label := strconv.Itoa(i + 1)
+			l.page.text(x+barW/2-textWidth(label, 7, false)/2, top+h+labels-4, 7, false, colorMuted, label)
+		}
+	}
+	l.y = top + h + labels
+}
+
+// question draws one question's figures and a bar per option showing its share of the
+// answers. The correct option is green and the most chosen wrong one red.
+func (l *summaryLayout) question(q models.QuestionReport) {
+	title := wrap(fmt.Sprintf("Q%d. %s", q.QuestionIndex+1, q.Text), 10, contentWidth, true, 2)
+	h := 14*float64(len(title)) + 14 + 14*float64(len(q.Options)) + 12
+	l.ensure(h)
+
+	for _, line := range title {
+		l.y += 12
+		l.page.text(pageMargin, l.y, 10, true, colorText, line)
+		l.y += 2
+	}
+	figures := "Not played"
+	if q.Played {
+		figures = fmt.Sprintf("%s correct · %d answered · %d unanswered · %.1fs on average",
+			percent(q.Accuracy), q.AnswerCount, q.UnansweredCount, float64(q.AverageTimeMs)/1000)
+	}
+	l.y += 12
+	l.page.text(pageMargin, l.y, 8, false, colorMuted, figures)
+	l.y += 2
+
+	const (
+		labelW = 220.0
+		barW   = 190.0
+	)
+	for _, o := range q.Options {
+		l.y += 14
+		l.page.text(pageMargin+8, l.y, 9, o.IsCorrect, colorText, fit(o.Text, 9, labelW-16, o.IsCorrect))
+		barX := pageMargin + labelW
+		l.page.rect(barX, l.y-8, barW, 9, colorShade)
+		c := colorNeutral
+		switch {
+		case o.IsCorrect:
+			c = colorGood
+		case o.OptionID == q.MostConfusedOptionID:
+			c = colorPoor
+		}
+		if o.Count > 0 {
+			l.page.rect(barX, l.y-8, math.Max(barW*o.Share, 1), 9, c)
+		}
+		l.page.textRight(pageWidth-pageMargin, l.y, 9, false, colorText, fmt.Sprintf("%d (%s)", o.Count, percent(o.Share)))
+	}
+	l.y += 12
+}
+
+// Right edges of the player table's columns, relative to the left margin, except the player
+// column, which is its left edge.
+const (
+	colRank     = 28.0
+	colPlayer   = 42.0
+	colScore    = 300.0
+	colCorrect  = 360.0
+	colAccuracy = 425.0
+	colTime     = contentWidth
+)
+
+func (l *summaryLayout) playerHeader() {
+	l.y += 14
+	x := pageMargin
+	l.page.textRight(x+colRank, l.y, 9, true, colorText, "Rank")
+	l.page.text(x+colPlayer, l.y, 9, true, colorText, "Player")
+	l.page.textRight(x+colScore, l.y, 9, true, colorText, "Score")
+	l.page.textRight(x+colCorrect, l.y, 9, true, colorText, "Correct")
+	l.page.textRight(x+colAccuracy, l.y, 9, true, colorText, "Accuracy")
+	l.page.textRight(x+colTime, l.y, 9, true, colorText, "Avg. time")
+	l.page.line(x, l.y+4, x+contentWidth, l.y+4, colorGrid)
+	l.y += 4
+}
+
+func (l *summaryLayout) player(i int, p models.PlayerReport) {
+	x := pageMargin
+	if i%2 == 1 {
+		l.page.rect(x, l.y, contentWidth, 14, colorShade)
+	}
+	l.y += 14
+	name := p.Nickname
+	if name == "" {
+		name = p.UserID
+	}
+	if p.Rank > 0 {
+		l.page.textRight(x+colRank, l.y-4, 9, false, colorText, strconv.FormatInt(p.Rank, 10))
+	}
+	l.page.text(x+colPlayer, l.y-4, 9, false, colorText, fit(name, 9, colScore-colPlayer-60, false))
+	l.page.textRight(x+colScore, l.y-4, 9, false, colorText, strconv.FormatFloat(p.Score, 'f', -1, 64))
+	l.page.textRight(x+colCorrect, l.y-4, 9, false, colorText, strconv.Itoa(p.CorrectCount))
+	l.page.textRight(x+colAccuracy, l.y-4, 9, false, colorText, percent(p.Accuracy))
+	l.page.textRight(x+colTime, l.y-4, 9, false, colorText, fmt.Sprintf("%.1fs", float64(p.AverageTimeMs)/1000))
+}
+
+// accuracyColor grades an accuracy as good, fair or poor.
+func accuracyColor(accuracy float64) color {
+	switch {
+	case accuracy >= 0.7:
+		return colorGood
+	case accuracy >= 0.4:
+		return colorFair
+	default:
+		return colorPoor
+	}
+}
+
+// percent formats a fraction as a whole percentage.
+func percent(f float64) string {
+	return strconv.Itoa(int(math.Round(f*100))) + "%"
+}
@@ -0,0 +1,167 @@
+package reportio
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"kahootclone/internal/models"
+)
+
+// The fixed parts of a one-sheet workbook. Style 1 is the bold header row.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Gradebook" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// writeGradebookXLSX writes a gradebook as a one-sheet XLSX workbook. The sheet is written
+// row by row with inline strings, so no shared string table has to be built first.
+func writeGradebookXLSX(w io.Writer, g *models.Gradebook) error {
+	zw := zip.NewWriter(w)
+	for _, part := range []struct{ name, data string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.data); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	sw := &sheetWriter{w: bufio.NewWriter(f)}
+	sw.w.WriteString(xlsxSheetStart)
+
+	sw.startRow()
+	for _, name := range gradebookHeader(g) {
+		sw.text(name, 1)
+	}
+	sw.endRow()
+
+	err = g.EachRow(func(row models.GradebookRow) error {
+		p := row.Player
+		sw.startRow()
+		if p.Rank > 0 {
+			sw.number(strconv.FormatInt(p.Rank, 10))
+		} else {
+			sw.skip()
+		}
+		sw.text(p.Nickname, 0)
+		sw.text(p.UserID, 0)
+		sw.number(strconv.FormatFloat(p.Score, 'f', -1, 64))
+		sw.number(strconv.Itoa(correctCount(row.Cells)))
+		for _, c := range row.Cells {
+			if !c.Answered {
+				sw.skip()
+				sw.skip()
+				continue
+			}
+			if c.Correct {
+				sw.number("1")
+			} else {
+				sw.number("0")
+			}
+			sw.number(strconv.Itoa(int(c.Points)))
+		}
+		sw.endRow()
+		return sw.err()
+	})
+	if err != nil {
+		return err
+	}
+
+	sw.w.WriteString(xlsxSheetEnd)
+	if err := sw.w.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// sheetWriter writes the rows of a worksheet, giving each cell its reference. Write errors
+// are kept by the bufio.Writer and reported by its Flush.
+type sheetWriter struct {
+	w   *bufio.Writer
+	row int
+	col int
+}
+
+// err returns the first write error, so a failed download stops reading rows.
+func (s *sheetWriter) err() error {
+	_, err := s.w.Write(nil) // reports the bufio.Writer's sticky error without writing
+	return err
+}
+
+func (s *sheetWriter) startRow() {
+	s.row++
+	s.col = 0
+	s.w.WriteString(`<row r="`)
+	s.w.WriteString(strconv.Itoa(s.row))
+	s.w.WriteString(`">`)
+}
+
+func (s *sheetWriter) endRow() {
+	s.w.WriteString(`</row>`)
+}
+
+// skip leaves the next cell blank.
+func (s *sheetWriter) skip() {
+	s.col++
+}
+
+// ref returns the reference of the next cell and moves past it.
+func (s *sheetWriter) ref() string {
+	s.col++
+	return columnName(s.col) + strconv.Itoa(s.row)
+}
+
+func (s *sheetWriter) number(v string) {
+	s.w.WriteString(`<c r="` + s.ref() + `"><v>` + v + `</v></c>`)
+}
+
+// text writes a string cell in the given style; empty strings leave the cell blank.
+func (s *sheetWriter) text(v string, style int) {
+	if v == "" {
+		s.skip()
+		return
+	}
+	s.w.WriteString(`<c r="` + s.ref() + `" t="inlineStr"`)
+	if style != 0 {
+		s.w.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	s.w.WriteString(`><is><t xml:space="preserve">`)
+	xml.EscapeText(s.w, []byte(v))
+	s.w.WriteString(`</t></is></c>`)
+}
+
+// columnName returns the letters of a 1-based column number: A, B, ..., Z, AA, AB, ...
+func columnName(n int) string {
+	var b []byte
+	for n > 0 {
+		n--
+		b = append([]byte{byte('A' + n%26)}, b...)
+		n /= 26
+	}
+	return string(b)
+}
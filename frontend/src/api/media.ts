@@ -0,0 +1,12 @@
+import apiClient from './client';
+import { UploadedMedia, ApiResponse } from '../types';
+
+// uploadMedia stores an image or audio clip to attach to a question or option. The server
+// identifies the file by its content, re-encodes images and returns preview URLs.
+export async function uploadMedia(file: File, alt?: string): Promise<UploadedMedia> {
+  const response = await apiClient.post<ApiResponse<UploadedMedia>>('/media', file, {
+    params: alt ? { alt } : undefined,
+    headers: { 'Content-Type': 'application/octet-stream' },
+  });
+  return response.data.data;
+}
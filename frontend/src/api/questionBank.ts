@@ -0,0 +1,31 @@
+import apiClient from './client';
+import { BankQuestion, BankQuestionInput, BankQuestionPage, ApiResponse } from '../types';
+
+export async function createBankQuestion(data: BankQuestionInput): Promise<BankQuestion> {
+  const response = await apiClient.post<ApiResponse<BankQuestion>>('/question-bank', data);
+  return response.data.data;
+}
+
+export async function getBankQuestion(questionId: string): Promise<BankQuestion> {
+  const response = await apiClient.get<ApiResponse<BankQuestion>>(`/question-bank/${questionId}`);
+  return response.data.data;
+}
+
+export async function updateBankQuestion(questionId: string, data: BankQuestionInput): Promise<BankQuestion> {
+  const response = await apiClient.put<ApiResponse<BankQuestion>>(`/question-bank/${questionId}`, data);
+  return response.data.data;
+}
+
+export async function deleteBankQuestion(questionId: string): Promise<void> {
+  await apiClient.delete(`/question-bank/${questionId}`);
+}
+
+export async function searchBankQuestions(params: {
+  q?: string;
+  tag?: string;
+  limit?: number;
+  cursor?: string;
+} = {}): Promise<BankQuestionPage> {
+  const response = await apiClient.get<ApiResponse<BankQuestionPage>>('/question-bank', { params });
+  return response.data.data;
+}
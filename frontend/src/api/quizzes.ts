@@ -1,10 +1,12 @@
 import apiClient from './client';
-import { Quiz, Question, ApiResponse } from '../types';
+import { Quiz, QuizPage, Question, QuestionRule, ApiResponse } from '../types';
 
 export async function createQuiz(data: {
   title: string;
   description: string;
+  tags?: string[];
   questions: Question[];
+  rules?: QuestionRule[];
 }): Promise<Quiz> {
   const response = await apiClient.post<ApiResponse<Quiz>>('/quizzes', data);
   return response.data.data;
@@ -15,7 +17,52 @@ export async function getQuiz(quizId: string): Promise<Quiz> {
   return response.data.data;
 }
 
-export async function listMyQuizzes(): Promise<Quiz[]> {
-  const response = await apiClient.get<ApiResponse<Quiz[]>>('/quizzes');
+export async function updateQuiz(quizId: string, data: {
+  title: string;
+  description: string;
+  tags?: string[];
+  questions: Question[];
+  rules?: QuestionRule[];
+  version: number;
+}): Promise<Quiz> {
+  const response = await apiClient.put<ApiResponse<Quiz>>(`/quizzes/${quizId}`, data);
+  return response.data.data;
+}
+
+export async function deleteQuiz(quizId: string): Promise<void> {
+  await apiClient.delete(`/quizzes/${quizId}`);
+}
+
+export async function duplicateQuiz(quizId: string): Promise<Quiz> {
+  const response = await apiClient.post<ApiResponse<Quiz>>(`/quizzes/${quizId}/duplicate`);
+  return response.data.data;
+}
+
+export async function importQuiz(file: File, params: {
+  format: 'csv' | 'xlsx' | 'gift' | 'aiken' | 'moodlexml' | 'qti';
+  title?: string;
+}): Promise<Quiz> {
+  const response = await apiClient.post<ApiResponse<Quiz>>('/quizzes/import', file, {
+    params,
+    headers: { 'Content-Type': 'application/octet-stream' },
+  });
+  return response.data.data;
+}
+
+export async function exportQuiz(quizId: string, format: 'gift' | 'aiken' | 'moodlexml' | 'qti'): Promise<Blob> {
+  const response = await apiClient.get<Blob>(`/quizzes/${quizId}/export`, {
+    params: { format },
+    responseType: 'blob',
+  });
+  return response.data;
+}
+
+export async function listMyQuizzes(params: {
+  title?: string;
+  tag?: string;
+  limit?: number;
+  cursor?: string;
+} = {}): Promise<QuizPage> {
+  const response = await apiClient.get<ApiResponse<QuizPage>>('/quizzes', { params });
   return response.data.data;
 }
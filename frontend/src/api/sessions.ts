@@ -1,8 +1,17 @@
 import apiClient from './client';
-import { Session, PlayerScore, ApiResponse } from '../types';
+import {
+  Session,
+  PlayerScore,
+  SessionReport,
+  ReportDownload,
+  PlayedSessionPage,
+  SessionReview,
+  ApiResponse,
+} from '../types';
 
-export async function createSession(quizId: string): Promise<Session> {
-  const response = await apiClient.post<ApiResponse<Session>>('/sessions', { quizId });
+// Passing an earlier session's quizSeed replays the questions its quiz's rules drew.
+export async function createSession(quizId: string, seed?: number): Promise<Session> {
+  const response = await apiClient.post<ApiResponse<Session>>('/sessions', { quizId, seed });
   return response.data.data;
 }
 
@@ -39,3 +48,40 @@ export async function getLeaderboard(sessionId: string): Promise<{
   }>>(`/sessions/${sessionId}/leaderboard`);
   return response.data.data;
 }
+
+// getSessionReport returns the post-game report of a session; only its host may read it.
+export async function getSessionReport(sessionId: string): Promise<SessionReport> {
+  const response = await apiClient.get<ApiResponse<SessionReport>>(`/sessions/${sessionId}/report`);
+  return response.data.data;
+}
+
+// exportSessionReport downloads a finished session's gradebook (csv, xlsx) or PDF summary.
+// Exports too large for the API response come back as a signed link, which is fetched
+// without the API's credentials.
+export async function exportSessionReport(sessionId: string, format: 'csv' | 'xlsx' | 'pdf'): Promise<Blob> {
+  const response = await apiClient.get<Blob>(`/sessions/${sessionId}/report/export`, {
+    params: { format },
+    responseType: 'blob',
+  });
+  if (!String(response.headers['content-type'] ?? '').startsWith('application/json')) {
+    return response.data;
+  }
+  const body: ApiResponse<ReportDownload> = JSON.parse(await response.data.text());
+  const file = await fetch(body.data.url);
+  if (!file.ok) {
+    throw new Error(`Failed to download export: ${file.status}`);
+  }
+  return file.blob();
+}
+
+// listMySessions lists the sessions the signed-in player answered in, most recent first.
+export async function listMySessions(params: { limit?: number; cursor?: string } = {}): Promise<PlayedSessionPage> {
+  const response = await apiClient.get<ApiResponse<PlayedSessionPage>>('/me/sessions', { params });
+  return response.data.data;
+}
+
+// getMySession returns the signed-in player's answers in a finished session beside the correct ones.
+export async function getMySession(sessionId: string): Promise<SessionReview> {
+  const response = await apiClient.get<ApiResponse<SessionReview>>(`/me/sessions/${sessionId}`);
+  return response.data.data;
+}
@@ -1,7 +1,10 @@
 import { motion } from 'framer-motion';
+import { MediaPayload } from '../types';
+import QuestionMedia from './QuestionMedia';
 
 interface AnswerButtonProps {
   label: string;
+  media?: MediaPayload; // images are shown in the button; other media is up to the caller
   shape: string;
   color: 'red' | 'blue' | 'yellow' | 'green';
   onClick: () => void;
@@ -9,7 +12,7 @@ interface AnswerButtonProps {
   index: number;
 }
 
-export default function AnswerButton({ label, shape, color, onClick, disabled, index }: AnswerButtonProps) {
+export default function AnswerButton({ label, media, shape, color, onClick, disabled, index }: AnswerButtonProps) {
   return (
     <motion.button
       initial={{ opacity: 0, y: 20 }}
@@ -22,6 +25,11 @@ export default function AnswerButton({ label, shape, color, onClick, disabled, i
       className={`answer-btn ${color}`}
     >
       <span className="mr-3 opacity-60">{shape}</span>
+      {media?.type === 'image' && (
+        <span className="mr-3 inline-block align-middle">
+          <QuestionMedia media={media} compact />
+        </span>
+      )}
       {label}
     </motion.button>
   );
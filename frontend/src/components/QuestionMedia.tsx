@@ -0,0 +1,46 @@
+import { MediaPayload } from '../types';
+
+interface QuestionMediaProps {
+  media: MediaPayload;
+  compact?: boolean; // thumbnails only, for answer options
+}
+
+// mediaFragment limits playback to the clip's start and end, as a W3C media fragment.
+function mediaFragment(media: MediaPayload): string {
+  if (!media.startSeconds && !media.endSeconds) return '';
+  return `#t=${media.startSeconds ?? 0}${media.endSeconds ? `,${media.endSeconds}` : ''}`;
+}
+
+export default function QuestionMedia({ media, compact = false }: QuestionMediaProps) {
+  switch (media.type) {
+    case 'image':
+      return (
+        <img
+          src={compact ? media.thumbnailUrl ?? media.url : media.url}
+          alt={media.alt ?? ''}
+          className={compact ? 'h-12 w-12 rounded object-cover' : 'max-h-[40vh] max-w-full rounded-xl mx-auto'}
+        />
+      );
+    case 'audio':
+      return (
+        <audio
+          src={media.url + mediaFragment(media)}
+          controls
+          autoPlay={!compact}
+          aria-label={media.alt}
+          className={compact ? 'h-8 w-40' : 'w-full max-w-md mx-auto'}
+        />
+      );
+    case 'video':
+      return (
+        <video
+          src={media.url + mediaFragment(media)}
+          controls
+          autoPlay
+          playsInline
+          aria-label={media.alt}
+          className="max-h-[40vh] max-w-full rounded-xl mx-auto"
+        />
+      );
+  }
+}
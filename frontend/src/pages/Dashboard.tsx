@@ -19,7 +19,7 @@ export default function Dashboard() {
   const loadQuizzes = async () => {
     try {
       const data = await listMyQuizzes();
-      setQuizzes(data || []);
+      setQuizzes(data.quizzes);
     } catch (err) {
       console.error('Failed to load quizzes:', err);
     } finally {
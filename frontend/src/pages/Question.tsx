@@ -6,6 +6,7 @@ import { useGameSocket } from '../websocket/useGameSocket';
 import { WS_ACTIONS } from '../websocket/eventTypes';
 import CountdownTimer from '../components/CountdownTimer';
 import AnswerButton from '../components/AnswerButton';
+import QuestionMedia from '../components/QuestionMedia';
 
 const ANSWER_COLORS: Array<'red' | 'blue' | 'yellow' | 'green'> = ['red', 'blue', 'yellow', 'green'];
 const ANSWER_SHAPES = ['▲', '◆', '●', '■'];
@@ -103,6 +104,12 @@ export default function Question() {
           {currentQuestion.text}
         </motion.h2>
 
+        {currentQuestion.media && (
+          <div className="mb-8 w-full max-w-2xl">
+            <QuestionMedia media={currentQuestion.media} />
+          </div>
+        )}
+
         {hasAnswered ? (
           <motion.div
             initial={{ opacity: 0, scale: 0.9 }}
@@ -115,15 +122,18 @@ export default function Question() {
         ) : (
           <div className="grid grid-cols-1 sm:grid-cols-2 gap-3 w-full max-w-2xl">
             {currentQuestion.options.map((option, i) => (
-              <AnswerButton
-                key={option.id}
-                label={option.text}
-                shape={ANSWER_SHAPES[i]}
-                color={ANSWER_COLORS[i]}
-                onClick={() => handleAnswer(option.id)}
-                disabled={hasAnswered}
-                index={i}
-              />
+              <div key={option.id} className="flex flex-col gap-1">
+                <AnswerButton
+                  label={option.text}
+                  media={option.media}
+                  shape={ANSWER_SHAPES[i]}
+                  color={ANSWER_COLORS[i]}
+                  onClick={() => handleAnswer(option.id)}
+                  disabled={hasAnswered}
+                  index={i}
+                />
+                {option.media?.type === 'audio' && <QuestionMedia media={option.media} compact />}
+              </div>
             ))}
           </div>
         )}
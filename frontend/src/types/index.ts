@@ -2,9 +2,39 @@
 
 // --- Quiz Types ---
 
+export type MediaType = 'image' | 'audio' | 'video';
+
+// Images and audio clips are uploaded (key); videos are linked (url).
+export interface Media {
+  type: MediaType;
+  key?: string;
+  contentType?: string;
+  url?: string;
+  alt?: string;
+  startSeconds?: number;
+  endSeconds?: number;
+}
+
+// Media as sent to players, with signed URLs in place of store keys.
+export interface MediaPayload {
+  type: MediaType;
+  url: string;
+  thumbnailUrl?: string;
+  contentType?: string;
+  alt?: string;
+  startSeconds?: number;
+  endSeconds?: number;
+}
+
+export interface UploadedMedia {
+  media: Media;
+  preview: MediaPayload;
+}
+
 export interface Option {
   id: string;
   text: string;
+  media?: Media;
 }
 
 export interface Question {
@@ -14,6 +44,9 @@ export interface Question {
   correctOptionId: string;
   timeLimitSeconds: number;
   points: number;
+  media?: Media;
+  // Set on questions linked to the question bank; the fields above are a copy of it
+  bankQuestionId?: string;
 }
 
 export interface Quiz {
@@ -21,11 +54,56 @@ export interface Quiz {
   hostUserId: string;
   title: string;
   description: string;
+  tags?: string[];
   questions: Question[];
+  // Draw more questions from the question bank each time a session starts
+  rules?: QuestionRule[];
+  createdAt: string;
+  updatedAt: string;
+  version: number;
+}
+
+// Draws count random bank questions with the tag (any, if omitted) and a difficulty in range
+export interface QuestionRule {
+  tag?: string;
+  minDifficulty?: number;
+  maxDifficulty?: number;
+  count: number;
+}
+
+export interface QuizPage {
+  quizzes: Quiz[];
+  nextCursor: string;
+}
+
+// --- Question Bank Types ---
+
+export interface BankQuestion extends Omit<Question, 'bankQuestionId'> {
+  hostUserId: string;
+  subject?: string;
+  difficulty?: number; // 1 (easiest) to 5
+  tags?: string[];
   createdAt: string;
   updatedAt: string;
 }
 
+export interface BankQuestionInput {
+  text: string;
+  options: Option[];
+  correctOptionId: string;
+  timeLimitSeconds?: number;
+  points: number;
+  media?: Media;
+  subject?: string;
+  difficulty?: number;
+  tags?: string[];
+}
+
+export interface BankQuestionPage {
+  questions: BankQuestion[];
+  nextCursor: string;
+}
+
 // --- Session Types ---
 
 export type SessionStatus = 'LOBBY' | 'ACTIVE' | 'FINISHED';
@@ -37,11 +115,113 @@ export interface Session {
   hostUserId: string;
   status: SessionStatus;
   currentQuestionIndex: number;
+  quizSeed?: number; // picks the questions the quiz's rules drew
   startedAt?: string;
   endedAt?: string;
   createdAt: string;
 }
 
+// --- Report Types ---
+
+// Rates (completion, accuracy, share) are fractions between 0 and 1.
+export interface OptionReport {
+  optionId: string;
+  text: string;
+  isCorrect: boolean;
+  count: number;
+  share: number;
+}
+
+export interface QuestionReport {
+  questionId: string;
+  questionIndex: number;
+  text: string;
+  correctOptionId: string;
+  played: boolean;
+  answerCount: number;
+  correctCount: number;
+  unansweredCount: number;
+  accuracy: number;
+  averageTimeMs: number;
+  options: OptionReport[];
+  mostConfusedOptionId?: string;
+}
+
+export interface PlayerReport {
+  userId: string;
+  nickname: string;
+  rank: number;
+  score: number;
+  answeredCount: number;
+  correctCount: number;
+  unansweredCount: number;
+  accuracy: number;
+  averageTimeMs: number;
+}
+
+export interface SessionReport {
+  sessionId: string;
+  quizId: string;
+  quizVersion?: number;
+  quizTitle: string;
+  status: SessionStatus;
+  startedAt?: string;
+  endedAt?: string;
+  playerCount: number;
+  questionCount: number;
+  questionsPlayed: number;
+  completion: number;
+  accuracy: number;
+  averageScore: number;
+  questions: QuestionReport[];
+  players: PlayerReport[];
+}
+
+// A link to a report export too large to return directly; see exportSessionReport.
+export interface ReportDownload {
+  url: string;
+  fileName: string;
+  expiresAt: string;
+}
+
+// --- Learner History ---
+
+// A session the signed-in player answered in. rank is set once the session has finished.
+export interface PlayedSession {
+  sessionId: string;
+  quizId: string;
+  quizTitle: string;
+  status: SessionStatus;
+  endedAt?: string;
+  playerCount?: number;
+  nickname?: string;
+  rank?: number;
+  score: number;
+  answeredCount: number;
+  correctCount: number;
+}
+
+export interface PlayedSessionPage {
+  sessions: PlayedSession[];
+  nextCursor: string;
+}
+
+export interface ReviewedQuestion {
+  questionId: string;
+  questionIndex: number;
+  text: string;
+  options?: { id: string; text: string }[];
+  correctOptionId: string;
+  selectedOptionId?: string; // missing if the player didn't answer
+  isCorrect: boolean;
+  pointsEarned: number;
+  timeTakenMs?: number;
+}
+
+export interface SessionReview extends PlayedSession {
+  questions: ReviewedQuestion[];
+}
+
 // --- Player Types ---
 
 export type PlayerRole = 'HOST' | 'PLAYER';
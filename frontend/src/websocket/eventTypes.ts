@@ -1,6 +1,6 @@
 // WebSocket event type definitions
 
-import { Option, PlayerScore } from '../types';
+import { MediaPayload, PlayerScore } from '../types';
 
 // --- Inbound (client → server) ---
 
@@ -48,11 +48,18 @@ export interface GameStartedPayload {
   totalQuestions: number;
 }
 
+export interface OptionPayload {
+  id: string;
+  text: string;
+  media?: MediaPayload;
+}
+
 export interface QuestionPayload {
   questionIndex: number;
   totalQuestions: number;
   text: string;
-  options: Option[];
+  media?: MediaPayload;
+  options: OptionPayload[];
   timeLimitMs: number;
   points: number;
 }
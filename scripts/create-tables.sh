@@ -13,8 +13,26 @@ ENDPOINT="--endpoint-url http://localhost:8000"
 echo "Creating kahootclone-quizzes table..."
 aws dynamodb create-table \
   --table-name kahootclone-quizzes \
-  --attribute-definitions AttributeName=quizId,AttributeType=S \
+  --attribute-definitions \
+    AttributeName=quizId,AttributeType=S \
+    AttributeName=hostUserId,AttributeType=S \
+    AttributeName=updatedAt,AttributeType=S \
   --key-schema AttributeName=quizId,KeyType=HASH \
+  --global-secondary-indexes '[{
+    "IndexName":"hostUserId-index",
+    "KeySchema":[{"AttributeName":"hostUserId","KeyType":"HASH"},{"AttributeName":"updatedAt","KeyType":"RANGE"}],
+    "Projection":{"ProjectionType":"ALL"}
+  }]' \
+  --billing-mode PAY_PER_REQUEST \
+  $ENDPOINT
+
+echo "Creating kahootclone-quiz-versions table..."
+aws dynamodb create-table \
+  --table-name kahootclone-quiz-versions \
+  --attribute-definitions \
+    AttributeName=quizId,AttributeType=S \
+    AttributeName=version,AttributeType=N \
+  --key-schema AttributeName=quizId,KeyType=HASH AttributeName=version,KeyType=RANGE \
   --billing-mode PAY_PER_REQUEST \
   $ENDPOINT
 
@@ -24,11 +42,23 @@ aws dynamodb create-table \
   --attribute-definitions \
     AttributeName=sessionId,AttributeType=S \
     AttributeName=pin,AttributeType=S \
+    AttributeName=status,AttributeType=S \
+    AttributeName=lastActivityAt,AttributeType=N \
+    AttributeName=quizId,AttributeType=S \
+    AttributeName=createdAt,AttributeType=S \
   --key-schema AttributeName=sessionId,KeyType=HASH \
   --global-secondary-indexes '[{
     "IndexName":"pin-index",
     "KeySchema":[{"AttributeName":"pin","KeyType":"HASH"}],
     "Projection":{"ProjectionType":"ALL"}
+  },{
+    "IndexName":"status-index",
+    "KeySchema":[{"AttributeName":"status","KeyType":"HASH"},{"AttributeName":"lastActivityAt","KeyType":"RANGE"}],
+    "Projection":{"ProjectionType":"ALL"}
+  },{
+    "IndexName":"quizId-createdAt-index",
+    "KeySchema":[{"AttributeName":"quizId","KeyType":"HASH"},{"AttributeName":"createdAt","KeyType":"RANGE"}],
+    "Projection":{"ProjectionType":"ALL"}
   }]' \
   --billing-mode PAY_PER_REQUEST \
   $ENDPOINT
@@ -54,10 +84,71 @@ aws dynamodb create-table \
   --attribute-definitions \
     AttributeName=sessionId,AttributeType=S \
     AttributeName=userIdQuestionId,AttributeType=S \
+    AttributeName=historyUserId,AttributeType=S \
+    AttributeName=answeredAt,AttributeType=S \
   --key-schema AttributeName=sessionId,KeyType=HASH AttributeName=userIdQuestionId,KeyType=RANGE \
+  --global-secondary-indexes '[{
+    "IndexName":"historyUserId-answeredAt-index",
+    "KeySchema":[{"AttributeName":"historyUserId","KeyType":"HASH"},{"AttributeName":"answeredAt","KeyType":"RANGE"}],
+    "Projection":{"ProjectionType":"KEYS_ONLY"}
+  }]' \
+  --billing-mode PAY_PER_REQUEST \
+  $ENDPOINT
+
+echo "Creating kahootclone-pins table..."
+aws dynamodb create-table \
+  --table-name kahootclone-pins \
+  --attribute-definitions AttributeName=pin,AttributeType=S \
+  --key-schema AttributeName=pin,KeyType=HASH \
+  --billing-mode PAY_PER_REQUEST \
+  $ENDPOINT
+
+echo "Creating kahootclone-results table..."
+aws dynamodb create-table \
+  --table-name kahootclone-results \
+  --attribute-definitions \
+    AttributeName=sessionId,AttributeType=S \
+    AttributeName=recordKey,AttributeType=S \
+    AttributeName=quizId,AttributeType=S \
+    AttributeName=hostUserId,AttributeType=S \
+    AttributeName=finishedAt,AttributeType=N \
+  --key-schema AttributeName=sessionId,KeyType=HASH AttributeName=recordKey,KeyType=RANGE \
+  --global-secondary-indexes '[{
+    "IndexName":"quizId-finishedAt-index",
+    "KeySchema":[{"AttributeName":"quizId","KeyType":"HASH"},{"AttributeName":"finishedAt","KeyType":"RANGE"}],
+    "Projection":{"ProjectionType":"ALL"}
+  },{
+    "IndexName":"hostUserId-finishedAt-index",
+    "KeySchema":[{"AttributeName":"hostUserId","KeyType":"HASH"},{"AttributeName":"finishedAt","KeyType":"RANGE"}],
+    "Projection":{"ProjectionType":"ALL"}
+  }]' \
   --billing-mode PAY_PER_REQUEST \
   $ENDPOINT
 
+echo "Creating kahootclone-question-bank table..."
+aws dynamodb create-table \
+  --table-name kahootclone-question-bank \
+  --attribute-definitions \
+    AttributeName=questionId,AttributeType=S \
+    AttributeName=hostUserId,AttributeType=S \
+    AttributeName=updatedAt,AttributeType=S \
+  --key-schema AttributeName=questionId,KeyType=HASH \
+  --global-secondary-indexes '[{
+    "IndexName":"hostUserId-index",
+    "KeySchema":[{"AttributeName":"hostUserId","KeyType":"HASH"},{"AttributeName":"updatedAt","KeyType":"RANGE"}],
+    "Projection":{"ProjectionType":"ALL"}
+  }]' \
+  --billing-mode PAY_PER_REQUEST \
+  $ENDPOINT
+
+echo "Enabling TTL..."
+for table in kahootclone-sessions kahootclone-connections kahootclone-answers kahootclone-pins; do
+  aws dynamodb update-time-to-live \
+    --table-name $table \
+    --time-to-live-specification Enabled=true,AttributeName=ttl \
+    $ENDPOINT
+done
+
 echo ""
 echo "All tables created. Verifying..."
 aws dynamodb list-tables $ENDPOINT